@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// GitHub Actions passes the inputs of a step as INPUT_<NAME> environment
+// variables, writes the job summary from the Markdown appended to
+// $GITHUB_STEP_SUMMARY and reads the step's outputs from the name=value
+// lines appended to $GITHUB_OUTPUT.
+
+// actionSummaryRows bounds the findings listed in the job summary, which
+// GitHub caps at 1 MiB.
+const actionSummaryRows = 100
+
+// newFindingsError fails a -github-action run that found high-confidence
+// results absent from the -state file.
+type newFindingsError struct {
+	count int
+}
+
+func (e *newFindingsError) Error() string {
+	return fmt.Sprintf("%d new high-confidence finding(s)", e.count)
+}
+
+// actionInput returns the value of a step input, "" when it isn't set.
+func actionInput(name string) string {
+	return strings.TrimSpace(os.Getenv("INPUT_" + strings.ToUpper(strings.Replace(name, " ", "_", -1))))
+}
+
+// applyActionInputs sets the flags not given on the command line from the
+// step's inputs: an input named after a flag, such as max, categories or
+// state, sets it.
+func applyActionInputs(fs *flag.FlagSet) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		value := actionInput(f.Name)
+		if err != nil || explicit[f.Name] || value == "" {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("input %q: %w", f.Name, setErr)
+		}
+	})
+	return err
+}
+
+// actionKeywords returns the keywords of the keywords input, one per line.
+func actionKeywords() []string {
+	var keywords []string
+	for _, line := range strings.Split(actionInput("keywords"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			keywords = append(keywords, line)
+		}
+	}
+	return keywords
+}
+
+// actionFindings sorts the results of an action run.
+type actionFindings struct {
+	keywords int
+	results  []result
+
+	// fresh are the results absent from the -state file before the run,
+	// every result without one, and high those of them whose name is the
+	// keyword.
+	fresh, high []result
+}
+
+// previousResults returns the keys of the results the -state file held
+// before the run, nil without one.
+func previousResults(cfg config) (map[string]bool, error) {
+	if cfg.stateFlag == "" {
+		return nil, nil
+	}
+	state, err := readStateFile(outputPath(cfg.stateFlag))
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool)
+	for _, s := range state.Results {
+		keys[stateKey(s.Platform, s.Category, s.Name)] = true
+	}
+	return keys, nil
+}
+
+// sortFindings picks the new and the new high-confidence results among
+// those of the run.
+func sortFindings(results []result, previous map[string]bool, keywords int) actionFindings {
+	findings := actionFindings{keywords: keywords, results: results}
+	for _, r := range results {
+		if previous[stateKey(r.Platform, r.Category, r.Name)] {
+			continue
+		}
+		findings.fresh = append(findings.fresh, r)
+		if resultConfidence(r.Category, r.Query, r.Name) == confidenceHigh {
+			findings.high = append(findings.high, r)
+		}
+	}
+	return findings
+}
+
+// runGitHubAction runs scan, a scan of keywords, as a GitHub Actions step:
+// it writes a job summary of the findings, sets the step's outputs, and
+// fails the job with a newFindingsError when high-confidence results turned
+// up that the -state file didn't have.
+func runGitHubAction(cfg config, keywords int, scan func() error) error {
+	previous, err := previousResults(cfg)
+	if err != nil {
+		return fmt.Errorf("reading state file: %w", err)
+	}
+
+	runErr := scan()
+	findings := sortFindings(collectedResults, previous, keywords)
+
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendToFile(path, actionSummary(findings, cfg, failedOperations())); err != nil {
+			return fmt.Errorf("writing job summary: %w", err)
+		}
+	}
+	if path := os.Getenv("GITHUB_OUTPUT"); path != "" {
+		if err := appendToFile(path, actionOutputs(findings, cfg)); err != nil {
+			return fmt.Errorf("setting step outputs: %w", err)
+		}
+	}
+
+	var partial *partialFailureError
+	var exceeded *runtimeExceededError
+	if runErr != nil && !errors.As(runErr, &partial) && !errors.As(runErr, &exceeded) {
+		return runErr
+	}
+	if len(findings.high) > 0 {
+		for _, r := range findings.high {
+			fmt.Printf("::error title=New %s %s::%s found on %s for '%s'\n", r.Platform, r.Category, r.Name, r.Platform, r.Query)
+		}
+		return &newFindingsError{count: len(findings.high)}
+	}
+	return runErr
+}
+
+// actionSummary renders the job summary: the counts, then the new
+// high-confidence findings and every new result, as tables.
+func actionSummary(findings actionFindings, cfg config, failed int) string {
+	var b strings.Builder
+	b.WriteString("## dorky\n\n")
+	fmt.Fprintf(&b, "Searched %d keyword(s) and found %d result(s), %d of them new", findings.keywords, len(findings.results), len(findings.fresh))
+	if cfg.stateFlag == "" {
+		b.WriteString(" (without a state file, every result is new)")
+	}
+	b.WriteString(".\n")
+	if failed > 0 {
+		fmt.Fprintf(&b, "\n:warning: %d operation(s) failed, so the results are incomplete: see the step's log.\n", failed)
+	}
+
+	if len(findings.high) > 0 {
+		fmt.Fprintf(&b, "\n### %d new high-confidence finding(s)\n\n", len(findings.high))
+		writeFindingsTable(&b, findings.high)
+	}
+	if len(findings.fresh) > 0 {
+		fmt.Fprintf(&b, "\n<details><summary>Every new result (%d)</summary>\n\n", len(findings.fresh))
+		writeFindingsTable(&b, findings.fresh)
+		b.WriteString("\n</details>\n")
+	}
+	return b.String()
+}
+
+// writeFindingsTable writes results as a Markdown table, up to
+// actionSummaryRows of them.
+func writeFindingsTable(b *strings.Builder, results []result) {
+	cell := func(s string) string {
+		return strings.Replace(s, "|", `\|`, -1)
+	}
+	b.WriteString("| Platform | Category | Name | Keyword |\n|---|---|---|---|\n")
+	for i, r := range results {
+		if i == actionSummaryRows {
+			fmt.Fprintf(b, "\nand %d more.\n", len(results)-actionSummaryRows)
+			break
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s |\n", r.Platform, r.Category, cell(r.Name), cell(r.Query))
+	}
+}
+
+// actionOutputs renders the step's outputs.
+func actionOutputs(findings actionFindings, cfg config) string {
+	lines := []string{
+		fmt.Sprintf("results=%d", len(findings.results)),
+		fmt.Sprintf("new-results=%d", len(findings.fresh)),
+		fmt.Sprintf("new-high-confidence=%d", len(findings.high)),
+	}
+	if cfg.jsonFlag != "" {
+		lines = append(lines, "json-report="+outputPath(cfg.jsonFlag))
+	}
+	if cfg.sarifFlag != "" {
+		lines = append(lines, "sarif-report="+outputPath(cfg.sarifFlag))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// appendToFile appends text to the file GitHub Actions reads it from.
+func appendToFile(filename, text string) error {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
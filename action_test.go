@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyActionInputs(t *testing.T) {
+	fs := flag.NewFlagSet("dorky", flag.ContinueOnError)
+	max := fs.Int("max", 10, "")
+	categories := fs.String("categories", "", "")
+	clean := fs.Bool("c", false, "")
+	fs.Parse([]string{"-max", "3"})
+
+	setenv(t, "INPUT_MAX", "50")
+	setenv(t, "INPUT_CATEGORIES", "org,repo")
+	setenv(t, "INPUT_C", "true")
+	setenv(t, "INPUT_KEYWORDS", "acme\n\n  Acme Corp \n")
+
+	if err := applyActionInputs(fs); err != nil {
+		t.Fatal(err)
+	}
+	if *max != 3 || *categories != "org,repo" || !*clean {
+		t.Errorf("max = %d, categories = %q, c = %v, want 3 (given on the command line), org,repo and true", *max, *categories, *clean)
+	}
+	if got, want := actionKeywords(), []string{"acme", "Acme Corp"}; !equalStrings(got, want) {
+		t.Errorf("keywords = %v, want %v", got, want)
+	}
+
+	setenv(t, "INPUT_MAX", "lots")
+	fs = flag.NewFlagSet("dorky", flag.ContinueOnError)
+	fs.Int("max", 10, "")
+	if err := applyActionInputs(fs); err == nil || !strings.Contains(err.Error(), `input "max"`) {
+		t.Errorf("error = %v, want one naming the max input", err)
+	}
+}
+
+func TestGitHubAction(t *testing.T) {
+	setupRun(t, config{stateFlag: "state.json"})
+	summary := filepath.Join(t.TempDir(), "summary.md")
+	outputs := filepath.Join(t.TempDir(), "outputs")
+	setenv(t, "GITHUB_STEP_SUMMARY", summary)
+	setenv(t, "GITHUB_OUTPUT", outputs)
+
+	// acme was found by an earlier run: only acme-corp and acme-fans are
+	// new, and only acme-corp is named after its keyword.
+	if _, err := updateState(outputPath("state.json"), []result{{Platform: "github", Category: "organization", Name: "Acme"}}, time.Now().UTC(), 0); err != nil {
+		t.Fatal(err)
+	}
+	err := runGitHubAction(flags, 2, func() error {
+		return runAndExport(flags, func() {
+			emitResults("github", "organization", "acme", "GitHub organizations matching 'acme'", "github_organizations.txt", []string{"acme", "acme-fans"})
+			emitResults("github", "organization", "acme corp", "GitHub organizations matching 'acme corp'", "github_organizations.txt", []string{"acme-corp"})
+		})
+	})
+	if code := exitCode(err); code != 4 {
+		t.Errorf("exitCode = %d, want 4 (err: %v)", code, err)
+	}
+
+	data, err := ioutil.ReadFile(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "results=3\nnew-results=2\nnew-high-confidence=1\n"; got != want {
+		t.Errorf("outputs = %q, want %q", got, want)
+	}
+
+	data, err = ioutil.ReadFile(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"found 3 result(s), 2 of them new.",
+		"### 1 new high-confidence finding(s)\n\n| Platform | Category | Name | Keyword |\n|---|---|---|---|\n| github | organization | acme-corp | acme corp |\n",
+		"Every new result (2)",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("summary lacks %q:\n%s", want, data)
+		}
+	}
+
+	// The next run knows them all and passes.
+	startRun()
+	err = runGitHubAction(flags, 2, func() error {
+		return runAndExport(flags, func() {
+			emitResults("github", "organization", "acme corp", "GitHub organizations matching 'acme corp'", "github_organizations.txt", []string{"acme-corp"})
+		})
+	})
+	if err != nil {
+		t.Errorf("second run failed: %v", err)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// adaptiveLimiter paces the requests to one API, separately for each quota
+// the API keeps (GitHub limits search, GraphQL and everything else apart).
+// It halves a quota's request rate whenever the API throttles it and pauses
+// until the quota resets once it is exhausted. Otherwise the rate creeps
+// back up towards max, but never faster than the remaining quota can
+// sustain until it resets once that quota runs low. Pauses are shared with
+// the limiters created later in the run and kept in the -state file.
+//
+// The number of a quota's requests in flight adapts the same way: it
+// halves whenever the API throttles the quota and grows by one with every
+// other response, up to -concurrency, so searches beyond it wait their turn
+// instead of piling onto a throttled API.
+type adaptiveLimiter struct {
+	platform string
+	max, min rate.Limit
+
+	// resource names the quota a request counts against.
+	resource func(*http.Request) string
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	paused   map[string]time.Time
+
+	// slots is the number of requests a quota may have in flight, and
+	// inflight how many it has. freed is closed, and replaced, whenever a
+	// slot frees up.
+	slots    map[string]int
+	inflight map[string]int
+	freed    chan struct{}
+}
+
+func newAdaptiveLimiter(platform string, max, min rate.Limit, resource func(*http.Request) string) *adaptiveLimiter {
+	return &adaptiveLimiter{
+		platform: platform,
+		max:      max,
+		min:      min,
+		resource: resource,
+		limiters: make(map[string]*rate.Limiter),
+		paused:   platformCooldowns(platform),
+		slots:    make(map[string]int),
+		inflight: make(map[string]int),
+		freed:    make(chan struct{}),
+	}
+}
+
+// githubResource mirrors how GitHub splits its rate limits.
+func githubResource(req *http.Request) string {
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/search/"):
+		return "search"
+	case req.URL.Path == "/graphql":
+		return "graphql"
+	default:
+		return "core"
+	}
+}
+
+// isThrottled reports whether resp is the API refusing a request for going
+// over its rate limits: a 429, or a 403 that comes with Retry-After or an
+// exhausted quota.
+func isThrottled(resp *http.Response) bool {
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	if remaining == "" {
+		remaining = resp.Header.Get("RateLimit-Remaining")
+	}
+	retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusForbidden && (retryAfter > 0 || remaining == "0")
+}
+
+// limiterFor returns the limiter of a resource; a.mu must be held.
+func (a *adaptiveLimiter) limiterFor(resource string) *rate.Limiter {
+	l, ok := a.limiters[resource]
+	if !ok {
+		l = rate.NewLimiter(a.max, 1)
+		a.limiters[resource] = l
+	}
+	return l
+}
+
+// slotsFor returns how many requests of a resource may be in flight;
+// a.mu must be held.
+func (a *adaptiveLimiter) slotsFor(resource string) int {
+	ceiling := flags.concurrencyFlag
+	if ceiling < 1 {
+		ceiling = 1
+	}
+	slots, ok := a.slots[resource]
+	if !ok || slots > ceiling {
+		slots = ceiling
+		a.slots[resource] = slots
+	}
+	return slots
+}
+
+// wake lets the requests waiting for a slot check again; a.mu must be
+// held.
+func (a *adaptiveLimiter) wake() {
+	close(a.freed)
+	a.freed = make(chan struct{})
+}
+
+// Wait blocks until req may be sent. Once it returns nil, req holds one of
+// its quota's slots until Done is called.
+func (a *adaptiveLimiter) Wait(req *http.Request) error {
+	resource := a.resource(req)
+
+	a.mu.Lock()
+	for a.inflight[resource] >= a.slotsFor(resource) {
+		freed := a.freed
+		a.mu.Unlock()
+		select {
+		case <-freed:
+		case <-req.Context().Done():
+			return req.Context().Err()
+		}
+		a.mu.Lock()
+	}
+	a.inflight[resource]++
+	l := a.limiterFor(resource)
+	pausedUntil := a.paused[resource]
+	a.mu.Unlock()
+
+	if pause := time.Until(pausedUntil); pause > 0 {
+		timer := time.NewTimer(pause)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			a.Done(req)
+			return req.Context().Err()
+		}
+	}
+
+	if err := l.Wait(req.Context()); err != nil {
+		a.Done(req)
+		return err
+	}
+	return nil
+}
+
+// Done frees the slot req took in Wait.
+func (a *adaptiveLimiter) Done(req *http.Request) {
+	resource := a.resource(req)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.inflight[resource]--
+	a.wake()
+}
+
+// observe adapts the rate of req's quota to the response it got, and
+// reports whether the API throttled the request.
+func (a *adaptiveLimiter) observe(req *http.Request, resp *http.Response) bool {
+	resource := a.resource(req)
+
+	prefix := "RateLimit-"
+	if resp.Header.Get("X-RateLimit-Remaining") != "" {
+		prefix = "X-RateLimit-"
+	}
+	remaining, remainingErr := strconv.Atoi(resp.Header.Get(prefix + "Remaining"))
+	quota, _ := strconv.Atoi(resp.Header.Get(prefix + "Limit"))
+	var reset time.Time
+	if epoch, err := strconv.ParseInt(resp.Header.Get(prefix+"Reset"), 10, 64); err == nil {
+		reset = time.Unix(epoch, 0)
+	}
+	retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+
+	exhausted := remainingErr == nil && remaining == 0
+	throttled := isThrottled(resp)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	l := a.limiterFor(resource)
+	limit := l.Limit()
+
+	var pauseUntil time.Time
+	switch {
+	case retryAfter > 0 && throttled:
+		pauseUntil = time.Now().Add(time.Duration(retryAfter) * time.Second)
+	case exhausted && !reset.IsZero():
+		pauseUntil = reset
+	}
+	if pauseUntil.After(a.paused[resource]) {
+		a.paused[resource] = pauseUntil
+		recordCooldown(a.platform, resource, pauseUntil)
+		fmt.Fprintf(os.Stderr, "%s %s requests throttled, pausing until %s\n",
+			a.platform, resource, pauseUntil.Local().Format("15:04:05"))
+	}
+
+	if throttled {
+		limit /= 2
+	} else {
+		limit += a.max / 10
+	}
+
+	// Once a quota runs low, spread what's left over the time until it
+	// resets instead of running into the wall.
+	if remainingErr == nil && remaining > 0 && (quota == 0 || remaining < quota/4) {
+		if window := time.Until(reset); window > 0 {
+			if sustainable := rate.Limit(float64(remaining) / window.Seconds()); sustainable < limit {
+				limit = sustainable
+			}
+		}
+	}
+
+	if limit > a.max {
+		limit = a.max
+	}
+	if limit < a.min {
+		limit = a.min
+	}
+	if limit != l.Limit() {
+		verbosePrint("Pacing %s %s requests at %.2f/s\n", a.platform, resource, float64(limit))
+		l.SetLimit(limit)
+	}
+
+	slots := a.slotsFor(resource)
+	switch {
+	case throttled && slots > 1:
+		slots /= 2
+	case !throttled && slots < flags.concurrencyFlag:
+		slots++
+		a.wake()
+	}
+	if slots != a.slots[resource] {
+		verbosePrint("Sending up to %d %s %s requests at once\n", slots, a.platform, resource)
+		a.slots[resource] = slots
+	}
+
+	return throttled
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func limiterResponse(status int, headers map[string]string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}}
+	for key, value := range headers {
+		resp.Header.Set(key, value)
+	}
+	return resp
+}
+
+func TestGitHubResource(t *testing.T) {
+	tests := map[string]string{
+		"https://api.github.com/search/users?q=acme": "search",
+		"https://api.github.com/graphql":             "graphql",
+		"https://api.github.com/users/acme":          "core",
+	}
+	for rawURL, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, rawURL, nil)
+		if got := githubResource(req); got != want {
+			t.Errorf("githubResource(%s) = %q, want %q", rawURL, got, want)
+		}
+	}
+}
+
+func TestAdaptiveLimiterObserve(t *testing.T) {
+	setupRun(t, config{})
+
+	search := httptest.NewRequest(http.MethodGet, "https://api.github.com/search/users", nil)
+	core := httptest.NewRequest(http.MethodGet, "https://api.github.com/users/acme", nil)
+	soon := strconv.FormatInt(time.Now().Add(100*time.Second).Unix(), 10)
+
+	a := newAdaptiveLimiter("github", 10, 1, githubResource)
+	limit := func(req *http.Request) rate.Limit { return a.limiterFor(githubResource(req)).Limit() }
+
+	if !a.observe(search, limiterResponse(http.StatusTooManyRequests, nil)) {
+		t.Error("429 not reported as throttled")
+	}
+	if got := limit(search); got != 5 {
+		t.Errorf("limit after 429 = %v, want 5", got)
+	}
+	if got := limit(core); got != 10 {
+		t.Errorf("core limit = %v, want 10: quotas must adapt separately", got)
+	}
+
+	a.observe(search, limiterResponse(http.StatusOK, nil))
+	if got := limit(search); got != 6 {
+		t.Errorf("limit after success = %v, want 6", got)
+	}
+
+	if a.observe(search, limiterResponse(http.StatusForbidden, nil)) {
+		t.Error("plain 403 reported as throttled")
+	}
+
+	for i := 0; i < 5; i++ {
+		a.observe(search, limiterResponse(http.StatusTooManyRequests, nil))
+	}
+	if got := limit(search); got != 1 {
+		t.Errorf("limit = %v, want the minimum 1", got)
+	}
+
+	// 20 requests left for 100 seconds can't be spent faster than 0.2/s.
+	a.observe(core, limiterResponse(http.StatusOK, map[string]string{
+		"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "20", "X-RateLimit-Reset": soon,
+	}))
+	if got := limit(core); got != 1 {
+		t.Errorf("low quota limit = %v, want the minimum 1", got)
+	}
+
+	throttled := a.observe(core, limiterResponse(http.StatusForbidden, map[string]string{
+		"X-RateLimit-Limit": "5000", "X-RateLimit-Remaining": "0", "X-RateLimit-Reset": soon,
+	}))
+	if !throttled {
+		t.Error("403 with an exhausted quota not reported as throttled")
+	}
+	if pause := time.Until(a.paused["core"]); pause < 90*time.Second {
+		t.Errorf("core paused for %v, want until the reset", pause)
+	}
+	if !a.paused["search"].IsZero() {
+		t.Errorf("search paused until %v", a.paused["search"])
+	}
+}
+
+func TestAdaptiveLimiterSlots(t *testing.T) {
+	setupRun(t, config{concurrencyFlag: 4})
+
+	req := httptest.NewRequest(http.MethodGet, "https://api.github.com/search/users", nil)
+	a := newAdaptiveLimiter("github", 1000, 1, githubResource)
+	slots := func() int {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return a.slotsFor("search")
+	}
+
+	if got := slots(); got != 4 {
+		t.Errorf("slots = %d, want -concurrency 4", got)
+	}
+	a.observe(req, limiterResponse(http.StatusTooManyRequests, nil))
+	a.observe(req, limiterResponse(http.StatusTooManyRequests, nil))
+	if got := slots(); got != 1 {
+		t.Errorf("slots after two 429s = %d, want 1", got)
+	}
+	a.observe(req, limiterResponse(http.StatusOK, nil))
+	if got := slots(); got != 2 {
+		t.Errorf("slots after success = %d, want 2", got)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := a.Wait(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := a.Wait(req.WithContext(ctx)); err == nil {
+		t.Error("a third request was sent with two slots")
+	}
+
+	a.Done(req)
+	if err := a.Wait(req); err != nil {
+		t.Errorf("request not sent once a slot freed up: %s", err)
+	}
+}
+
+func TestProviderTransportRetriesThrottledRequest(t *testing.T) {
+	setupRun(t, config{})
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newProviderTransport("bitbucket", nil, newAdaptiveLimiter("bitbucket", 1000, 1, func(*http.Request) string { return "rest" }))}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || requests != 2 {
+		t.Errorf("got status %d after %d requests, want 200 after 2", resp.StatusCode, requests)
+	}
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	statusAvailable  = "available"
+	statusTarget     = "registered by target"
+	statusThirdParty = "registered by third party"
+)
+
+// namespaceRegexp matches names that are valid GitHub and GitLab top-level
+// namespaces; anything else can never be registered as-is.
+var namespaceRegexp = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9._-]{0,37}[A-Za-z0-9])?$`)
+
+// availabilityCheck is the registration state of one exact namespace on one
+// platform.
+type availabilityCheck struct {
+	Platform string `json:"platform"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Kind     string `json:"kind,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+
+	// RenamedTo is the namespace the name now redirects to after a rename.
+	RenamedTo string `json:"renamed_to,omitempty"`
+
+	// Followers, PublicRepos and UpdatedAt describe how popular and active
+	// the holder is, where the platform exposes it.
+	Followers   int       `json:"followers,omitempty"`
+	PublicRepos int       `json:"public_repos,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+
+	// CreatedAt, DisplayName, Description and AvatarURL are compared with
+	// the target's own namespaces to tell impersonators from namesakes.
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Description string    `json:"description,omitempty"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+}
+
+var (
+	availabilityChecks []availabilityCheck
+
+	// namespaceCache avoids looking up the same namespace twice in a run,
+	// e.g. when both -check-availability and -impersonation need it.
+	namespaceCache = make(map[string]availabilityCheck)
+)
+
+// namespaceOwner is what a platform tells us about whoever holds a namespace,
+// used to decide whether the holder is the target.
+type namespaceOwner struct {
+	kind     string
+	websites []string
+}
+
+func checkAvailability(ghClient *github.Client, glClient *gitlab.Client, name string, cfg config) {
+	if !namespaceRegexp.MatchString(name) {
+		verbosePrint("Skipping availability check for '%s': not a valid namespace\n", name)
+		return
+	}
+
+	checks := lookupNamespace(ghClient, glClient, name, cfg)
+
+	var ghWebURL, glWebURL string
+	if ghClient != nil {
+		ghWebURL = githubWebURL
+	}
+	if glClient != nil {
+		glWebURL = gitLabWebURL(glClient)
+	}
+	checkRenames(ghWebURL, glWebURL, checks)
+
+	for _, check := range checks {
+		line := check.Name + ": " + check.Status
+		if check.Kind != "" {
+			line += " (" + check.Kind + ")"
+		}
+		if check.Detail != "" {
+			line += " " + check.Detail
+		}
+		if check.RenamedTo != "" {
+			line += ", renamed to " + check.RenamedTo
+		}
+
+		platformName := "GitHub"
+		if check.Platform == "gitlab" {
+			platformName = "GitLab"
+		}
+		emitResults(check.Platform, "availability", name, fmt.Sprintf("%s availability of '%s'", platformName, name),
+			check.Platform+"_availability.txt", []string{line})
+		reportRename(check, platformName)
+
+		if check.Status == statusAvailable {
+			webURL := ghWebURL
+			if check.Platform == "gitlab" {
+				webURL = glWebURL
+			}
+			recordReservation(check, webURL)
+		}
+	}
+
+	stateMu.Lock()
+	availabilityChecks = append(availabilityChecks, checks...)
+	stateMu.Unlock()
+}
+
+// lookupNamespace checks name on every enabled platform, caching results.
+func lookupNamespace(ghClient *github.Client, glClient *gitlab.Client, name string, cfg config) []availabilityCheck {
+	var checks []availabilityCheck
+
+	if ghClient != nil && !cfg.glOnlyFlag {
+		if check, ok := cachedNamespace("github:" + name); ok {
+			checks = append(checks, check)
+		} else if check, err := checkGitHubNamespace(ghClient, name, cfg); err != nil {
+			recordSearchError("github", "namespace check", name, err)
+		} else {
+			cacheNamespace("github:"+name, check)
+			checks = append(checks, check)
+		}
+	}
+
+	if glClient != nil && !cfg.ghOnlyFlag {
+		if check, ok := cachedNamespace("gitlab:" + name); ok {
+			checks = append(checks, check)
+		} else if check, err := checkGitLabNamespace(glClient, name, cfg); err != nil {
+			recordSearchError("gitlab", "namespace check", name, err)
+		} else {
+			cacheNamespace("gitlab:"+name, check)
+			checks = append(checks, check)
+		}
+	}
+
+	return checks
+}
+
+func cachedNamespace(key string) (availabilityCheck, bool) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	check, ok := namespaceCache[key]
+	return check, ok
+}
+
+func cacheNamespace(key string, check availabilityCheck) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	namespaceCache[key] = check
+}
+
+func checkGitHubNamespace(client *github.Client, name string, cfg config) (availabilityCheck, error) {
+	check := availabilityCheck{Platform: "github", Name: name}
+
+	user, resp, err := client.Users.Get(context.Background(), name)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		check.Status = statusAvailable
+		return check, nil
+	}
+	if err != nil {
+		return check, err
+	}
+
+	owner := namespaceOwner{kind: strings.ToLower(user.GetType()), websites: []string{user.GetBlog(), user.GetEmail()}}
+	check.Kind = owner.kind
+	check.Status = ownershipStatus(user.GetLogin(), owner, cfg)
+	check.Detail = user.GetHTMLURL()
+	recordAvatar("github", user.GetLogin(), user.GetAvatarURL())
+	check.Followers = user.GetFollowers()
+	check.PublicRepos = user.GetPublicRepos()
+	check.UpdatedAt = user.GetUpdatedAt().Time
+	check.CreatedAt = user.GetCreatedAt().Time
+	check.DisplayName = user.GetName()
+	check.Description = user.GetBio()
+	check.AvatarURL = user.GetAvatarURL()
+
+	// The API follows rename redirects, answering with the new account.
+	if !strings.EqualFold(user.GetLogin(), name) {
+		check.RenamedTo = user.GetLogin()
+	}
+
+	return check, nil
+}
+
+// gitLabNamespaceExists asks whether a group or user holds name. The client
+// library predates the endpoint, so the request is built by hand.
+func gitLabNamespaceExists(client *gitlab.Client, name string) (bool, error) {
+	req, err := client.NewRequest(http.MethodGet, fmt.Sprintf("namespaces/%s/exists", url.PathEscape(name)), nil, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var exists struct {
+		Exists bool `json:"exists"`
+	}
+	if _, err := client.Do(req, &exists); err != nil {
+		return false, err
+	}
+	return exists.Exists, nil
+}
+
+func checkGitLabNamespace(client *gitlab.Client, name string, cfg config) (availabilityCheck, error) {
+	check := availabilityCheck{Platform: "gitlab", Name: name}
+
+	// The namespaces API needs a token. Anonymously, a name neither a public
+	// group nor a user holds is reported available.
+	if !gitlabAnonymous {
+		exists, err := gitLabNamespaceExists(client, name)
+		if err != nil {
+			return check, err
+		}
+		if !exists {
+			check.Status = statusAvailable
+			return check, nil
+		}
+	}
+
+	if group, resp, err := client.Groups.GetGroup(name, nil); err == nil {
+		check.Kind = "group"
+		check.Status = ownershipStatus(group.FullPath, namespaceOwner{kind: "group"}, cfg)
+		check.Detail = group.WebURL
+		check.DisplayName, check.Description, check.AvatarURL = group.Name, group.Description, group.AvatarURL
+		if group.CreatedAt != nil {
+			check.CreatedAt = *group.CreatedAt
+		}
+		recordAvatar("gitlab", group.FullPath, group.AvatarURL)
+		return check, nil
+	} else if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return check, err
+	}
+
+	users, _, err := client.Users.ListUsers(&gitlab.ListUsersOptions{Username: gitlab.String(name)})
+	if err != nil {
+		return check, err
+	}
+	if len(users) > 0 {
+		check.Kind = "user"
+		check.Status = ownershipStatus(users[0].Username, namespaceOwner{kind: "user", websites: []string{users[0].WebsiteURL, users[0].PublicEmail}}, cfg)
+		check.Detail = users[0].WebURL
+		check.DisplayName, check.Description, check.AvatarURL = users[0].Name, users[0].Bio, users[0].AvatarURL
+		if users[0].CreatedAt != nil {
+			check.CreatedAt = *users[0].CreatedAt
+		}
+		recordAvatar("gitlab", users[0].Username, users[0].AvatarURL)
+		return check, nil
+	}
+
+	if gitlabAnonymous {
+		check.Status = statusAvailable
+		return check, nil
+	}
+
+	// The namespace exists but is not visible to us, e.g. a private group.
+	check.Status = statusThirdParty
+	check.Kind = "private"
+	return check, nil
+}
+
+// ownershipStatus decides whether a registered namespace belongs to the
+// target: either it is listed in -owned, or the profile's website or email
+// is on one of the -target-domain domains.
+func ownershipStatus(name string, owner namespaceOwner, cfg config) string {
+	for _, owned := range splitList(cfg.ownedFlag) {
+		if strings.EqualFold(owned, name) {
+			return statusTarget
+		}
+	}
+
+	for _, website := range owner.websites {
+		host := website
+		if i := strings.LastIndex(host, "@"); i >= 0 {
+			host = host[i+1:]
+		} else if u, err := url.Parse(website); err == nil && u.Host != "" {
+			host = u.Host
+		} else if u, err := url.Parse("http://" + website); err == nil {
+			host = u.Host
+		}
+		host = strings.ToLower(strings.TrimPrefix(host, "www."))
+
+		for _, domain := range splitList(cfg.targetDomainFlag) {
+			domain = strings.ToLower(domain)
+			if host != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+				return statusTarget
+			}
+		}
+	}
+
+	return statusThirdParty
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestOwnershipStatus(t *testing.T) {
+	cfg := config{ownedFlag: "acme, acme-corp", targetDomainFlag: "acme.com"}
+
+	tests := []struct {
+		name  string
+		owner namespaceOwner
+		want  string
+	}{
+		{"ACME", namespaceOwner{}, statusTarget},
+		{"acme-labs", namespaceOwner{websites: []string{"https://blog.acme.com/"}}, statusTarget},
+		{"acme-labs", namespaceOwner{websites: []string{"dev@acme.com"}}, statusTarget},
+		{"acme-labs", namespaceOwner{websites: []string{"www.acme.com"}}, statusTarget},
+		{"acme-fan", namespaceOwner{websites: []string{"https://notacme.com"}}, statusThirdParty},
+		{"acme-fan", namespaceOwner{}, statusThirdParty},
+	}
+
+	for _, tt := range tests {
+		if got := ownershipStatus(tt.name, tt.owner, cfg); got != tt.want {
+			t.Errorf("ownershipStatus(%q, %v) = %q, want %q", tt.name, tt.owner.websites, got, tt.want)
+		}
+	}
+}
+
+func TestNamespaceRegexp(t *testing.T) {
+	tests := map[string]bool{
+		"acme":        true,
+		"acme-corp":   true,
+		"a":           true,
+		"-acme":       false,
+		"acme corp":   false,
+		"acme.com/x":  false,
+		"":            false,
+		"müller-gmbh": false,
+	}
+
+	for name, want := range tests {
+		if got := namespaceRegexp.MatchString(name); got != want {
+			t.Errorf("namespaceRegexp.MatchString(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// avatarURLs maps "platform:name" of every matched account to its avatar.
+var avatarURLs = make(map[string]string)
+
+func recordAvatar(platform, name, url string) {
+	if url != "" {
+		stateMu.Lock()
+		avatarURLs[platform+":"+name] = url
+		stateMu.Unlock()
+	}
+}
+
+// correlateAvatars downloads and hashes the avatar of every matched account
+// and reports accounts sharing an identical image, a strong sign of the same
+// operator or of an impersonator copying branding.
+func correlateAvatars() {
+	accounts := make([]string, 0, len(avatarURLs))
+	for account := range avatarURLs {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+
+	byHash := make(map[string][]string)
+	for _, account := range accounts {
+		hash, err := hashAvatar(avatarURLs[account])
+		if err != nil {
+			parts := strings.SplitN(account, ":", 2)
+			recordSearchError(parts[0], "avatar fetch", parts[1], err)
+			continue
+		}
+		byHash[hash] = append(byHash[hash], account)
+	}
+
+	var matches []string
+	for hash, group := range byHash {
+		if len(group) > 1 {
+			matches = append(matches, fmt.Sprintf("%s: %s", hash[:12], strings.Join(group, ", ")))
+		}
+	}
+	sort.Strings(matches)
+
+	emitResults("all", "avatar_match", "", "Accounts sharing identical avatars", "avatar_matches.txt", matches)
+}
+
+// avatarClient fetches avatars from the platforms' image hosts.
+var avatarClient = &http.Client{
+	Transport: &identifyTransport{platform: "avatars", next: http.DefaultTransport},
+	Timeout:   30 * time.Second,
+}
+
+// cachedAvatarHash returns hashAvatar remembering its results, for avatars
+// compared more than once.
+func cachedAvatarHash() func(url string) (string, error) {
+	hashes := make(map[string]string)
+	failed := make(map[string]error)
+	return func(url string) (string, error) {
+		if hash, ok := hashes[url]; ok {
+			return hash, nil
+		}
+		if err, ok := failed[url]; ok {
+			return "", err
+		}
+		hash, err := hashAvatar(url)
+		if err != nil {
+			failed[url] = err
+			return "", err
+		}
+		hashes[url] = hash
+		return hash, nil
+	}
+}
+
+func hashAvatar(url string) (string, error) {
+	resp, err := avatarClient.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
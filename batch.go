@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// batchTarget is one line of a -targets file: a label followed by the
+// keywords to search for it, e.g. `acme: acme, acme corp, acmecloud`.
+type batchTarget struct {
+	label    string
+	keywords []string
+}
+
+func readTargetsFile(filename string) ([]batchTarget, error) {
+	// remaining_targets.txt is an output file, encrypted like the others.
+	data, err := readOutputFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []batchTarget
+	seen := make(map[string]bool)
+	scanner := newLineScanner(bytes.NewReader(data))
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected 'label: keyword, keyword, ...'", lineNo)
+		}
+
+		label := strings.TrimSpace(parts[0])
+		if !validDirName(label) {
+			return nil, fmt.Errorf("line %d: invalid label %q", lineNo, label)
+		}
+		if seen[label] {
+			return nil, fmt.Errorf("line %d: label '%s' defined more than once", lineNo, label)
+		}
+		seen[label] = true
+
+		var keywords []string
+		for _, keyword := range strings.Split(parts[1], ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				keywords = append(keywords, keyword)
+			}
+		}
+		if len(keywords) == 0 {
+			return nil, fmt.Errorf("line %d: target '%s' has no keywords", lineNo, label)
+		}
+
+		targets = append(targets, batchTarget{label: label, keywords: keywords})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// runBatch scans every target in turn, writing each target's output files
+// and summary into a subdirectory named after its label.
+func runBatch(targets []batchTarget, cfg config) error {
+	baseDir := outputDir
+	totals := make(map[string]map[string]int)
+	var incomplete []string
+	failed := 0
+
+	for i, target := range targets {
+		fmt.Printf("\n== Target '%s' ==\n", target.label)
+
+		resetKeywordSources()
+		words := make(map[string]struct{})
+		for _, keyword := range target.keywords {
+			processWord(keyword, words, cfg)
+		}
+
+		outputDir = filepath.Join(baseDir, target.label)
+		err := runScan(words, cfg)
+		var interrupted *runInterruptedError
+		if errors.As(err, &interrupted) {
+			if err := writeSummary(outputPath("summary.txt"), target.label, countResults(collectedResults)); err != nil {
+				return fmt.Errorf("target '%s': writing summary: %w", target.label, err)
+			}
+			outputDir = baseDir
+			return fmt.Errorf("target '%s': %w", target.label, err)
+		}
+		var exceeded *runtimeExceededError
+		if errors.As(err, &exceeded) {
+			outputDir = baseDir
+			return saveRemainingTargets(target.label, remainingKeywords(), targets[i+1:])
+		}
+		var aborted *errorBudgetError
+		if errors.As(err, &aborted) {
+			outputDir = baseDir
+			lines, _ := writeRemainingTargets(target.label, remainingKeywords(), targets[i+1:])
+			fmt.Printf("\n-max-errors reached: %d targets were not fully searched, see remaining_targets.txt\n", lines)
+			return fmt.Errorf("target '%s': %w", target.label, err)
+		}
+		if err != nil {
+			var partial *partialFailureError
+			if !errors.As(err, &partial) {
+				return fmt.Errorf("target '%s': %w", target.label, err)
+			}
+			failed += partial.failed
+			incomplete = append(incomplete, target.label)
+		}
+
+		counts := countResults(collectedResults)
+		totals[target.label] = counts
+		if err := writeSummary(outputPath("summary.txt"), target.label, counts); err != nil {
+			return fmt.Errorf("target '%s': writing summary: %w", target.label, err)
+		}
+	}
+	outputDir = baseDir
+
+	printBatchSummary(targets, totals)
+
+	if len(incomplete) > 0 {
+		return fmt.Errorf("targets %s are incomplete: %w", strings.Join(incomplete, ", "), &partialFailureError{failed: failed})
+	}
+	return nil
+}
+
+// saveRemainingTargets writes the targets a batch cut short by -max-runtime
+// didn't get to, starting with the unsearched keywords of the interrupted
+// target, to remaining_targets.txt as a -targets file for the next run.
+func saveRemainingTargets(label string, unsearched []string, later []batchTarget) error {
+	lines, count := writeRemainingTargets(label, unsearched, later)
+	fmt.Printf("\n-max-runtime reached: %d targets were not fully searched, see remaining_targets.txt\n", lines)
+	return &runtimeExceededError{unsearched: count}
+}
+
+// writeRemainingTargets saves the targets not fully searched to
+// remaining_targets.txt, returning how many there are and how many
+// keywords they hold.
+func writeRemainingTargets(label string, unsearched []string, later []batchTarget) (int, int) {
+	var lines []string
+	count := len(unsearched)
+	if len(unsearched) > 0 {
+		lines = append(lines, label+": "+strings.Join(unsearched, ", "))
+	}
+	for _, target := range later {
+		lines = append(lines, target.label+": "+strings.Join(target.keywords, ", "))
+		count += len(target.keywords)
+	}
+
+	saveResults("remaining_targets.txt", lines)
+	return len(lines), count
+}
+
+// countResults tallies results per "platform category" key.
+func countResults(res []result) map[string]int {
+	counts := make(map[string]int)
+	for _, r := range res {
+		counts[r.Platform+" "+r.Category]++
+	}
+	return counts
+}
+
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeSummary(filename, label string, counts map[string]int) error {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "target: %s\nrun: %s\nversion: %s\nstarted: %s\n",
+		label, runID, version, runStarted.Format(time.RFC3339))
+	if reason := runPartial(); reason != "" {
+		fmt.Fprintf(&buf, "partial: %s\n", reason)
+	}
+	for _, key := range sortedKeys(counts) {
+		fmt.Fprintf(&buf, "%s: %d\n", key, counts[key])
+	}
+	if err := writeFileAtomically(filename, buf.Bytes()); err != nil {
+		return err
+	}
+	trackOutputFile(filename)
+
+	return nil
+}
+
+func printBatchSummary(targets []batchTarget, totals map[string]map[string]int) {
+	fmt.Printf("\nBatch summary:\n")
+	for _, target := range targets {
+		counts := totals[target.label]
+		total := 0
+		for _, n := range counts {
+			total += n
+		}
+
+		fmt.Printf("- %s: %d results\n", target.label, total)
+		for _, key := range sortedKeys(counts) {
+			fmt.Printf("    %s: %d\n", key, counts[key])
+		}
+	}
+}
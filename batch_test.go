@@ -0,0 +1,49 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func writeTempFile(t *testing.T, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "input")
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestReadTargetsFile(t *testing.T) {
+	filename := writeTempFile(t, "# programs\nacme: acme, acme corp ,\n\nglobex:globex\n")
+
+	targets, err := readTargetsFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(targets) != 2 {
+		t.Fatalf("got %d targets, want 2", len(targets))
+	}
+	if targets[0].label != "acme" || !equalStrings(targets[0].keywords, []string{"acme", "acme corp"}) {
+		t.Errorf("first target = %+v", targets[0])
+	}
+	if targets[1].label != "globex" || !equalStrings(targets[1].keywords, []string{"globex"}) {
+		t.Errorf("second target = %+v", targets[1])
+	}
+}
+
+func TestReadTargetsFileErrors(t *testing.T) {
+	for _, content := range []string{
+		"no separator\n",
+		"acme:\n",
+		"../escape: acme\n",
+		"acme: a\nacme: b\n",
+	} {
+		if _, err := readTargetsFile(writeTempFile(t, content)); err == nil {
+			t.Errorf("readTargetsFile(%q) succeeded, want error", content)
+		}
+	}
+}
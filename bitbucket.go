@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// bitbucketClient talks to the REST API of a self-hosted Bitbucket Data
+// Center (formerly Server) instance, authenticating with a personal access
+// token.
+type bitbucketClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+type bitbucketPage struct {
+	Values []struct {
+		Key         string `json:"key"`
+		Slug        string `json:"slug"`
+		Description string `json:"description"`
+		Project     struct {
+			Key string `json:"key"`
+		} `json:"project"`
+	} `json:"values"`
+}
+
+func createBitbucketClient(baseURL string) (*bitbucketClient, error) {
+	token := os.Getenv("BITBUCKET_ACCESS_TOKEN")
+	if token == "" {
+		return nil, errors.New("BITBUCKET_ACCESS_TOKEN environment variable is not set")
+	}
+
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid Bitbucket URL %q", baseURL)
+	}
+
+	transport := newProviderTransport("bitbucket", nil, newAdaptiveLimiter("bitbucket", 10, rate.Every(time.Minute), func(*http.Request) string { return "rest" }))
+
+	return &bitbucketClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		httpClient: &http.Client{Transport: transport},
+	}, nil
+}
+
+// list fetches the first page of a paged REST API 1.0 collection.
+func (c *bitbucketClient) list(path string, params url.Values) (*bitbucketPage, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/rest/api/1.0/"+path+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	}
+
+	var page bitbucketPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func searchBitbucket(client *bitbucketClient, query string, cfg config) {
+	if client == nil {
+		return
+	}
+
+	if cfg.orgFlag {
+		searchBitbucketProjects(client, query, cfg.maxFlag)
+	}
+
+	if cfg.repoFlag {
+		searchBitbucketRepositories(client, query, cfg.maxFlag)
+	}
+
+	if cfg.userFlag {
+		searchBitbucketUsers(client, query, cfg.maxFlag)
+	}
+}
+
+// searchBitbucketProjects searches projects, Bitbucket's equivalent of
+// organizations, and reports their keys.
+func searchBitbucketProjects(client *bitbucketClient, query string, maxResults int) {
+	page, err := client.list("projects", url.Values{"name": {searchText(query)}, "limit": {strconv.Itoa(pageSize(maxResults))}})
+	if err != nil {
+		recordSearchError("bitbucket", "project search", query, err)
+		return
+	}
+
+	projectKeys := make([]string, len(page.Values))
+	descriptions := make(map[string]string)
+	for i, project := range page.Values {
+		projectKeys[i] = project.Key
+		descriptions[project.Key] = project.Description
+	}
+
+	emitResults("bitbucket", "project", query, fmt.Sprintf("Bitbucket projects matching '%s'", query), "bitbucket_projects.txt", projectKeys)
+	reportAtlassianLinks(query, descriptions)
+}
+
+func searchBitbucketRepositories(client *bitbucketClient, query string, maxResults int) {
+	page, err := client.list("repos", url.Values{"name": {searchText(query)}, "limit": {strconv.Itoa(pageSize(maxResults))}})
+	if err != nil {
+		recordSearchError("bitbucket", "repository search", query, err)
+		return
+	}
+
+	repoNames := make([]string, len(page.Values))
+	descriptions := make(map[string]string)
+	for i, repo := range page.Values {
+		repoNames[i] = repo.Project.Key + "/" + repo.Slug
+		descriptions[repoNames[i]] = repo.Description
+	}
+
+	emitResults("bitbucket", "repository", query, fmt.Sprintf("Bitbucket repositories matching '%s'", query), "bitbucket_repositories.txt", repoNames)
+	reportAtlassianLinks(query, descriptions)
+}
+
+func searchBitbucketUsers(client *bitbucketClient, query string, maxResults int) {
+	page, err := client.list("users", url.Values{"filter": {searchText(query)}, "limit": {strconv.Itoa(pageSize(maxResults))}})
+	if err != nil {
+		recordSearchError("bitbucket", "user search", query, err)
+		return
+	}
+
+	userSlugs := make([]string, len(page.Values))
+	for i, user := range page.Values {
+		userSlugs[i] = user.Slug
+	}
+
+	emitResults("bitbucket", "user", query, fmt.Sprintf("Bitbucket users matching '%s'", query), "bitbucket_users.txt", userSlugs)
+}
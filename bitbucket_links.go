@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var descriptionURLRegexp = regexp.MustCompile(`https?://[^\s<>"'()\[\]]+`)
+
+// atlassianLinks returns the Confluence and Jira URLs in a description.
+// Self-hosted ones reveal internal hostnames, and Cloud ones the site
+// name.
+func atlassianLinks(description string) []string {
+	var links []string
+	for _, raw := range descriptionURLRegexp.FindAllString(description, -1) {
+		raw = strings.TrimRight(raw, ".,;:!?")
+		if u, err := url.Parse(raw); err == nil && isAtlassianURL(u) {
+			links = append(links, raw)
+		}
+	}
+	return links
+}
+
+func isAtlassianURL(u *url.URL) bool {
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return false
+	}
+	if strings.HasSuffix(host, ".atlassian.net") {
+		return true
+	}
+
+	first := strings.SplitN(host, ".", 2)[0]
+	for _, prefix := range []string{"confluence", "jira", "wiki"} {
+		if strings.HasPrefix(first, prefix) {
+			return true
+		}
+	}
+
+	path := strings.ToLower(u.Path)
+	for _, prefix := range []string{"/confluence/", "/jira/", "/wiki/", "/display/", "/browse/"} {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// reportAtlassianLinks reports the Confluence and Jira links in the
+// descriptions of Bitbucket projects or repositories, as "name: url", and
+// the hosts they point to.
+func reportAtlassianLinks(query string, descriptions map[string]string) {
+	names := make([]string, 0, len(descriptions))
+	for name := range descriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var links, hosts []string
+	for _, name := range names {
+		for _, link := range atlassianLinks(descriptions[name]) {
+			links = append(links, name+": "+link)
+			if u, err := url.Parse(link); err == nil {
+				hosts = append(hosts, strings.ToLower(u.Hostname()))
+			}
+		}
+	}
+	if len(links) == 0 {
+		return
+	}
+
+	emitResults("bitbucket", "atlassian_link", query, fmt.Sprintf("Confluence and Jira links in Bitbucket descriptions matching '%s'", query), "bitbucket_atlassian_links.txt", links)
+	emitResults("bitbucket", "atlassian_host", query, fmt.Sprintf("Confluence and Jira hosts linked from Bitbucket matching '%s'", query), "bitbucket_atlassian_hosts.txt", hosts)
+}
@@ -0,0 +1,99 @@
+package main
+
+import "testing"
+
+func TestSearchBitbucketAgainstFakeAPI(t *testing.T) {
+	cfg := config{orgFlag: true, repoFlag: true, userFlag: true, maxFlag: 5}
+	setupRun(t, cfg)
+
+	srv, requests := newFakeAPI(t, map[string]interface{}{
+		"/bitbucket/rest/api/1.0/projects": map[string]interface{}{
+			"values": []map[string]string{{"key": "ACME"}},
+		},
+		"/bitbucket/rest/api/1.0/repos": map[string]interface{}{
+			"values": []map[string]interface{}{{"slug": "acme-web", "project": map[string]string{"key": "ACME"}}},
+		},
+	})
+
+	client := &bitbucketClient{baseURL: srv.URL + "/bitbucket", token: "test-token", httpClient: srv.Client()}
+	searchBitbucket(client, "acme", cfg)
+
+	if got, want := resultNames("bitbucket", "project"), []string{"ACME"}; !equalStrings(got, want) {
+		t.Errorf("projects = %v, want %v", got, want)
+	}
+	if got, want := resultNames("bitbucket", "repository"), []string{"ACME/acme-web"}; !equalStrings(got, want) {
+		t.Errorf("repositories = %v, want %v", got, want)
+	}
+
+	// The fake API has no user route, so the user search fails.
+	summary := searchErrorSummary()
+	if len(summary) != 1 || summary[0].Operation != "user search" || summary[0].Class != "not_found" {
+		t.Errorf("errors = %+v, want one not_found user search", summary)
+	}
+
+	if len(*requests) != 3 {
+		t.Fatalf("made %d requests, want 3", len(*requests))
+	}
+	if q := (*requests)[0]; q.Get("name") != "acme" || q.Get("limit") != "5" {
+		t.Errorf("project query = %v", q)
+	}
+	if q := (*requests)[2]; q.Get("filter") != "acme" {
+		t.Errorf("user query = %v", q)
+	}
+}
+
+func TestCreateBitbucketClient(t *testing.T) {
+	setenv(t, "BITBUCKET_ACCESS_TOKEN", "")
+	if _, err := createBitbucketClient("https://bitbucket.example.com"); err == nil {
+		t.Error("created a client without a token")
+	}
+
+	setenv(t, "BITBUCKET_ACCESS_TOKEN", "token")
+	if _, err := createBitbucketClient("bitbucket.example.com"); err == nil {
+		t.Error("accepted a URL without a scheme")
+	}
+
+	client, err := createBitbucketClient("https://bitbucket.example.com/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.baseURL != "https://bitbucket.example.com" {
+		t.Errorf("baseURL = %q", client.baseURL)
+	}
+	if _, ok := client.httpClient.Transport.(*cacheTransport); !ok {
+		t.Errorf("transport = %T, want the provider middleware stack", client.httpClient.Transport)
+	}
+}
+
+func TestBitbucketAtlassianLinks(t *testing.T) {
+	cfg := config{orgFlag: true, repoFlag: true, maxFlag: 5}
+	setupRun(t, cfg)
+
+	srv, _ := newFakeAPI(t, map[string]interface{}{
+		"/rest/api/1.0/projects": map[string]interface{}{
+			"values": []map[string]string{{"key": "ACME", "description": "Runbooks: https://confluence.corp.acme.com/display/OPS."}},
+		},
+		"/rest/api/1.0/repos": map[string]interface{}{
+			"values": []map[string]interface{}{
+				{"slug": "web", "project": map[string]string{"key": "ACME"}, "description": "See https://acme.atlassian.net/browse/WEB-1 and https://acme.com"},
+				{"slug": "api", "project": map[string]string{"key": "ACME"}, "description": "Docs (https://intranet.acme.com/wiki/api)"},
+			},
+		},
+	})
+
+	client := &bitbucketClient{baseURL: srv.URL, token: "test-token", httpClient: srv.Client()}
+	searchBitbucket(client, "acme", cfg)
+
+	links := []string{
+		"ACME: https://confluence.corp.acme.com/display/OPS",
+		"ACME/api: https://intranet.acme.com/wiki/api",
+		"ACME/web: https://acme.atlassian.net/browse/WEB-1",
+	}
+	if got := resultNames("bitbucket", "atlassian_link"); !equalStrings(got, links) {
+		t.Errorf("links = %v, want %v", got, links)
+	}
+	hosts := []string{"confluence.corp.acme.com", "intranet.acme.com", "acme.atlassian.net"}
+	if got := resultNames("bitbucket", "atlassian_host"); !equalStrings(got, hosts) {
+		t.Errorf("hosts = %v, want %v", got, hosts)
+	}
+}
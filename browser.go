@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// bookmarkHrefRegexp matches the links of a Netscape bookmarks file, the
+// HTML export of every major browser.
+var bookmarkHrefRegexp = regexp.MustCompile(`(?i)<a\s[^>]*\bhref\s*=\s*"([^"]*)"`)
+
+// readBrowserHostnames returns the hostnames of the web pages in a HAR file
+// or a bookmarks export, without duplicates. Bookmarks may be
+// exported as HTML, as Chrome's Bookmarks JSON file or as a Firefox JSON
+// backup. Only http and https URLs count.
+func readBrowserHostnames(filename string) ([]string, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var urls []string
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")):
+		var doc interface{}
+		if err := json.Unmarshal(trimmed, &doc); err != nil {
+			return nil, err
+		}
+		urls = browserJSONURLs(doc)
+	case bookmarkHrefRegexp.Match(data):
+		for _, m := range bookmarkHrefRegexp.FindAllSubmatch(data, -1) {
+			urls = append(urls, html.UnescapeString(string(m[1])))
+		}
+	default:
+		return nil, errors.New("not a HAR file or a bookmarks export")
+	}
+
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, raw := range urls {
+		u, err := url.Parse(strings.TrimSpace(raw))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+		if host != "" && !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
+
+// browserJSONURLs returns the URLs of a JSON browser export: the request
+// URLs of a HAR file's entries, in order, or the bookmarks of a Chrome
+// ("url") or Firefox ("uri") bookmarks tree, however nested, folder by
+// folder.
+func browserJSONURLs(doc interface{}) []string {
+	root, _ := doc.(map[string]interface{})
+	if har, ok := root["log"].(map[string]interface{}); ok {
+		var urls []string
+		entries, _ := har["entries"].([]interface{})
+		for _, entry := range entries {
+			e, _ := entry.(map[string]interface{})
+			request, _ := e["request"].(map[string]interface{})
+			if u, ok := request["url"].(string); ok {
+				urls = append(urls, u)
+			}
+		}
+		return urls
+	}
+
+	var urls []string
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			for _, key := range []string{"url", "uri"} {
+				if u, ok := n[key].(string); ok {
+					urls = append(urls, u)
+				}
+			}
+			// Chrome nests its folders under roots and children, Firefox
+			// under children only.
+			keys := make([]string, 0, len(n))
+			for key := range n {
+				if key != "url" && key != "uri" {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				walk(n[key])
+			}
+		case []interface{}:
+			for _, child := range n {
+				walk(child)
+			}
+		}
+	}
+	walk(doc)
+	return urls
+}
+
+// withinDomains reports whether host is one of domains or a subdomain of
+// one.
+func withinDomains(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// addBrowserKeywords seeds words with the keywords of every hostname of the
+// -from-browser file, run through the same cleaning as -c. A browsing
+// session also reaches analytics, CDNs and other third parties, so with
+// -target-domain only the hostnames under those domains are kept.
+func addBrowserKeywords(words map[string]struct{}, cfg config) {
+	if cfg.fromBrowserFlag == "" {
+		return
+	}
+
+	hosts, err := readBrowserHostnames(cfg.fromBrowserFlag)
+	if err != nil {
+		fmt.Printf("Error reading %s: %s\n", cfg.fromBrowserFlag, err)
+		os.Exit(1)
+	}
+	verbosePrint("Read %d hostnames from %s\n", len(hosts), cfg.fromBrowserFlag)
+
+	domains := splitList(cfg.targetDomainFlag)
+	cleanCfg := cfg
+	cleanCfg.cleanFlag = true
+	for _, host := range hosts {
+		if len(domains) > 0 && !withinDomains(host, domains) {
+			verbosePrint("Skipping '%s': outside -target-domain\n", host)
+			continue
+		}
+		processWord(host, words, cleanCfg)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import "testing"
+
+func TestReadBrowserHostnames(t *testing.T) {
+	tests := []struct {
+		name, content string
+		want          []string
+	}{
+		{"HAR", `{"log": {"version": "1.2", "entries": [
+			{"request": {"method": "GET", "url": "https://portal.acme.com/login"}},
+			{"request": {"method": "GET", "url": "https://cdn.acme-static.net/app.js"}},
+			{"request": {"method": "POST", "url": "https://PORTAL.acme.com./api/session"}},
+			{"request": {"method": "GET", "url": "data:image/png;base64,AAAA"}}
+		]}}`, []string{"portal.acme.com", "cdn.acme-static.net"}},
+		{"Chrome bookmarks", `{"roots": {"bookmark_bar": {"children": [
+			{"type": "url", "name": "Jira", "url": "https://jira.acme.com/browse/OPS"},
+			{"type": "folder", "children": [{"type": "url", "url": "chrome://settings"}]}
+		]}}, "version": 1}`, []string{"jira.acme.com"}},
+		{"Firefox backup", `{"title": "", "children": [{"title": "Wiki", "uri": "http://wiki.acme.com/"}]}`, []string{"wiki.acme.com"}},
+		{"bookmarks HTML", `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<DL><p>
+    <DT><A HREF="https://grafana.acme.com/d/abc?a=1&amp;b=2" ADD_DATE="1">Grafana</A>
+    <DT><A HREF="javascript:void(0)">Bookmarklet</A>
+</DL><p>`, []string{"grafana.acme.com"}},
+	}
+
+	for _, tt := range tests {
+		hosts, err := readBrowserHostnames(writeTempFile(t, tt.content))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !equalStrings(hosts, tt.want) {
+			t.Errorf("%s: hosts = %v, want %v", tt.name, hosts, tt.want)
+		}
+	}
+
+	if _, err := readBrowserHostnames(writeTempFile(t, "api.acme.com\n")); err == nil {
+		t.Error("plain text: expected an error")
+	}
+}
+
+func TestBrowserKeywords(t *testing.T) {
+	filename := writeTempFile(t, `{"log": {"entries": [
+		{"request": {"url": "https://vpn.acme.com/"}},
+		{"request": {"url": "https://www.google-analytics.com/collect"}}
+	]}}`)
+	setupRun(t, config{fromBrowserFlag: filename, targetDomainFlag: "acme.com", minWordLengthFlag: 2})
+
+	words := readAndCleanWords(flags, nil)
+
+	if got, want := sortedWords(words), []string{"acme", "vpn", "vpn.acme.com"}; !equalStrings(got, want) {
+		t.Errorf("words = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import "sync"
+
+// resultBudget shares the -max-total cap on a run's results fairly among
+// its keywords, so the first keywords can't exhaust it: each keyword gets
+// an equal share of what's left when it first reports results, and what it
+// leaves unused goes back to the keywords after it.
+type resultBudget struct {
+	mu        sync.Mutex
+	remaining int
+	pending   map[string]bool
+	shares    map[string]int
+	exhausted bool
+}
+
+// budget is the current run's -max-total budget, or nil without a cap.
+var budget *resultBudget
+
+func newResultBudget(total int, words []string) *resultBudget {
+	b := &resultBudget{remaining: total, pending: make(map[string]bool), shares: make(map[string]int)}
+	b.add(words)
+	return b
+}
+
+// add makes words share the budget too, as recursion keywords do.
+func (b *resultBudget) add(words []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, word := range words {
+		if _, allocated := b.shares[word]; !allocated {
+			b.pending[word] = true
+		}
+	}
+}
+
+// take returns how many of n results of query fit in its share. Queries
+// that aren't keywords, like the repositories enrichment looks into, are
+// off budget.
+func (b *resultBudget) take(query string, n int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.pending[query] {
+		delete(b.pending, query)
+		share := b.remaining / (len(b.pending) + 1)
+		if share == 0 && b.remaining > 0 {
+			share = 1
+		}
+		b.shares[query] = share
+		b.remaining -= share
+	}
+
+	share, ok := b.shares[query]
+	if !ok {
+		return n
+	}
+	if n > share {
+		if !b.exhausted && b.remaining == 0 {
+			b.exhausted = true
+			verbosePrint("-max-total reached: keywords are limited to their share of results\n")
+		}
+		n = share
+	}
+	b.shares[query] -= n
+	return n
+}
+
+// release returns what's left of word's share once its searches are done.
+func (b *resultBudget) release(word string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if share, ok := b.shares[word]; ok {
+		b.remaining += share
+		b.shares[word] = 0
+	}
+	delete(b.pending, word)
+}
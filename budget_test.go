@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestResultBudgetSharesFairly(t *testing.T) {
+	setupRun(t, config{})
+	budget = newResultBudget(6, []string{"acme", "globex", "initech"})
+
+	many := []string{"a1", "a2", "a3", "a4", "a5", "a6"}
+	emitResults("github", "repository", "acme", "Repositories", "github_repositories.txt", many)
+	if got := resultNames("github", "repository"); len(got) != 2 {
+		t.Errorf("first keyword got %d results, want its share of 2", len(got))
+	}
+
+	// Unused shares go to the keywords after them.
+	budget.release("acme")
+	emitResults("github", "repository", "globex", "Repositories", "github_repositories.txt", []string{"g1"})
+	budget.release("globex")
+	emitResults("github", "repository", "initech", "Repositories", "github_repositories.txt", []string{"i1", "i2", "i3", "i4"})
+
+	if got := len(resultNames("github", "repository")); got != 6 {
+		t.Errorf("run got %d results, want the 6 of -max-total", got)
+	}
+
+	// Enrichment queries aren't keywords and are off budget.
+	emitResults("github", "release_asset", "acme/web", "Release assets", "github_release_assets.txt", []string{"r1"})
+	if got := len(resultNames("github", "release_asset")); got != 1 {
+		t.Errorf("release assets = %d, want 1", got)
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Before searching, the categories a run selects are matched against what
+// each enabled platform can search, so a category a platform doesn't have
+// is reported once up front and left out of its searches, rather than
+// queried in vain for every keyword or skipped without a word.
+
+// pluginCapabilitiesTimeout bounds the run asking a plugin what it searches.
+const pluginCapabilitiesTimeout = 10 * time.Second
+
+// forgePlatforms are the platforms of accounts and repositories. The pastes
+// and stackoverflow categories each search a service of their own, which
+// the other platforms can't be expected to have.
+var forgePlatforms = []string{"github", "gitlab", "bitbucket", "plugins"}
+
+// categoryGap is a category selected for a run that an enabled platform
+// won't search, and why.
+type categoryGap struct {
+	Category string
+	Platform string
+	Reason   string
+}
+
+// pluginCapabilities is what a plugin run with the argument "capabilities"
+// answers: the request categories it searches.
+type pluginCapabilities struct {
+	Categories *[]string `json:"categories"`
+}
+
+// negotiatePlugins asks each plugin which categories it searches.
+func negotiatePlugins(plugins []plugin) {
+	for i := range plugins {
+		plugins[i].Categories = queryPluginCapabilities(plugins[i])
+	}
+}
+
+// queryPluginCapabilities runs p with the single argument "capabilities"
+// and nothing on stdin. A plugin answering a line such as
+// {"categories": ["repository"]} is only asked for those; nil means it
+// didn't answer, as plugins written before the exchange don't, and is asked
+// for every category.
+func queryPluginCapabilities(p plugin) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginCapabilitiesTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, p.Path, "capabilities").Output()
+	if err != nil {
+		verbosePrint("Plugin %s doesn't declare its categories (%s), asking it for all of them\n", p.Name, err)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var caps pluginCapabilities
+		if json.Unmarshal(scanner.Bytes(), &caps) != nil || caps.Categories == nil {
+			break
+		}
+
+		categories := []string{}
+		for _, category := range *caps.Categories {
+			if _, ok := pluginCategoryFiles[category]; !ok {
+				fmt.Fprintf(os.Stderr, "Ignoring unknown category %q declared by plugin %s\n", category, p.Name)
+				continue
+			}
+			categories = append(categories, category)
+		}
+		verbosePrint("Plugin %s searches: %s\n", p.Name, strings.Join(categories, ", "))
+		return categories
+	}
+	verbosePrint("Plugin %s doesn't declare its categories, asking it for all of them\n", p.Name)
+	return nil
+}
+
+// supports reports whether p searches a request category.
+func (p plugin) supports(category string) bool {
+	return p.Categories == nil || containsString(p.Categories, category)
+}
+
+// pluginRequestCategories maps the -categories names of the categories
+// plugins search to those of their requests.
+var pluginRequestCategories = map[string]string{"org": "organization", "repo": "repository", "user": "user"}
+
+// selectedCategories returns the categories cfg searches, by -categories
+// name, in the order of searchCategories.
+func selectedCategories(cfg config) []string {
+	selected := map[string]bool{
+		"org": cfg.orgFlag, "repo": cfg.repoFlag, "user": cfg.userFlag, "discussions": cfg.discussionsFlag,
+		"wiki": cfg.wikiFlag, "pastes": cfg.pastesFlag, "stackoverflow": cfg.stackFlag,
+	}
+	var names []string
+	for _, category := range searchCategories {
+		if selected[category.Name] {
+			names = append(names, category.Name)
+		}
+	}
+	return names
+}
+
+// platformGap returns why platform won't search category in this run, ""
+// if it will.
+func platformGap(category searchCategory, platform string, p *plugin) string {
+	family := platform
+	if p != nil {
+		family = "plugins"
+	}
+	if !containsString(category.Platforms, family) {
+		return "it doesn't have " + category.Name
+	}
+	if p != nil && !p.supports(pluginRequestCategories[category.Name]) {
+		return "the plugin doesn't search " + category.Name
+	}
+	if platform == "gitlab" && category.Name == "wiki" && gitlabAnonymous {
+		return "wiki search needs GITLAB_ACCESS_TOKEN or dorky login"
+	}
+	return ""
+}
+
+// planCategories matches the categories cfg selects against the enabled
+// platforms, the built-in ones and plugins, returning the combinations the
+// run won't search.
+func planCategories(cfg config, platforms []string, plugins []plugin) []categoryGap {
+	byName := make(map[string]*plugin)
+	for i := range plugins {
+		byName[plugins[i].Name] = &plugins[i]
+		platforms = append(platforms, plugins[i].Name)
+	}
+
+	var gaps []categoryGap
+	for _, name := range selectedCategories(cfg) {
+		category, _ := lookupSearchCategory(name)
+		if !containsAnyString(category.Platforms, forgePlatforms) {
+			continue
+		}
+		for _, platform := range platforms {
+			if reason := platformGap(category, platform, byName[platform]); reason != "" {
+				gaps = append(gaps, categoryGap{Category: name, Platform: platform, Reason: reason})
+			}
+		}
+	}
+	return gaps
+}
+
+// printCategoryGaps tells which categories the run won't search where, one
+// line per category, and those no enabled platform searches at all.
+func printCategoryGaps(w io.Writer, gaps []categoryGap, platforms int) {
+	var order []string
+	byCategory := make(map[string][]categoryGap)
+	for _, gap := range gaps {
+		if byCategory[gap.Category] == nil {
+			order = append(order, gap.Category)
+		}
+		byCategory[gap.Category] = append(byCategory[gap.Category], gap)
+	}
+
+	for _, name := range order {
+		descriptions := make([]string, len(byCategory[name]))
+		for i, gap := range byCategory[name] {
+			descriptions[i] = fmt.Sprintf("%s (%s)", gap.Platform, gap.Reason)
+		}
+		line := fmt.Sprintf("Not searching %s on %s", name, strings.Join(descriptions, ", "))
+		if len(byCategory[name]) == platforms {
+			line += ": no enabled platform searches it"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// reportCategoryGaps plans the categories of a run on its enabled
+// platforms and prints the gaps on stderr.
+func reportCategoryGaps(cfg config, platforms []string, plugins []plugin) {
+	printCategoryGaps(os.Stderr, planCategories(cfg, platforms, plugins), len(platforms)+len(plugins))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestQueryPluginCapabilities(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		script string
+		want   []string
+	}{
+		{`[ "$1" = capabilities ] && echo '{"categories": ["repository", "user", "gist"]}'`, []string{"repository", "user"}},
+		{`[ "$1" = capabilities ] && echo '{"categories": []}'`, []string{}},
+		// Written before the exchange: reads its request and fails.
+		{"read request || exit 1\n", nil},
+		{`echo '{"category": "repository", "name": "acme/api"}'`, nil},
+	}
+
+	for i, tt := range tests {
+		p := plugin{Name: "gitea", Path: writePlugin(t, dir, "gitea", tt.script, 0755)}
+		got := queryPluginCapabilities(p)
+		if (got == nil) != (tt.want == nil) || !equalStrings(got, tt.want) {
+			t.Errorf("test %d: categories = %#v, want %#v", i, got, tt.want)
+		}
+	}
+}
+
+func TestPlanCategories(t *testing.T) {
+	oldAnonymous := gitlabAnonymous
+	gitlabAnonymous = true
+	t.Cleanup(func() { gitlabAnonymous = oldAnonymous })
+
+	cfg := config{orgFlag: true, repoFlag: true, wikiFlag: true, pastesFlag: true}
+	plugins := []plugin{{Name: "gitea", Categories: []string{"repository"}}, {Name: "legacy"}}
+	gaps := planCategories(cfg, []string{"github", "gitlab", "bitbucket"}, plugins)
+
+	var out bytes.Buffer
+	printCategoryGaps(&out, gaps, 5)
+	want := "Not searching org on gitea (the plugin doesn't search org)\n" +
+		"Not searching wiki on gitlab (wiki search needs GITLAB_ACCESS_TOKEN or dorky login), bitbucket (it doesn't have wiki), gitea (it doesn't have wiki), legacy (it doesn't have wiki)\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	printCategoryGaps(&out, planCategories(config{wikiFlag: true, glOnlyFlag: true}, []string{"gitlab"}, nil), 1)
+	if want := "Not searching wiki on gitlab (wiki search needs GITLAB_ACCESS_TOKEN or dorky login): no enabled platform searches it\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestSearchPluginDeclaredCategories(t *testing.T) {
+	cfg := config{orgFlag: true, repoFlag: true, maxFlag: 5}
+	setupRun(t, cfg)
+
+	dir := t.TempDir()
+	gitea := writePlugin(t, dir, "gitea", `read request
+case "$request" in
+*'"categories":["repository"]'*) echo '{"category": "repository", "name": "acme/api"}' ;;
+*) echo "unexpected request: $request" >&2; exit 1 ;;
+esac
+`, 0755)
+	searchPlugin(plugin{Name: "gitea", Path: gitea, Categories: []string{"repository", "user"}}, "acme", cfg)
+	if len(searchErrors) != 0 {
+		t.Fatalf("search errors = %+v", searchErrors)
+	}
+	if got := readOutputLines(t, "gitea_repositories.txt"); !equalStrings(got, []string{"acme/api"}) {
+		t.Errorf("gitea_repositories.txt = %v", got)
+	}
+
+	// A plugin searching none of the selected categories isn't run.
+	broken := writePlugin(t, dir, "broken", "exit 1\n", 0755)
+	searchPlugin(plugin{Name: "broken", Path: broken, Categories: []string{"user"}}, "acme", cfg)
+	if len(searchErrors) != 0 {
+		t.Errorf("search errors = %+v, want the plugin skipped", searchErrors)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// searchCategory is a category of search -categories can select, with the
+// platforms it's searched on.
+type searchCategory struct {
+	Name      string
+	Platforms []string
+	enable    func(*config)
+}
+
+// searchCategories lists the categories -categories accepts, in the order
+// they're listed in errors. New searches get an entry here.
+var searchCategories = []searchCategory{
+	{"org", []string{"github", "gitlab", "bitbucket", "plugins"}, func(cfg *config) { cfg.orgFlag = true }},
+	{"repo", []string{"github", "gitlab", "bitbucket", "plugins"}, func(cfg *config) { cfg.repoFlag = true }},
+	{"user", []string{"github", "gitlab", "bitbucket", "plugins"}, func(cfg *config) { cfg.userFlag = true }},
+	{"discussions", []string{"github"}, func(cfg *config) { cfg.discussionsFlag = true }},
+	{"wiki", []string{"github", "gitlab"}, func(cfg *config) { cfg.wikiFlag = true }},
+	{"pastes", []string{"pastes"}, func(cfg *config) { cfg.pastesFlag = true }},
+	{"stackoverflow", []string{"stackexchange"}, func(cfg *config) { cfg.stackFlag = true }},
+}
+
+func lookupSearchCategory(name string) (searchCategory, bool) {
+	for _, category := range searchCategories {
+		if category.Name == name {
+			return category, true
+		}
+	}
+	return searchCategory{}, false
+}
+
+// supportedCategories describes every category with its platforms, for
+// errors.
+func supportedCategories() string {
+	descriptions := make([]string, len(searchCategories))
+	for i, category := range searchCategories {
+		descriptions[i] = fmt.Sprintf("%s (%s)", category.Name, strings.Join(category.Platforms, ", "))
+	}
+	return strings.Join(descriptions, ", ")
+}
+
+// applyCategories turns on the searches of the comma-separated categories,
+// on top of those selected by -o, -r, -u and the other search flags. With
+// -gh or -gl, categories that platform doesn't have are rejected rather
+// than silently searching nothing.
+func applyCategories(cfg *config, list string) error {
+	only := ""
+	if cfg.ghOnlyFlag {
+		only = "github"
+	} else if cfg.glOnlyFlag {
+		only = "gitlab"
+	}
+
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		category, ok := lookupSearchCategory(name)
+		if !ok {
+			return fmt.Errorf("unknown category %q (supported: %s)", name, supportedCategories())
+		}
+		if only != "" && !containsString(category.Platforms, only) {
+			return fmt.Errorf("category %q isn't searched on %s, only on %s", name, only, strings.Join(category.Platforms, ", "))
+		}
+		category.enable(cfg)
+	}
+	return nil
+}
+
+// applyCategoriesFlag makes the categories of -categories effective.
+func applyCategoriesFlag(cfg *config) {
+	if cfg.categoriesFlag == "" {
+		return
+	}
+
+	if err := applyCategories(cfg, cfg.categoriesFlag); err != nil {
+		fmt.Printf("Error in -categories: %s\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyCategories(t *testing.T) {
+	cfg := config{orgFlag: true}
+	if err := applyCategories(&cfg, "Repo, wiki,,pastes"); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.orgFlag || !cfg.repoFlag || !cfg.wikiFlag || !cfg.pastesFlag || cfg.userFlag || cfg.discussionsFlag {
+		t.Errorf("config = %+v, want org, repo, wiki and pastes", cfg)
+	}
+}
+
+func TestApplyCategoriesErrors(t *testing.T) {
+	err := applyCategories(&config{}, "org,gists")
+	if err == nil || !strings.Contains(err.Error(), `"gists"`) || !strings.Contains(err.Error(), "discussions (github)") {
+		t.Errorf("unknown category error = %v, want it to list the supported categories", err)
+	}
+
+	err = applyCategories(&config{glOnlyFlag: true}, "discussions")
+	if err == nil || !strings.Contains(err.Error(), "only on github") {
+		t.Errorf("-gl discussions error = %v, want it to name the platforms", err)
+	}
+
+	if err := applyCategories(&config{ghOnlyFlag: true}, "org,wiki"); err != nil {
+		t.Errorf("-gh org,wiki: %v", err)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+const (
+	githubWorkflowsDir = ".github/workflows"
+	gitlabCIFile       = ".gitlab-ci.yml"
+
+	// dockerfile is read along with the CI configuration for the images
+	// it builds on.
+	dockerfile = "Dockerfile"
+)
+
+var (
+	ciURLRegexp         = regexp.MustCompile(`(?i)\bhttps?://([a-z0-9-]+(?:\.[a-z0-9-]+)+)`)
+	ciImageRegexp       = regexp.MustCompile(`(?im)^[ \t-]*image[ \t]*:[ \t]*["']?([^\s"'#]+)`)
+	ciImageNameRegexp   = regexp.MustCompile(`(?im)^[ \t-]*image[ \t]*:[ \t]*\n[ \t]*name[ \t]*:[ \t]*["']?([^\s"'#]+)`)
+	ciRegistryRegexp    = regexp.MustCompile(`(?im)^[ \t-]*(?:registry|registry-url)[ \t]*:[ \t]*["']?([^\s"'#]+)`)
+	ciDockerLoginRegexp = regexp.MustCompile(`(?i)docker login\b([^\n]*)`)
+
+	// ciSecretRegexp matches GitHub Actions secrets; ciVariableRegexp the
+	// GitLab CI variables named like credentials, since GitLab doesn't set
+	// secrets apart.
+	ciSecretRegexp   = regexp.MustCompile(`\$\{\{\s*secrets\.([A-Za-z0-9_]+)\s*\}\}`)
+	ciVariableRegexp = regexp.MustCompile(`\$\{?([A-Z][A-Z0-9_]*(?:TOKEN|KEY|SECRET|PASSWORD|PASS|PWD|CREDENTIALS))\b`)
+
+	// ciCommonHosts are the platforms' own hosts, which every pipeline
+	// references and say nothing about the target's infrastructure.
+	ciCommonHosts = map[string]bool{
+		"github.com": true, "api.github.com": true, "raw.githubusercontent.com": true, "objects.githubusercontent.com": true,
+		"gitlab.com": true, "registry.gitlab.com": true,
+	}
+)
+
+// ciFindings are what a CI configuration or Dockerfile reveals about the
+// infrastructure it deploys to. images holds fully qualified image
+// references, namespaces the registry accounts owning them.
+type ciFindings struct {
+	hosts, registries, secrets []string
+	images, namespaces         []string
+}
+
+// parseCIConfig extracts the external hostnames, container registries,
+// images and secret names a CI configuration references, each sorted.
+func parseCIConfig(content string) ciFindings {
+	hosts, registries, secrets := make(map[string]bool), make(map[string]bool), make(map[string]bool)
+	images, namespaces := make(map[string]bool), make(map[string]bool)
+
+	for _, m := range ciURLRegexp.FindAllStringSubmatch(content, -1) {
+		if host := strings.ToLower(m[1]); !ciCommonHosts[host] {
+			hosts[host] = true
+		}
+	}
+	var refs []string
+	for _, re := range []*regexp.Regexp{ciImageRegexp, ciImageNameRegexp, dockerURIRegexp} {
+		for _, m := range re.FindAllStringSubmatch(content, -1) {
+			refs = append(refs, m[1])
+		}
+	}
+	addImages(refs, images, namespaces, registries)
+	for _, m := range ciRegistryRegexp.FindAllStringSubmatch(content, -1) {
+		registry := m[1]
+		if u, err := url.Parse(registry); err == nil && u.Host != "" {
+			registry = u.Host
+		}
+		registries[strings.ToLower(registry)] = true
+	}
+	for _, m := range ciDockerLoginRegexp.FindAllStringSubmatch(content, -1) {
+		if registry := dockerLoginRegistry(m[1]); registry != "" {
+			registries[registry] = true
+		}
+	}
+	for _, m := range ciSecretRegexp.FindAllStringSubmatch(content, -1) {
+		if m[1] != "GITHUB_TOKEN" {
+			secrets[m[1]] = true
+		}
+	}
+	for _, m := range ciVariableRegexp.FindAllStringSubmatch(content, -1) {
+		if !strings.HasPrefix(m[1], "CI_") {
+			secrets[m[1]] = true
+		}
+	}
+
+	return ciFindings{
+		hosts: sortedSet(hosts), registries: sortedSet(registries), secrets: sortedSet(secrets),
+		images: sortedSet(images), namespaces: sortedSet(namespaces),
+	}
+}
+
+// parseDockerfile extracts the base images of a Dockerfile, with their
+// namespaces and registries other than Docker Hub.
+func parseDockerfile(content string) ciFindings {
+	images, namespaces, registries := make(map[string]bool), make(map[string]bool), make(map[string]bool)
+	addImages(dockerfileImages(content), images, namespaces, registries)
+	return ciFindings{images: sortedSet(images), namespaces: sortedSet(namespaces), registries: sortedSet(registries)}
+}
+
+// addImages adds the image references refs to images, with their
+// namespaces and, for registries other than Docker Hub, registries.
+func addImages(refs []string, images, namespaces, registries map[string]bool) {
+	for _, ref := range refs {
+		img, ok := parseImageRef(ref)
+		if !ok {
+			continue
+		}
+		images[img.String()] = true
+		if ns := img.namespaceRef(); ns != "" {
+			namespaces[ns] = true
+		}
+		if img.Registry != dockerHub {
+			registries[img.Registry] = true
+		}
+	}
+}
+
+// dockerLoginRegistry returns the registry host of the arguments of a
+// docker login command: its first argument that isn't an option, or ""
+// when that's a variable or Docker Hub is implied.
+func dockerLoginRegistry(args string) string {
+	fields := strings.Fields(args)
+	for i := 0; i < len(fields); i++ {
+		switch field := fields[i]; {
+		case field == "-u" || field == "-p" || field == "--username" || field == "--password":
+			i++
+		case strings.HasPrefix(field, "-"):
+		default:
+			if strings.ContainsAny(field, "$\"'|&;") || !strings.Contains(field, ".") {
+				return ""
+			}
+			return strings.ToLower(field)
+		}
+	}
+	return ""
+}
+
+func sortedSet(set map[string]bool) []string {
+	list := make([]string, 0, len(set))
+	for item := range set {
+		list = append(list, item)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// discoverCIConfigs flags the repositories found so far that hold CI
+// configuration, GitHub Actions workflows or a .gitlab-ci.yml, and reports
+// what it references: external hostnames, container registries and the
+// names of the secrets it uses.
+func discoverCIConfigs(ghClient *github.Client, glClient *gitlab.Client) {
+	seen := make(map[string]bool)
+	discovered := append([]result(nil), collectedResults...)
+
+	for _, r := range discovered {
+		key := r.Platform + ":" + r.Name
+		if seen[key] {
+			continue
+		}
+
+		switch {
+		case r.Platform == "github" && r.Category == "repository" && ghClient != nil:
+			seen[key] = true
+			discoverGitHubWorkflows(ghClient.Repositories, r.Name)
+		case r.Platform == "gitlab" && r.Category == "project" && glClient != nil:
+			seen[key] = true
+			discoverGitLabCI(glClient, r.Name)
+		}
+	}
+}
+
+type githubContentsService interface {
+	GetContents(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error)
+}
+
+func discoverGitHubWorkflows(client githubContentsService, fullName string) {
+	parts := strings.SplitN(fullName, "/", 2)
+	if len(parts) != 2 {
+		return
+	}
+
+	verbosePrint("Looking for workflows in GitHub repository: %s\n", fullName)
+	_, entries, _, err := client.GetContents(context.Background(), parts[0], parts[1], githubWorkflowsDir, nil)
+	if err != nil {
+		if classifyError(err) != "not_found" {
+			recordSearchError("github", "workflow listing", fullName, err)
+		}
+		return
+	}
+
+	files := make(map[string]string)
+	for _, entry := range entries {
+		if entry.GetType() != "file" || !(strings.HasSuffix(entry.GetName(), ".yml") || strings.HasSuffix(entry.GetName(), ".yaml")) {
+			continue
+		}
+		file, _, _, err := client.GetContents(context.Background(), parts[0], parts[1], entry.GetPath(), nil)
+		if err != nil {
+			recordSearchError("github", "workflow fetch", fullName+":"+entry.GetPath(), err)
+			continue
+		}
+		content, err := file.GetContent()
+		if err != nil {
+			recordSearchError("github", "workflow fetch", fullName+":"+entry.GetPath(), err)
+			continue
+		}
+		files[entry.GetPath()] = content
+	}
+
+	if file, _, _, err := client.GetContents(context.Background(), parts[0], parts[1], dockerfile, nil); err == nil {
+		if content, err := file.GetContent(); err == nil {
+			files[dockerfile] = content
+		}
+	} else if classifyError(err) != "not_found" {
+		recordSearchError("github", "Dockerfile fetch", fullName, err)
+	}
+
+	reportCIConfigs("github", "GitHub", fullName, files)
+}
+
+func discoverGitLabCI(client *gitlab.Client, pathWithNamespace string) {
+	verbosePrint("Looking for CI configuration in GitLab project: %s\n", pathWithNamespace)
+	files := make(map[string]string)
+	for _, path := range []string{gitlabCIFile, dockerfile} {
+		content, _, err := client.RepositoryFiles.GetRawFile(pathWithNamespace, path, &gitlab.GetRawFileOptions{Ref: gitlab.String("HEAD")})
+		if err != nil {
+			if classifyError(err) != "not_found" {
+				recordSearchError("gitlab", path+" fetch", pathWithNamespace, err)
+			}
+			continue
+		}
+		files[path] = string(content)
+	}
+
+	reportCIConfigs("gitlab", "GitLab", pathWithNamespace, files)
+}
+
+// reportCIConfigs reports the CI configuration files of repo, given by path
+// along with its Dockerfile, as "repo:path", and what they reference as
+// "repo: value".
+func reportCIConfigs(platform, platformName, repo string, files map[string]string) {
+	if len(files) == 0 {
+		return
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var configs []string
+	hosts, registries, secrets := make(map[string]bool), make(map[string]bool), make(map[string]bool)
+	images, namespaces := make(map[string]bool), make(map[string]bool)
+	for _, path := range paths {
+		found := parseDockerfile(files[path])
+		if path != dockerfile {
+			configs = append(configs, repo+":"+path)
+			found = parseCIConfig(files[path])
+		}
+		for _, host := range found.hosts {
+			hosts[repo+": "+host] = true
+		}
+		for _, registry := range found.registries {
+			registries[repo+": "+registry] = true
+		}
+		for _, secret := range found.secrets {
+			secrets[repo+": "+secret] = true
+		}
+		for _, image := range found.images {
+			images[repo+": "+image] = true
+		}
+		for _, ns := range found.namespaces {
+			namespaces[repo+": "+ns] = true
+		}
+	}
+
+	if len(configs) > 0 {
+		emitResults(platform, "ci_config", repo, fmt.Sprintf("%s CI configuration of '%s'", platformName, repo), platform+"_ci_configs.txt", configs)
+	}
+	if len(hosts) > 0 {
+		emitResults(platform, "ci_host", repo, fmt.Sprintf("Hostnames referenced by the CI of '%s'", repo), platform+"_ci_hosts.txt", sortedSet(hosts))
+	}
+	if len(registries) > 0 {
+		emitResults(platform, "ci_registry", repo, fmt.Sprintf("Registries used by the CI of '%s'", repo), platform+"_ci_registries.txt", sortedSet(registries))
+	}
+	if len(secrets) > 0 {
+		emitResults(platform, "ci_secret", repo, fmt.Sprintf("Secrets used by the CI of '%s'", repo), platform+"_ci_secrets.txt", sortedSet(secrets))
+	}
+	if len(images) > 0 {
+		emitResults(platform, "container_image", repo, fmt.Sprintf("Container images used by '%s'", repo), platform+"_container_images.txt", sortedSet(images))
+	}
+	if len(namespaces) > 0 {
+		emitResults(platform, "image_namespace", repo, fmt.Sprintf("Registry namespaces of the images used by '%s'", repo), platform+"_image_namespaces.txt", sortedSet(namespaces))
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+const testWorkflow = `name: deploy
+on: push
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    container:
+      image: registry.acme.com/build/node:18
+    steps:
+      - uses: actions/checkout@v3
+      - uses: actions/setup-node@v3
+        with:
+          registry-url: https://npm.acme.com/
+      - run: docker login ghcr.io -u acme -p ${{ secrets.GHCR_TOKEN }}
+      - run: curl -H "Authorization: ${{ secrets.DEPLOY_KEY }}" https://deploy.acme.com/hook
+      - run: gh release list --repo https://github.com/acme/api
+        env:
+          GITHUB_TOKEN: ${{ secrets.GITHUB_TOKEN }}
+`
+
+func TestParseCIConfig(t *testing.T) {
+	found := parseCIConfig(testWorkflow)
+
+	if want := []string{"deploy.acme.com", "npm.acme.com"}; !equalStrings(found.hosts, want) {
+		t.Errorf("hosts = %v, want %v", found.hosts, want)
+	}
+	if want := []string{"ghcr.io", "npm.acme.com", "registry.acme.com"}; !equalStrings(found.registries, want) {
+		t.Errorf("registries = %v, want %v", found.registries, want)
+	}
+	if want := []string{"DEPLOY_KEY", "GHCR_TOKEN"}; !equalStrings(found.secrets, want) {
+		t.Errorf("secrets = %v, want %v", found.secrets, want)
+	}
+
+	gitlabCI := parseCIConfig("build:\n  image: node:18\n  script:\n    - docker login -u ci -p $REGISTRY_PASSWORD $CI_REGISTRY\n    - ./deploy --token ${DEPLOY_TOKEN} --job $CI_JOB_TOKEN\n")
+	if want := []string{"DEPLOY_TOKEN", "REGISTRY_PASSWORD"}; !equalStrings(gitlabCI.secrets, want) {
+		t.Errorf("GitLab CI secrets = %v, want %v", gitlabCI.secrets, want)
+	}
+	if len(gitlabCI.registries) != 0 {
+		t.Errorf("GitLab CI registries = %v, want none for Docker Hub images", gitlabCI.registries)
+	}
+}
+
+func TestDiscoverGitHubWorkflows(t *testing.T) {
+	setupRun(t, config{ciConfigsFlag: true})
+	srv, _ := newFakeAPI(t, map[string]interface{}{
+		"/repos/acme/api/contents/.github/workflows": []map[string]string{
+			{"type": "file", "name": "deploy.yml", "path": ".github/workflows/deploy.yml"},
+			{"type": "file", "name": "README.md", "path": ".github/workflows/README.md"},
+		},
+		"/repos/acme/api/contents/.github/workflows/deploy.yml": map[string]string{
+			"type": "file", "encoding": "base64", "content": base64.StdEncoding.EncodeToString([]byte(testWorkflow)),
+		},
+	})
+	client := newFakeGitHubClient(t, srv)
+
+	recordResults("github", "repository", "acme", []string{"acme/api", "acme/web"})
+	discoverCIConfigs(client, nil)
+
+	if got, want := resultNames("github", "ci_config"), []string{"acme/api:.github/workflows/deploy.yml"}; !equalStrings(got, want) {
+		t.Errorf("CI configs = %v, want %v", got, want)
+	}
+	if got, want := resultNames("github", "ci_secret"), []string{"acme/api: DEPLOY_KEY", "acme/api: GHCR_TOKEN"}; !equalStrings(got, want) {
+		t.Errorf("CI secrets = %v, want %v", got, want)
+	}
+	// Repositories without workflows aren't errors.
+	if failed := failedOperations(); failed != 0 {
+		t.Errorf("failedOperations = %d, want 0", failed)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// The interfaces below are the narrow slices of the GitHub and GitLab SDKs
+// that the search functions depend on. The SDK services satisfy them
+// directly (client.Search, client.Users, ...), and tests substitute fakes.
+
+type githubSearchService interface {
+	Users(ctx context.Context, query string, opts *github.SearchOptions) (*github.UsersSearchResult, *github.Response, error)
+	Repositories(ctx context.Context, query string, opts *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error)
+	Code(ctx context.Context, query string, opts *github.SearchOptions) (*github.CodeSearchResult, *github.Response, error)
+}
+
+type githubUsersService interface {
+	Get(ctx context.Context, user string) (*github.User, *github.Response, error)
+}
+
+type gitlabUsersService interface {
+	ListUsers(opt *gitlab.ListUsersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.User, *gitlab.Response, error)
+	GetUser(user int, opt gitlab.GetUsersOptions, options ...gitlab.RequestOptionFunc) (*gitlab.User, *gitlab.Response, error)
+}
+
+type gitlabProjectsService interface {
+	ListProjects(opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error)
+}
+
+type githubOrganizationsService interface {
+	Get(ctx context.Context, org string) (*github.Organization, *github.Response, error)
+	List(ctx context.Context, user string, opts *github.ListOptions) ([]*github.Organization, *github.Response, error)
+}
+
+type githubRepositoriesService interface {
+	ListByOrg(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
+	ListContributors(ctx context.Context, owner, repository string, opts *github.ListContributorsOptions) ([]*github.Contributor, *github.Response, error)
+}
+
+type githubCollaboratorsService interface {
+	ListByOrg(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
+	ListCollaborators(ctx context.Context, owner, repo string, opts *github.ListCollaboratorsOptions) ([]*github.User, *github.Response, error)
+}
+
+type githubActivityService interface {
+	ListStarred(ctx context.Context, user string, opts *github.ActivityListStarredOptions) ([]*github.StarredRepository, *github.Response, error)
+	ListWatched(ctx context.Context, user string, opts *github.ListOptions) ([]*github.Repository, *github.Response, error)
+}
+
+type githubRelatedReposService interface {
+	ListByOrg(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error)
+	Get(ctx context.Context, owner, repo string) (*github.Repository, *github.Response, error)
+	ListForks(ctx context.Context, owner, repo string, opts *github.RepositoryListForksOptions) ([]*github.Repository, *github.Response, error)
+}
+
+type githubKeysService interface {
+	ListKeys(ctx context.Context, user string, opts *github.ListOptions) ([]*github.Key, *github.Response, error)
+	ListGPGKeys(ctx context.Context, user string, opts *github.ListOptions) ([]*github.GPGKey, *github.Response, error)
+}
+
+type githubOrgMembersService interface {
+	List(ctx context.Context, user string, opts *github.ListOptions) ([]*github.Organization, *github.Response, error)
+	ListMembers(ctx context.Context, org string, opts *github.ListMembersOptions) ([]*github.User, *github.Response, error)
+}
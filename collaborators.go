@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// auditCollaborators lists the outside collaborators of the public
+// repositories of every GitHub organization found so far, as "org/repo:
+// login". Listing collaborators takes push access to the repository, so
+// organizations the token can't see into are skipped. Collaborators whose
+// login doesn't match employees, the -employee-pattern, are also flagged as
+// unexpected: external access defenders should review.
+func auditCollaborators(repos githubCollaboratorsService, employees *regexp.Regexp) {
+	seen := make(map[string]bool)
+	discovered := append([]result(nil), collectedResults...)
+
+	var collaborators, unexpected []string
+	for _, r := range discovered {
+		if r.Platform != "github" || r.Category != "organization" || seen[normalizeName(r.Name)] {
+			continue
+		}
+		seen[normalizeName(r.Name)] = true
+
+		found, ok := listOutsideCollaborators(repos, r.Name)
+		if !ok {
+			continue
+		}
+		for _, c := range found {
+			line := c.repo + ": " + c.login
+			collaborators = append(collaborators, line)
+			if employees != nil && !employees.MatchString(c.login) {
+				unexpected = append(unexpected, line)
+			}
+		}
+	}
+
+	if len(collaborators) > 0 {
+		emitResults("github", "outside_collaborator", "", "Outside collaborators of discovered GitHub organizations", "github_outside_collaborators.txt", collaborators)
+	}
+	if len(unexpected) > 0 {
+		emitResults("github", "unexpected_collaborator", "", "Outside collaborators not matching -employee-pattern", "github_unexpected_collaborators.txt", unexpected)
+	}
+}
+
+type outsideCollaborator struct {
+	repo, login string
+}
+
+// listOutsideCollaborators returns the outside collaborators of org's public
+// repositories, sorted by repository and login. It reports false if the
+// token may not list them.
+func listOutsideCollaborators(repos githubCollaboratorsService, org string) ([]outsideCollaborator, bool) {
+	verbosePrint("Listing outside collaborators of GitHub organization: %s\n", org)
+
+	var names []string
+	opt := &github.RepositoryListByOrgOptions{Type: "public", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := repos.ListByOrg(context.Background(), org, opt)
+		if err != nil {
+			recordSearchError("github", "repository listing", org, err)
+			return nil, false
+		}
+		for _, repo := range page {
+			names = append(names, repo.GetName())
+		}
+		if resp == nil || resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	var found []outsideCollaborator
+	for _, name := range names {
+		collabOpt := &github.ListCollaboratorsOptions{Affiliation: "outside", ListOptions: github.ListOptions{PerPage: 100}}
+		for {
+			users, resp, err := repos.ListCollaborators(context.Background(), org, name, collabOpt)
+			if err != nil {
+				// Without push access to one repository, the token has
+				// none to the organization's others either.
+				if class := classifyError(err); class == "forbidden" || class == "not_found" {
+					verbosePrint("Skipping outside collaborators of %s: no access\n", org)
+					return nil, false
+				}
+				recordSearchError("github", "collaborator listing", org+"/"+name, err)
+				break
+			}
+			for _, user := range users {
+				found = append(found, outsideCollaborator{repo: org + "/" + name, login: user.GetLogin()})
+			}
+			if resp == nil || resp.NextPage == 0 {
+				break
+			}
+			collabOpt.Page = resp.NextPage
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].repo != found[j].repo {
+			return found[i].repo < found[j].repo
+		}
+		return found[i].login < found[j].login
+	})
+	return found, true
+}
+
+// compileEmployeePattern compiles -employee-pattern, a case-insensitive
+// regular expression matching the logins of the target's employees.
+func compileEmployeePattern(pattern string) *regexp.Regexp {
+	if pattern == "" {
+		return nil
+	}
+
+	re, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		fmt.Printf("-employee-pattern: %s\n", err)
+		os.Exit(1)
+	}
+	return re
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestAuditCollaborators(t *testing.T) {
+	setupRun(t, config{collabFlag: true})
+	srv, _ := newFakeAPI(t, map[string]interface{}{
+		"/orgs/acme/repos":              []map[string]string{{"name": "api"}, {"name": "web"}},
+		"/repos/acme/api/collaborators": []map[string]string{{"login": "contractor-bob"}, {"login": "acme-alice"}},
+		"/repos/acme/web/collaborators": []map[string]string{},
+		"/orgs/globex/repos":            []map[string]string{{"name": "site"}},
+	})
+	client := newFakeGitHubClient(t, srv)
+
+	recordResults("github", "organization", "acme", []string{"acme", "globex"})
+	auditCollaborators(client.Repositories, compileEmployeePattern("^acme-"))
+
+	// globex's collaborators can't be listed, which isn't an error.
+	if got, want := resultNames("github", "outside_collaborator"), []string{"acme/api: acme-alice", "acme/api: contractor-bob"}; !equalStrings(got, want) {
+		t.Errorf("outside collaborators = %v, want %v", got, want)
+	}
+	if got, want := resultNames("github", "unexpected_collaborator"), []string{"acme/api: contractor-bob"}; !equalStrings(got, want) {
+		t.Errorf("unexpected collaborators = %v, want %v", got, want)
+	}
+	if failed := failedOperations(); failed != 0 {
+		t.Errorf("failedOperations = %d, want 0", failed)
+	}
+	if got := canonicalID("github", "unexpected_collaborator", "", "acme/api: contractor-bob"); got != "github:acme/api" {
+		t.Errorf("canonicalID = %s, want github:acme/api", got)
+	}
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportDelta is the difference between the results of two -json reports,
+// as written by `dorky compare -json`.
+type reportDelta struct {
+	Old     deltaRun       `json:"old"`
+	New     deltaRun       `json:"new"`
+	Added   []result       `json:"added"`
+	Removed []result       `json:"removed"`
+	Changed []resultChange `json:"changed"`
+}
+
+type deltaRun struct {
+	File       string    `json:"file"`
+	RunID      string    `json:"run_id"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// resultChange lists the metadata that differs for a result found by both
+// runs.
+type resultChange struct {
+	Platform string        `json:"platform"`
+	Category string        `json:"category"`
+	Name     string        `json:"name"`
+	Fields   []fieldChange `json:"fields"`
+}
+
+type fieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// runCompareCommand implements `dorky compare old.json new.json`.
+func runCompareCommand(args []string) {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	jsonOut := fs.String("json", "", "also write the delta as JSON to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dorky compare [-json delta.json] old.json new.json")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	delta, err := compareReportFiles(fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		fmt.Printf("Error %s\n", err)
+		os.Exit(1)
+	}
+
+	printDelta(os.Stdout, delta)
+
+	if *jsonOut != "" {
+		data, err := json.MarshalIndent(delta, "", "  ")
+		if err == nil {
+			err = writeFileAtomically(*jsonOut, append(data, '\n'))
+		}
+		if err != nil {
+			fmt.Printf("Error writing delta: %s\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func readReport(filename string) (*report, error) {
+	data, err := readOutputFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var r report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &r, nil
+}
+
+func compareReportFiles(oldFile, newFile string) (reportDelta, error) {
+	oldReport, err := readReport(oldFile)
+	if err != nil {
+		return reportDelta{}, err
+	}
+	newReport, err := readReport(newFile)
+	if err != nil {
+		return reportDelta{}, err
+	}
+
+	delta := compareResults(oldReport.Results, newReport.Results)
+	delta.Old = deltaRun{File: oldFile, RunID: oldReport.RunID, FinishedAt: oldReport.FinishedAt}
+	delta.New = deltaRun{File: newFile, RunID: newReport.RunID, FinishedAt: newReport.FinishedAt}
+	return delta, nil
+}
+
+// compareResults matches results by platform, category and normalized
+// name, the identity dedupeResults uses within a run.
+func compareResults(oldResults, newResults []result) reportDelta {
+	key := func(r result) string {
+		return r.Platform + "\x00" + r.Category + "\x00" + normalizeName(r.Name)
+	}
+
+	oldByKey := make(map[string]result)
+	for _, r := range oldResults {
+		oldByKey[key(r)] = r
+	}
+	newByKey := make(map[string]result)
+	for _, r := range newResults {
+		newByKey[key(r)] = r
+	}
+
+	delta := reportDelta{Added: []result{}, Removed: []result{}, Changed: []resultChange{}}
+	for k, r := range newByKey {
+		old, ok := oldByKey[k]
+		if !ok {
+			delta.Added = append(delta.Added, r)
+			continue
+		}
+		if fields := metadataChanges(old, r); len(fields) > 0 {
+			delta.Changed = append(delta.Changed, resultChange{Platform: r.Platform, Category: r.Category, Name: r.Name, Fields: fields})
+		}
+	}
+	for k, r := range oldByKey {
+		if _, ok := newByKey[k]; !ok {
+			delta.Removed = append(delta.Removed, r)
+		}
+	}
+
+	sortResults(delta.Added)
+	sortResults(delta.Removed)
+	sort.Slice(delta.Changed, func(i, j int) bool {
+		a, b := delta.Changed[i], delta.Changed[j]
+		return a.Platform+"\x00"+a.Category+"\x00"+a.Name < b.Platform+"\x00"+b.Category+"\x00"+b.Name
+	})
+
+	return delta
+}
+
+func sortResults(results []result) {
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		return a.Platform+"\x00"+a.Category+"\x00"+a.Name < b.Platform+"\x00"+b.Category+"\x00"+b.Name
+	})
+}
+
+// resultMetadata flattens what is known about a result besides its
+// identity into comparable strings.
+func resultMetadata(r result) map[string]string {
+	m := map[string]string{
+		"query": r.Query,
+		"id":    r.ID,
+		"tags":  strings.Join(r.Tags, ","),
+	}
+	if p := r.Project; p != nil {
+		m["project.visibility"] = p.Visibility
+		m["project.issues_enabled"] = strconv.FormatBool(p.IssuesEnabled)
+		m["project.wiki_enabled"] = strconv.FormatBool(p.WikiEnabled)
+		m["project.snippets_enabled"] = strconv.FormatBool(p.SnippetsEnabled)
+		if p.LastActivityAt != nil {
+			m["project.last_activity_at"] = p.LastActivityAt.UTC().Format(time.RFC3339)
+		}
+	}
+	return m
+}
+
+func metadataChanges(oldResult, newResult result) []fieldChange {
+	oldMeta, newMeta := resultMetadata(oldResult), resultMetadata(newResult)
+
+	fields := make(map[string]bool)
+	for field := range oldMeta {
+		fields[field] = true
+	}
+	for field := range newMeta {
+		fields[field] = true
+	}
+
+	var changes []fieldChange
+	for field := range fields {
+		if oldMeta[field] != newMeta[field] {
+			changes = append(changes, fieldChange{Field: field, Old: oldMeta[field], New: newMeta[field]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+
+	return changes
+}
+
+func printDelta(w io.Writer, delta reportDelta) {
+	fmt.Fprintf(w, "Comparing run %s (%s) with run %s (%s)\n", delta.Old.RunID, delta.Old.File, delta.New.RunID, delta.New.File)
+
+	fmt.Fprintf(w, "\nAdded (%d):\n", len(delta.Added))
+	for _, r := range delta.Added {
+		fmt.Fprintf(w, "+ %s %s %s\n", r.Platform, r.Category, r.Name)
+	}
+
+	fmt.Fprintf(w, "\nRemoved (%d):\n", len(delta.Removed))
+	for _, r := range delta.Removed {
+		fmt.Fprintf(w, "- %s %s %s\n", r.Platform, r.Category, r.Name)
+	}
+
+	fmt.Fprintf(w, "\nChanged (%d):\n", len(delta.Changed))
+	for _, c := range delta.Changed {
+		changes := make([]string, len(c.Fields))
+		for i, f := range c.Fields {
+			changes[i] = fmt.Sprintf("%s %q -> %q", f.Field, f.Old, f.New)
+		}
+		fmt.Fprintf(w, "~ %s %s %s: %s\n", c.Platform, c.Category, c.Name, strings.Join(changes, ", "))
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareReportFiles(t *testing.T) {
+	setupRun(t, config{})
+
+	private := &projectDetails{Visibility: "private"}
+	public := &projectDetails{Visibility: "public"}
+	oldReport := report{provenance: provenance{RunID: "run1"}, Results: []result{
+		{Platform: "github", Category: "organization", Query: "acme", Name: "acme", ID: "github:acme"},
+		{Platform: "github", Category: "user", Query: "acme", Name: "acme-bot"},
+		{Platform: "gitlab", Category: "project", Query: "acme", Name: "acme/infra", Project: private},
+	}}
+	newReport := report{provenance: provenance{RunID: "run2", FinishedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}, Results: []result{
+		{Platform: "github", Category: "organization", Query: "acme", Name: "ACME", ID: "github:acme"},
+		{Platform: "github", Category: "organization", Query: "acme", Name: "acme-labs"},
+		{Platform: "gitlab", Category: "project", Query: "acme corp", Name: "acme/infra", Project: public},
+	}}
+
+	oldFile, newFile := filepath.Join(outputDir, "old.json"), filepath.Join(outputDir, "new.json")
+	for filename, r := range map[string]report{oldFile: oldReport, newFile: newReport} {
+		if err := writeJSONReport(filename, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	delta, err := compareReportFiles(oldFile, newFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if delta.Old.RunID != "run1" || delta.New.RunID != "run2" || delta.New.File != newFile {
+		t.Errorf("runs = %+v, %+v", delta.Old, delta.New)
+	}
+	if len(delta.Added) != 1 || delta.Added[0].Name != "acme-labs" {
+		t.Errorf("added = %+v", delta.Added)
+	}
+	if len(delta.Removed) != 1 || delta.Removed[0].Name != "acme-bot" {
+		t.Errorf("removed = %+v", delta.Removed)
+	}
+	if len(delta.Changed) != 1 {
+		t.Fatalf("changed = %+v", delta.Changed)
+	}
+	want := []fieldChange{
+		{Field: "project.visibility", Old: "private", New: "public"},
+		{Field: "query", Old: "acme", New: "acme corp"},
+	}
+	if got := delta.Changed[0].Fields; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("changed fields = %+v, want %+v", got, want)
+	}
+
+	var out bytes.Buffer
+	printDelta(&out, delta)
+	for _, line := range []string{
+		"+ github organization acme-labs",
+		"- github user acme-bot",
+		`~ gitlab project acme/infra: project.visibility "private" -> "public", query "acme" -> "acme corp"`,
+	} {
+		if !strings.Contains(out.String(), line+"\n") {
+			t.Errorf("output lacks %q:\n%s", line, out.String())
+		}
+	}
+}
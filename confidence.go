@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Confidence levels of a result: how closely its name matches the keyword
+// that found it.
+const (
+	confidenceHigh   = "high"
+	confidenceMedium = "medium"
+	confidenceLow    = "low"
+)
+
+// confidenceCategories are the result categories named after accounts and
+// repositories, which the platforms' searches also match by description,
+// display name or fuzzy spelling.
+var confidenceCategories = map[string]bool{
+	"organization": true, "user": true, "repository": true, "group": true, "project": true,
+}
+
+// resultConfidence scores a result found by query: high when its name, or
+// the last segment of its path, is the keyword, punctuation aside; medium
+// when its name contains the keyword; low when the platform matched it on
+// something else. Results of other categories aren't scored.
+func resultConfidence(category, query, name string) string {
+	keyword := compactName(query)
+	if !confidenceCategories[category] || keyword == "" {
+		return ""
+	}
+
+	last := name[strings.LastIndex(name, "/")+1:]
+	compact := compactName(name)
+	switch {
+	case compact == keyword || compactName(last) == keyword:
+		return confidenceHigh
+	case strings.Contains(compact, keyword):
+		return confidenceMedium
+	}
+	return confidenceLow
+}
+
+// compactName folds a name like foldName and drops everything but letters
+// and digits, so acme-corp, Acme_Corp and "acme corp" compare equal.
+func compactName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, foldName(name))
+}
+
+// confidentResults drops the low-confidence names of a batch, returning the
+// names kept and how many were dropped.
+func confidentResults(category, query string, names []string) ([]string, int) {
+	var kept []string
+	for _, name := range names {
+		if resultConfidence(category, query, name) != confidenceLow {
+			kept = append(kept, name)
+		}
+	}
+	return kept, len(names) - len(kept)
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestResultConfidence(t *testing.T) {
+	tests := []struct {
+		category, query, name string
+		want                  string
+	}{
+		{"organization", "acme corp", "Acme-Corp", confidenceHigh},
+		{"repository", "acme", "someone/acme", confidenceHigh},
+		{"user", "müller", "Muller", confidenceHigh},
+		{"repository", "acme", "acme-corp/config-backup", confidenceMedium},
+		{"group", "acme", "acme-labs", confidenceMedium},
+		{"user", "acme", "jdoe", confidenceLow},
+		{"project", "acme corp", "acme/db-dump", confidenceLow},
+		{"ci_host", "acme", "ci.example.com", ""},
+		{"user", "", "jdoe", ""},
+	}
+
+	for _, tt := range tests {
+		if got := resultConfidence(tt.category, tt.query, tt.name); got != tt.want {
+			t.Errorf("resultConfidence(%q, %q, %q) = %q, want %q", tt.category, tt.query, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// requestEstimate is the number of API requests a scan is expected to make:
+// a fixed number known up front, and a number that depends on what the
+// searches find, such as profile fetches for user filters.
+type requestEstimate struct {
+	requests int
+	upTo     int
+
+	// releases is set when releases are enumerated, costing one more
+	// request per repository found.
+	releases bool
+
+	// orgRollups is set when GitHub organizations are rolled up, costing
+	// a few more requests per organization found.
+	orgRollups bool
+
+	// memberships is set when the organizations of discovered GitHub
+	// users are listed, costing one more request per user found.
+	memberships bool
+
+	// keys is set when the public SSH and GPG keys of discovered GitHub
+	// users are listed, costing two more requests per user found.
+	keys bool
+
+	// stars is set when the starred and watched repositories of
+	// discovered GitHub users are inspected, costing two more requests per
+	// user found.
+	stars bool
+
+	// collaborators is set when the outside collaborators of discovered
+	// GitHub organizations are listed, costing one more request per public
+	// repository of each organization found.
+	collaborators bool
+
+	// ciConfigs is set when discovered repositories are checked for CI
+	// configuration and a Dockerfile, costing two requests per repository
+	// found, plus one per GitHub workflow.
+	ciConfigs bool
+
+	// iacSearches is set when the IaC dorks of discovered GitHub
+	// namespaces are run, costing one search per dork and namespace found,
+	// plus one request per file found.
+	iacSearches bool
+
+	// funding is set when the FUNDING.yml of discovered GitHub
+	// repositories and of their owners' .github repository are read,
+	// costing one request per repository and one per account found.
+	funding bool
+
+	// exhaustive is set when every GitHub repository matching a keyword is
+	// fetched, costing one request per 100 repositories and one per date
+	// slice of the broadest keywords.
+	exhaustive bool
+
+	// homepages is set when the websites of discovered accounts are read,
+	// costing one request per GitHub organization or user found and two
+	// per GitLab user found.
+	homepages bool
+}
+
+func (e *requestEstimate) add(other requestEstimate) {
+	e.requests += other.requests
+	e.upTo += other.upTo
+	e.releases = e.releases || other.releases
+	e.orgRollups = e.orgRollups || other.orgRollups
+	e.memberships = e.memberships || other.memberships
+	e.keys = e.keys || other.keys
+	e.stars = e.stars || other.stars
+	e.collaborators = e.collaborators || other.collaborators
+	e.ciConfigs = e.ciConfigs || other.ciConfigs
+	e.iacSearches = e.iacSearches || other.iacSearches
+	e.funding = e.funding || other.funding
+	e.exhaustive = e.exhaustive || other.exhaustive
+	e.homepages = e.homepages || other.homepages
+}
+
+func (e requestEstimate) String() string {
+	s := fmt.Sprintf("about %d API requests", e.requests)
+	if e.upTo > 0 {
+		s += fmt.Sprintf(", plus up to %d depending on the results", e.upTo)
+	}
+	if e.releases {
+		s += ", plus one per repository found to list releases"
+	}
+	if e.orgRollups {
+		s += fmt.Sprintf(", plus up to %d per GitHub organization found to roll it up", 2+rollupRecentRepos)
+	}
+	if e.memberships {
+		s += ", plus one per GitHub user found to list memberships"
+	}
+	if e.keys {
+		s += ", plus two per GitHub user found to list SSH and GPG keys"
+	}
+	if e.stars {
+		s += ", plus two per GitHub user found to inspect their stars"
+	}
+	if e.collaborators {
+		s += ", plus one per public repository of each GitHub organization found to list collaborators"
+	}
+	if e.ciConfigs {
+		s += ", plus two per repository found and one per GitHub workflow to read CI configuration"
+	}
+	if e.iacSearches {
+		s += fmt.Sprintf(", plus %d per GitHub organization or user found and one per file found to run IaC dorks", len(iacDorks))
+	}
+	if e.funding {
+		s += ", plus one per GitHub repository and account found to read funding files"
+	}
+	if e.exhaustive {
+		s += ", plus one per 100 GitHub repositories matching a keyword and one per date slice of the broadest keywords to fetch them all"
+	}
+	if e.homepages {
+		s += ", plus one per GitHub organization or user and two per GitLab user found to read their websites"
+	}
+	return s
+}
+
+// enabledPlatforms reports which platforms a scan with cfg will search,
+// mirroring the client setup in searchPlatforms.
+func enabledPlatforms(cfg config) (gh, gl, bb bool) {
+	gh = tokenOrigin("github") != "" && !cfg.glOnlyFlag
+	gl = !cfg.ghOnlyFlag
+	bb = cfg.bbURLFlag != "" && os.Getenv("BITBUCKET_ACCESS_TOKEN") != "" && !cfg.ghOnlyFlag && !cfg.glOnlyFlag
+	return gh, gl, bb
+}
+
+// estimateRequests predicts the API traffic of searching words with cfg.
+func estimateRequests(words map[string]struct{}, cfg config) requestEstimate {
+	gh, gl, bb := enabledPlatforms(cfg)
+	scopes, _ := parseGitLabScopes(cfg.glSearch)
+	// Anonymous GitLab clients skip the search API.
+	glSearchAPI := gl && tokenOrigin("gitlab") != ""
+	if !glSearchAPI {
+		scopes = nil
+	}
+
+	var e requestEstimate
+	e.releases = cfg.releasesFlag && (gh || gl)
+	e.orgRollups = cfg.orgRollupFlag && gh
+	e.memberships = cfg.membershipsFlag && gh
+	e.keys = cfg.keysFlag && gh
+	e.stars = cfg.starsFlag && gh
+	e.collaborators = cfg.collabFlag && gh
+	e.ciConfigs = cfg.ciConfigsFlag && (gh || gl)
+	e.iacSearches = cfg.iacSearchFlag && gh
+	e.funding = cfg.fundingFlag && gh
+	e.exhaustive = cfg.exhaustiveFlag && gh
+	e.homepages = cfg.urlsFlag && (gh || gl)
+
+	graphQLWords, orWords := 0, 0
+	for word := range words {
+		wordCfg := configForWord(cfg, word)
+
+		if gh {
+			if cfg.ghAPIFlag == "graphql" {
+				if wordCfg.orgFlag || wordCfg.repoFlag || wordCfg.userFlag || wordCfg.discussionsFlag {
+					graphQLWords++
+				}
+			} else if _, tagged := wordBehavior(word); cfg.orBatchFlag > 1 && !tagged {
+				// Counted per OR query below.
+				orWords++
+				e.requests += countTrue(wordCfg.discussionsFlag)
+			} else {
+				e.requests += countTrue(wordCfg.orgFlag, wordCfg.repoFlag, wordCfg.userFlag, wordCfg.discussionsFlag)
+				// -max above a page takes more pages when there are results.
+				pages := (wordCfg.maxFlag + maxResultsPerSearch - 1) / maxResultsPerSearch
+				e.upTo += (pages - 1) * countTrue(wordCfg.orgFlag, wordCfg.repoFlag, wordCfg.userFlag)
+				if wordCfg.userFlag && wordCfg.bioContainsFlag != "" {
+					e.upTo += wordCfg.maxFlag
+				}
+			}
+			e.requests += countTrue(wordCfg.wikiFlag)
+		}
+
+		if gl {
+			if wordCfg.orgFlag || wordCfg.userFlag {
+				e.requests += 2
+				if wordCfg.userFlag && (wordCfg.locationFlag != "" || wordCfg.bioContainsFlag != "") {
+					e.upTo += wordCfg.maxFlag
+				}
+			}
+			e.requests += countTrue(wordCfg.repoFlag) + len(scopes)
+			if glSearchAPI && wordCfg.wikiFlag && !containsString(scopes, "wiki_blobs") {
+				e.requests++
+			}
+			if len(scopes) > 0 {
+				// Code, milestone and wiki matches resolve their project paths.
+				e.upTo += wordCfg.maxFlag
+			}
+		}
+
+		if bb {
+			e.requests += countTrue(wordCfg.orgFlag, wordCfg.repoFlag, wordCfg.userFlag)
+		}
+
+		names, renames := 0, 0
+		if cfg.checkAvailabilityFlag && namespaceRegexp.MatchString(word) {
+			// A free name is checked for a rename redirect.
+			names, renames = 1, 1
+		}
+		if cfg.impersonationFlag && namespaceRegexp.MatchString(word) {
+			names = 1 + len(typoVariants(word))
+		}
+		if gh {
+			e.requests += names
+			e.upTo += renames
+		}
+		if gl {
+			// A taken GitLab namespace needs up to two more requests to
+			// tell groups from users.
+			e.requests += names
+			e.upTo += 2*names + renames
+		}
+	}
+	e.requests += (graphQLWords + graphQLKeywordsPerRequest - 1) / graphQLKeywordsPerRequest
+	if orWords > 0 {
+		queries := (orWords + cfg.orBatchFlag - 1) / cfg.orBatchFlag
+		e.requests += queries * countTrue(cfg.orgFlag, cfg.repoFlag, cfg.userFlag)
+		if cfg.userFlag && cfg.bioContainsFlag != "" {
+			e.upTo += queries * orPerPage(cfg.orBatchFlag, cfg.maxFlag)
+		}
+	}
+
+	return e
+}
+
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
+
+// confirmScan lists the words about to be searched and the estimated API
+// traffic, and asks whether to go ahead. The answer is read from the
+// terminal, since stdin usually carries the keywords.
+func confirmScan(groups map[string]map[string]struct{}, cfg config) (bool, error) {
+	labels := make([]string, 0, len(groups))
+	for label := range groups {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var total requestEstimate
+	for _, label := range labels {
+		dedupeKeywords(groups[label])
+		words := make([]string, 0, len(groups[label]))
+		for word := range groups[label] {
+			words = append(words, word)
+		}
+		sort.Strings(words)
+
+		if label == "" {
+			fmt.Fprintf(os.Stderr, "%d words to search:\n", len(words))
+		} else {
+			fmt.Fprintf(os.Stderr, "Target '%s', %d words to search:\n", label, len(words))
+		}
+		for _, word := range words {
+			if tags := keywordTags[word]; len(tags) > 0 {
+				fmt.Fprintf(os.Stderr, "- %s #%s\n", word, strings.Join(tags, " #"))
+			} else {
+				fmt.Fprintf(os.Stderr, "- %s\n", word)
+			}
+		}
+
+		total.add(estimateRequests(groups[label], cfg))
+	}
+
+	gh, gl, bb := enabledPlatforms(cfg)
+	var platforms []string
+	for _, p := range []struct {
+		name    string
+		enabled bool
+	}{{"GitHub", gh}, {"GitLab", gl}, {"Bitbucket", bb}} {
+		if p.enabled {
+			platforms = append(platforms, p.name)
+		}
+	}
+	if len(platforms) == 0 {
+		platforms = []string{"no platforms (no access tokens set)"}
+	}
+	fmt.Fprintf(os.Stderr, "\nSearching %s: %s.\n", strings.Join(platforms, ", "), total)
+
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return false, fmt.Errorf("-confirm needs a terminal to ask on: %w", err)
+	}
+	defer tty.Close()
+
+	return askYesNo(tty, "Proceed? [y/N] ")
+}
+
+func askYesNo(in io.Reader, prompt string) (bool, error) {
+	fmt.Fprint(os.Stderr, prompt)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
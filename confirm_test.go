@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateRequests(t *testing.T) {
+	setenv(t, "GITHUB_ACCESS_TOKEN", "token")
+	setenv(t, "GITLAB_ACCESS_TOKEN", "token")
+	setenv(t, "BITBUCKET_ACCESS_TOKEN", "")
+	setupRun(t, config{})
+
+	words := map[string]struct{}{"acme": {}, "acme corp": {}}
+
+	tests := []struct {
+		name string
+		cfg  config
+		want requestEstimate
+	}{
+		{
+			name: "rest",
+			cfg:  config{orgFlag: true, repoFlag: true, userFlag: true, maxFlag: 10, ghAPIFlag: "rest"},
+			// Per word: 3 GitHub searches, GitLab groups, users and projects.
+			want: requestEstimate{requests: 12},
+		},
+		{
+			name: "graphql batches words",
+			cfg:  config{orgFlag: true, repoFlag: true, maxFlag: 10, ghAPIFlag: "graphql", ghOnlyFlag: true},
+			want: requestEstimate{requests: 1},
+		},
+		{
+			name: "or batches words",
+			cfg:  config{orgFlag: true, repoFlag: true, maxFlag: 10, ghAPIFlag: "rest", ghOnlyFlag: true, orBatchFlag: 2},
+			want: requestEstimate{requests: 2},
+		},
+		{
+			name: "user filters",
+			cfg:  config{userFlag: true, maxFlag: 5, ghAPIFlag: "rest", bioContainsFlag: "acme"},
+			want: requestEstimate{requests: 6, upTo: 20},
+		},
+		{
+			name: "availability skips invalid namespaces",
+			cfg:  config{checkAvailabilityFlag: true, releasesFlag: true, ghAPIFlag: "rest"},
+			want: requestEstimate{requests: 2, upTo: 4, releases: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := estimateRequests(words, tt.cfg); got != tt.want {
+				t.Errorf("estimateRequests = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEstimateRequestsFollowsTags(t *testing.T) {
+	setenv(t, "GITHUB_ACCESS_TOKEN", "token")
+	setupRun(t, config{})
+	tagBehaviors = map[string]tagBehavior{"product": {Search: []string{"repo", "wiki"}}}
+	tagWord("payments", []string{"product"})
+
+	cfg := config{orgFlag: true, ghOnlyFlag: true, ghAPIFlag: "rest"}
+	words := map[string]struct{}{"acme": {}, "payments": {}}
+
+	if got := estimateRequests(words, cfg); got.requests != 3 {
+		t.Errorf("requests = %d, want 3", got.requests)
+	}
+}
+
+func TestAskYesNo(t *testing.T) {
+	tests := map[string]bool{
+		"y\n":   true,
+		" YES ": true,
+		"n\n":   false,
+		"\n":    false,
+		"":      false,
+	}
+
+	for answer, want := range tests {
+		got, err := askYesNo(strings.NewReader(answer), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("askYesNo(%q) = %v, want %v", answer, got, want)
+		}
+	}
+}
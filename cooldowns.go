@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rateLimitCooldown is a quota an API paused until it resets, kept in the
+// -state file so that a restarted run waits for the reset instead of
+// retrying straight into the same 403s.
+type rateLimitCooldown struct {
+	Platform string    `json:"platform"`
+	Resource string    `json:"resource"`
+	Until    time.Time `json:"until"`
+}
+
+var (
+	cooldownsMu sync.Mutex
+
+	// cooldowns holds the quotas paused in the current run or by a previous
+	// run sharing its -state file, keyed by platform and resource.
+	cooldowns = make(map[string]rateLimitCooldown)
+)
+
+// recordCooldown notes that a quota is paused until the given time, unless
+// it is already paused for longer.
+func recordCooldown(platform, resource string, until time.Time) {
+	cooldownsMu.Lock()
+	defer cooldownsMu.Unlock()
+
+	key := platform + "/" + resource
+	if until.After(cooldowns[key].Until) {
+		cooldowns[key] = rateLimitCooldown{Platform: platform, Resource: resource, Until: until.UTC()}
+	}
+}
+
+// platformCooldowns returns when the paused quotas of a platform reset, for
+// a new limiter to start paused.
+func platformCooldowns(platform string) map[string]time.Time {
+	cooldownsMu.Lock()
+	defer cooldownsMu.Unlock()
+
+	paused := make(map[string]time.Time)
+	for _, c := range cooldowns {
+		if c.Platform == platform {
+			paused[c.Resource] = c.Until
+		}
+	}
+	return paused
+}
+
+// activeCooldowns merges the cooldowns read from a state file with those of
+// the run, dropping those over by now.
+func activeCooldowns(stored []rateLimitCooldown, now time.Time) []rateLimitCooldown {
+	cooldownsMu.Lock()
+	merged := make(map[string]rateLimitCooldown)
+	for _, c := range cooldowns {
+		merged[c.Platform+"/"+c.Resource] = c
+	}
+	cooldownsMu.Unlock()
+
+	for _, c := range stored {
+		if key := c.Platform + "/" + c.Resource; c.Until.After(merged[key].Until) {
+			merged[key] = c
+		}
+	}
+
+	var active []rateLimitCooldown
+	for _, c := range merged {
+		if c.Until.After(now) {
+			active = append(active, c)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].Platform+"/"+active[i].Resource < active[j].Platform+"/"+active[j].Resource
+	})
+	return active
+}
+
+// loadCooldowns reads the quotas a previous run left paused from the -state
+// file, so the run's requests wait for them to reset.
+func loadCooldowns(cfg config, now time.Time) error {
+	if cfg.stateFlag == "" {
+		return nil
+	}
+
+	state, err := readStateFile(outputPath(cfg.stateFlag))
+	if err != nil {
+		return err
+	}
+	for _, c := range state.Cooldowns {
+		if !c.Until.After(now) {
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s %s requests throttled by a previous run, pausing until %s\n",
+			c.Platform, c.Resource, c.Until.Local().Format("15:04:05"))
+		recordCooldown(c.Platform, c.Resource, c.Until)
+	}
+	return nil
+}
+
+func resetCooldowns() {
+	cooldownsMu.Lock()
+	defer cooldownsMu.Unlock()
+
+	cooldowns = make(map[string]rateLimitCooldown)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCooldownsSurviveRestart(t *testing.T) {
+	setupRun(t, config{stateFlag: "state.json"})
+	now := time.Now().UTC()
+	filename := outputPath("state.json")
+
+	// A first run exhausts the search quota and ends before it resets.
+	a := newAdaptiveLimiter("github", 10, 1, githubResource)
+	search := httptest.NewRequest(http.MethodGet, "https://api.github.com/search/users", nil)
+	a.observe(search, limiterResponse(http.StatusForbidden, map[string]string{"Retry-After": "600"}))
+	recordCooldown("gitlab", "api", now.Add(-time.Minute))
+
+	if _, err := updateState(filename, nil, now, 0); err != nil {
+		t.Fatal(err)
+	}
+	state, err := readStateFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Cooldowns) != 1 || state.Cooldowns[0].Platform != "github" || state.Cooldowns[0].Resource != "search" {
+		t.Fatalf("cooldowns = %+v, want the github search quota only", state.Cooldowns)
+	}
+
+	// The restarted run starts with its limiters paused.
+	startRun()
+	if err := loadCooldowns(flags, now); err != nil {
+		t.Fatal(err)
+	}
+	b := newAdaptiveLimiter("github", 10, 1, githubResource)
+	if pause := b.paused["search"].Sub(now); pause < 590*time.Second {
+		t.Errorf("search paused for %v after a restart, want about 10 minutes", pause)
+	}
+	if !b.paused["core"].IsZero() {
+		t.Errorf("core paused until %v", b.paused["core"])
+	}
+
+	// Cooldowns read from the file are kept by runs that don't renew them,
+	// and dropped once over.
+	startRun()
+	if _, err := updateState(filename, nil, now, 0); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := readStateFile(filename); len(state.Cooldowns) != 1 {
+		t.Errorf("cooldowns = %+v, want the stored one kept", state.Cooldowns)
+	}
+	if _, err := updateState(filename, nil, now.Add(time.Hour), 0); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := readStateFile(filename); len(state.Cooldowns) != 0 {
+		t.Errorf("cooldowns = %+v, want none after the reset", state.Cooldowns)
+	}
+}
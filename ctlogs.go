@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// defaultCTURL is crt.sh, a searchable mirror of the certificate
+// transparency logs.
+const defaultCTURL = "https://crt.sh/"
+
+// ctEntry is a certificate listed by a crt.sh-style JSON API. NameValue
+// holds the names the certificate covers, one per line.
+type ctEntry struct {
+	NameValue string `json:"name_value"`
+}
+
+// fetchCTHostnames returns the hostnames under domain, domain included,
+// named by the certificates logged for it, sorted. Wildcards count as the
+// domain they cover.
+func fetchCTHostnames(client *http.Client, baseURL, domain string) ([]string, error) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	u := strings.TrimRight(baseURL, "/") + "/?q=" + url.QueryEscape("%."+domain) + "&output=json"
+
+	resp, err := client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	}
+
+	var entries []ctEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var hosts []string
+	for _, entry := range entries {
+		for _, name := range strings.Split(entry.NameValue, "\n") {
+			name = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(name)), "*.")
+			if name == "" || seen[name] || strings.Contains(name, "@") {
+				continue
+			}
+			if name != domain && !strings.HasSuffix(name, "."+domain) {
+				continue
+			}
+			seen[name] = true
+			hosts = append(hosts, name)
+		}
+	}
+	sort.Strings(hosts)
+	return hosts, nil
+}
+
+// addDomainKeywords seeds words with the keywords of every hostname the
+// certificate transparency logs know under the -domain domains, run
+// through the same cleaning as -c.
+func addDomainKeywords(words map[string]struct{}, cfg config) {
+	domains := splitList(cfg.domainFlag)
+	if len(domains) == 0 {
+		return
+	}
+
+	client := sharedCTClient()
+	cleanCfg := cfg
+	cleanCfg.cleanFlag = true
+
+	for _, domain := range domains {
+		hosts, err := fetchCTHostnames(client, cfg.ctURLFlag, domain)
+		if err != nil {
+			fmt.Printf("Error querying certificate transparency logs for %s: %s\n", domain, err)
+			os.Exit(1)
+		}
+		verbosePrint("Certificate transparency logs list %d hostnames under %s\n", len(hosts), domain)
+
+		processWord(domain, words, cleanCfg)
+		for _, host := range hosts {
+			processWord(host, words, cleanCfg)
+		}
+	}
+}
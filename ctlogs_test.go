@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchCTHostnames(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		w.Write([]byte(`[
+			{"name_value": "acme.com\nwww.acme.com"},
+			{"name_value": "*.build.acme.com\nJenkins.Build.acme.com"},
+			{"name_value": "hostmaster@acme.com"},
+			{"name_value": "acme.com.evil.net\nwww.acme.com"}
+		]`))
+	}))
+	defer srv.Close()
+
+	hosts, err := fetchCTHostnames(srv.Client(), srv.URL, "Acme.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "%.acme.com" {
+		t.Errorf("q = %q, want %%.acme.com", query)
+	}
+	if want := []string{"acme.com", "build.acme.com", "jenkins.build.acme.com", "www.acme.com"}; !equalStrings(hosts, want) {
+		t.Errorf("hosts = %v, want %v", hosts, want)
+	}
+}
+
+func TestDomainKeywords(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name_value": "www.acme.com\njenkins.build.acme.com"}]`))
+	}))
+	defer srv.Close()
+	setupRun(t, config{domainFlag: "acme.com", ctURLFlag: srv.URL, stripPrefixesFlag: "www", minWordLengthFlag: 2})
+
+	words := readAndCleanWords(flags, nil)
+
+	want := []string{"acme", "acme.com", "build", "jenkins", "jenkins.build.acme.com", "www.acme.com"}
+	if got := sortedWords(words); !equalStrings(got, want) {
+		t.Errorf("words = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// debugHTTPCount numbers the exchanges dumped by -debug-http.
+var debugHTTPCount int64
+
+// secretHeaders and secretParams carry credentials, and are redacted from
+// -debug-http dumps.
+var (
+	secretHeaders = []string{"Authorization", "Private-Token", "Job-Token", "Cookie", "Set-Cookie", "X-Api-Key"}
+	secretParams  = []string{"key", "access_token", "private_token", "token"}
+)
+
+// secretEnv lists the environment variables holding credentials, whose
+// values are redacted wherever they appear in a dump.
+var secretEnv = []string{"GITHUB_ACCESS_TOKEN", "GITLAB_ACCESS_TOKEN", "BITBUCKET_ACCESS_TOKEN", "STACKEXCHANGE_KEY", passphraseEnv}
+
+const redacted = "REDACTED"
+
+// failedExchange reports whether an API call is worth dumping.
+func failedExchange(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode >= 400
+}
+
+// dumpHTTPExchange writes a failed API call to the -debug-http directory,
+// with credentials redacted. The response body is read and replaced, so the
+// caller still gets all of it.
+func dumpHTTPExchange(dir, platform string, req *http.Request, resp *http.Response, err error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s\n", req.Method, redactURL(req.URL))
+	writeHeaders(&buf, req.Header)
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := ioutil.ReadAll(body)
+			body.Close()
+			fmt.Fprintf(&buf, "\n%s\n", data)
+		}
+	}
+
+	status := "error"
+	if err != nil {
+		fmt.Fprintf(&buf, "\nerror: %s\n", err)
+	} else {
+		status = fmt.Sprint(resp.StatusCode)
+		fmt.Fprintf(&buf, "\n%s %s\n", resp.Proto, resp.Status)
+		writeHeaders(&buf, resp.Header)
+		data, readErr := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+		fmt.Fprintf(&buf, "\n%s\n", data)
+		if readErr != nil {
+			fmt.Fprintf(&buf, "\nerror reading body: %s\n", readErr)
+		}
+	}
+
+	n := atomic.AddInt64(&debugHTTPCount, 1)
+	filename := filepath.Join(dir, fmt.Sprintf("%04d-%s-%s.txt", n, platform, status))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fmt.Printf("Error creating debug directory: %s\n", err)
+		return
+	}
+	data, err := sealOutput(filename, redactSecrets(buf.Bytes()))
+	if err == nil {
+		err = ioutil.WriteFile(filename, data, 0600)
+	}
+	if err != nil {
+		fmt.Printf("Error writing %s: %s\n", filename, err)
+	}
+}
+
+func writeHeaders(buf *bytes.Buffer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			if containsFold(secretHeaders, name) {
+				value = redacted
+			}
+			fmt.Fprintf(buf, "%s: %s\n", name, value)
+		}
+	}
+}
+
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	query := redactedURL.Query()
+	for name := range query {
+		if containsFold(secretParams, name) {
+			query.Set(name, redacted)
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+	if redactedURL.User != nil {
+		redactedURL.User = url.User(redacted)
+	}
+	return redactedURL.String()
+}
+
+// redactSecrets replaces the credentials dorky was given wherever they
+// appear, such as echoed back in an error message.
+func redactSecrets(data []byte) []byte {
+	for _, name := range secretEnv {
+		if secret := os.Getenv(name); secret != "" {
+			data = bytes.ReplaceAll(data, []byte(secret), []byte(redacted))
+		}
+	}
+	for _, secret := range resolvedTokens() {
+		data = bytes.ReplaceAll(data, []byte(secret), []byte(redacted))
+	}
+	return data
+}
+
+func containsFold(list []string, value string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDebugHTTPDumpsFailedExchanges(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "debug")
+	setupRun(t, config{debugHTTPFlag: dir})
+	setenv(t, "GITLAB_ACCESS_TOKEN", "glpat-secret")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok" {
+			w.Write([]byte("[]"))
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"message":"token glpat-secret lacks the read_api scope"}`))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newProviderTransport("gitlab", nil, nil)}
+	get := func(path string) string {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Private-Token", "glpat-secret")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	get("/ok")
+	if body := get("/groups?search=acme&private_token=glpat-secret"); !strings.Contains(body, "read_api") {
+		t.Errorf("caller got body %q, want the full response", body)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "0001-gitlab-403.txt" {
+		t.Fatalf("dumped files = %v, want only 0001-gitlab-403.txt", files)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, files[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := string(data)
+	if strings.Contains(dump, "glpat-secret") {
+		t.Errorf("dump leaks the token:\n%s", dump)
+	}
+	for _, want := range []string{"GET " + server.URL + "/groups?private_token=REDACTED&search=acme", "Private-Token: REDACTED", "403 Forbidden", "token REDACTED lacks the read_api scope"} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump lacks %q:\n%s", want, dump)
+		}
+	}
+}
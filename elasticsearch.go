@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const esBulkBatchSize = 500
+
+type esBulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// esResultDoc is a single result as indexed into Elasticsearch.
+type esResultDoc struct {
+	result
+	DocType     string `json:"doc_type"`
+	ToolVersion string `json:"tool_version"`
+}
+
+// esRunDoc describes the run itself, so results can be joined on run_id to
+// the settings that produced them.
+type esRunDoc struct {
+	provenance
+	DocType   string    `json:"doc_type"`
+	Timestamp time.Time `json:"@timestamp"`
+}
+
+// exportToElasticsearch bulk-indexes the collected results, plus one document
+// describing the run, into an Elasticsearch or OpenSearch index. Credentials
+// are read from ELASTICSEARCH_API_KEY, or ELASTICSEARCH_USERNAME and
+// ELASTICSEARCH_PASSWORD.
+func exportToElasticsearch(baseURL, index string, prov provenance, res []result) error {
+	endpoint := strings.TrimRight(baseURL, "/") + "/_bulk"
+
+	docs := make([]interface{}, 0, len(res)+1)
+	docs = append(docs, esRunDoc{provenance: prov, DocType: "run", Timestamp: prov.StartedAt})
+	for _, r := range res {
+		docs = append(docs, esResultDoc{result: r, DocType: "result", ToolVersion: prov.ToolVersion})
+	}
+
+	for start := 0; start < len(docs); start += esBulkBatchSize {
+		end := start + esBulkBatchSize
+		if end > len(docs) {
+			end = len(docs)
+		}
+
+		body, err := buildBulkBody(index, docs[start:end])
+		if err != nil {
+			return err
+		}
+
+		if err := postBulk(endpoint, body); err != nil {
+			return err
+		}
+		verbosePrint("Indexed %d documents into %s.\n", end-start, index)
+	}
+
+	return nil
+}
+
+func buildBulkBody(index string, docs []interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	action, err := json.Marshal(map[string]map[string]string{"index": {"_index": index}})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, doc := range docs {
+		source, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(source)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+func postBulk(endpoint string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	if apiKey := os.Getenv("ELASTICSEARCH_API_KEY"); apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+apiKey)
+	} else if user := os.Getenv("ELASTICSEARCH_USERNAME"); user != "" {
+		req.SetBasicAuth(user, os.Getenv("ELASTICSEARCH_PASSWORD"))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("bulk request failed with status %s: %s", resp.Status, respBody)
+	}
+
+	var bulkResp esBulkResponse
+	if err := json.Unmarshal(respBody, &bulkResp); err != nil {
+		return err
+	}
+
+	if bulkResp.Errors {
+		for _, item := range bulkResp.Items {
+			for _, op := range item {
+				if op.Error.Type != "" {
+					return fmt.Errorf("bulk indexing failed: %s: %s", op.Error.Type, op.Error.Reason)
+				}
+			}
+		}
+		return errors.New("bulk indexing reported errors")
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBuildBulkBody(t *testing.T) {
+	body, err := buildBulkBody("dorky", []interface{}{
+		result{RunID: "run1", Platform: "github", Category: "user", Name: "acme"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(body), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2", len(lines))
+	}
+	if lines[0] != `{"index":{"_index":"dorky"}}` {
+		t.Errorf("action line = %s", lines[0])
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[1]), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["name"] != "acme" || doc["run_id"] != "run1" {
+		t.Errorf("document = %v", doc)
+	}
+}
+
+func TestExportToElasticsearch(t *testing.T) {
+	var bodies [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/_bulk" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		var buf bytes.Buffer
+		buf.ReadFrom(r.Body)
+		bodies = append(bodies, buf.Bytes())
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	defer srv.Close()
+
+	res := []result{{Platform: "github", Category: "user", Name: "acme"}}
+	if err := exportToElasticsearch(srv.URL+"/", "dorky", provenance{RunID: "run1"}, res); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(bodies) != 1 {
+		t.Fatalf("made %d bulk requests, want 1", len(bodies))
+	}
+	if !bytes.Contains(bodies[0], []byte(`"doc_type":"run"`)) || !bytes.Contains(bodies[0], []byte(`"doc_type":"result"`)) {
+		t.Errorf("bulk body lacks run or result documents: %s", bodies[0])
+	}
+}
+
+func TestExportToElasticsearchReportsItemErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":true,"items":[{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad"}}}]}`))
+	}))
+	defer srv.Close()
+
+	err := exportToElasticsearch(srv.URL, "dorky", provenance{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Errorf("err = %v, want mapper_parsing_exception", err)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// emptySearch is a search that completed without finding anything, as
+// opposed to one that failed.
+type emptySearch struct {
+	Platform string `json:"platform"`
+	Category string `json:"category"`
+	Query    string `json:"query"`
+}
+
+// searchCounts counts the results every search of the run found, before
+// deduplication, keyed by platform, category and keyword.
+var searchCounts = make(map[emptySearch]int)
+
+// countSearch records that a search for query returned n results.
+// Batches not tied to a keyword, like membership listings, aren't searches.
+func countSearch(platform, category, query string, n int) {
+	if query == "" {
+		return
+	}
+	searchCounts[emptySearch{Platform: platform, Category: category, Query: query}] += n
+}
+
+// emptySearches returns the searches of the run that found nothing in any
+// of their batches, sorted by keyword, platform and category.
+func emptySearches() []emptySearch {
+	var empty []emptySearch
+	for search, n := range searchCounts {
+		if n == 0 {
+			empty = append(empty, search)
+		}
+	}
+	sort.Slice(empty, func(i, j int) bool {
+		a, b := empty[i], empty[j]
+		if a.Query != b.Query {
+			return a.Query < b.Query
+		}
+		if a.Platform != b.Platform {
+			return a.Platform < b.Platform
+		}
+		return a.Category < b.Category
+	})
+	return empty
+}
+
+// reportEmptySearches lists the searches without results on stderr, next
+// to the error report, and saves them to no_results.txt as "platform
+// category keyword" lines, so a keyword that found nothing can be told
+// apart from one that wasn't searched.
+func reportEmptySearches() {
+	empty := emptySearches()
+	if len(empty) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\nSearches without results (%d):\n", len(empty))
+	lines := make([]string, len(empty))
+	for i, e := range empty {
+		fmt.Fprintf(os.Stderr, "- %s %s '%s'\n", e.Platform, e.Category, e.Query)
+		lines[i] = fmt.Sprintf("%s %s %s", e.Platform, e.Category, e.Query)
+	}
+	saveResults("no_results.txt", lines)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmptySearchesReported(t *testing.T) {
+	setupRun(t, config{})
+
+	emitResults("github", "organization", "acme", "GitHub organizations matching 'acme'", "github_organizations.txt", []string{"acme"})
+	emitResults("gitlab", "group", "acme", "GitLab groups matching 'acme'", "gitlab_groups.txt", nil)
+	emitResults("github", "organization", "zeta", "GitHub organizations matching 'zeta'", "github_organizations.txt", nil)
+
+	// A later batch with results means the search wasn't empty.
+	emitResults("stackexchange", "user", "acme", "Stack Exchange authors", "stackexchange_users.txt", nil)
+	emitResults("stackexchange", "user", "acme", "Stack Exchange users", "stackexchange_users.txt", []string{"alice"})
+
+	// Batches not tied to a keyword aren't searches.
+	emitResults("github", "user", "", "Members", "github_users.txt", nil)
+
+	want := []emptySearch{
+		{Platform: "gitlab", Category: "group", Query: "acme"},
+		{Platform: "github", Category: "organization", Query: "zeta"},
+	}
+	if got := emptySearches(); !reflect.DeepEqual(got, want) {
+		t.Errorf("emptySearches() = %v, want %v", got, want)
+	}
+
+	reportEmptySearches()
+	lines := readOutputLines(t, "no_results.txt")
+	if !equalStrings(lines, []string{"gitlab group acme", "github organization zeta"}) {
+		t.Errorf("no_results.txt = %v", lines)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// resultBatch is a group of results printed together: what one search
+// found, or a report such as an organization rollup.
+type resultBatch struct {
+	Platform string
+	Category string
+	Query    string
+	Header   string
+	Results  []string
+}
+
+// Encoder prints result batches to the console in one output format,
+// selected by -format.
+type Encoder interface {
+	Encode(w io.Writer, batch resultBatch) error
+}
+
+// consoleEncoder is the Encoder of the current run, set by startRun.
+var consoleEncoder Encoder = textEncoder{}
+
+// outputFormats lists the -format values.
+var outputFormats = []string{"text", "simple", "json", "csv", "template"}
+
+// newEncoder returns the Encoder selected by cfg. -s is shorthand for
+// -format simple.
+func newEncoder(cfg config) (Encoder, error) {
+	format := cfg.formatFlag
+	if format == "" {
+		format = "text"
+	}
+	if cfg.simpleFlag {
+		if format != "text" && format != "simple" {
+			return nil, fmt.Errorf("-s can't be combined with -format %s", format)
+		}
+		format = "simple"
+	}
+	if cfg.templateFlag != "" && format != "template" {
+		return nil, fmt.Errorf("-template requires -format template")
+	}
+	if (cfg.delimiterFlag != "" || cfg.nullFlag || cfg.prefixFlag != "") && format != "simple" {
+		return nil, fmt.Errorf("-delimiter, -null and -prefix require -s or -format simple")
+	}
+
+	switch format {
+	case "text":
+		return textEncoder{all: cfg.allFlag}, nil
+	case "simple":
+		return newSimpleEncoder(cfg)
+	case "json":
+		return jsonEncoder{}, nil
+	case "csv":
+		return csvEncoder{}, nil
+	case "template":
+		if cfg.templateFlag == "" {
+			return nil, fmt.Errorf("-format template requires -template")
+		}
+		tmpl, err := template.New("result").Parse(cfg.templateFlag)
+		if err != nil {
+			return nil, fmt.Errorf("parsing -template: %w", err)
+		}
+		return templateEncoder{tmpl}, nil
+	}
+	return nil, fmt.Errorf("-format must be one of %s", strings.Join(outputFormats, ", "))
+}
+
+// textEncoder prints a header followed by one bulleted line per result,
+// annotated with the details recorded for it. Low-confidence accounts and
+// repositories are left out and counted, unless -all is set, so generic
+// keywords don't bury the relevant matches.
+type textEncoder struct {
+	all bool
+}
+
+func (e textEncoder) Encode(w io.Writer, batch resultBatch) error {
+	if _, err := fmt.Fprintf(w, "\n%s:\n", batch.Header); err != nil {
+		return err
+	}
+	names, hidden := batch.Results, 0
+	if !e.all {
+		names, hidden = confidentResults(batch.Category, batch.Query, names)
+	}
+	for _, line := range annotateResults(batch.Platform, batch.Category, batch.Query, names) {
+		if _, err := fmt.Fprintf(w, "- %s\n", line); err != nil {
+			return err
+		}
+	}
+	if hidden > 0 {
+		noun := "results"
+		if hidden == 1 {
+			noun = "result"
+		}
+		if _, err := fmt.Fprintf(w, "(%d low-confidence %s hidden, -all shows them)\n", hidden, noun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// simpleFields are the fields -prefix can put before each simple result.
+var simpleFields = []string{"platform", "category", "query"}
+
+// simpleEncoder prints bare results, one per line, for piping. -delimiter
+// or -null end each result with another string, and -prefix puts fields
+// such as the platform before it.
+type simpleEncoder struct {
+	// delimiter ends each result; empty means a newline.
+	delimiter string
+
+	// prefix lists the fields put before each result, separated by colons.
+	prefix string
+}
+
+func newSimpleEncoder(cfg config) (Encoder, error) {
+	var enc simpleEncoder
+	if cfg.delimiterFlag != "" {
+		if cfg.nullFlag {
+			return nil, fmt.Errorf("-delimiter and -null are mutually exclusive")
+		}
+		delimiter, err := strconv.Unquote(`"` + strings.Replace(cfg.delimiterFlag, `"`, `\"`, -1) + `"`)
+		if err != nil {
+			return nil, fmt.Errorf("-delimiter %q has an invalid escape sequence", cfg.delimiterFlag)
+		}
+		enc.delimiter = delimiter
+	}
+	if cfg.nullFlag {
+		enc.delimiter = "\x00"
+	}
+
+	if cfg.prefixFlag != "" {
+		fields := strings.Split(strings.TrimSuffix(cfg.prefixFlag, ":"), ":")
+		for _, field := range fields {
+			if !containsString(simpleFields, field) {
+				return nil, fmt.Errorf("-prefix fields must be among %s, separated by colons", strings.Join(simpleFields, ", "))
+			}
+		}
+		enc.prefix = strings.Join(fields, ":")
+	}
+	return enc, nil
+}
+
+func (e simpleEncoder) Encode(w io.Writer, batch resultBatch) error {
+	delimiter := e.delimiter
+	if delimiter == "" {
+		delimiter = "\n"
+	}
+
+	var prefix string
+	if e.prefix != "" {
+		values := map[string]string{"platform": batch.Platform, "category": batch.Category, "query": batch.Query}
+		for _, field := range strings.Split(e.prefix, ":") {
+			prefix += values[field] + ":"
+		}
+	}
+
+	for _, line := range batch.Results {
+		if _, err := io.WriteString(w, prefix+line+delimiter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consoleRecord is a single result as the json and template formats see it.
+type consoleRecord struct {
+	Platform string          `json:"platform"`
+	Category string          `json:"category"`
+	Query    string          `json:"query"`
+	Name     string          `json:"name"`
+	Tags     []string        `json:"tags,omitempty"`
+	Project  *projectDetails `json:"project,omitempty"`
+	Triage   string          `json:"triage,omitempty"`
+}
+
+func consoleRecords(batch resultBatch) []consoleRecord {
+	records := make([]consoleRecord, len(batch.Results))
+	for i, name := range batch.Results {
+		records[i] = consoleRecord{
+			Platform: batch.Platform,
+			Category: batch.Category,
+			Query:    batch.Query,
+			Name:     name,
+			Tags:     resultTags(batch.Platform, batch.Category, batch.Query, name),
+			Project:  lookupProjectDetails(batch.Platform, batch.Category, name),
+			Triage:   triageMark(batch.Platform, batch.Category, name),
+		}
+	}
+	return records
+}
+
+// jsonEncoder prints one JSON object per result.
+type jsonEncoder struct{}
+
+func (jsonEncoder) Encode(w io.Writer, batch resultBatch) error {
+	enc := json.NewEncoder(w)
+	for _, record := range consoleRecords(batch) {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvEncoder prints one platform,category,query,name,tags row per result,
+// with the tags separated by spaces. There is no header row, so the output
+// of several runs can be concatenated.
+type csvEncoder struct{}
+
+func (csvEncoder) Encode(w io.Writer, batch resultBatch) error {
+	cw := csv.NewWriter(w)
+	for _, name := range batch.Results {
+		tags := strings.Join(resultTags(batch.Platform, batch.Category, batch.Query, name), " ")
+		if err := cw.Write([]string{batch.Platform, batch.Category, batch.Query, name, tags}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// templateEncoder executes a text/template once per result, with the
+// fields of consoleRecord, followed by a newline.
+type templateEncoder struct {
+	tmpl *template.Template
+}
+
+func (e templateEncoder) Encode(w io.Writer, batch resultBatch) error {
+	for _, record := range consoleRecords(batch) {
+		if err := e.tmpl.Execute(w, record); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
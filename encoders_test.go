@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewEncoder(t *testing.T) {
+	tests := []struct {
+		cfg     config
+		want    Encoder
+		wantErr bool
+	}{
+		{config{}, textEncoder{}, false},
+		{config{formatFlag: "text", simpleFlag: true}, simpleEncoder{}, false},
+		{config{formatFlag: "json"}, jsonEncoder{}, false},
+		{config{formatFlag: "csv"}, csvEncoder{}, false},
+		{config{formatFlag: "json", simpleFlag: true}, nil, true},
+		{config{formatFlag: "yaml"}, nil, true},
+		{config{formatFlag: "template"}, nil, true},
+		{config{formatFlag: "template", templateFlag: "{{.Name"}, nil, true},
+		{config{formatFlag: "text", templateFlag: "{{.Name}}"}, nil, true},
+		{config{simpleFlag: true, nullFlag: true}, simpleEncoder{delimiter: "\x00"}, false},
+		{config{simpleFlag: true, delimiterFlag: `\t`, prefixFlag: "platform:category:"}, simpleEncoder{delimiter: "\t", prefix: "platform:category"}, false},
+		{config{simpleFlag: true, delimiterFlag: ",", nullFlag: true}, nil, true},
+		{config{simpleFlag: true, delimiterFlag: `\q`}, nil, true},
+		{config{simpleFlag: true, prefixFlag: "name:"}, nil, true},
+		{config{formatFlag: "json", nullFlag: true}, nil, true},
+	}
+
+	for _, tt := range tests {
+		got, err := newEncoder(tt.cfg)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("newEncoder(%+v) error = %v, want error %v", tt.cfg, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("newEncoder(%+v) = %T, want %T", tt.cfg, got, tt.want)
+		}
+	}
+}
+
+func TestEncoders(t *testing.T) {
+	batch := resultBatch{
+		Platform: "github",
+		Category: "organization",
+		Query:    "acme",
+		Header:   "GitHub organizations matching 'acme'",
+		Results:  []string{"acme-corp", "acme,labs"},
+	}
+
+	tests := []struct {
+		cfg  config
+		want string
+	}{
+		{config{}, "\nGitHub organizations matching 'acme':\n- acme-corp\n- acme,labs\n"},
+		{config{simpleFlag: true}, "acme-corp\nacme,labs\n"},
+		{config{simpleFlag: true, nullFlag: true, prefixFlag: "platform:"}, "github:acme-corp\x00github:acme,labs\x00"},
+		{config{simpleFlag: true, delimiterFlag: " ", prefixFlag: "platform:query"}, "github:acme:acme-corp github:acme:acme,labs "},
+		{config{formatFlag: "json"}, `{"platform":"github","category":"organization","query":"acme","name":"acme-corp"}` + "\n" +
+			`{"platform":"github","category":"organization","query":"acme","name":"acme,labs"}` + "\n"},
+		{config{formatFlag: "csv"}, "github,organization,acme,acme-corp,\ngithub,organization,acme,\"acme,labs\",\n"},
+		{config{formatFlag: "template", templateFlag: "{{.Platform}}/{{.Name}} ({{.Query}})"}, "github/acme-corp (acme)\ngithub/acme,labs (acme)\n"},
+	}
+
+	for _, tt := range tests {
+		setupRun(t, tt.cfg)
+
+		var out bytes.Buffer
+		printResults(&out, batch)
+		if got := out.String(); got != tt.want {
+			t.Errorf("format %q printed %q, want %q", tt.cfg.formatFlag, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Output files, reports and state files are encrypted at rest when a
+// passphrase is set, in the age format (age-encryption.org/v1) with a
+// passphrase recipient, so `age -d` decrypts them as well as dorky does.
+
+// ageMagic starts every age file.
+const ageMagic = "age-encryption.org/v1\n"
+
+// ageChunkSize is the size of the plaintext chunks of an age payload.
+const ageChunkSize = 64 * 1024
+
+// ageScryptWorkFactor is the log2 of the scrypt cost of new files, age's
+// default. Decryption accepts up to ageMaxWorkFactor, as age does.
+var ageScryptWorkFactor = 18
+
+const ageMaxWorkFactor = 22
+
+// passphraseEnv and passphraseFileEnv hold the passphrase results are
+// encrypted with: itself, or the file it's in, first line only.
+const (
+	passphraseEnv     = "DORKY_PASSPHRASE"
+	passphraseFileEnv = "DORKY_PASSPHRASE_FILE"
+)
+
+// errNoPassphrase is returned reading an encrypted file without a
+// passphrase.
+var errNoPassphrase = errors.New("the file is encrypted: set " + passphraseEnv + " or " + passphraseFileEnv)
+
+// outputPassphrase returns the passphrase to encrypt and decrypt results
+// with, "" when none is set.
+func outputPassphrase() (string, error) {
+	if passphrase := os.Getenv(passphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	filename := os.Getenv(passphraseFileEnv)
+	if filename == "" {
+		return "", nil
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", passphraseFileEnv, err)
+	}
+	passphrase := strings.TrimRight(strings.SplitN(string(data), "\n", 2)[0], "\r")
+	if passphrase == "" {
+		return "", fmt.Errorf("%s %s is empty", passphraseFileEnv, filename)
+	}
+	return passphrase, nil
+}
+
+// sealOutput encrypts data for filename, written at rest, when a
+// passphrase is set.
+func sealOutput(filename string, data []byte) ([]byte, error) {
+	passphrase, err := outputPassphrase()
+	if err != nil || passphrase == "" {
+		return data, err
+	}
+	return encryptAgeFile(passphrase, filename, data)
+}
+
+// readOutputFile reads a file dorky wrote, decrypting it if it's
+// encrypted. Plain files are read as they are, so results written before
+// a passphrase was set stay readable.
+func readOutputFile(filename string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil || !isAgeEncrypted(data) {
+		return data, err
+	}
+	return openOutput(filename, data)
+}
+
+// openOutput decrypts the encrypted contents of filename.
+func openOutput(filename string, data []byte) ([]byte, error) {
+	passphrase, err := outputPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("%s: %w", filename, errNoPassphrase)
+	}
+	plaintext, err := decryptAge(passphrase, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return plaintext, nil
+}
+
+// runDecryptCommand prints the decrypted contents of output files, e.g. to
+// pipe remaining_keywords.txt back into dorky.
+func runDecryptCommand(args []string) {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dorky decrypt file...")
+		fmt.Fprintf(fs.Output(), "Prints the contents of output files encrypted with %s or %s.\n", passphraseEnv, passphraseFileEnv)
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	for _, filename := range fs.Args() {
+		data, err := readOutputFile(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+	}
+}
+
+func isAgeEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(ageMagic))
+}
+
+// ageFileKeys caches the file keys unwrapped by a passphrase, by
+// passphrase and header stanza. Deriving the wrapping key costs a quarter
+// of a second and 256 MiB by design, and a run reads back the state and
+// history files it writes.
+var (
+	ageMu       sync.Mutex
+	ageFileKeys = make(map[string][]byte)
+	// ageHeaders caches the header and file key of each file written, by
+	// passphrase and path, for the same reason: output files are rewritten
+	// with every batch of results.
+	ageHeaders = make(map[string]ageHeader)
+)
+
+// ageHeader is the header of a file and the file key it wraps.
+type ageHeader struct {
+	header  []byte
+	fileKey []byte
+}
+
+var b64 = base64.RawStdEncoding
+
+// newAgeHeader makes the header of a file encrypted with passphrase,
+// wrapping a new file key under a new salt, and returns it with the file
+// key.
+func newAgeHeader(passphrase string) ([]byte, []byte, error) {
+	fileKey := make([]byte, 16)
+	salt := make([]byte, 16)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, nil, err
+	}
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+
+	wrappingKey, err := ageScryptKey(passphrase, salt, ageScryptWorkFactor)
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := chacha20poly1305.New(wrappingKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := b64.EncodeToString(aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil))
+
+	var header bytes.Buffer
+	header.WriteString(ageMagic)
+	fmt.Fprintf(&header, "-> scrypt %s %d\n", b64.EncodeToString(salt), ageScryptWorkFactor)
+	header.WriteString(body + "\n")
+	header.WriteString("---")
+	mac, err := ageHeaderMAC(fileKey, header.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	header.WriteString(" " + b64.EncodeToString(mac) + "\n")
+
+	return header.Bytes(), fileKey, nil
+}
+
+func ageScryptKey(passphrase string, salt []byte, workFactor int) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), append([]byte("age-encryption.org/v1/scrypt"), salt...), 1<<workFactor, 8, 1, chacha20poly1305.KeySize)
+}
+
+// ageHeaderMAC authenticates a header, up to and including its "---".
+func ageHeaderMAC(fileKey, header []byte) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, fileKey, nil, []byte("header")), key); err != nil {
+		return nil, err
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(header)
+	return h.Sum(nil), nil
+}
+
+// agePayloadAEAD returns the cipher of a payload starting with nonce.
+func agePayloadAEAD(fileKey, nonce []byte) (cipherAEAD, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, fileKey, nonce, []byte("payload")), key); err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.New(key)
+}
+
+// cipherAEAD is the part of cipher.AEAD the payload uses.
+type cipherAEAD interface {
+	Seal(dst, nonce, plaintext, additionalData []byte) []byte
+	Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error)
+}
+
+// ageChunkNonce is the nonce of chunk i: an 11-byte big-endian counter
+// and a flag set on the last chunk.
+func ageChunkNonce(i uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.BigEndian.PutUint64(nonce[3:11], i)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptAge encrypts plaintext to passphrase as an age file with a file
+// key of its own.
+func encryptAge(passphrase string, plaintext []byte) ([]byte, error) {
+	header, fileKey, err := newAgeHeader(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return sealAge(header, fileKey, plaintext)
+}
+
+// encryptAgeFile encrypts a version of filename to passphrase. The file
+// gets its own file key, derived once and reused by every rewrite of it;
+// each version still gets its own payload key, from a new nonce.
+func encryptAgeFile(passphrase, filename string, plaintext []byte) ([]byte, error) {
+	cacheKey := passphrase + "\x00" + filepath.Clean(filename)
+	ageMu.Lock()
+	cached, ok := ageHeaders[cacheKey]
+	if !ok {
+		header, fileKey, err := newAgeHeader(passphrase)
+		if err != nil {
+			ageMu.Unlock()
+			return nil, err
+		}
+		cached = ageHeader{header: header, fileKey: fileKey}
+		ageHeaders[cacheKey] = cached
+	}
+	ageMu.Unlock()
+
+	return sealAge(cached.header, cached.fileKey, plaintext)
+}
+
+// sealAge encrypts the payload of plaintext under fileKey after header.
+func sealAge(header, fileKey, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	aead, err := agePayloadAEAD(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	out := append(append([]byte(nil), header...), nonce...)
+	for i := uint64(0); ; i++ {
+		chunk := plaintext
+		if len(chunk) > ageChunkSize {
+			chunk = chunk[:ageChunkSize]
+		}
+		plaintext = plaintext[len(chunk):]
+		last := len(plaintext) == 0
+		out = aead.Seal(out, ageChunkNonce(i, last), chunk, nil)
+		if last {
+			return out, nil
+		}
+	}
+}
+
+// decryptAge decrypts an age file encrypted to passphrase.
+func decryptAge(passphrase string, data []byte) ([]byte, error) {
+	fileKey, payload, err := openAgeHeader(passphrase, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < 16 {
+		return nil, errors.New("truncated age payload")
+	}
+	aead, err := agePayloadAEAD(fileKey, payload[:16])
+	if err != nil {
+		return nil, err
+	}
+
+	payload = payload[16:]
+	var plaintext []byte
+	for i := uint64(0); ; i++ {
+		chunk := payload
+		if len(chunk) > ageChunkSize+16 {
+			chunk = chunk[:ageChunkSize+16]
+		}
+		payload = payload[len(chunk):]
+		last := len(payload) == 0
+		if plaintext, err = aead.Open(plaintext, ageChunkNonce(i, last), chunk, nil); err != nil {
+			return nil, errors.New("the encrypted contents are damaged or truncated")
+		}
+		if last {
+			return plaintext, nil
+		}
+	}
+}
+
+// openAgeHeader checks the header of an age file with a passphrase
+// recipient, returning its file key and the payload after it.
+func openAgeHeader(passphrase string, data []byte) ([]byte, []byte, error) {
+	end := bytes.Index(data, []byte("\n---"))
+	if !isAgeEncrypted(data) || end < 0 {
+		return nil, nil, errors.New("not an age file")
+	}
+	macStart := end + len("\n---")
+	lineEnd := bytes.IndexByte(data[macStart:], '\n')
+	if lineEnd < 0 || data[macStart] != ' ' {
+		return nil, nil, errors.New("malformed age header")
+	}
+	header, macLine, payload := data[:macStart], string(data[macStart+1:macStart+lineEnd]), data[macStart+lineEnd+1:]
+
+	lines := strings.Split(string(header[len(ageMagic):len(header)-len("\n---")]), "\n")
+	args := strings.Fields(lines[0])
+	if len(lines) < 2 || len(args) != 4 || args[0] != "->" || args[1] != "scrypt" {
+		return nil, nil, errors.New("the file isn't encrypted with a passphrase alone, decrypt it with age and its identity")
+	}
+	salt, err := b64.DecodeString(args[2])
+	if err != nil || len(salt) != 16 {
+		return nil, nil, errors.New("malformed age scrypt stanza")
+	}
+	workFactor, err := strconv.Atoi(args[3])
+	if err != nil || workFactor < 1 || workFactor > ageMaxWorkFactor {
+		return nil, nil, fmt.Errorf("age scrypt work factor %s is out of range", args[3])
+	}
+	body, err := b64.DecodeString(strings.Join(lines[1:], ""))
+	if err != nil {
+		return nil, nil, errors.New("malformed age scrypt stanza")
+	}
+
+	cacheKey := passphrase + "\x00" + strings.Join(lines, "\n")
+	ageMu.Lock()
+	fileKey, ok := ageFileKeys[cacheKey]
+	ageMu.Unlock()
+	if !ok {
+		wrappingKey, err := ageScryptKey(passphrase, salt, workFactor)
+		if err != nil {
+			return nil, nil, err
+		}
+		aead, err := chacha20poly1305.New(wrappingKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if fileKey, err = aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), body, nil); err != nil {
+			return nil, nil, errors.New("wrong passphrase")
+		}
+		ageMu.Lock()
+		ageFileKeys[cacheKey] = fileKey
+		ageMu.Unlock()
+	}
+
+	mac, err := b64.DecodeString(macLine)
+	if err != nil {
+		return nil, nil, errors.New("malformed age header MAC")
+	}
+	expected, err := ageHeaderMAC(fileKey, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !hmac.Equal(mac, expected) {
+		return nil, nil, errors.New("the age header was tampered with")
+	}
+	return fileKey, payload, nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// setPassphrase encrypts the test's output with passphrase, at a work
+// factor low enough for tests.
+func setPassphrase(t *testing.T, passphrase string) {
+	t.Helper()
+	setenv(t, passphraseEnv, passphrase)
+	setenv(t, passphraseFileEnv, "")
+	old := ageScryptWorkFactor
+	ageScryptWorkFactor = 10
+	t.Cleanup(func() { ageScryptWorkFactor = old })
+}
+
+func TestAgeRoundTrip(t *testing.T) {
+	setPassphrase(t, "correct horse")
+
+	// Empty, short, exactly one chunk and several chunks.
+	for _, size := range []int{0, 11, ageChunkSize, 2*ageChunkSize + 7} {
+		plaintext := bytes.Repeat([]byte("acme\n"), size/5+1)[:size]
+		sealed, err := encryptAge("correct horse", plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(sealed), ageMagic+"-> scrypt ") {
+			t.Fatalf("size %d: header = %q, want an age scrypt stanza", size, sealed[:40])
+		}
+
+		opened, err := decryptAge("correct horse", sealed)
+		if err != nil || !bytes.Equal(opened, plaintext) {
+			t.Errorf("size %d: decryptAge = %d bytes, %v, want the plaintext", size, len(opened), err)
+		}
+	}
+
+	sealed, _ := encryptAge("correct horse", []byte("acme-internal\n"))
+	if _, err := decryptAge("battery staple", sealed); err == nil || err.Error() != "wrong passphrase" {
+		t.Errorf("decryptAge with the wrong passphrase = %v", err)
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := decryptAge("correct horse", sealed); err == nil {
+		t.Error("decryptAge accepted a tampered payload")
+	}
+	if _, err := decryptAge("correct horse", sealed[:len(sealed)-20]); err == nil {
+		t.Error("decryptAge accepted a truncated payload")
+	}
+}
+
+func TestAgeFileKeysDiffer(t *testing.T) {
+	setPassphrase(t, "correct horse")
+
+	// Every file wraps a file key of its own under a salt of its own.
+	a, _ := encryptAge("correct horse", []byte("acme\n"))
+	b, _ := encryptAge("correct horse", []byte("acme\n"))
+	keyA, _, errA := openAgeHeader("correct horse", a)
+	keyB, _, errB := openAgeHeader("correct horse", b)
+	if errA != nil || errB != nil {
+		t.Fatal(errA, errB)
+	}
+	stanza := func(data []byte) []byte { return data[:bytes.Index(data, []byte("\n---"))] }
+	if bytes.Equal(stanza(a), stanza(b)) || bytes.Equal(keyA, keyB) {
+		t.Error("want distinct headers and file keys for identical files")
+	}
+}
+
+func TestAgeFileRewrites(t *testing.T) {
+	setPassphrase(t, "correct horse")
+
+	// Rewrites of a file reuse its header but not its payload key.
+	dir := t.TempDir()
+	first, _ := encryptAgeFile("correct horse", filepath.Join(dir, "github_orgs.txt"), []byte("acme\n"))
+	second, _ := encryptAgeFile("correct horse", filepath.Join(dir, "github_orgs.txt"), []byte("acme\n"))
+	other, _ := encryptAgeFile("correct horse", filepath.Join(dir, "github_users.txt"), []byte("acme\n"))
+	header := bytes.Index(first, []byte("\n---")) + 1
+	if !bytes.Equal(first[:header], second[:header]) || bytes.Equal(first[header:], second[header:]) {
+		t.Error("want one header and distinct payloads for rewrites of a file")
+	}
+	if bytes.Equal(first[:header], other[:header]) {
+		t.Error("want distinct headers for distinct files")
+	}
+	for _, sealed := range [][]byte{first, second, other} {
+		if opened, err := decryptAge("correct horse", sealed); err != nil || string(opened) != "acme\n" {
+			t.Errorf("decryptAge = %q, %v", opened, err)
+		}
+	}
+}
+
+// ageHeaderPattern is the header of a file encrypted to a passphrase alone,
+// per age-encryption.org/v1: a scrypt stanza of a 16-byte salt, its work
+// factor and the wrapped 16-byte file key, then the 32-byte header MAC, all
+// in unpadded base64.
+var ageHeaderPattern = regexp.MustCompile(`^age-encryption\.org/v1\n-> scrypt [A-Za-z0-9+/]{22} [1-9][0-9]?\n[A-Za-z0-9+/]{43}\n--- [A-Za-z0-9+/]{43}\n`)
+
+func TestAgeHeaderFormat(t *testing.T) {
+	setPassphrase(t, "correct horse")
+
+	sealed, err := encryptAge("correct horse", []byte("acme\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := ageHeaderPattern.Find(sealed)
+	if header == nil {
+		t.Fatalf("header = %q, want an age scrypt header", sealed[:bytes.Index(sealed, []byte("\n---"))])
+	}
+	// The payload is a 16-byte nonce and a single sealed chunk.
+	if payload := len(sealed) - len(header); payload != 16+len("acme\n")+16 {
+		t.Errorf("payload is %d bytes, want %d", payload, 16+len("acme\n")+16)
+	}
+	if !strings.Contains(string(header), " 10\n") {
+		t.Errorf("header = %q, want the work factor 10", header)
+	}
+}
+
+// testdata/passphrase.age was encrypted to "correct horse battery staple" at
+// work factor 10 by an implementation of the age spec independent of dorky's,
+// with a payload of two chunks.
+func TestAgeFixture(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "passphrase.age"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ageHeaderPattern.Match(data) {
+		t.Errorf("fixture header doesn't match the header dorky writes")
+	}
+
+	plaintext, err := decryptAge("correct horse battery staple", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want strings.Builder
+	for i := 0; i < 4000; i++ {
+		fmt.Fprintf(&want, "acme-internal-%05d\n", i)
+	}
+	if string(plaintext) != want.String() {
+		t.Errorf("decryptAge = %d bytes, want %d lines of acme-internal", len(plaintext), 4000)
+	}
+}
+
+func TestEncryptedStateFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state.json")
+	state := &stateFile{Results: []resultState{{Platform: "github", Category: "organization", Name: "acme-internal"}}}
+
+	// Written before a passphrase is set: plain, and still readable after.
+	if err := writeStateFile(filename, state); err != nil {
+		t.Fatal(err)
+	}
+	setPassphrase(t, "correct horse")
+	if read, err := readStateFile(filename); err != nil || len(read.Results) != 1 {
+		t.Fatalf("readStateFile of a plain file = %+v, %v", read, err)
+	}
+
+	if err := writeStateFile(filename, state); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := ioutil.ReadFile(filename)
+	if !isAgeEncrypted(data) || bytes.Contains(data, []byte("acme-internal")) {
+		t.Fatalf("state file = %q, want it encrypted", data)
+	}
+	read, err := readStateFile(filename)
+	if err != nil || len(read.Results) != 1 || read.Results[0].Name != "acme-internal" {
+		t.Fatalf("readStateFile = %+v, %v", read, err)
+	}
+
+	setenv(t, passphraseEnv, "")
+	if _, err := readStateFile(filename); !errors.Is(err, errNoPassphrase) {
+		t.Errorf("readStateFile without a passphrase = %v, want errNoPassphrase", err)
+	}
+}
+
+func TestPassphraseFile(t *testing.T) {
+	setPassphrase(t, "")
+	setenv(t, passphraseFileEnv, writeTempFile(t, "correct horse\nignored\n"))
+	if passphrase, err := outputPassphrase(); err != nil || passphrase != "correct horse" {
+		t.Errorf("outputPassphrase = %q, %v, want the first line", passphrase, err)
+	}
+
+	setenv(t, passphraseFileEnv, writeTempFile(t, "\n"))
+	if _, err := outputPassphrase(); err == nil {
+		t.Error("outputPassphrase accepted an empty passphrase file")
+	}
+}
+
+func TestEncryptionRejectsNDJSON(t *testing.T) {
+	setupRun(t, config{})
+	setPassphrase(t, "correct horse")
+
+	cfg := config{orgFlag: true, ghAPIFlag: "rest", maxFlag: 10, concurrencyFlag: 1, orBatchFlag: 1, recurseMinReposFlag: 2, minWordLengthFlag: 2, ndjsonFlag: "results.ndjson"}
+	want := []string{"-ndjson streams results to disk unencrypted: drop it, or unset DORKY_PASSPHRASE and DORKY_PASSPHRASE_FILE"}
+	if problems := outputFlagProblems(cfg); !equalStrings(problems, want) {
+		t.Errorf("problems = %q, want %q", problems, want)
+	}
+}
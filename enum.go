@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// packageTypes are the GitHub Packages registries listed by `dorky enum`.
+// The API has no way to list every type at once.
+var packageTypes = []string{"container", "npm", "maven", "rubygems", "nuget"}
+
+// ghPackage is the part of a GitHub Packages API package that enum reports.
+// The client library predates the endpoints, so requests are built by hand.
+type ghPackage struct {
+	Name        string `json:"name"`
+	PackageType string `json:"package_type"`
+}
+
+// runEnumCommand implements `dorky enum -org acme -user bob`: instead of
+// searching keywords, it lists what already-known GitHub namespaces hold,
+// then runs the usual enrichment and exports on it.
+func runEnumCommand(args []string) {
+	fs := flag.NewFlagSet("enum", flag.ExitOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	orgs := fs.String("org", "", "comma-separated GitHub organizations to enumerate")
+	users := fs.String("user", "", "comma-separated GitHub users to enumerate")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dorky enum [-org acme,...] [-user bob,...] [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 || (*orgs == "" && *users == "") {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if flags.workspace != "" {
+		if err := applyWorkspace(fs, flags.workspace); err != nil {
+			fmt.Printf("Error %s\n", err)
+			os.Exit(1)
+		}
+	}
+	loadRulesFlag(flags)
+	validateOutputFlags(flags)
+
+	httpClient, err := createGitHubHTTPClient()
+	if err != nil {
+		fmt.Printf("Error creating GitHub client: %s\n", err)
+		os.Exit(1)
+	}
+	client := github.NewClient(httpClient)
+
+	err = runAndExport(flags, func() {
+		enumerateNamespaces(client, splitList(*orgs), splitList(*users), flags)
+	})
+	if err != nil {
+		fmt.Printf("Error %s\n", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// enumerateNamespaces reports the repositories, members, gists, packages
+// and Pages sites of orgs and users, then enriches the results like a
+// search would, matching stars against the namespace names.
+func enumerateNamespaces(client *github.Client, orgs, users []string, cfg config) {
+	words := make(map[string]struct{})
+
+	for _, org := range orgs {
+		verbosePrint("Enumerating GitHub organization: %s\n", org)
+		words[org] = struct{}{}
+		emitResults("github", "organization", org, "GitHub organizations enumerated", "github_organizations.txt", []string{org})
+
+		if repos, ok := listGitHubOrgRepos(client, org); ok {
+			reportEnumeratedRepos(client, org, repos)
+		}
+		enumerateGitHubMembers(client, org)
+		enumerateGitHubPackages(client, "orgs", org)
+	}
+
+	for _, user := range users {
+		verbosePrint("Enumerating GitHub user: %s\n", user)
+		words[user] = struct{}{}
+		emitResults("github", "user", user, "GitHub users enumerated", "github_users.txt", []string{user})
+
+		if repos, ok := listGitHubUserRepos(client, user); ok {
+			reportEnumeratedRepos(client, user, repos)
+		}
+		enumerateGitHubGists(client, user)
+		enumerateGitHubPackages(client, "users", user)
+	}
+
+	enrichResults(client, nil, words, cfg)
+}
+
+func listGitHubOrgRepos(client *github.Client, org string) ([]*github.Repository, bool) {
+	var all []*github.Repository
+	opt := &github.RepositoryListByOrgOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		repos, resp, err := client.Repositories.ListByOrg(context.Background(), org, opt)
+		if err != nil {
+			recordSearchError("github", "repository listing", org, err)
+			return nil, false
+		}
+		all = append(all, repos...)
+		if resp.NextPage == 0 {
+			return all, true
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+func listGitHubUserRepos(client *github.Client, user string) ([]*github.Repository, bool) {
+	var all []*github.Repository
+	opt := &github.RepositoryListOptions{Type: "owner", ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		repos, resp, err := client.Repositories.List(context.Background(), user, opt)
+		if err != nil {
+			recordSearchError("github", "repository listing", user, err)
+			return nil, false
+		}
+		all = append(all, repos...)
+		if resp.NextPage == 0 {
+			return all, true
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// reportEnumeratedRepos reports the repositories of owner, and the Pages
+// sites of those that publish one.
+func reportEnumeratedRepos(client *github.Client, owner string, repos []*github.Repository) {
+	var names, pages []string
+	for _, repo := range repos {
+		names = append(names, repo.GetFullName())
+		recordRepoTerms(repo.GetFullName(), repo.Topics, repo.GetDescription())
+		recordHomepage("github", repo.GetFullName(), repo.GetHomepage())
+		if !repo.GetHasPages() {
+			continue
+		}
+
+		info, _, err := client.Repositories.GetPagesInfo(context.Background(), owner, repo.GetName())
+		if err != nil {
+			recordSearchError("github", "pages lookup", repo.GetFullName(), err)
+			continue
+		}
+		if info.GetHTMLURL() != "" {
+			pages = append(pages, repo.GetFullName()+": "+info.GetHTMLURL())
+		}
+	}
+
+	emitResults("github", "repository", owner, fmt.Sprintf("GitHub repositories of '%s'", owner), "github_repositories.txt", names)
+	emitResults("github", "pages", owner, fmt.Sprintf("GitHub Pages sites of '%s'", owner), "github_pages.txt", pages)
+}
+
+// enumerateGitHubMembers reports the members of org and returns them.
+func enumerateGitHubMembers(client *github.Client, org string) []string {
+	var members []string
+	opt := &github.ListMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		users, resp, err := client.Organizations.ListMembers(context.Background(), org, opt)
+		if err != nil {
+			recordSearchError("github", "member listing", org, err)
+			return nil
+		}
+		for _, user := range users {
+			members = append(members, user.GetLogin())
+			recordAvatar("github", user.GetLogin(), user.GetAvatarURL())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	emitResults("github", "user", org, fmt.Sprintf("GitHub members of '%s'", org), "github_users.txt", members)
+	return members
+}
+
+// enumerateGitHubGists reports user's public gists as "url description".
+func enumerateGitHubGists(client *github.Client, user string) {
+	var gists []string
+	opt := &github.GistListOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	for {
+		page, resp, err := client.Gists.List(context.Background(), user, opt)
+		if err != nil {
+			recordSearchError("github", "gist listing", user, err)
+			return
+		}
+		for _, gist := range page {
+			gists = append(gists, strings.TrimSpace(gist.GetHTMLURL()+" "+gist.GetDescription()))
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	emitResults("github", "gist", user, fmt.Sprintf("GitHub gists of '%s'", user), "github_gists.txt", gists)
+}
+
+// enumerateGitHubPackages reports the packages of an org or user (scope
+// "orgs" or "users") as "owner/name (type)". Listing packages needs a
+// token with the read:packages scope.
+func enumerateGitHubPackages(client *github.Client, scope, owner string) {
+	var packages []string
+	for _, packageType := range packageTypes {
+		u := fmt.Sprintf("%s/%s/packages?package_type=%s&per_page=100", scope, owner, packageType)
+		req, err := client.NewRequest(http.MethodGet, u, nil)
+		if err != nil {
+			recordSearchError("github", "package listing", owner, err)
+			return
+		}
+
+		var page []ghPackage
+		if _, err := client.Do(context.Background(), req, &page); err != nil {
+			recordSearchError("github", "package listing", owner, err)
+			return
+		}
+		for _, p := range page {
+			if p.PackageType == "" {
+				p.PackageType = packageType
+			}
+			packages = append(packages, fmt.Sprintf("%s/%s (%s)", owner, p.Name, p.PackageType))
+		}
+	}
+
+	emitResults("github", "package", owner, fmt.Sprintf("GitHub packages of '%s'", owner), "github_packages.txt", packages)
+}
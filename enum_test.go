@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestEnumerateNamespaces(t *testing.T) {
+	cfg := config{membershipsFlag: true}
+	setupRun(t, cfg)
+
+	srv, _ := newFakeAPI(t, map[string]interface{}{
+		"/orgs/acme/repos": []map[string]interface{}{
+			{"name": "api", "full_name": "acme/api", "has_pages": true},
+			{"name": "web", "full_name": "acme/web"},
+		},
+		"/repos/acme/api/pages": map[string]string{"html_url": "https://acme.github.io/api/"},
+		"/orgs/acme/members":    []map[string]string{{"login": "alice"}},
+		"/orgs/acme/packages":   []map[string]string{{"name": "api", "package_type": "container"}},
+		"/users/alice/orgs":     []map[string]string{{"login": "acme"}, {"login": "widgetco"}},
+		"/users/bob/repos":      []map[string]string{{"name": "dotfiles", "full_name": "bob/dotfiles"}},
+		"/users/bob/gists":      []map[string]string{{"html_url": "https://gist.github.com/bob/abc123", "description": "acme deploy notes"}},
+		"/users/bob/orgs":       []map[string]string{},
+	})
+
+	enumerateNamespaces(newFakeGitHubClient(t, srv), []string{"acme"}, []string{"bob"}, cfg)
+
+	tests := []struct {
+		category string
+		want     []string
+	}{
+		{"organization", []string{"acme"}},
+		{"repository", []string{"acme/api", "acme/web", "bob/dotfiles"}},
+		{"pages", []string{"acme/api: https://acme.github.io/api/"}},
+		{"user", []string{"alice", "bob"}},
+		// Every package type answers with the same package, reported once.
+		{"package", []string{"acme/api (container)"}},
+		{"gist", []string{"https://gist.github.com/bob/abc123 acme deploy notes"}},
+		{"target_member", []string{"alice: acme"}},
+	}
+	for _, tt := range tests {
+		if got := resultNames("github", tt.category); !equalStrings(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.category, got, tt.want)
+		}
+	}
+
+	// bob has no packages endpoint in the fake API.
+	if failed := failedOperations(); failed != 1 {
+		t.Errorf("failed operations = %d, want 1", failed)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// errorBudget is how many failures -max-errors lets a run through: a number
+// of failed operations, or a percentage of the keywords searched that had a
+// failed search.
+type errorBudget struct {
+	limit   float64
+	percent bool
+}
+
+// errorBudgetSample is how many keywords a run searches before a percentage
+// budget applies, so that the first failed keyword doesn't end it.
+const errorBudgetSample = 10
+
+var (
+	// runErrorBudget is the -max-errors budget of the run, nil without one.
+	runErrorBudget *errorBudget
+	// errorBudgetSpent is set once the run goes over its budget, and stays
+	// set: a percentage can dip back under it as searches under way return.
+	errorBudgetSpent bool
+)
+
+// errorClassHints tell what to do about the errors of a class, for the
+// failure summary of runs over their budget.
+var errorClassHints = map[string]string{
+	"unauthorized": "check that the tokens are set and valid",
+	"forbidden":    "check the tokens' scopes and SSO authorization",
+	"rate_limited": "wait for the quota to reset, or lower -concurrency",
+	"network":      "check the network connection and any proxy",
+	"timeout":      "check the network connection and any proxy",
+	"server_error": "the platform is having trouble, try again later",
+}
+
+// errorBudgetError is returned by a run aborted by -max-errors, once the
+// failure summary is printed. It counts the failed operations that spent
+// the budget and the keywords left unsearched.
+type errorBudgetError struct {
+	failed     int
+	unsearched int
+}
+
+func (e *errorBudgetError) Error() string {
+	return fmt.Sprintf("-max-errors reached after %d failed operations, %d keywords were not searched", e.failed, e.unsearched)
+}
+
+// parseErrorBudget parses -max-errors: a number of failed operations such
+// as 50, or a percentage of the keywords searched such as 20%. It returns
+// nil for an empty value.
+func parseErrorBudget(value string) (*errorBudget, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "%")), 64)
+		if err != nil || percent < 0 || percent >= 100 {
+			return nil, fmt.Errorf("-max-errors %s must be a percentage from 0%% to under 100%%", value)
+		}
+		return &errorBudget{limit: percent, percent: true}, nil
+	}
+
+	operations, err := strconv.Atoi(value)
+	if err != nil || operations < 0 {
+		return nil, fmt.Errorf("-max-errors %s must be a number of failed operations, such as 50, or a percentage of the keywords, such as 20%%", value)
+	}
+	return &errorBudget{limit: float64(operations)}, nil
+}
+
+// startErrorBudget sets the -max-errors budget of a new run.
+func startErrorBudget(cfg config) {
+	runErrorBudget, _ = parseErrorBudget(cfg.maxErrorsFlag)
+	errorBudgetSpent = false
+}
+
+// errorCounts returns the failed operations of the run, the keywords it
+// searched with a failed search, and the keywords it searched.
+func errorCounts() (failed, failedKeywords, searched int) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	failedWords := make(map[string]bool)
+	for _, entry := range searchErrors {
+		failed += entry.Count
+		if _, ok := queriedWords[entry.Query]; ok {
+			failedWords[entry.Query] = true
+		}
+	}
+	return failed, len(failedWords), len(queriedWords)
+}
+
+// errorBudgetExceeded reports whether the run's failures went over
+// -max-errors, after which no new searches are started.
+func errorBudgetExceeded() bool {
+	if runErrorBudget == nil {
+		return false
+	}
+
+	stateMu.Lock()
+	spent := errorBudgetSpent
+	stateMu.Unlock()
+	if spent {
+		return true
+	}
+
+	failed, failedKeywords, searched := errorCounts()
+	if runErrorBudget.percent {
+		spent = searched >= errorBudgetSample && float64(failedKeywords)*100 > runErrorBudget.limit*float64(searched)
+	} else {
+		spent = float64(failed) > runErrorBudget.limit
+	}
+
+	if spent {
+		stateMu.Lock()
+		errorBudgetSpent = true
+		stateMu.Unlock()
+	}
+	return spent
+}
+
+// errorBudgetSummary explains why a run went over -max-errors: how much
+// failed, the most frequent error classes and what to do about them.
+func errorBudgetSummary(unsearched int) string {
+	failed, failedKeywords, searched := errorCounts()
+
+	var b strings.Builder
+	if runErrorBudget.percent {
+		fmt.Fprintf(&b, "\nAborted: %d of the %d keywords searched had failed searches, over -max-errors %g%%.\n", failedKeywords, searched, runErrorBudget.limit)
+	} else {
+		fmt.Fprintf(&b, "\nAborted: %d operations failed, over -max-errors %g.\n", failed, runErrorBudget.limit)
+	}
+
+	classes := make(map[string]int)
+	for _, e := range searchErrorSummary() {
+		classes[e.Class] += e.Count
+	}
+	names := make([]string, 0, len(classes))
+	for class := range classes {
+		names = append(names, class)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if classes[names[i]] != classes[names[j]] {
+			return classes[names[i]] > classes[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	for _, class := range names {
+		fmt.Fprintf(&b, "- %s: %d", class, classes[class])
+		if hint := errorClassHints[class]; hint != "" {
+			b.WriteString(" (" + hint + ")")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("The results found so far were saved")
+	if unsearched > 0 {
+		fmt.Fprintf(&b, ", and the %d keywords not searched are in remaining_keywords.txt", unsearched)
+	}
+	b.WriteString(".\n")
+	return b.String()
+}
+
+// printErrorBudgetSummary writes the failure summary of a run over
+// -max-errors to stderr, next to the error report.
+func printErrorBudgetSummary(unsearched int) {
+	fmt.Fprint(os.Stderr, errorBudgetSummary(unsearched))
+}
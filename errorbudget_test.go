@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseErrorBudget(t *testing.T) {
+	tests := []struct {
+		value string
+		want  *errorBudget
+		err   bool
+	}{
+		{"", nil, false},
+		{"50", &errorBudget{limit: 50}, false},
+		{"0", &errorBudget{limit: 0}, false},
+		{"20%", &errorBudget{limit: 20, percent: true}, false},
+		{" 2.5 % ", &errorBudget{limit: 2.5, percent: true}, false},
+		{"-1", nil, true},
+		{"100%", nil, true},
+		{"many", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseErrorBudget(tt.value)
+		if (err != nil) != tt.err {
+			t.Errorf("parseErrorBudget(%q) error = %v, want error %v", tt.value, err, tt.err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+			t.Errorf("parseErrorBudget(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMaxErrorsAborts(t *testing.T) {
+	setupRun(t, config{maxErrorsFlag: "1"})
+	t.Cleanup(func() { runErrorBudget = nil })
+
+	err := runAndExport(flags, func() {
+		emitResults("github", "organization", "acme", "GitHub organizations matching 'acme'", "github_organizations.txt", []string{"acme"})
+		recordSearchError("github", "user search", "acme", &httpStatusError{StatusCode: 401, Status: "401 Unauthorized"})
+		if errorBudgetExceeded() {
+			t.Error("errorBudgetExceeded() = true after 1 failed operation, want false")
+		}
+		recordSearchError("gitlab", "group search", "acme", &httpStatusError{StatusCode: 401, Status: "401 Unauthorized"})
+		if !errorBudgetExceeded() {
+			t.Error("errorBudgetExceeded() = false after 2 failed operations, want true")
+		}
+		recordUnsearched("globex")
+	})
+
+	var aborted *errorBudgetError
+	if !errors.As(err, &aborted) || exitCode(err) != 5 {
+		t.Fatalf("err = %v, want an errorBudgetError with exit status 5", err)
+	}
+	if aborted.failed != 2 || aborted.unsearched != 1 {
+		t.Errorf("err = %+v, want 2 failed and 1 unsearched", aborted)
+	}
+	if got := readOutputLines(t, "github_organizations.txt"); !equalStrings(got, []string{"acme"}) {
+		t.Errorf("saved results = %v, want [acme]", got)
+	}
+	if got := readOutputLines(t, "remaining_keywords.txt"); !equalStrings(got, []string{"globex"}) {
+		t.Errorf("remaining keywords = %v, want [globex]", got)
+	}
+	if summary := errorBudgetSummary(1); !strings.Contains(summary, "unauthorized: 2 (check that the tokens are set and valid)") {
+		t.Errorf("summary = %q, want the unauthorized errors with their hint", summary)
+	}
+}
+
+func TestMaxErrorsPercentage(t *testing.T) {
+	setupRun(t, config{maxErrorsFlag: "20%"})
+	startErrorBudget(flags)
+	t.Cleanup(func() { runErrorBudget = nil })
+
+	// Three failed keywords out of nine are under the sample size, and out
+	// of ten over 20%.
+	for i := 0; i < 9; i++ {
+		word := fmt.Sprintf("word%d", i)
+		if i < 3 {
+			recordSearchError("github", "organization search", word, errors.New("connection reset"))
+		}
+		recordQueried(word)
+	}
+	if errorBudgetExceeded() {
+		t.Error("errorBudgetExceeded() = true before the sample size, want false")
+	}
+	recordQueried("word9")
+	if !errorBudgetExceeded() {
+		t.Error("errorBudgetExceeded() = false with 30% of the keywords failed, want true")
+	}
+
+	// Once spent, the budget stays spent.
+	for i := 10; i < 30; i++ {
+		recordQueried(fmt.Sprintf("word%d", i))
+	}
+	if !errorBudgetExceeded() {
+		t.Error("errorBudgetExceeded() = false after dipping under 20%, want true")
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// newFakeAPI starts an HTTP server answering each path with the JSON
+// encoding of its value, and records the query of every request.
+func newFakeAPI(t *testing.T, routes map[string]interface{}) (*httptest.Server, *[]url.Values) {
+	t.Helper()
+
+	var requests []url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query())
+
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func newFakeGitHubClient(t *testing.T, srv *httptest.Server) *github.Client {
+	t.Helper()
+
+	client := github.NewClient(srv.Client())
+	base, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.BaseURL = base
+	return client
+}
+
+func newFakeGitLabClient(t *testing.T, srv *httptest.Server) *gitlab.Client {
+	t.Helper()
+
+	client, err := gitlab.NewClient("test-token", gitlab.WithBaseURL(srv.URL), gitlab.WithHTTPClient(srv.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestSearchGitHubAgainstFakeAPI(t *testing.T) {
+	cfg := config{orgFlag: true, repoFlag: true, userFlag: true, maxFlag: 5}
+	setupRun(t, cfg)
+
+	srv, requests := newFakeAPI(t, map[string]interface{}{
+		"/search/users": map[string]interface{}{
+			"total_count": 1,
+			"items":       []map[string]string{{"login": "acme"}},
+		},
+		"/search/repositories": map[string]interface{}{
+			"total_count": 2,
+			"items":       []map[string]string{{"full_name": "acme/api"}, {"full_name": "acme/web"}},
+		},
+	})
+
+	searchGitHub(newFakeGitHubClient(t, srv), "acme", cfg)
+
+	if got, want := resultNames("github", "organization"), []string{"acme"}; !equalStrings(got, want) {
+		t.Errorf("organizations = %v, want %v", got, want)
+	}
+	if got, want := resultNames("github", "repository"), []string{"acme/api", "acme/web"}; !equalStrings(got, want) {
+		t.Errorf("repositories = %v, want %v", got, want)
+	}
+	if got, want := resultNames("github", "user"), []string{"acme"}; !equalStrings(got, want) {
+		t.Errorf("users = %v, want %v", got, want)
+	}
+
+	if len(*requests) != 3 {
+		t.Fatalf("made %d requests, want 3", len(*requests))
+	}
+	for _, q := range *requests {
+		if q.Get("per_page") != "5" {
+			t.Errorf("per_page = %q, want 5", q.Get("per_page"))
+		}
+	}
+	if got := (*requests)[0].Get("q"); got != "type:org acme" {
+		t.Errorf("organization query = %q", got)
+	}
+}
+
+func TestSearchGitLabAgainstFakeAPI(t *testing.T) {
+	cfg := config{orgFlag: true, repoFlag: true, userFlag: true, maxFlag: 5}
+	setupRun(t, cfg)
+
+	srv, _ := newFakeAPI(t, map[string]interface{}{
+		"/api/v4/groups":   []map[string]interface{}{{"id": 1, "full_path": "acme"}, {"id": 2, "full_path": "acme/platform"}},
+		"/api/v4/users":    []map[string]interface{}{{"id": 3, "username": "acme-bot"}},
+		"/api/v4/projects": []map[string]interface{}{{"id": 4, "path_with_namespace": "acme/platform/api"}},
+	})
+
+	searchGitLab(newFakeGitLabClient(t, srv), "acme", cfg)
+
+	if got, want := resultNames("gitlab", "group"), []string{"acme", "acme/platform"}; !equalStrings(got, want) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+	if got, want := resultNames("gitlab", "user"), []string{"acme-bot"}; !equalStrings(got, want) {
+		t.Errorf("users = %v, want %v", got, want)
+	}
+	if got, want := resultNames("gitlab", "project"), []string{"acme/platform/api"}; !equalStrings(got, want) {
+		t.Errorf("projects = %v, want %v", got, want)
+	}
+}
+
+func TestSearchGitHubGraphQLAgainstFakeAPI(t *testing.T) {
+	cfg := config{orgFlag: true, repoFlag: true, maxFlag: 5}
+	setupRun(t, cfg)
+
+	var gotQuery map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&gotQuery)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": map[string]interface{}{
+				"k0_org":  map[string]interface{}{"nodes": []map[string]string{{"login": "acme"}, {}}},
+				"k0_repo": map[string]interface{}{"nodes": []map[string]string{{"nameWithOwner": "acme/api", "homepageUrl": "https://api.acme.com"}}},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	oldURL := githubGraphQLURL
+	githubGraphQLURL = srv.URL
+	defer func() { githubGraphQLURL = oldURL }()
+
+	searchGitHubGraphQL(srv.Client(), map[string]struct{}{"acme": {}}, cfg)
+
+	if got, want := resultNames("github", "organization"), []string{"acme"}; !equalStrings(got, want) {
+		t.Errorf("organizations = %v, want %v", got, want)
+	}
+	if got, want := resultNames("github", "repository"), []string{"acme/api"}; !equalStrings(got, want) {
+		t.Errorf("repositories = %v, want %v", got, want)
+	}
+	if got := homepageURLs["github:acme/api"]; got != "https://api.acme.com" {
+		t.Errorf("homepage of acme/api = %q, want it recorded for -urls", got)
+	}
+
+	variables, _ := gotQuery["variables"].(map[string]interface{})
+	if variables["k0_org"] != "type:org acme" || variables["k0_repo"] != "acme" {
+		t.Errorf("unexpected variables %v", variables)
+	}
+}
+
+func TestSearchGitLabTopLevelGroups(t *testing.T) {
+	for _, topLevel := range []bool{false, true} {
+		cfg := config{orgFlag: true, maxFlag: 5, glTopLevelFlag: topLevel}
+		setupRun(t, cfg)
+
+		srv, requests := newFakeAPI(t, map[string]interface{}{
+			"/api/v4/groups": []map[string]interface{}{{"id": 1, "full_path": "acme"}},
+		})
+		searchGitLabGroupsAndUsers(newFakeGitLabClient(t, srv), "acme", cfg)
+
+		var got string
+		for _, query := range *requests {
+			if query.Get("search") == "acme" {
+				got = query.Get("top_level_only")
+				break
+			}
+		}
+		if (got == "true") != topLevel {
+			t.Errorf("-gl-top-level=%v: top_level_only = %q", topLevel, got)
+		}
+	}
+}
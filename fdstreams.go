@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// categoryStreams are where -fd-org, -fd-repo and -fd-user route the
+// results of their category, by -categories name, as they're found. Each
+// line is a result's name, with a tab and its keyword under -with-keyword,
+// as in output files. stateMu guards them.
+var categoryStreams = make(map[string]*os.File)
+
+// streamFlags returns the targets of the category streams of cfg, by
+// -categories name.
+func streamFlags(cfg config) map[string]string {
+	targets := make(map[string]string)
+	for name, target := range map[string]string{"org": cfg.fdOrgFlag, "repo": cfg.fdRepoFlag, "user": cfg.fdUserFlag} {
+		if target = strings.TrimSpace(target); target != "" {
+			targets[name] = target
+		}
+	}
+	return targets
+}
+
+// parseStreamTarget returns the file descriptor of target, or -1 when it's
+// a path, such as a named pipe. Descriptors 0 to 2 are stdin, stdout and
+// stderr, which the run uses already.
+func parseStreamTarget(name, target string) (int, error) {
+	fd, err := strconv.Atoi(target)
+	if err != nil {
+		return -1, nil
+	}
+	if fd < 3 {
+		return 0, fmt.Errorf("-fd-%s %d: use a file descriptor from 3 on, 0 to 2 are stdin, stdout and stderr", name, fd)
+	}
+	return fd, nil
+}
+
+// openCategoryStreams opens the category streams of cfg: a descriptor the
+// shell opened for dorky, such as 3 with 3>orgs.txt, or a file or named
+// pipe, appended to. Opening a named pipe waits for its reader. The streams
+// stay open for every run of the process.
+func openCategoryStreams(cfg config) error {
+	for name, target := range streamFlags(cfg) {
+		fd, err := parseStreamTarget(name, target)
+		if err != nil {
+			return err
+		}
+
+		var f *os.File
+		if fd >= 0 {
+			f = os.NewFile(uintptr(fd), "fd "+target)
+			if _, err := f.Stat(); err != nil {
+				// Closed now rather than by the finalizer, once the
+				// descriptor may have been reused.
+				f.Close()
+				return fmt.Errorf("-fd-%s: file descriptor %d isn't open, open it in the shell with %d>file or %d>(command)", name, fd, fd, fd)
+			}
+		} else if f, err = os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+			return fmt.Errorf("-fd-%s: %w", name, err)
+		}
+
+		stateMu.Lock()
+		categoryStreams[name] = f
+		stateMu.Unlock()
+	}
+	return nil
+}
+
+// closeCategoryStreams closes the category streams, so their readers see
+// the end of their input.
+func closeCategoryStreams() {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	for name, f := range categoryStreams {
+		f.Close()
+		delete(categoryStreams, name)
+	}
+}
+
+// streamCategory returns the -categories name of the search finding results
+// of category on platform, for the category streams: org, repo or user, or
+// "" for the others. Bitbucket projects hold repositories, like
+// organizations, while GitLab projects are repositories. Stack Exchange
+// users are profiles rather than accounts of a code platform.
+func streamCategory(platform, category string) string {
+	switch {
+	case category == "organization", category == "group", platform == "bitbucket" && category == "project":
+		return "org"
+	case category == "repository", category == "project":
+		return "repo"
+	case category == "user" && platform != "stackexchange":
+		return "user"
+	}
+	return ""
+}
+
+// routeResult writes r to the stream of its category, if there's one. A
+// stream that fails, such as a pipe whose reader exited, is dropped with a
+// warning rather than failing the run. stateMu must be held.
+func routeResult(r result) {
+	name := streamCategory(r.Platform, r.Category)
+	f := categoryStreams[name]
+	if f == nil {
+		return
+	}
+
+	if _, err := f.WriteString(withKeyword(r.Query, []string{r.Name})[0] + "\n"); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing to -fd-%s, no longer writing to it: %s\n", name, err)
+		f.Close()
+		delete(categoryStreams, name)
+	}
+}
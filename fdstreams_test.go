@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestStreamCategory(t *testing.T) {
+	tests := []struct {
+		platform, category, want string
+	}{
+		{"github", "organization", "org"},
+		{"gitlab", "group", "org"},
+		{"bitbucket", "project", "org"},
+		{"gitlab", "project", "repo"},
+		{"github", "repository", "repo"},
+		{"bitbucket", "user", "user"},
+		{"stackexchange", "user", ""},
+		{"github", "gist", ""},
+	}
+	for _, tt := range tests {
+		if got := streamCategory(tt.platform, tt.category); got != tt.want {
+			t.Errorf("streamCategory(%s, %s) = %q, want %q", tt.platform, tt.category, got, tt.want)
+		}
+	}
+}
+
+func TestParseStreamTarget(t *testing.T) {
+	if fd, err := parseStreamTarget("org", "3"); fd != 3 || err != nil {
+		t.Errorf("parseStreamTarget(3) = %d, %v, want 3", fd, err)
+	}
+	if fd, err := parseStreamTarget("org", "/tmp/orgs.fifo"); fd != -1 || err != nil {
+		t.Errorf("parseStreamTarget(/tmp/orgs.fifo) = %d, %v, want -1", fd, err)
+	}
+	if _, err := parseStreamTarget("org", "1"); err == nil {
+		t.Error("parseStreamTarget(1) succeeded, want an error for stdout")
+	}
+}
+
+func TestCategoryStreams(t *testing.T) {
+	dir := t.TempDir()
+	orgsPath, usersPath := filepath.Join(dir, "orgs"), filepath.Join(dir, "users")
+	if err := ioutil.WriteFile(orgsPath, []byte("earlier\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	setupRun(t, config{withKeyword: true, fdOrgFlag: orgsPath, fdUserFlag: usersPath})
+	if err := openCategoryStreams(flags); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(closeCategoryStreams)
+
+	emitResults("github", "organization", "acme", "", "github_organizations.txt", []string{"acme", "acme-corp"})
+	emitResults("gitlab", "group", "acme", "", "gitlab_groups.txt", []string{"acme-group"})
+	emitResults("github", "user", "acme", "", "github_users.txt", []string{"acme-bot"})
+	emitResults("github", "repository", "acme", "", "github_repositories.txt", []string{"acme/api"})
+
+	orgs, err := ioutil.ReadFile(orgsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "earlier\nacme\tacme\nacme-corp\tacme\nacme-group\tacme\n"; string(orgs) != want {
+		t.Errorf("org stream = %q, want %q", orgs, want)
+	}
+	users, err := ioutil.ReadFile(usersPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "acme-bot\tacme\n"; string(users) != want {
+		t.Errorf("user stream = %q, want %q", users, want)
+	}
+}
+
+func TestOpenCategoryStreamsClosedDescriptor(t *testing.T) {
+	f, err := ioutil.TempFile(t.TempDir(), "closed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd := f.Fd()
+	f.Close()
+
+	if err := openCategoryStreams(config{fdRepoFlag: strconv.Itoa(int(fd))}); err == nil {
+		closeCategoryStreams()
+		t.Error("openCategoryStreams succeeded on a closed descriptor")
+	}
+}
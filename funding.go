@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// fundingFile is where a repository configures its sponsor button.
+	fundingFile = ".github/FUNDING.yml"
+
+	// communityHealthRepo is the repository whose FUNDING.yml, at its root,
+	// applies to every repository of its owner without one.
+	communityHealthRepo = ".github"
+)
+
+// fundingPlatforms maps the keys of FUNDING.yml to the profile URLs of the
+// accounts they name. github names GitHub Sponsors accounts and custom
+// holds URLs; both are handled apart.
+var fundingPlatforms = map[string]string{
+	"patreon":          "https://www.patreon.com/%s",
+	"open_collective":  "https://opencollective.com/%s",
+	"ko_fi":            "https://ko-fi.com/%s",
+	"tidelift":         "https://tidelift.com/funding/github/%s",
+	"community_bridge": "https://crowdfunding.lfx.linuxfoundation.org/projects/%s",
+	"lfx_crowdfunding": "https://crowdfunding.lfx.linuxfoundation.org/projects/%s",
+	"liberapay":        "https://liberapay.com/%s",
+	"issuehunt":        "https://issuehunt.io/r/%s",
+	"otechie":          "https://otechie.com/%s",
+	"polar":            "https://polar.sh/%s",
+	"buy_me_a_coffee":  "https://buymeacoffee.com/%s",
+	"thanks_dev":       "https://thanks.dev/%s",
+}
+
+// sponsorsURLRegexp matches a GitHub Sponsors profile among custom links.
+var sponsorsURLRegexp = regexp.MustCompile(`(?i)^https?://(?:www\.)?github\.com/sponsors/([a-z0-9-]+)/?$`)
+
+// fundingAccounts are what a FUNDING.yml reveals about the people behind a
+// repository: the GitHub accounts sponsored for it and the profile URLs of
+// their accounts elsewhere, each sorted.
+type fundingAccounts struct {
+	users, external []string
+}
+
+// parseFundingFile extracts the accounts a FUNDING.yml names. Each key holds
+// a name or a list of names; invalid YAML gives no accounts.
+func parseFundingFile(content string) fundingAccounts {
+	var fields map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &fields); err != nil {
+		return fundingAccounts{}
+	}
+
+	users, external := make(map[string]bool), make(map[string]bool)
+	for key, value := range fields {
+		for _, name := range fundingValues(value) {
+			switch pattern, known := fundingPlatforms[key]; {
+			case key == "github":
+				users[strings.TrimPrefix(name, "@")] = true
+			case key == "custom":
+				if m := sponsorsURLRegexp.FindStringSubmatch(name); m != nil {
+					users[m[1]] = true
+				} else if u := normalizeHomepage(name); u != "" {
+					external[u] = true
+				}
+			case known:
+				external[fmt.Sprintf(pattern, url.PathEscape(name))] = true
+			}
+		}
+	}
+	return fundingAccounts{users: sortedSet(users), external: sortedSet(external)}
+}
+
+// fundingValues returns the names a FUNDING.yml key holds, whether a single
+// name or a list.
+func fundingValues(value interface{}) []string {
+	var values []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		values = v
+	default:
+		values = []interface{}{v}
+	}
+
+	var names []string
+	for _, v := range values {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			names = append(names, strings.TrimSpace(s))
+		}
+	}
+	return names
+}
+
+// discoverFunding reads the FUNDING.yml of the GitHub repositories found so
+// far, and of the .github repository of their owners and of the accounts
+// found, and reports the GitHub accounts it sponsors as "repo: login" and
+// the accounts elsewhere, such as Patreon or Open Collective, as "repo:
+// url". The GitHub accounts are the target's developers more often than
+// not, and their logins are saved to suggested_seeds.txt as keywords for
+// the next run.
+func discoverFunding(client githubContentsService) {
+	var repos []string
+	owners := make(map[string]string)
+	addOwner := func(owner string) {
+		if _, ok := owners[normalizeName(owner)]; !ok {
+			owners[normalizeName(owner)] = owner
+		}
+	}
+	seen := make(map[string]bool)
+	for _, r := range collectedResults {
+		if r.Platform != "github" {
+			continue
+		}
+		switch r.Category {
+		case "repository":
+			if key := normalizeName(r.Name); !seen[key] {
+				seen[key] = true
+				repos = append(repos, r.Name)
+				addOwner(strings.SplitN(r.Name, "/", 2)[0])
+			}
+		case "organization", "user":
+			addOwner(r.Name)
+		}
+	}
+	sort.Strings(repos)
+
+	ownerNames := make([]string, 0, len(owners))
+	for _, owner := range owners {
+		ownerNames = append(ownerNames, owner)
+	}
+	sort.Strings(ownerNames)
+
+	var logins []string
+	found := make(map[string]bool)
+	report := func(repo, path string) {
+		accounts := readFundingFile(client, repo, path)
+		owner := strings.SplitN(repo, "/", 2)[0]
+
+		var users, external []string
+		for _, login := range accounts.users {
+			if strings.EqualFold(login, owner) {
+				continue
+			}
+			users = append(users, repo+": "+login)
+			if key := normalizeName(login); !found[key] {
+				found[key] = true
+				logins = append(logins, login)
+			}
+		}
+		for _, u := range accounts.external {
+			external = append(external, repo+": "+u)
+		}
+
+		if len(users) > 0 {
+			emitResults("github", "funding_user", repo, fmt.Sprintf("GitHub accounts sponsored through '%s'", repo), "github_funding_users.txt", users)
+		}
+		if len(external) > 0 {
+			emitResults("github", "funding_account", repo, fmt.Sprintf("Funding accounts of '%s'", repo), "github_funding_accounts.txt", external)
+		}
+	}
+
+	for _, repo := range repos {
+		if !strings.HasSuffix(repo, "/"+communityHealthRepo) {
+			report(repo, fundingFile)
+		}
+	}
+	for _, owner := range ownerNames {
+		report(owner+"/"+communityHealthRepo, "FUNDING.yml")
+	}
+
+	if len(logins) > 0 {
+		saveResults("suggested_seeds.txt", logins)
+	}
+}
+
+// readFundingFile fetches and parses a FUNDING.yml; a missing one has no
+// accounts.
+func readFundingFile(client githubContentsService, fullName, path string) fundingAccounts {
+	parts := strings.SplitN(fullName, "/", 2)
+	if len(parts) != 2 {
+		return fundingAccounts{}
+	}
+
+	verbosePrint("Looking for funding links in GitHub repository: %s\n", fullName)
+	file, _, _, err := client.GetContents(context.Background(), parts[0], parts[1], path, nil)
+	if err != nil {
+		if classifyError(err) != "not_found" {
+			recordSearchError("github", "funding file fetch", fullName, err)
+		}
+		return fundingAccounts{}
+	}
+	content, err := file.GetContent()
+	if err != nil {
+		recordSearchError("github", "funding file fetch", fullName, err)
+		return fundingAccounts{}
+	}
+	return parseFundingFile(content)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// fundingContents serves the files of fakeGitHubContents and answers 404
+// for the others, like the API.
+type fundingContents struct {
+	fakeGitHubContents
+}
+
+func (f fundingContents) GetContents(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error) {
+	if _, ok := f.fakeGitHubContents[owner+"/"+repo+":"+path]; !ok {
+		return nil, nil, nil, &github.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+	}
+	return f.fakeGitHubContents.GetContents(ctx, owner, repo, path, opts)
+}
+
+func TestParseFundingFile(t *testing.T) {
+	content := `github: [alice, "@bob"]
+patreon: acme
+open_collective: acme-labs
+ko_fi: ""
+custom: ["https://github.com/sponsors/carol", "acme.com/donate", "mailto:money@acme.com"]
+unknown_platform: x
+`
+	got := parseFundingFile(content)
+	if want := []string{"alice", "bob", "carol"}; !equalStrings(got.users, want) {
+		t.Errorf("users = %v, want %v", got.users, want)
+	}
+	want := []string{"https://acme.com/donate", "https://opencollective.com/acme-labs", "https://www.patreon.com/acme"}
+	if !equalStrings(got.external, want) {
+		t.Errorf("external = %v, want %v", got.external, want)
+	}
+
+	if got := parseFundingFile("github: [unterminated"); got.users != nil || got.external != nil {
+		t.Errorf("invalid YAML gave %+v", got)
+	}
+}
+
+func TestDiscoverFunding(t *testing.T) {
+	setupRun(t, config{})
+	recordResults("github", "repository", "acme", []string{"acme/api", "acme/cli"})
+	recordResults("github", "organization", "acme", []string{"Acme"})
+
+	contents := fundingContents{fakeGitHubContents{
+		"acme/api:.github/FUNDING.yml": "github: [acme, alice]\npatreon: acme\n",
+		"acme/.github:FUNDING.yml":     "github: bob\nopen_collective: acme\n",
+	}}
+	discoverFunding(contents)
+
+	if got, want := resultNames("github", "funding_user"), []string{"acme/api: alice", "acme/.github: bob"}; !equalStrings(got, want) {
+		t.Errorf("funding users = %v, want %v", got, want)
+	}
+	if got, want := resultNames("github", "funding_account"), []string{"acme/api: https://www.patreon.com/acme", "acme/.github: https://opencollective.com/acme"}; !equalStrings(got, want) {
+		t.Errorf("funding accounts = %v, want %v", got, want)
+	}
+	if got, want := readOutputLines(t, "suggested_seeds.txt"), []string{"alice", "bob"}; !equalStrings(got, want) {
+		t.Errorf("suggested_seeds.txt = %v, want %v", got, want)
+	}
+	if summary := searchErrorSummary(); len(summary) != 0 {
+		t.Errorf("errors = %+v, want none for missing funding files", summary)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/time/rate"
+)
+
+// keywordGenerator turns a keyword into the keywords searched for it.
+// Generators are chained: each one runs on every keyword the previous ones
+// produced.
+type keywordGenerator interface {
+	// generate returns the keywords word becomes. Generators adding
+	// variants return word along with them, while domain parsing replaces
+	// a hostname by its keywords.
+	generate(word string) []string
+}
+
+// namedGenerator is a generator -generators can list.
+type namedGenerator struct {
+	Name  string
+	build func(cfg config) keywordGenerator
+}
+
+// keywordGenerators lists the generators -generators accepts, in the order
+// they're listed in errors. New keyword expansions get an entry here rather
+// than a flag of their own.
+var keywordGenerators = []namedGenerator{
+	{"ct", func(cfg config) keywordGenerator { return ctGenerator{baseURL: cfg.ctURLFlag} }},
+	{"domain", func(cfg config) keywordGenerator {
+		return domainGenerator{prefixes: splitList(cfg.stripPrefixesFlag)}
+	}},
+	{"transliterate", func(config) keywordGenerator { return transliterateGenerator{} }},
+	{"permutations", func(config) keywordGenerator { return permutationGenerator{} }},
+	{"acronyms", func(config) keywordGenerator { return acronymGenerator{} }},
+	{"typos", func(config) keywordGenerator { return typoGenerator{limit: typoGeneratorLimit} }},
+}
+
+// typoGeneratorLimit is how many typosquat variants the typos generator
+// adds per keyword, the closest ones first: every variant is another
+// search.
+const typoGeneratorLimit = 5
+
+// permutationAffixes are the suffixes the permutations generator appends,
+// those organizations most often add to a name already taken.
+var permutationAffixes = []string{"-dev", "-hq", "-inc", "-io", "-labs", "-oss", "-team"}
+
+func lookupKeywordGenerator(name string) (namedGenerator, bool) {
+	for _, g := range keywordGenerators {
+		if g.Name == name {
+			return g, true
+		}
+	}
+	return namedGenerator{}, false
+}
+
+// supportedGenerators names every generator, for errors.
+func supportedGenerators() string {
+	names := make([]string, len(keywordGenerators))
+	for i, g := range keywordGenerators {
+		names[i] = g.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// generatorNames returns the generators of cfg in the order they run: those
+// of -generators, with domain first when -c asks for it and transliterate
+// last when -transliterate does, unless they're listed already.
+func generatorNames(cfg config) ([]string, error) {
+	var names []string
+	for _, name := range splitList(strings.ToLower(cfg.generatorsFlag)) {
+		if _, ok := lookupKeywordGenerator(name); !ok {
+			return nil, fmt.Errorf("unknown keyword generator %q in -generators (supported: %s)", name, supportedGenerators())
+		}
+		if containsString(names, name) {
+			return nil, fmt.Errorf("keyword generator %q is listed twice in -generators", name)
+		}
+		names = append(names, name)
+	}
+
+	if cfg.cleanFlag && !containsString(names, "domain") {
+		names = append([]string{"domain"}, names...)
+	}
+	if cfg.transliterateFlag && !containsString(names, "transliterate") {
+		names = append(names, "transliterate")
+	}
+	return names, nil
+}
+
+// generatorChain builds the generators of cfg, in the order they run.
+func generatorChain(cfg config) ([]keywordGenerator, error) {
+	names, err := generatorNames(cfg)
+	if err != nil {
+		return nil, err
+	}
+	chain := make([]keywordGenerator, len(names))
+	for i, name := range names {
+		g, _ := lookupKeywordGenerator(name)
+		chain[i] = g.build(cfg)
+	}
+	return chain, nil
+}
+
+// expandKeyword runs word through chain and returns the keywords it gives,
+// without duplicates. Keywords with spaces are then also searched without
+// them and with hyphens instead, whatever the chain, unless they're phrases.
+func expandKeyword(word string, chain []keywordGenerator) []string {
+	words := []string{normalizePhrase(word)}
+	for _, g := range chain {
+		var next []string
+		for _, w := range words {
+			if isPhrase(w) {
+				next = append(next, generatePhrase(g, w)...)
+			} else {
+				next = append(next, g.generate(w)...)
+			}
+		}
+		words = next
+	}
+
+	seen := make(map[string]bool)
+	var expanded []string
+	for _, w := range words {
+		variants := []string{w}
+		if !isPhrase(w) {
+			variants = append(variants, strings.Split(removeWhitespace(w), "\n")...)
+		}
+		for _, v := range variants {
+			if v != "" && !seen[v] {
+				seen[v] = true
+				expanded = append(expanded, v)
+			}
+		}
+	}
+	return expanded
+}
+
+// domainGenerator reduces URLs and hostnames to their keywords, like -c.
+type domainGenerator struct {
+	prefixes []string
+}
+
+func (g domainGenerator) generate(word string) []string {
+	cleaned := cleanWord(word)
+	if cleaned == "" {
+		verbosePrint("Skipping '%s': not a usable hostname\n", word)
+		return nil
+	}
+	return hostKeywords(cleaned, g.prefixes)
+}
+
+// transliterateGenerator adds the ASCII spellings of non-ASCII keywords,
+// like -transliterate.
+type transliterateGenerator struct{}
+
+func (transliterateGenerator) generate(word string) []string {
+	return append([]string{word}, asciiCandidates(word)...)
+}
+
+// permutationGenerator adds the spellings a name takes where it's already
+// taken: its parts joined by other separators, or in the other order, and
+// the name with a common suffix.
+type permutationGenerator struct{}
+
+func (permutationGenerator) generate(word string) []string {
+	variants := []string{word}
+	if strings.ContainsAny(word, " \t.") {
+		return variants
+	}
+
+	parts := strings.FieldsFunc(word, func(r rune) bool { return r == '-' || r == '_' })
+	if len(parts) > 1 {
+		variants = append(variants, strings.Join(parts, ""), strings.Join(parts, "-"), strings.Join(parts, "_"))
+		if len(parts) == 2 {
+			variants = append(variants, parts[1]+"-"+parts[0])
+		}
+	}
+	for _, affix := range permutationAffixes {
+		if !strings.HasSuffix(strings.ToLower(word), affix) {
+			variants = append(variants, word+affix)
+		}
+	}
+	return variants
+}
+
+// acronymGenerator adds the initials of keywords made of several words,
+// split at spaces, hyphens, underscores and camel case humps: "Acme Widget
+// Corp" and AcmeWidgetCorp give awc. Hostnames are left alone.
+type acronymGenerator struct{}
+
+func (acronymGenerator) generate(word string) []string {
+	if strings.Contains(word, ".") {
+		return []string{word}
+	}
+
+	var initials []rune
+	previous := ' '
+	for _, r := range word {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+		case !unicode.IsLetter(previous) && !unicode.IsDigit(previous),
+			unicode.IsUpper(r) && unicode.IsLower(previous):
+			initials = append(initials, unicode.ToLower(r))
+		}
+		previous = r
+	}
+	if len(initials) < 2 {
+		return []string{word}
+	}
+	return []string{word, string(initials)}
+}
+
+// typoGenerator adds the closest typosquat variants of keywords, those
+// checked by -impersonation.
+type typoGenerator struct {
+	limit int
+}
+
+func (g typoGenerator) generate(word string) []string {
+	variants := typoVariants(word)
+	if len(variants) > g.limit {
+		variants = variants[:g.limit]
+	}
+	return append([]string{word}, variants...)
+}
+
+// ctGenerator adds the hostnames the certificate transparency logs know
+// under keywords that are domains, like -domain does for the domains it's
+// given. List domain after it to reduce them to keywords.
+type ctGenerator struct {
+	baseURL string
+}
+
+var (
+	ctClientOnce sync.Once
+	ctClient     *http.Client
+)
+
+// sharedCTClient returns the client of every certificate transparency
+// query, rate limited as a whole.
+func sharedCTClient() *http.Client {
+	ctClientOnce.Do(func() {
+		ctClient = &http.Client{
+			Transport: newProviderTransport("ct", nil, newAdaptiveLimiter("ct", 1, rate.Every(time.Minute), func(*http.Request) string { return "search" })),
+			Timeout:   2 * time.Minute,
+		}
+	})
+	return ctClient
+}
+
+func (g ctGenerator) generate(word string) []string {
+	domain := cleanWord(word)
+	if domain == "" || domain != strings.ToLower(strings.TrimSpace(word)) || !strings.Contains(domain, ".") {
+		return []string{word}
+	}
+
+	hosts, err := fetchCTHostnames(sharedCTClient(), g.baseURL, domain)
+	if err != nil {
+		fmt.Printf("Error querying certificate transparency logs for %s: %s\n", domain, err)
+		os.Exit(1)
+	}
+	verbosePrint("Certificate transparency logs list %d hostnames under %s\n", len(hosts), domain)
+	return append([]string{word}, hosts...)
+}
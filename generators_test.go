@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGeneratorNames(t *testing.T) {
+	tests := []struct {
+		cfg  config
+		want string
+		err  string
+	}{
+		{config{}, "", ""},
+		{config{cleanFlag: true, transliterateFlag: true}, "domain,transliterate", ""},
+		{config{generatorsFlag: "ct, Acronyms", cleanFlag: true}, "domain,ct,acronyms", ""},
+		{config{generatorsFlag: "ct,domain,typos", cleanFlag: true, transliterateFlag: true}, "ct,domain,typos,transliterate", ""},
+		{config{generatorsFlag: "typos,anagrams"}, "", `unknown keyword generator "anagrams"`},
+		{config{generatorsFlag: "typos,typos"}, "", `"typos" is listed twice`},
+	}
+	for _, tt := range tests {
+		names, err := generatorNames(tt.cfg)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("generatorNames(%q) error = %v, want %q", tt.cfg.generatorsFlag, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || strings.Join(names, ",") != tt.want {
+			t.Errorf("generatorNames(%q) = %v, %v, want %s", tt.cfg.generatorsFlag, names, err, tt.want)
+		}
+	}
+}
+
+func TestKeywordGenerators(t *testing.T) {
+	tests := []struct {
+		generator keywordGenerator
+		word      string
+		want      []string
+	}{
+		{acronymGenerator{}, "Acme Widget Corp", []string{"Acme Widget Corp", "awc"}},
+		{acronymGenerator{}, "AcmeWidget-corp", []string{"AcmeWidget-corp", "awc"}},
+		{acronymGenerator{}, "acme", []string{"acme"}},
+		{permutationGenerator{}, "acme-corp", []string{"acme-corp", "acmecorp", "acme-corp", "acme_corp", "corp-acme", "acme-corp-dev", "acme-corp-hq", "acme-corp-inc", "acme-corp-io", "acme-corp-labs", "acme-corp-oss", "acme-corp-team"}},
+		{permutationGenerator{}, "acme-dev", []string{"acme-dev", "acmedev", "acme-dev", "acme_dev", "dev-acme", "acme-dev-hq", "acme-dev-inc", "acme-dev-io", "acme-dev-labs", "acme-dev-oss", "acme-dev-team"}},
+		{typoGenerator{limit: 2}, "acme", append([]string{"acme"}, typoVariants("acme")[:2]...)},
+		{domainGenerator{prefixes: []string{"www"}}, "https://www.acme.com/login", []string{"www.acme.com", "acme.com", "acme"}},
+		{domainGenerator{}, "10.0.0.1", nil},
+	}
+	for _, tt := range tests {
+		if got := tt.generator.generate(tt.word); !equalStrings(got, tt.want) {
+			t.Errorf("%T.generate(%q) = %v, want %v", tt.generator, tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestGeneratorChain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name_value": "widget-shop.acme.com"}]`))
+	}))
+	defer srv.Close()
+
+	// Each generator runs on what the previous ones produced: the hostnames
+	// found in the logs are reduced to keywords, then abbreviated.
+	setupRun(t, config{generatorsFlag: "ct,domain,acronyms", ctURLFlag: srv.URL, minWordLengthFlag: 2})
+	words := make(map[string]struct{})
+	processWord("acme.com", words, flags)
+
+	want := []string{"acme", "acme.com", "widget-shop", "widget-shop.acme.com", "ws"}
+	if got := sortedWords(words); !equalStrings(got, want) {
+		t.Errorf("words = %v, want %v", got, want)
+	}
+}
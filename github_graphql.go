@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// githubGraphQLURL is the GraphQL endpoint, a variable so tests can point it
+// at a fake server.
+var githubGraphQLURL = "https://api.github.com/graphql"
+
+const (
+	// graphQLKeywordsPerRequest bounds how many keywords share one request,
+	// keeping each query well inside GitHub's node and complexity limits.
+	graphQLKeywordsPerRequest = 10
+	graphQLMaxFirst           = 100
+)
+
+// graphQLSearch is one aliased search inside a batched GraphQL query.
+type graphQLSearch struct {
+	alias    string
+	keyword  string
+	category string
+	query    string
+	kind     string
+	fragment string
+	first    int
+}
+
+type graphQLResponse struct {
+	Data   map[string]graphQLConnection `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type graphQLConnection struct {
+	Nodes []graphQLNode `json:"nodes"`
+}
+
+type graphQLNode struct {
+	Login         string `json:"login"`
+	AvatarURL     string `json:"avatarUrl"`
+	Location      string `json:"location"`
+	Bio           string `json:"bio"`
+	NameWithOwner string `json:"nameWithOwner"`
+	URL           string `json:"url"`
+	Repository    struct {
+		NameWithOwner string `json:"nameWithOwner"`
+	} `json:"repository"`
+	Description      string `json:"description"`
+	HomepageURL      string `json:"homepageUrl"`
+	RepositoryTopics struct {
+		Nodes []struct {
+			Topic struct {
+				Name string `json:"name"`
+			} `json:"topic"`
+		} `json:"nodes"`
+	} `json:"repositoryTopics"`
+}
+
+// topics returns the topic names of a repository node.
+func (n graphQLNode) topics() []string {
+	topics := make([]string, len(n.RepositoryTopics.Nodes))
+	for i, node := range n.RepositoryTopics.Nodes {
+		topics[i] = node.Topic.Name
+	}
+	return topics
+}
+
+// name returns the identifier reported for a node: the login of users and
+// organizations, and the repository for repositories and discussions.
+func (n graphQLNode) name() string {
+	switch {
+	case n.Login != "":
+		return n.Login
+	case n.NameWithOwner != "":
+		return n.NameWithOwner
+	default:
+		return n.Repository.NameWithOwner
+	}
+}
+
+const discussionFragment = "... on Discussion { url repository { nameWithOwner } }"
+
+// repositoryFragment also fetches what -recurse extracts keywords from and
+// the homepage -urls saves.
+const repositoryFragment = "... on Repository { nameWithOwner description homepageUrl repositoryTopics(first: 20) { nodes { topic { name } } } }"
+
+// searchGitHubGraphQL runs the requested GitHub searches through the GraphQL
+// API, combining the org, user and repository searches of several keywords
+// into a single request to stretch the rate limit on large scans.
+func searchGitHubGraphQL(client *http.Client, words map[string]struct{}, cfg config) {
+	keywords := make([]string, 0, len(words))
+	for word := range words {
+		keywords = append(keywords, word)
+	}
+	sort.Strings(keywords)
+
+	for start := 0; start < len(keywords); start += graphQLKeywordsPerRequest {
+		end := start + graphQLKeywordsPerRequest
+		if end > len(keywords) {
+			end = len(keywords)
+		}
+
+		searches := buildGraphQLSearches(keywords[start:end], cfg)
+		if len(searches) == 0 {
+			return
+		}
+		verbosePrint("Searching GitHub (GraphQL) for %d words in one request\n", end-start)
+
+		resp, err := runGraphQLSearches(client, searches)
+		if err != nil {
+			recordSearchError("github", "GraphQL search", strings.Join(keywords[start:end], ", "), err)
+			continue
+		}
+
+		for _, search := range searches {
+			conn := resp.Data[search.alias]
+			if search.category == "user" && userFiltersActive() {
+				conn.Nodes = filterGraphQLUsers(conn.Nodes)
+			}
+			for _, node := range conn.Nodes {
+				recordAvatar("github", node.Login, node.AvatarURL)
+				if search.category == "repository" {
+					recordRepoTerms(node.NameWithOwner, node.topics(), node.Description)
+					recordHomepage("github", node.NameWithOwner, node.HomepageURL)
+				}
+			}
+			reportGraphQLResults(search, connectionNames(conn))
+		}
+	}
+}
+
+func filterGraphQLUsers(nodes []graphQLNode) []graphQLNode {
+	var kept []graphQLNode
+	for _, node := range nodes {
+		if userMatchesFilters(node.Location, node.Bio) {
+			kept = append(kept, node)
+		}
+	}
+	return kept
+}
+
+// connectionNames extracts the unique, non-empty names from a search
+// connection. Several discussions can live in the same repository, so
+// duplicates are dropped.
+func connectionNames(conn graphQLConnection) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, node := range conn.Nodes {
+		if name := node.name(); name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// searchGitHubDiscussions searches GitHub Discussions, which are only
+// searchable through GraphQL, and reports the repositories hosting matches.
+func searchGitHubDiscussions(client *http.Client, query string, maxResults int) {
+	search := graphQLSearch{
+		alias: "discussions", keyword: query, category: "discussion",
+		query: query, kind: "DISCUSSION", fragment: discussionFragment, first: pageSize(maxResults),
+	}
+
+	resp, err := runGraphQLSearches(client, []graphQLSearch{search})
+	if err != nil {
+		recordSearchError("github", "discussion search", query, err)
+		return
+	}
+
+	reportGraphQLResults(search, connectionNames(resp.Data[search.alias]))
+}
+
+func buildGraphQLSearches(keywords []string, cfg config) []graphQLSearch {
+	var searches []graphQLSearch
+
+	for i, keyword := range keywords {
+		cfg := configForWord(cfg, keyword)
+
+		if cfg.orgFlag {
+			searches = append(searches, graphQLSearch{
+				alias: fmt.Sprintf("k%d_org", i), keyword: keyword, category: "organization",
+				query: "type:org " + keyword, kind: "USER", fragment: "... on Organization { login avatarUrl }", first: pageSize(cfg.maxFlag),
+			})
+		}
+		if cfg.repoFlag {
+			searches = append(searches, graphQLSearch{
+				alias: fmt.Sprintf("k%d_repo", i), keyword: keyword, category: "repository",
+				query: keyword, kind: "REPOSITORY", fragment: repositoryFragment, first: pageSize(cfg.maxFlag),
+			})
+		}
+		if cfg.userFlag {
+			searches = append(searches, graphQLSearch{
+				alias: fmt.Sprintf("k%d_user", i), keyword: keyword, category: "user",
+				query: "type:user " + keyword + githubUserQualifiers(), kind: "USER", fragment: "... on User { login avatarUrl location bio }", first: pageSize(cfg.maxFlag),
+			})
+		}
+		if cfg.discussionsFlag {
+			searches = append(searches, graphQLSearch{
+				alias: fmt.Sprintf("k%d_discussion", i), keyword: keyword, category: "discussion",
+				query: keyword, kind: "DISCUSSION", fragment: discussionFragment, first: pageSize(cfg.maxFlag),
+			})
+		}
+	}
+
+	return searches
+}
+
+// runGraphQLSearches runs searches as one query. Each search passes its
+// query string and result count as variables named after its alias.
+func runGraphQLSearches(client *http.Client, searches []graphQLSearch) (*graphQLResponse, error) {
+	var params, fields []string
+	variables := make(map[string]interface{})
+
+	for _, search := range searches {
+		first := search.first
+		if first > graphQLMaxFirst {
+			first = graphQLMaxFirst
+		}
+
+		params = append(params, fmt.Sprintf("$%s: String!", search.alias), fmt.Sprintf("$%s_first: Int!", search.alias))
+		fields = append(fields, fmt.Sprintf("%s: search(query: $%s, type: %s, first: $%s_first) { nodes { %s } }",
+			search.alias, search.alias, search.kind, search.alias, search.fragment))
+		variables[search.alias] = search.query
+		variables[search.alias+"_first"] = first
+	}
+
+	query := fmt.Sprintf("query(%s) {\n%s\n}", strings.Join(params, ", "), strings.Join(fields, "\n"))
+	body, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, githubGraphQLURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: respBody}
+	}
+
+	var result graphQLResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Errors) > 0 && len(result.Data) == 0 {
+		messages := make([]string, len(result.Errors))
+		for i, e := range result.Errors {
+			messages[i] = e.Message
+		}
+		return nil, errors.New(strings.Join(messages, "; "))
+	}
+
+	return &result, nil
+}
+
+// graphQLOutputs maps each search category to its output header and file.
+var graphQLOutputs = map[string]struct{ header, filename string }{
+	"organization": {"GitHub organizations matching '%s'", "github_organizations.txt"},
+	"repository":   {"GitHub repositories matching '%s'", "github_repositories.txt"},
+	"user":         {"GitHub users matching '%s'", "github_users.txt"},
+	"discussion":   {"GitHub repositories with discussions matching '%s'", "github_discussions.txt"},
+}
+
+func reportGraphQLResults(search graphQLSearch, names []string) {
+	out := graphQLOutputs[search.category]
+	emitResults("github", search.category, search.keyword, fmt.Sprintf(out.header, search.keyword), out.filename, names)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// gitlabAnonymous is set when no GITLAB_ACCESS_TOKEN is available and
+// GitLab is searched without authentication. Only public projects, groups
+// and users are visible then, and the search API, behind -gl-search and -w,
+// isn't available at all.
+var gitlabAnonymous bool
+
+// anonymousTransport drops the empty token header go-gitlab sends when it
+// has no token, so GitLab serves requests as unauthenticated rather than
+// rejecting them.
+type anonymousTransport struct {
+	transport http.RoundTripper
+}
+
+func (t anonymousTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if _, ok := req.Header["Private-Token"]; ok {
+		req = req.Clone(req.Context())
+		req.Header.Del("Private-Token")
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// printGitLabMode tells on stderr whether GitLab is searched with a token,
+// since a missing one quietly narrows what is found.
+func printGitLabMode() {
+	if gitlabAnonymous {
+		fmt.Fprintln(os.Stderr, "GitLab: GITLAB_ACCESS_TOKEN is not set and dorky login wasn't run, searching public projects, groups and users only (-gl-search and -w need a token)")
+		return
+	}
+	if os.Getenv("GITLAB_ACCESS_TOKEN") == "" {
+		fmt.Fprintln(os.Stderr, "GitLab: authenticated with the token of dorky login")
+		return
+	}
+	fmt.Fprintln(os.Stderr, "GitLab: authenticated with GITLAB_ACCESS_TOKEN")
+}
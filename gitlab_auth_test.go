@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestAnonymousGitLabSearch(t *testing.T) {
+	cfg := config{orgFlag: true, repoFlag: true, glSearch: "blobs", maxFlag: 5, checkAvailabilityFlag: true}
+	setupRun(t, cfg)
+
+	old := gitlabAnonymous
+	gitlabAnonymous = true
+	t.Cleanup(func() { gitlabAnonymous = old })
+
+	routes := map[string]interface{}{
+		"/api/v4/groups":      []map[string]interface{}{{"id": 1, "full_path": "acme"}},
+		"/api/v4/groups/acme": map[string]interface{}{"id": 1, "full_path": "acme"},
+		"/api/v4/users":       []map[string]interface{}{},
+		"/api/v4/projects":    []map[string]interface{}{{"id": 2, "path_with_namespace": "acme/api"}},
+	}
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if _, ok := r.Header["Private-Token"]; ok {
+			t.Errorf("%s sent a token header", r.URL.Path)
+		}
+
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	httpClient := &http.Client{Transport: anonymousTransport{transport: srv.Client().Transport}}
+	client, err := gitlab.NewClient("", gitlab.WithBaseURL(srv.URL), gitlab.WithHTTPClient(httpClient))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	searchGitLab(client, "acme", cfg)
+	if got, want := resultNames("gitlab", "group"), []string{"acme"}; !equalStrings(got, want) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+	if got, want := resultNames("gitlab", "project"), []string{"acme/api"}; !equalStrings(got, want) {
+		t.Errorf("projects = %v, want %v", got, want)
+	}
+
+	taken, err := checkGitLabNamespace(client, "acme", cfg)
+	if err != nil || taken.Status != statusThirdParty || taken.Kind != "group" {
+		t.Errorf("acme = %+v, %v, want a third-party group", taken, err)
+	}
+	free, err := checkGitLabNamespace(client, "acme-labs", cfg)
+	if err != nil || free.Status != statusAvailable {
+		t.Errorf("acme-labs = %+v, %v, want available", free, err)
+	}
+
+	for _, path := range paths {
+		if path == "/api/v4/search" || path == "/api/v4/namespaces/acme/exists" || path == "/api/v4/namespaces/acme-labs/exists" {
+			t.Errorf("anonymous client requested %s, which needs a token", path)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// gitLabSearchScopes are the scopes of GitLab's /search API that -gl-search
+// can enable, mapped to the output file each one writes.
+var gitLabSearchScopes = map[string]string{
+	"projects":   "gitlab_search_projects.txt",
+	"blobs":      "gitlab_blobs.txt",
+	"commits":    "gitlab_commits.txt",
+	"milestones": "gitlab_milestones.txt",
+	"wiki_blobs": "gitlab_wiki_blobs.txt",
+}
+
+func parseGitLabScopes(value string) ([]string, error) {
+	var scopes []string
+	seen := make(map[string]bool)
+
+	for _, scope := range strings.Split(value, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		if _, ok := gitLabSearchScopes[scope]; !ok {
+			return nil, fmt.Errorf("unknown GitLab search scope %q (supported: projects, blobs, commits, milestones, wiki_blobs)", scope)
+		}
+		seen[scope] = true
+		scopes = append(scopes, scope)
+	}
+
+	return scopes, nil
+}
+
+// searchGitLabScopes queries GitLab's global /search API for each requested
+// scope, which unlike the list endpoints also covers code and commits.
+func searchGitLabScopes(client *gitlab.Client, query string, scopes []string, maxResults int) {
+	opt := &gitlab.SearchOptions{ListOptions: gitlab.ListOptions{PerPage: pageSize(maxResults)}}
+	projectPaths := make(map[int]string)
+
+	for _, scope := range scopes {
+		var names []string
+		var err error
+
+		switch scope {
+		case "projects":
+			var projects []*gitlab.Project
+			projects, _, err = client.Search.Projects(query, opt)
+			for _, project := range projects {
+				names = append(names, project.PathWithNamespace)
+				recordGitLabProject(project)
+			}
+		case "blobs":
+			var blobs []*gitlab.Blob
+			blobs, _, err = client.Search.Blobs(query, opt)
+			for _, blob := range blobs {
+				names = append(names, fmt.Sprintf("%s:%s", gitLabProjectPath(client, blob.ProjectID, projectPaths), blob.Filename))
+			}
+		case "commits":
+			var commits []*gitlab.Commit
+			commits, _, err = client.Search.Commits(query, opt)
+			for _, commit := range commits {
+				names = append(names, fmt.Sprintf("%s %s", commit.ShortID, commit.Title))
+			}
+		case "milestones":
+			var milestones []*gitlab.Milestone
+			milestones, _, err = client.Search.Milestones(query, opt)
+			for _, milestone := range milestones {
+				names = append(names, fmt.Sprintf("%s:%s", gitLabProjectPath(client, milestone.ProjectID, projectPaths), milestone.Title))
+			}
+		case "wiki_blobs":
+			var wikis []*gitlab.Wiki
+			wikis, _, err = client.Search.WikiBlobs(query, opt)
+			for _, wiki := range wikis {
+				names = append(names, wiki.Slug)
+			}
+		}
+
+		if err != nil {
+			recordSearchError("gitlab", scope+" search", query, err)
+			continue
+		}
+
+		emitResults("gitlab", scope, query, fmt.Sprintf("GitLab %s matching '%s'", strings.Replace(scope, "_", " ", -1), query), gitLabSearchScopes[scope], names)
+	}
+}
+
+// gitLabProjectPath resolves a project ID to its full path, caching lookups
+// for the duration of one query.
+func gitLabProjectPath(client *gitlab.Client, id int, cache map[int]string) string {
+	if id == 0 {
+		return "unknown"
+	}
+	if path, ok := cache[id]; ok {
+		return path
+	}
+
+	path := fmt.Sprint(id)
+	if project, _, err := client.Projects.GetProject(id, nil); err == nil {
+		path = project.PathWithNamespace
+	}
+	cache[id] = path
+
+	return path
+}
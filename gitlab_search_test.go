@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestParseGitLabScopes(t *testing.T) {
+	scopes, err := parseGitLabScopes(" blobs,commits,,blobs ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"blobs", "commits"}; !equalStrings(scopes, want) {
+		t.Errorf("scopes = %v, want %v", scopes, want)
+	}
+
+	if _, err := parseGitLabScopes("issues"); err == nil {
+		t.Error("unknown scope accepted")
+	}
+}
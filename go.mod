@@ -4,7 +4,11 @@ go 1.16
 
 require (
 	github.com/google/go-github/v38 v38.0.0
+	github.com/lib/pq v1.10.9
 	github.com/xanzy/go-gitlab v0.50.2
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
 	golang.org/x/oauth2 v0.7.0
+	golang.org/x/text v0.9.0
 	golang.org/x/time v0.3.0
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files of the output format tests")
+
+// goldenTime stands in for the clock in golden output.
+var goldenTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// goldenBatches is the canned result set every output format is tested
+// with: several platforms and categories, tags from keywords and rules,
+// GitLab project details, and names that need quoting or escaping.
+func goldenBatches() []resultBatch {
+	return []resultBatch{
+		{Platform: "github", Category: "organization", Query: "acme", Header: "GitHub organizations matching 'acme'", Results: []string{"acme-corp", "acme,labs"}},
+		{Platform: "github", Category: "repository", Query: "acme", Header: "GitHub repositories matching 'acme'", Results: []string{"acme-corp/config-backup", `acme-corp/"quoted"`}},
+		{Platform: "gitlab", Category: "project", Query: "acme corp", Header: "GitLab projects matching 'acme corp'", Results: []string{"acme/db-dump"}},
+		{Platform: "github", Category: "user", Query: "ácme", Header: "GitHub users matching 'ácme'", Results: []string{"jürgen"}},
+	}
+}
+
+// setupGolden starts a run with cfg over the canned result set's tags,
+// rules and project details.
+func setupGolden(t *testing.T, cfg config) {
+	t.Helper()
+
+	setupRun(t, cfg)
+	runID, runStarted = "0123456789abcdef", goldenTime
+	tagWord("acme", []string{"brand"})
+	rules, err := loadRulesFile(writeRulesFile(t, "rules:\n  - tag: high-risk\n    name: backup|dump\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	riskRules = rules
+	activity := goldenTime.Add(-48 * time.Hour)
+	projectInfo["gitlab:acme/db-dump"] = projectDetails{Visibility: "public", IssuesEnabled: true, LastActivityAt: &activity}
+}
+
+// recordGolden records the canned result set as a run would.
+func recordGolden() {
+	for _, batch := range goldenBatches() {
+		recordResults(batch.Platform, batch.Category, batch.Query, batch.Results)
+	}
+	for i := range collectedResults {
+		collectedResults[i].Timestamp = goldenTime
+	}
+}
+
+// checkGolden compares got with testdata/golden/name, or rewrites the file
+// with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	filename := filepath.Join("testdata", "golden", name)
+	if *updateGolden {
+		if err := ioutil.WriteFile(filename, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("%s (run go test -run Golden -update to create it)", err)
+	}
+	// Checkouts may convert the golden files to CRLF line endings.
+	want = bytes.ReplaceAll(want, []byte("\r\n"), []byte("\n"))
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s changed; if that's deliberate, run go test -run Golden -update\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestGoldenConsoleFormats(t *testing.T) {
+	tests := map[string]config{
+		"text.golden":          {formatFlag: "text", allFlag: true},
+		"text_filtered.golden": {formatFlag: "text"},
+		"simple.golden":        {simpleFlag: true},
+		"json.golden":          {formatFlag: "json"},
+		"csv.golden":           {formatFlag: "csv"},
+		"template.golden":      {formatFlag: "template", templateFlag: "{{.Platform}}/{{.Category}}: {{.Name}}{{range .Tags}} #{{.}}{{end}}"},
+	}
+
+	for name, cfg := range tests {
+		setupGolden(t, cfg)
+
+		var out bytes.Buffer
+		for _, batch := range goldenBatches() {
+			printResults(&out, batch)
+		}
+		checkGolden(t, name, out.Bytes())
+	}
+}
+
+func TestGoldenJSONReport(t *testing.T) {
+	setupGolden(t, config{})
+	recordGolden()
+	recordSearchError("gitlab", "group search", "acme corp", &httpStatusError{StatusCode: 403, Status: "403 Forbidden"})
+
+	filename := filepath.Join(t.TempDir(), "report.json")
+	prov := provenance{RunID: runID, ToolVersion: "v1.0.0", StartedAt: goldenTime, FinishedAt: goldenTime.Add(time.Minute), Config: map[string]string{"format": "text", "max": "10"}}
+	r := report{provenance: prov, RateLimits: []rateLimitUsage{}, Errors: searchErrorSummary(), Results: collectedResults}
+	if err := writeJSONReport(filename, r); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "report.json", readGoldenFile(t, filename))
+}
+
+func TestGoldenSARIFReport(t *testing.T) {
+	setupGolden(t, config{})
+	recordGolden()
+	oldVersion := version
+	version = "v1.0.0"
+	t.Cleanup(func() { version = oldVersion })
+
+	filename := filepath.Join(t.TempDir(), "report.sarif")
+	if err := writeSARIFReport(filename, collectedResults); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "report.sarif", readGoldenFile(t, filename))
+}
+
+func TestGoldenNDJSON(t *testing.T) {
+	setupGolden(t, config{})
+	recordGolden()
+
+	filename := filepath.Join(t.TempDir(), "results.ndjson")
+	if err := openNDJSON(filename); err != nil {
+		t.Fatal(err)
+	}
+	stateMu.Lock()
+	for _, r := range collectedResults {
+		streamResult(r)
+	}
+	stateMu.Unlock()
+	closeNDJSON()
+
+	checkGolden(t, "results.ndjson", readGoldenFile(t, filename))
+}
+
+func readGoldenFile(t *testing.T, filename string) []byte {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// setupRun gives a test a fresh run with the given flags and no keyword
+// tags, writing output files into a temporary directory, and restores the
+// globals afterwards.
+func setupRun(t *testing.T, cfg config) {
+	t.Helper()
+
+	oldFlags, oldDir := flags, outputDir
+	oldBehaviors, oldTags, oldRules := tagBehaviors, keywordTags, riskRules
+	flags = cfg
+	outputDir = t.TempDir()
+	tagBehaviors, keywordTags, riskRules = make(map[string]tagBehavior), make(map[string][]string), nil
+	startRun()
+
+	t.Cleanup(func() {
+		flags, outputDir = oldFlags, oldDir
+		tagBehaviors, keywordTags, riskRules = oldBehaviors, oldTags, oldRules
+		startRun()
+	})
+}
+
+// setenv sets an environment variable for the duration of a test.
+func setenv(t *testing.T, key, value string) {
+	t.Helper()
+
+	old, had := os.LookupEnv(key)
+	os.Setenv(key, value)
+
+	t.Cleanup(func() {
+		if had {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+// resultNames returns the names recorded for a platform and category.
+func resultNames(platform, category string) []string {
+	var names []string
+	for _, r := range collectedResults {
+		if r.Platform == platform && r.Category == category {
+			names = append(names, r.Name)
+		}
+	}
+	return names
+}
+
+func readOutputLines(t *testing.T, filename string) []string {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(filepath.Join(outputDir, filename))
+	if err != nil {
+		t.Fatalf("reading %s: %s", filename, err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedCopy(list []string) []string {
+	out := append([]string(nil), list...)
+	sort.Strings(out)
+	return out
+}
+
+// fakeGitHubSearch is an in-memory githubSearchService keyed by the exact
+// query string it expects.
+type fakeGitHubSearch struct {
+	users map[string][]string
+	repos map[string][]string
+	code  map[string][]string
+	// homepages holds the homepage of repositories, by name.
+	homepages map[string]string
+	err       error
+	queries   []string
+}
+
+func (f *fakeGitHubSearch) Users(ctx context.Context, query string, opts *github.SearchOptions) (*github.UsersSearchResult, *github.Response, error) {
+	f.queries = append(f.queries, query)
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+
+	result := &github.UsersSearchResult{}
+	for _, login := range f.users[query] {
+		result.Users = append(result.Users, &github.User{Login: github.String(login)})
+	}
+	return result, nil, nil
+}
+
+func (f *fakeGitHubSearch) Repositories(ctx context.Context, query string, opts *github.SearchOptions) (*github.RepositoriesSearchResult, *github.Response, error) {
+	f.queries = append(f.queries, query)
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+
+	result := &github.RepositoriesSearchResult{}
+	for _, name := range f.repos[query] {
+		repo := &github.Repository{FullName: github.String(name)}
+		if homepage, ok := f.homepages[name]; ok {
+			repo.Homepage = github.String(homepage)
+		}
+		result.Repositories = append(result.Repositories, repo)
+	}
+	return result, nil, nil
+}
+
+func (f *fakeGitHubSearch) Code(ctx context.Context, query string, opts *github.SearchOptions) (*github.CodeSearchResult, *github.Response, error) {
+	f.queries = append(f.queries, query)
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+
+	result := &github.CodeSearchResult{}
+	for _, hit := range f.code[query] {
+		parts := strings.SplitN(hit, ":", 2)
+		result.CodeResults = append(result.CodeResults, &github.CodeResult{
+			Path:       github.String(parts[1]),
+			Repository: &github.Repository{FullName: github.String(parts[0])},
+		})
+	}
+	return result, nil, nil
+}
+
+// fakeGitHubUsers serves user profiles by login.
+type fakeGitHubUsers map[string]*github.User
+
+func (f fakeGitHubUsers) Get(ctx context.Context, login string) (*github.User, *github.Response, error) {
+	if user, ok := f[login]; ok {
+		return user, nil, nil
+	}
+	return nil, nil, errors.New("not found")
+}
+
+// fakeGitLabProjects is an in-memory gitlabProjectsService.
+type fakeGitLabProjects struct {
+	projects map[string][]string
+	err      error
+
+	// details optionally supplies the full project of a path.
+	details map[string]*gitlab.Project
+}
+
+func (f *fakeGitLabProjects) ListProjects(opt *gitlab.ListProjectsOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.Project, *gitlab.Response, error) {
+	if f.err != nil {
+		return nil, nil, f.err
+	}
+
+	var projects []*gitlab.Project
+	for _, path := range f.projects[*opt.Search] {
+		if project, ok := f.details[path]; ok {
+			projects = append(projects, project)
+			continue
+		}
+		projects = append(projects, &gitlab.Project{PathWithNamespace: path})
+	}
+	return projects, nil, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// homepageURLs maps "platform:name" of matched repositories and accounts to
+// the homepage they list, as far as the search responses tell. Guarded by
+// stateMu.
+var homepageURLs = make(map[string]string)
+
+func recordHomepage(platform, name, homepage string) {
+	if homepage = strings.TrimSpace(homepage); homepage != "" {
+		stateMu.Lock()
+		homepageURLs[platform+":"+normalizeName(name)] = homepage
+		stateMu.Unlock()
+	}
+}
+
+// homepageCategories are the result categories whose homepage is harvested.
+var homepageCategories = map[string]bool{"organization": true, "user": true, "repository": true}
+
+// harvestHomepages reports the homepages of the repositories, organizations
+// and users found so far to urls.txt, one URL per line, for web tooling such
+// as httpx or nuclei to probe and screenshot. Repository homepages come with
+// the search results; the websites of GitHub accounts and GitLab users need
+// their profiles fetched.
+func harvestHomepages(ghUsers githubUsersService, glUsers gitlabUsersService) {
+	var accounts []result
+	seen := make(map[string]bool)
+	for _, r := range collectedResults {
+		key := r.Platform + ":" + normalizeName(r.Name)
+		if !homepageCategories[r.Category] || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if _, ok := homepageURLs[key]; !ok && r.Category != "repository" {
+			accounts = append(accounts, r)
+		}
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Platform+":"+accounts[i].Name < accounts[j].Platform+":"+accounts[j].Name
+	})
+
+	for _, account := range accounts {
+		switch {
+		case account.Platform == "github" && ghUsers != nil:
+			verbosePrint("Fetching the website of GitHub account: %s\n", account.Name)
+			user, _, err := ghUsers.Get(context.Background(), account.Name)
+			if err != nil {
+				recordSearchError("github", "website lookup", account.Name, err)
+				continue
+			}
+			recordHomepage("github", account.Name, user.GetBlog())
+		case account.Platform == "gitlab" && glUsers != nil:
+			verbosePrint("Fetching the website of GitLab user: %s\n", account.Name)
+			website, err := gitlabUserWebsite(glUsers, account.Name)
+			if err != nil {
+				recordSearchError("gitlab", "website lookup", account.Name, err)
+				continue
+			}
+			recordHomepage("gitlab", account.Name, website)
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var urls []string
+	for _, key := range keys {
+		raw, ok := homepageURLs[key]
+		if !ok {
+			continue
+		}
+		if u := normalizeHomepage(raw); u != "" {
+			verbosePrint("Homepage of %s: %s\n", key, u)
+			urls = append(urls, u)
+		}
+	}
+
+	emitResults("all", "homepage", "", "Homepages of discovered repositories and accounts", "urls.txt", urls)
+}
+
+// gitlabUserWebsite returns the website on a GitLab user's profile, which
+// only the single user endpoint includes.
+func gitlabUserWebsite(users gitlabUsersService, username string) (string, error) {
+	matches, _, err := users.ListUsers(&gitlab.ListUsersOptions{Username: gitlab.String(username)})
+	if err != nil || len(matches) == 0 {
+		return "", err
+	}
+
+	user, _, err := users.GetUser(matches[0].ID, gitlab.GetUsersOptions{})
+	if err != nil {
+		return "", err
+	}
+	return user.WebsiteURL, nil
+}
+
+// normalizeHomepage turns a homepage as people type it, often without a
+// scheme (acme.com), into an absolute http(s) URL, or returns an empty
+// string for anything else, such as mailto: links or bare words.
+func normalizeHomepage(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.ContainsAny(raw, " \t") {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + strings.TrimPrefix(raw, "//")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.User != nil || !strings.Contains(u.Hostname(), ".") {
+		return ""
+	}
+	u.Host = strings.ToLower(u.Host)
+	return u.String()
+}
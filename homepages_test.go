@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// fakeGitLabUsers is an in-memory gitlabUsersService serving the websites
+// of users by username.
+type fakeGitLabUsers map[string]string
+
+func (f fakeGitLabUsers) ListUsers(opt *gitlab.ListUsersOptions, options ...gitlab.RequestOptionFunc) ([]*gitlab.User, *gitlab.Response, error) {
+	for i, username := range f.usernames() {
+		if username == *opt.Username {
+			return []*gitlab.User{{ID: i + 1, Username: username}}, nil, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func (f fakeGitLabUsers) GetUser(id int, opt gitlab.GetUsersOptions, options ...gitlab.RequestOptionFunc) (*gitlab.User, *gitlab.Response, error) {
+	usernames := f.usernames()
+	if id < 1 || id > len(usernames) {
+		return nil, nil, errors.New("not found")
+	}
+	username := usernames[id-1]
+	return &gitlab.User{ID: id, Username: username, WebsiteURL: f[username]}, nil, nil
+}
+
+// usernames lists the users in a stable order, their IDs counting from 1.
+func (f fakeGitLabUsers) usernames() []string {
+	var usernames []string
+	for username := range f {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+func TestHarvestHomepages(t *testing.T) {
+	setupRun(t, config{})
+
+	recordHomepage("github", "acme/api", "https://api.acme.com/docs")
+	recordHomepage("github", "acme/site", "acme.com")
+	recordHomepage("github", "someone/unreported", "https://unreported.example.com")
+	recordResults("github", "repository", "acme", []string{"acme/api", "acme/site", "acme/cli"})
+	recordResults("github", "organization", "acme", []string{"Acme"})
+	recordResults("github", "user", "acme", []string{"acme-bot", "ghost"})
+	recordResults("gitlab", "user", "acme", []string{"acme-ops", "acme-dev"})
+	recordResults("gitlab", "group", "acme", []string{"acme"})
+
+	ghUsers := fakeGitHubUsers{
+		"Acme":     &github.User{Login: github.String("Acme"), Blog: github.String("www.Acme.com")},
+		"acme-bot": &github.User{Login: github.String("acme-bot"), Blog: github.String("mailto:bot@acme.com")},
+	}
+	glUsers := fakeGitLabUsers{"acme-ops": "https://status.acme.io", "acme-dev": ""}
+
+	harvestHomepages(ghUsers, glUsers)
+
+	want := []string{"https://www.acme.com", "https://api.acme.com/docs", "https://acme.com", "https://status.acme.io"}
+	if got := resultNames("all", "homepage"); !equalStrings(got, want) {
+		t.Errorf("homepages = %v, want %v", got, want)
+	}
+	if got := readOutputLines(t, "urls.txt"); !equalStrings(got, want) {
+		t.Errorf("urls.txt = %v, want %v", got, want)
+	}
+	if summary := searchErrorSummary(); len(summary) != 1 || summary[0].Query != "ghost" {
+		t.Errorf("errors = %+v, want the missing GitHub account only", summary)
+	}
+}
+
+func TestNormalizeHomepage(t *testing.T) {
+	tests := []struct{ raw, want string }{
+		{"https://acme.com", "https://acme.com"},
+		{"  http://Blog.Acme.com/path?q=1 ", "http://blog.acme.com/path?q=1"},
+		{"acme.com", "https://acme.com"},
+		{"//acme.com/about", "https://acme.com/about"},
+		{"HTTPS://ACME.COM", "https://acme.com"},
+		{"mailto:hello@acme.com", ""},
+		{"hello@acme.com", ""},
+		{"ftp://acme.com", ""},
+		{"localhost", ""},
+		{"acme corp", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeHomepage(tt.raw); got != tt.want {
+			t.Errorf("normalizeHomepage(%q) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import "time"
+
+// runHooks are callbacks following a scan as it happens, for code embedding
+// dorky that drives its own UI or telemetry instead of parsing the console
+// output. Any of them may be nil. dorky is still built as a single main
+// package, so the hooks are the seam a library package will expose rather
+// than something importable today.
+//
+// Hooks run on the goroutine that produced the event, several at once when
+// keywords are searched concurrently. OnResult, OnError and OnProgress are
+// called with the run state locked, so they must return quickly and not
+// call back into dorky.
+type runHooks struct {
+	// OnResult receives every result as it is recorded, after
+	// deduplication and -max-total.
+	OnResult func(result)
+
+	// OnError receives every failed operation, with the count of
+	// identical failures so far in the run.
+	OnError func(searchError)
+
+	// OnRateLimit receives every time a rate limit held a request back.
+	OnRateLimit func(rateLimitEvent)
+
+	// OnProgress receives every keyword searched.
+	OnProgress func(scanProgress)
+}
+
+// rateLimitEvent is a request held back by a rate limit: either paced by
+// the client-side limiter for Waited, or throttled by the API and about to
+// be retried.
+type rateLimitEvent struct {
+	Platform  string
+	Waited    time.Duration
+	Throttled bool
+}
+
+// scanProgress is a keyword done searching: Done of the Total keywords of
+// the current pass have been searched. Recursion passes start over with
+// their own total.
+type scanProgress struct {
+	Keyword string
+	Done    int
+	Total   int
+}
+
+// hooks are the callbacks of the process. They're kept across runs, so a
+// monitor embedding dorky sets them once.
+var hooks runHooks
+
+func (h runHooks) result(r result) {
+	if h.OnResult != nil {
+		h.OnResult(r)
+	}
+}
+
+func (h runHooks) error(e searchError) {
+	if h.OnError != nil {
+		h.OnError(e)
+	}
+}
+
+func (h runHooks) rateLimit(e rateLimitEvent) {
+	if h.OnRateLimit != nil {
+		h.OnRateLimit(e)
+	}
+}
+
+func (h runHooks) progress(p scanProgress) {
+	if h.OnProgress != nil {
+		h.OnProgress(p)
+	}
+}
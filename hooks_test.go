@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestRunHooks(t *testing.T) {
+	setupRun(t, config{})
+
+	var results []string
+	var errs []searchError
+	var limits []rateLimitEvent
+	var progress []scanProgress
+	hooks = runHooks{
+		OnResult:    func(r result) { results = append(results, r.Name) },
+		OnError:     func(e searchError) { errs = append(errs, e) },
+		OnRateLimit: func(e rateLimitEvent) { limits = append(limits, e) },
+		OnProgress:  func(p scanProgress) { progress = append(progress, p) },
+	}
+	t.Cleanup(func() { hooks = runHooks{} })
+
+	words := []string{"acme", "globex"}
+	streams := startKeywordStreams(words, ioutil.Discard)
+	searchKeywords(words, 1, streams, func(word string) {
+		emitResults("github", "organization", word, "Organizations", "github_organizations.txt", []string{word, "acme"})
+		recordSearchError("gitlab", "group search", word, errors.New("boom"))
+	})
+	streams.stop()
+	recordLimiterWait("github", time.Second)
+	recordLimiterWait("github", 0)
+
+	if want := []string{"acme", "globex"}; !equalStrings(results, want) {
+		t.Errorf("results = %v, want %v", results, want)
+	}
+	if len(errs) != 2 || errs[0].Query != "acme" || errs[1].Message != "boom" || errs[1].Class != "other" {
+		t.Errorf("errors = %+v", errs)
+	}
+	if len(limits) != 1 || limits[0] != (rateLimitEvent{Platform: "github", Waited: time.Second}) {
+		t.Errorf("rate limits = %+v", limits)
+	}
+	want := []scanProgress{{"acme", 1, 2}, {"globex", 2, 2}}
+	if len(progress) != len(want) || progress[0] != want[0] || progress[1] != want[1] {
+		t.Errorf("progress = %+v, want %+v", progress, want)
+	}
+}
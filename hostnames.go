@@ -0,0 +1,79 @@
+package main
+
+import "strings"
+
+// secondLevelSuffixes are labels that, directly under a two-letter country
+// code, form a public suffix such as co.uk or com.au.
+var secondLevelSuffixes = map[string]bool{
+	"co": true, "com": true, "org": true, "net": true, "gov": true, "ac": true, "edu": true,
+}
+
+// hostKeywords expands a cleaned hostname into keyword candidates: the full
+// hostname, the hostname with generic prefixes such as "www" or "api"
+// stripped, the organisation's own label, and every subdomain label that
+// isn't generic. api.acme.com yields api.acme.com, acme.com and acme, and
+// jenkins.build.acme.com also yields jenkins and build, since internal
+// service names make excellent search seeds.
+func hostKeywords(host string, prefixes []string) []string {
+	keywords := []string{host}
+	if !strings.Contains(host, ".") {
+		return keywords
+	}
+
+	stripped := stripHostPrefixes(host, prefixes)
+	if stripped != host {
+		keywords = append(keywords, stripped)
+	}
+
+	labels := strings.Split(stripped, ".")
+	primary := primaryLabelIndex(labels)
+	if primary < 0 {
+		return keywords
+	}
+	keywords = append(keywords, labels[primary])
+
+	for _, label := range labels[:primary] {
+		if label != "" && !isGenericPrefix(label, prefixes) {
+			keywords = append(keywords, label)
+		}
+	}
+
+	return keywords
+}
+
+func isGenericPrefix(label string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.EqualFold(label, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripHostPrefixes removes leading generic labels, repeatedly, as long as
+// at least a name and a TLD remain (mail.app.acme.com becomes acme.com).
+func stripHostPrefixes(host string, prefixes []string) string {
+	labels := strings.Split(host, ".")
+
+	for len(labels) > 2 && isGenericPrefix(labels[0], prefixes) {
+		labels = labels[1:]
+	}
+
+	return strings.Join(labels, ".")
+}
+
+// primaryLabelIndex returns the index of the label just below the public
+// suffix, using a small heuristic for two-level country suffixes like
+// co.uk, or -1 if the hostname has no such label.
+func primaryLabelIndex(labels []string) int {
+	n := len(labels)
+
+	switch {
+	case n < 2:
+		return -1
+	case n >= 3 && len(labels[n-1]) == 2 && secondLevelSuffixes[labels[n-2]]:
+		return n - 3
+	default:
+		return n - 2
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestHostKeywords(t *testing.T) {
+	prefixes := []string{"www", "app", "api", "portal", "mail"}
+	tests := []struct {
+		host string
+		want []string
+	}{
+		{"acme", []string{"acme"}},
+		{"acme.com", []string{"acme.com", "acme"}},
+		{"www.acme.com", []string{"www.acme.com", "acme.com", "acme"}},
+		{"api.acme.com", []string{"api.acme.com", "acme.com", "acme"}},
+		{"jenkins.build.acme.com", []string{"jenkins.build.acme.com", "acme", "jenkins", "build"}},
+		{"mail.internal.api.acme.co.uk", []string{"mail.internal.api.acme.co.uk", "internal.api.acme.co.uk", "acme", "internal"}},
+	}
+
+	for _, tt := range tests {
+		if got := hostKeywords(tt.host, prefixes); !equalStrings(got, tt.want) {
+			t.Errorf("hostKeywords(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestStripHostPrefixesKeepsRegistrableDomain(t *testing.T) {
+	if got := stripHostPrefixes("www.api.com", []string{"www", "api"}); got != "api.com" {
+		t.Errorf("stripHostPrefixes = %q, want api.com", got)
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// iacDorks are GitHub code searches for infrastructure-as-code files:
+// Terraform, Serverless Framework and Kubernetes manifests and charts.
+var iacDorks = []string{
+	"extension:tf",
+	"extension:tfvars",
+	"filename:serverless.yml",
+	"filename:serverless.yaml",
+	"apiVersion kind extension:yaml path:k8s",
+	"apiVersion kind extension:yaml path:kubernetes",
+	"filename:Chart.yaml",
+}
+
+// Tags of IaC files configuring cloud providers or hard-coding endpoints.
+const (
+	iacProviderTag = "iac-provider"
+	iacEndpointTag = "iac-endpoint"
+)
+
+var (
+	// iacProviderRegexp matches Terraform provider blocks and the top-level
+	// provider section of Serverless Framework files.
+	iacProviderRegexp = regexp.MustCompile(`(?m)^\s*provider\s+"[^"]+"\s*\{|^provider\s*:`)
+
+	iacIPRegexp = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+)
+
+// iacDorkQueries returns the IaC dorks scoped to each GitHub organization
+// and user found so far.
+func iacDorkQueries() []string {
+	seen := make(map[string]bool)
+	var queries []string
+	for _, r := range collectedResults {
+		if r.Platform != "github" || (r.Category != "organization" && r.Category != "user") || seen[normalizeName(r.Name)] {
+			continue
+		}
+		seen[normalizeName(r.Name)] = true
+
+		qualifier := "org:"
+		if r.Category == "user" {
+			qualifier = "user:"
+		}
+		for _, dork := range iacDorks {
+			queries = append(queries, dork+" "+qualifier+r.Name)
+		}
+	}
+	return queries
+}
+
+// iacFileTags returns the tags an IaC file earns from its content.
+func iacFileTags(content string) []string {
+	var tags []string
+	if iacProviderRegexp.MatchString(content) {
+		tags = append(tags, iacProviderTag)
+	}
+
+	endpoint := iacIPRegexp.MatchString(content)
+	for _, m := range ciURLRegexp.FindAllStringSubmatch(content, -1) {
+		if !ciCommonHosts[strings.ToLower(m[1])] {
+			endpoint = true
+			break
+		}
+	}
+	if endpoint {
+		tags = append(tags, iacEndpointTag)
+	}
+	return tags
+}
+
+// discoverIaC reports the IaC dorks of the namespaces found so far in
+// iac_dorks.txt and, if search is set, runs them, reporting the files found
+// as "owner/repo:path" tagged by what they contain.
+func discoverIaC(search githubSearchService, contents githubContentsService, searchDorks bool, maxResults int) {
+	queries := iacDorkQueries()
+	if len(queries) == 0 {
+		return
+	}
+	emitResults("github", "iac_dork", "", "IaC code search dorks for discovered namespaces", "iac_dorks.txt", queries)
+	if !searchDorks {
+		return
+	}
+
+	for _, query := range queries {
+		opt := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: maxResults}}
+		results, _, err := search.Code(context.Background(), query, opt)
+		if err != nil {
+			recordSearchError("github", "IaC search", query, err)
+			continue
+		}
+
+		var files []string
+		for _, file := range results.CodeResults {
+			repo := file.GetRepository()
+			name := repo.GetFullName() + ":" + file.GetPath()
+			files = append(files, name)
+
+			content, err := fetchGitHubFile(contents, repo.GetFullName(), file.GetPath())
+			if err != nil {
+				recordSearchError("github", "IaC file fetch", name, err)
+				continue
+			}
+			tagFinding("github", "iac_file", name, iacFileTags(content)...)
+		}
+
+		// Results are filed under their namespace, the last qualifier.
+		namespace := query[strings.LastIndex(query, ":")+1:]
+		emitResults("github", "iac_file", namespace, fmt.Sprintf("GitHub IaC files matching '%s'", query), "github_iac_files.txt", files)
+	}
+}
+
+// fetchGitHubFile returns the content of the file at path in the repository
+// fullName.
+func fetchGitHubFile(client githubContentsService, fullName, path string) (string, error) {
+	parts := strings.SplitN(fullName, "/", 2)
+	if len(parts) != 2 {
+		return "", fmt.Errorf("invalid repository name %q", fullName)
+	}
+
+	file, _, _, err := client.GetContents(context.Background(), parts[0], parts[1], path, nil)
+	if err != nil {
+		return "", err
+	}
+	if file == nil {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+	return file.GetContent()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// fakeGitHubContents serves file contents keyed by "owner/repo:path".
+type fakeGitHubContents map[string]string
+
+func (f fakeGitHubContents) GetContents(ctx context.Context, owner, repo, path string, opts *github.RepositoryContentGetOptions) (*github.RepositoryContent, []*github.RepositoryContent, *github.Response, error) {
+	content, ok := f[owner+"/"+repo+":"+path]
+	if !ok {
+		return nil, nil, nil, errors.New("not found")
+	}
+	return &github.RepositoryContent{Type: github.String("file"), Content: github.String(content)}, nil, nil, nil
+}
+
+func TestIaCFileTags(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"provider \"aws\" {\n  region = \"us-east-1\"\n}\n", []string{iacProviderTag}},
+		{"service: api\nprovider:\n  name: aws\n", []string{iacProviderTag}},
+		{"resource \"x\" \"y\" {\n  url = \"https://vault.acme.internal:8200\"\n}\n", []string{iacEndpointTag}},
+		{"variable \"db_host\" {\n  default = \"10.0.3.17\"\n}\n", []string{iacEndpointTag}},
+		{"module \"vpc\" {\n  source = \"https://github.com/acme/vpc\"\n}\n", nil},
+	}
+
+	for _, tt := range tests {
+		if got := iacFileTags(tt.content); !equalStrings(got, tt.want) {
+			t.Errorf("iacFileTags(%q) = %v, want %v", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestDiscoverIaC(t *testing.T) {
+	setupRun(t, config{iacFlag: true, iacSearchFlag: true})
+	recordResults("github", "organization", "acme", []string{"acme"})
+	recordResults("github", "user", "acme", []string{"bob"})
+
+	search := &fakeGitHubSearch{code: map[string][]string{
+		"extension:tf org:acme": {"acme/infra:main.tf", "acme/infra:variables.tf"},
+	}}
+	contents := fakeGitHubContents{
+		"acme/infra:main.tf":      "provider \"aws\" {}\nendpoint = \"https://api.acme.internal\"\n",
+		"acme/infra:variables.tf": "variable \"region\" {}\n",
+	}
+
+	discoverIaC(search, contents, true, 10)
+
+	if got := readOutputLines(t, "iac_dorks.txt"); len(got) != 2*len(iacDorks) || got[0] != "extension:tf org:acme" || got[len(iacDorks)] != "extension:tf user:bob" {
+		t.Errorf("iac_dorks.txt = %v", got)
+	}
+	if len(search.queries) != 2*len(iacDorks) {
+		t.Errorf("ran %d searches, want %d", len(search.queries), 2*len(iacDorks))
+	}
+
+	for _, r := range collectedResults {
+		if r.Category != "iac_file" {
+			continue
+		}
+		var want []string
+		if r.Name == "acme/infra:main.tf" {
+			want = []string{iacEndpointTag, iacProviderTag}
+		}
+		if !equalStrings(r.Tags, want) {
+			t.Errorf("tags of %s = %v, want %v", r.Name, r.Tags, want)
+		}
+		if r.Query != "acme" {
+			t.Errorf("query of %s = %s, want acme", r.Name, r.Query)
+		}
+	}
+	if got := resultNames("github", "iac_file"); len(got) != 2 {
+		t.Errorf("IaC files = %v, want 2", got)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// dockerHub is the registry of image references naming none.
+const dockerHub = "docker.io"
+
+var (
+	// dockerfileFromRegexp matches the base images of a Dockerfile's
+	// stages, with their optional stage names.
+	dockerfileFromRegexp = regexp.MustCompile(`(?im)^\s*FROM\s+(?:--platform=\S+\s+)?(\S+)(?:\s+AS\s+(\S+))?`)
+
+	// dockerURIRegexp matches the docker:// images of GitHub Actions steps.
+	dockerURIRegexp = regexp.MustCompile(`docker://([^\s"']+)`)
+)
+
+// imageRef is a parsed container image reference.
+type imageRef struct {
+	Registry  string
+	Namespace string
+	Name      string
+	Tag       string
+}
+
+// parseImageRef parses a reference such as registry.acme.com:5000/team/api:1.2
+// or nginx. Docker Hub images without a namespace are official ones, in
+// "library". It reports false for values that aren't image references,
+// like templated or variable ones.
+func parseImageRef(ref string) (imageRef, bool) {
+	if ref == "" || strings.ContainsAny(ref, "${}") || strings.HasPrefix(ref, "-") {
+		return imageRef{}, false
+	}
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+
+	img := imageRef{Registry: dockerHub}
+	parts := strings.Split(strings.ToLower(ref), "/")
+	if len(parts) > 1 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		img.Registry = parts[0]
+		parts = parts[1:]
+	}
+
+	last := parts[len(parts)-1]
+	if i := strings.LastIndex(last, ":"); i >= 0 {
+		last, img.Tag = last[:i], last[i+1:]
+	}
+	img.Name = last
+	img.Namespace = strings.Join(parts[:len(parts)-1], "/")
+	if img.Namespace == "" && img.Registry == dockerHub {
+		img.Namespace = "library"
+	}
+	if img.Name == "" {
+		return imageRef{}, false
+	}
+	return img, true
+}
+
+// String is the fully qualified reference, without the tag.
+func (i imageRef) String() string {
+	if i.Namespace == "" {
+		return i.Registry + "/" + i.Name
+	}
+	return i.Registry + "/" + i.Namespace + "/" + i.Name
+}
+
+// namespaceRef is the account or group owning the image on its registry,
+// a pivot to the other images it publishes, or "" for official Docker Hub
+// images and images at a registry's root.
+func (i imageRef) namespaceRef() string {
+	if i.Namespace == "" || (i.Registry == dockerHub && i.Namespace == "library") {
+		return ""
+	}
+	return i.Registry + "/" + strings.SplitN(i.Namespace, "/", 2)[0]
+}
+
+// dockerfileImages returns the base images of a Dockerfile, leaving out
+// references to its own earlier stages.
+func dockerfileImages(content string) []string {
+	stages := make(map[string]bool)
+	var images []string
+	for _, m := range dockerfileFromRegexp.FindAllStringSubmatch(content, -1) {
+		if !stages[strings.ToLower(m[1])] && m[1] != "scratch" {
+			images = append(images, m[1])
+		}
+		if m[2] != "" {
+			stages[strings.ToLower(m[2])] = true
+		}
+	}
+	return images
+}
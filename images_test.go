@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestParseImageRef(t *testing.T) {
+	tests := []struct {
+		ref, want, namespace string
+		ok                   bool
+	}{
+		{"nginx", "docker.io/library/nginx", "", true},
+		{"acme/api:1.2", "docker.io/acme/api", "docker.io/acme", true},
+		{"registry.acme.com:5000/team/sub/api@sha256:abc", "registry.acme.com:5000/team/sub/api", "registry.acme.com:5000/team", true},
+		{"localhost/api", "localhost/api", "", true},
+		{"ghcr.io/Acme/Build:latest", "ghcr.io/acme/build", "ghcr.io/acme", true},
+		{"$CI_REGISTRY_IMAGE:latest", "", "", false},
+		{"${{ matrix.image }}", "", "", false},
+	}
+
+	for _, tt := range tests {
+		img, ok := parseImageRef(tt.ref)
+		if ok != tt.ok {
+			t.Errorf("parseImageRef(%q) ok = %v, want %v", tt.ref, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if got := img.String(); got != tt.want {
+			t.Errorf("parseImageRef(%q) = %s, want %s", tt.ref, got, tt.want)
+		}
+		if got := img.namespaceRef(); got != tt.namespace {
+			t.Errorf("parseImageRef(%q) namespace = %q, want %q", tt.ref, got, tt.namespace)
+		}
+	}
+}
+
+func TestImageExtraction(t *testing.T) {
+	found := parseDockerfile("FROM --platform=linux/amd64 registry.acme.com/base/go:1.21 AS builder\nRUN make\nFROM builder AS test\nFROM acme/runtime\nFROM scratch\n")
+	if want := []string{"docker.io/acme/runtime", "registry.acme.com/base/go"}; !equalStrings(found.images, want) {
+		t.Errorf("Dockerfile images = %v, want %v", found.images, want)
+	}
+	if want := []string{"docker.io/acme", "registry.acme.com/base"}; !equalStrings(found.namespaces, want) {
+		t.Errorf("Dockerfile namespaces = %v, want %v", found.namespaces, want)
+	}
+
+	ci := parseCIConfig("build:\n  image:\n    name: quay.io/acme/builder:2\n  services:\n    - postgres:14\ntest:\n  image: node:18\n  steps:\n    - uses: docker://acme/linter:v1\n")
+	if want := []string{"docker.io/acme/linter", "docker.io/library/node", "quay.io/acme/builder"}; !equalStrings(ci.images, want) {
+		t.Errorf("CI images = %v, want %v", ci.images, want)
+	}
+	if want := []string{"quay.io"}; !equalStrings(ci.registries, want) {
+		t.Errorf("CI registries = %v, want %v", ci.registries, want)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// maxTyposPerKeyword caps the lookalikes checked per keyword, keeping the
+// most similar ones, since each costs an API call per platform.
+const maxTyposPerKeyword = 40
+
+var (
+	// homoglyphs maps characters to lookalikes commonly used by squatters.
+	homoglyphs = map[rune][]string{
+		'o': {"0"}, '0': {"o"},
+		'l': {"1", "i"}, '1': {"l"},
+		'i': {"1", "l"},
+		'e': {"3"}, 'a': {"4"}, 's': {"5"},
+		'm': {"rn"}, 'w': {"vv"},
+	}
+
+	// impersonationAffixes are suffixes squatters add to look official.
+	impersonationAffixes = []string{"-official", "official", "-inc", "inc", "-hq", "hq", "-team", "-dev", "-app"}
+)
+
+// impersonationCandidate is a third-party held namespace resembling one of
+// the keywords, with its computed risk.
+type impersonationCandidate struct {
+	availabilityCheck
+	Keyword    string  `json:"keyword"`
+	Similarity float64 `json:"similarity"`
+	Risk       float64 `json:"risk"`
+
+	// Signals are the signs of a deliberate impersonation rather than a
+	// namesake, such as a recent account copying the target's avatar.
+	Signals []string `json:"signals,omitempty"`
+}
+
+// typoVariants generates typosquat lookalikes of name: omissions,
+// transpositions, duplications, homoglyph swaps, and official-looking
+// affixes. The result is ordered by similarity to name.
+func typoVariants(name string) []string {
+	name = strings.ToLower(name)
+	seen := map[string]bool{name: true}
+	var variants []string
+
+	add := func(v string) {
+		if v != "" && !seen[v] && namespaceRegexp.MatchString(v) {
+			seen[v] = true
+			variants = append(variants, v)
+		}
+	}
+
+	for i := range name {
+		add(name[:i] + name[i+1:])
+		add(name[:i] + name[i:i+1] + name[i:])
+		if i+1 < len(name) {
+			add(name[:i] + name[i+1:i+2] + name[i:i+1] + name[i+2:])
+			add(name[:i+1] + "-" + name[i+1:])
+		}
+		for _, glyph := range homoglyphs[rune(name[i])] {
+			add(name[:i] + glyph + name[i+1:])
+		}
+	}
+	add(strings.Replace(name, "-", "", -1))
+	add(strings.Replace(name, "-", "_", -1))
+	for _, affix := range impersonationAffixes {
+		add(name + affix)
+	}
+
+	sort.SliceStable(variants, func(i, j int) bool {
+		return similarity(name, variants[i]) > similarity(name, variants[j])
+	})
+	if len(variants) > maxTyposPerKeyword {
+		variants = variants[:maxTyposPerKeyword]
+	}
+
+	return variants
+}
+
+// similarity is 1 minus the normalized Levenshtein distance of a and b.
+func similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+const (
+	// newAccountAge is how recently a lookalike must have been created to
+	// count as a new account.
+	newAccountAge = 90 * 24 * time.Hour
+
+	// signalRisk is the risk each impersonation signal adds.
+	signalRisk = 15
+)
+
+// impersonationSignals compares a lookalike with the target's own
+// namespaces, found by the same run, for the signs of an impersonation: a
+// new account, or a display name, description or avatar copied from the
+// target, the latter especially on an account without repositories.
+// Avatars are compared by the hashes hash returns.
+func impersonationSignals(c availabilityCheck, targets []availabilityCheck, now time.Time, hash func(url string) (string, error)) []string {
+	var signals []string
+	if !c.CreatedAt.IsZero() && now.Sub(c.CreatedAt) < newAccountAge {
+		signals = append(signals, fmt.Sprintf("created %d days ago", int(now.Sub(c.CreatedAt).Hours()/24)))
+	}
+
+	copied := func(field func(availabilityCheck) string) string {
+		value := strings.Join(strings.Fields(foldName(field(c))), " ")
+		if value == "" {
+			return ""
+		}
+		for _, target := range targets {
+			if strings.Join(strings.Fields(foldName(field(target))), " ") == value {
+				return target.Platform + ":" + target.Name
+			}
+		}
+		return ""
+	}
+	if target := copied(func(a availabilityCheck) string { return a.DisplayName }); target != "" {
+		signals = append(signals, "display name of "+target)
+	}
+	if target := copied(func(a availabilityCheck) string { return a.Description }); target != "" {
+		signals = append(signals, "description of "+target)
+	}
+
+	if c.AvatarURL == "" {
+		return signals
+	}
+	for _, target := range targets {
+		if target.AvatarURL == "" {
+			continue
+		}
+		avatar, err := hash(c.AvatarURL)
+		if err != nil {
+			break
+		}
+		if targetAvatar, err := hash(target.AvatarURL); err == nil && targetAvatar == avatar {
+			if c.Platform == "github" && c.PublicRepos == 0 {
+				signals = append(signals, "no repositories but the avatar of "+target.Platform+":"+target.Name)
+			} else {
+				signals = append(signals, "avatar of "+target.Platform+":"+target.Name)
+			}
+			break
+		}
+	}
+	return signals
+}
+
+// impersonationRisk scores a candidate from 0 to 100: similarity to the
+// keyword weighs most, followed by recent activity and popularity, and
+// every impersonation signal adds to it.
+func impersonationRisk(c impersonationCandidate, now time.Time) float64 {
+	risk := c.Similarity * 50
+
+	if !c.UpdatedAt.IsZero() {
+		switch age := now.Sub(c.UpdatedAt); {
+		case age < 90*24*time.Hour:
+			risk += 25
+		case age < 365*24*time.Hour:
+			risk += 15
+		default:
+			risk += 5
+		}
+	}
+
+	risk += math.Min(25, math.Log2(float64(c.Followers+c.PublicRepos+1))*5)
+	risk = math.Min(100, risk+float64(len(c.Signals)*signalRisk))
+
+	return math.Round(risk*10) / 10
+}
+
+// buildImpersonationReport checks each keyword and its typosquat variants on
+// every platform and ranks the third-party held ones by risk.
+func buildImpersonationReport(ghClient *github.Client, glClient *gitlab.Client, words map[string]struct{}, cfg config) []impersonationCandidate {
+	var candidates []impersonationCandidate
+	now := time.Now()
+
+	// The target's own namespaces, which impersonators copy: those listed
+	// in -owned, and the keywords registered by the target.
+	var targets []availabilityCheck
+	for _, owned := range splitList(cfg.ownedFlag) {
+		if namespaceRegexp.MatchString(owned) {
+			targets = append(targets, lookupNamespace(ghClient, glClient, strings.ToLower(owned), cfg)...)
+		}
+	}
+	for word := range words {
+		if namespaceRegexp.MatchString(word) {
+			targets = append(targets, lookupNamespace(ghClient, glClient, strings.ToLower(word), cfg)...)
+		}
+	}
+	var owned []availabilityCheck
+	for _, check := range targets {
+		if check.Status == statusTarget {
+			owned = append(owned, check)
+		}
+	}
+	hash := cachedAvatarHash()
+
+	for word := range words {
+		if !namespaceRegexp.MatchString(word) {
+			continue
+		}
+
+		names := append([]string{strings.ToLower(word)}, typoVariants(word)...)
+		verbosePrint("Checking %d lookalike namespaces of '%s'\n", len(names), word)
+
+		for _, name := range names {
+			for _, check := range lookupNamespace(ghClient, glClient, name, cfg) {
+				if check.Status != statusThirdParty {
+					continue
+				}
+
+				c := impersonationCandidate{
+					availabilityCheck: check,
+					Keyword:           word,
+					Similarity:        math.Round(similarity(strings.ToLower(word), strings.ToLower(name))*100) / 100,
+				}
+				c.Signals = impersonationSignals(check, owned, now, hash)
+				c.Risk = impersonationRisk(c, now)
+				candidates = append(candidates, c)
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Risk > candidates[j].Risk
+	})
+
+	return candidates
+}
+
+func reportImpersonation(candidates []impersonationCandidate) {
+	lines := make([]string, len(candidates))
+	for i, c := range candidates {
+		lines[i] = fmt.Sprintf("%.1f %s:%s (%s) lookalike of '%s', similarity %.2f, %d followers, %d repos %s",
+			c.Risk, c.Platform, c.Name, c.Kind, c.Keyword, c.Similarity, c.Followers, c.PublicRepos, c.Detail)
+		if len(c.Signals) > 0 {
+			lines[i] += " [" + strings.Join(c.Signals, "; ") + "]"
+		}
+		recordResults(c.Platform, "impersonation", c.Keyword, []string{c.Name})
+	}
+
+	printResults(os.Stdout, resultBatch{Platform: "all", Category: "impersonation_report", Header: "Impersonation risk report (highest risk first)", Results: lines})
+	saveResults("impersonation_report.txt", lines)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"acme", "acme", 1},
+		{"acme", "acne", 0.75},
+		{"acme", "", 0},
+		{"acme", "acme-hq", 4.0 / 7.0},
+	}
+
+	for _, tt := range tests {
+		if got := similarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTypoVariants(t *testing.T) {
+	variants := typoVariants("Acme")
+
+	for _, want := range []string{"acm", "aacme", "came", "acrne", "acme-official"} {
+		if !containsString(variants, want) {
+			t.Errorf("typoVariants(acme) is missing %q", want)
+		}
+	}
+	if containsString(variants, "acme") {
+		t.Error("typoVariants(acme) contains the keyword itself")
+	}
+	if len(variants) > maxTyposPerKeyword {
+		t.Errorf("typoVariants returned %d variants, more than %d", len(variants), maxTyposPerKeyword)
+	}
+	for i := 1; i < len(variants); i++ {
+		if similarity("acme", variants[i]) > similarity("acme", variants[i-1]) {
+			t.Fatalf("variants not ordered by similarity: %q before %q", variants[i-1], variants[i])
+		}
+	}
+}
+
+func TestImpersonationRiskPrefersActiveLookalikes(t *testing.T) {
+	now := time.Now()
+	dormant := impersonationCandidate{Similarity: 0.9}
+	dormant.UpdatedAt = now.AddDate(-3, 0, 0)
+	active := impersonationCandidate{Similarity: 0.9}
+	active.UpdatedAt = now.AddDate(0, 0, -3)
+	active.Followers = 200
+
+	if impersonationRisk(active, now) <= impersonationRisk(dormant, now) {
+		t.Error("an active, popular lookalike should outrank a dormant one")
+	}
+	if risk := impersonationRisk(active, now); risk > 100 {
+		t.Errorf("risk %v exceeds 100", risk)
+	}
+}
+
+func TestImpersonationSignals(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	targets := []availabilityCheck{
+		{Platform: "github", Name: "acme", DisplayName: "ACME Corp", Description: "Building  the future of rockets.", AvatarURL: "https://avatars.example/acme"},
+	}
+	hashes := map[string]string{
+		"https://avatars.example/acme":   "logo",
+		"https://avatars.example/copy":   "logo",
+		"https://avatars.example/theirs": "cat",
+	}
+	var fetched []string
+	hash := func(url string) (string, error) {
+		fetched = append(fetched, url)
+		return hashes[url], nil
+	}
+
+	tests := []struct {
+		name      string
+		lookalike availabilityCheck
+		want      []string
+	}{
+		{
+			name:      "impersonator",
+			lookalike: availabilityCheck{Platform: "github", Name: "acme-hq", CreatedAt: now.AddDate(0, 0, -12), DisplayName: "Acme corp", Description: "building the future of rockets.", AvatarURL: "https://avatars.example/copy"},
+			want:      []string{"created 12 days ago", "display name of github:acme", "description of github:acme", "no repositories but the avatar of github:acme"},
+		},
+		{
+			name:      "active account with the target's avatar",
+			lookalike: availabilityCheck{Platform: "github", Name: "acrne", PublicRepos: 3, CreatedAt: now.AddDate(-1, 0, 0), AvatarURL: "https://avatars.example/copy"},
+			want:      []string{"avatar of github:acme"},
+		},
+		{
+			name:      "namesake",
+			lookalike: availabilityCheck{Platform: "github", Name: "acm", CreatedAt: now.AddDate(-8, 0, 0), DisplayName: "Alex C. M.", AvatarURL: "https://avatars.example/theirs"},
+		},
+	}
+	for _, tt := range tests {
+		if got := impersonationSignals(tt.lookalike, targets, now, hash); !equalStrings(got, tt.want) {
+			t.Errorf("%s: signals = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	fetched = nil
+	impersonationSignals(tests[0].lookalike, nil, now, hash)
+	if len(fetched) != 0 {
+		t.Errorf("fetched avatars %v without a target to compare them with", fetched)
+	}
+}
+
+func TestImpersonationRiskCountsSignals(t *testing.T) {
+	now := time.Now()
+	namesake := impersonationCandidate{Similarity: 0.8}
+	namesake.UpdatedAt = now.AddDate(-5, 0, 0)
+	impersonator := namesake
+	impersonator.Signals = []string{"created 3 days ago", "avatar of github:acme"}
+
+	if got, want := impersonationRisk(impersonator, now), impersonationRisk(namesake, now)+2*signalRisk; got != want {
+		t.Errorf("risk = %v, want %v", got, want)
+	}
+
+	impersonator.Similarity = 1
+	impersonator.UpdatedAt = now
+	impersonator.Followers = 10000
+	impersonator.Signals = append(impersonator.Signals, "display name of github:acme", "description of github:acme")
+	if risk := impersonationRisk(impersonator, now); risk != 100 {
+		t.Errorf("risk = %v, want capped at 100", risk)
+	}
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// indexFile is where `dorky index` keeps its index, in the configuration
+// directory, unless -index says otherwise.
+const indexFile = "index.json"
+
+// resultIndex is the local index searched by `dorky index search`: the
+// results of every report, stream and state file added to it, merged by
+// platform, category and name.
+type resultIndex struct {
+	Results []indexedResult `json:"results"`
+}
+
+// indexedResult is a result as found by every run indexed, with the
+// keywords that found it and when it was first and last found.
+type indexedResult struct {
+	Platform  string    `json:"platform"`
+	Category  string    `json:"category"`
+	Name      string    `json:"name"`
+	ID        string    `json:"id,omitempty"`
+	Queries   []string  `json:"queries,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	Triage    string    `json:"triage,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Runs      []string  `json:"runs,omitempty"`
+
+	// Sources are the files the result was indexed from.
+	Sources []string `json:"sources"`
+}
+
+// indexQuery selects indexed results: those matching every term, in their
+// name or a keyword that found them, and every filter set.
+type indexQuery struct {
+	terms              []string
+	platform, category string
+	tag, triage        string
+	seenSince          time.Time
+}
+
+// runIndexCommand implements `dorky index add` and `dorky index search`.
+func runIndexCommand(args []string) {
+	usage := "Usage: dorky index <add|search> [flags] [files or terms...]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("index "+args[0], flag.ExitOnError)
+	indexPath := fs.String("index", "", "index file (default index.json in the configuration directory)")
+	var query indexQuery
+	var seenWithin string
+	jsonOut := false
+	switch args[0] {
+	case "add":
+		fs.Usage = func() {
+			fmt.Fprintln(fs.Output(), "Usage: dorky index add [-index file] report.json results.ndjson state.json...")
+			fs.PrintDefaults()
+		}
+	case "search":
+		fs.StringVar(&query.platform, "platform", "", "only results on this platform")
+		fs.StringVar(&query.category, "category", "", "only results of this category")
+		fs.StringVar(&query.tag, "tag", "", "only results with this tag")
+		fs.StringVar(&query.triage, "triage", "", "only results with this triage mark")
+		fs.StringVar(&seenWithin, "seen-within", "", "only results last found within this age, such as 30d")
+		fs.BoolVar(&jsonOut, "json", false, "print the matching results as JSON")
+		fs.Usage = func() {
+			fmt.Fprintln(fs.Output(), "Usage: dorky index search [-index file] [filters] [terms...]")
+			fs.PrintDefaults()
+		}
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	fs.Parse(args[1:])
+
+	filename := *indexPath
+	if filename == "" {
+		dir, err := configDir()
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			os.Exit(1)
+		}
+		filename = filepath.Join(dir, indexFile)
+	}
+	idx, err := readIndexFile(filename)
+	if err != nil {
+		fmt.Printf("Error reading index: %s\n", err)
+		os.Exit(1)
+	}
+
+	if args[0] == "add" {
+		if fs.NArg() == 0 {
+			fs.Usage()
+			os.Exit(1)
+		}
+		for _, source := range fs.Args() {
+			results, err := readIndexSource(source)
+			if err != nil {
+				fmt.Printf("Error reading %s: %s\n", source, err)
+				os.Exit(1)
+			}
+			added := idx.add(results, source)
+			fmt.Printf("Indexed %d result(s) from %s, %d of them new\n", len(results), source, added)
+		}
+		if err := writeIndexFile(filename, idx); err != nil {
+			fmt.Printf("Error writing index: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	query.terms = fs.Args()
+	if seenWithin != "" {
+		age, err := parseAge(seenWithin)
+		if err != nil {
+			fmt.Printf("Error: -seen-within: %s\n", err)
+			os.Exit(1)
+		}
+		query.seenSince = time.Now().UTC().Add(-age)
+	}
+	matches := idx.search(query)
+	if jsonOut {
+		data, _ := json.MarshalIndent(matches, "", "  ")
+		fmt.Printf("%s\n", data)
+		return
+	}
+	printIndexMatches(os.Stdout, matches, len(idx.Results))
+}
+
+func readIndexFile(filename string) (*resultIndex, error) {
+	data, err := readOutputFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return &resultIndex{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var idx resultIndex
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &idx, nil
+}
+
+// writeIndexFile replaces the index atomically, so an interrupted add
+// leaves the previous one.
+func writeIndexFile(filename string, idx *resultIndex) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(filename, append(data, '\n'))
+}
+
+// readIndexSource reads the results of a -json report, a -ndjson stream or
+// a -state file. State files record no keywords, but keep the triage marks
+// and when each result was first found.
+func readIndexSource(filename string) ([]indexedResult, error) {
+	data, err := readOutputFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reports and state files are a single object listing results, while
+	// each line of a stream is a result.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err == nil && fields["results"] != nil {
+		var doc struct {
+			RunID      string            `json:"run_id"`
+			FinishedAt time.Time         `json:"finished_at"`
+			Results    []json.RawMessage `json:"results"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+		var results []indexedResult
+		for _, raw := range doc.Results {
+			if _, report := fields["run_id"]; !report {
+				var s resultState
+				if err := json.Unmarshal(raw, &s); err != nil {
+					return nil, err
+				}
+				results = append(results, indexedResult{Platform: s.Platform, Category: s.Category, Name: s.Name, ID: s.ID, Triage: s.Triage, FirstSeen: s.FirstSeen, LastSeen: s.LastSeen})
+				continue
+			}
+			var r result
+			if err := json.Unmarshal(raw, &r); err != nil {
+				return nil, err
+			}
+			if r.Timestamp.IsZero() {
+				r.Timestamp = doc.FinishedAt
+			}
+			results = append(results, indexResult(r))
+		}
+		return results, nil
+	}
+
+	var results []indexedResult
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var r result
+		if err := json.Unmarshal(text, &r); err != nil || r.Platform == "" || r.Name == "" {
+			return nil, fmt.Errorf("line %d: not a result of a -json report, -ndjson stream or -state file", line)
+		}
+		results = append(results, indexResult(r))
+	}
+	return results, scanner.Err()
+}
+
+func indexResult(r result) indexedResult {
+	e := indexedResult{Platform: r.Platform, Category: r.Category, Name: r.Name, ID: r.ID, Tags: r.Tags, Triage: r.Triage, FirstSeen: r.Timestamp, LastSeen: r.Timestamp}
+	if r.Query != "" {
+		e.Queries = []string{r.Query}
+	}
+	if r.RunID != "" {
+		e.Runs = []string{r.RunID}
+	}
+	return e
+}
+
+// add merges results read from source into the index, returning how many
+// weren't indexed yet. Adding a file again changes nothing.
+func (idx *resultIndex) add(results []indexedResult, source string) int {
+	byKey := make(map[string]int)
+	for i, e := range idx.Results {
+		byKey[stateKey(e.Platform, e.Category, e.Name)] = i
+	}
+
+	added := 0
+	for _, r := range results {
+		r.Sources = []string{source}
+		key := stateKey(r.Platform, r.Category, r.Name)
+		i, ok := byKey[key]
+		if !ok {
+			byKey[key] = len(idx.Results)
+			idx.Results = append(idx.Results, indexedResult{Platform: r.Platform, Category: r.Category, Name: r.Name})
+			i = len(idx.Results) - 1
+			added++
+		}
+		idx.Results[i].merge(r)
+	}
+
+	sort.Slice(idx.Results, func(i, j int) bool {
+		a, b := idx.Results[i], idx.Results[j]
+		return a.Platform+"\x00"+a.Category+"\x00"+a.Name < b.Platform+"\x00"+b.Category+"\x00"+b.Name
+	})
+	return added
+}
+
+// merge folds another sighting of the result into e: the newest triage mark
+// wins, the keywords, tags, runs and sources add up.
+func (e *indexedResult) merge(r indexedResult) {
+	if e.ID == "" {
+		e.ID = r.ID
+	}
+	if r.Triage != "" && (e.Triage == "" || !r.LastSeen.Before(e.LastSeen)) {
+		e.Triage = r.Triage
+	}
+	if !r.FirstSeen.IsZero() && (e.FirstSeen.IsZero() || r.FirstSeen.Before(e.FirstSeen)) {
+		e.FirstSeen = r.FirstSeen
+	}
+	if r.LastSeen.After(e.LastSeen) {
+		e.LastSeen = r.LastSeen
+	}
+	e.Queries = mergeSorted(e.Queries, r.Queries)
+	e.Tags = mergeSorted(e.Tags, r.Tags)
+	e.Runs = mergeSorted(e.Runs, r.Runs)
+	e.Sources = mergeSorted(e.Sources, r.Sources)
+}
+
+// mergeSorted returns the values of a and b, sorted and without duplicates.
+func mergeSorted(a, b []string) []string {
+	set := make(map[string]bool)
+	for _, v := range append(append([]string(nil), a...), b...) {
+		set[v] = true
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return sortedSet(set)
+}
+
+// search returns the indexed results matching q, in index order. Terms and
+// filters ignore case and diacritics.
+func (idx *resultIndex) search(q indexQuery) []indexedResult {
+	matches := []indexedResult{}
+	for _, e := range idx.Results {
+		if q.matches(e) {
+			matches = append(matches, e)
+		}
+	}
+	return matches
+}
+
+func (q indexQuery) matches(e indexedResult) bool {
+	if (q.platform != "" && !strings.EqualFold(e.Platform, q.platform)) ||
+		(q.category != "" && !strings.EqualFold(e.Category, q.category)) ||
+		(q.triage != "" && !strings.EqualFold(e.Triage, q.triage)) ||
+		(!q.seenSince.IsZero() && e.LastSeen.Before(q.seenSince)) {
+		return false
+	}
+	if q.tag != "" && !containsFolded(e.Tags, q.tag, true) {
+		return false
+	}
+	for _, term := range q.terms {
+		if !strings.Contains(foldName(e.Name), foldName(term)) && !containsFolded(e.Queries, term, false) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsFolded reports whether one of values is s, or contains it unless
+// exact is set, ignoring case and diacritics.
+func containsFolded(values []string, s string, exact bool) bool {
+	s = foldName(s)
+	for _, v := range values {
+		if v = foldName(v); v == s || (!exact && strings.Contains(v, s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// printIndexMatches lists the results found in the index, one per line,
+// then how many matched.
+func printIndexMatches(w io.Writer, matches []indexedResult, total int) {
+	for _, e := range matches {
+		line := fmt.Sprintf("%s %s %s", e.Platform, e.Category, e.Name)
+		if len(e.Queries) > 0 {
+			line += fmt.Sprintf(" (%s)", strings.Join(e.Queries, ", "))
+		}
+		if !e.FirstSeen.IsZero() {
+			line += fmt.Sprintf(", first seen %s, last seen %s", e.FirstSeen.Format("2006-01-02"), e.LastSeen.Format("2006-01-02"))
+		}
+		if e.Triage != "" {
+			line += fmt.Sprintf(" [%s]", e.Triage)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintf(w, "%d of %d indexed result(s) match\n", len(matches), total)
+}
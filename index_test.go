@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultIndex(t *testing.T) {
+	dir := t.TempDir()
+	reportFile := writeTempFile(t, `{
+  "run_id": "run1",
+  "finished_at": "2024-01-10T00:00:00Z",
+  "results": [
+    {"run_id": "run1", "platform": "github", "category": "organization", "query": "acme", "name": "Acme", "tags": ["brand"]},
+    {"run_id": "run1", "platform": "github", "category": "repository", "query": "acme", "name": "acme/api", "@timestamp": "2024-01-09T12:00:00Z"}
+  ]
+}`)
+	streamFile := writeTempFile(t, `{"run_id": "run2", "platform": "github", "category": "organization", "query": "acme corp", "name": "acme", "@timestamp": "2024-02-01T00:00:00Z"}
+
+{"run_id": "run2", "platform": "gitlab", "category": "group", "query": "müller", "name": "mueller-gmbh", "@timestamp": "2024-02-01T00:00:00Z"}
+`)
+	stateFile := writeTempFile(t, `{"results": [
+  {"platform": "github", "category": "repository", "name": "acme/api", "first_seen": "2023-06-01T00:00:00Z", "last_seen": "2024-01-09T12:00:00Z", "triage": "interesting"}
+]}`)
+
+	// Adding a file twice changes nothing.
+	idx := &resultIndex{}
+	for _, add := range []struct {
+		source string
+		added  int
+	}{{reportFile, 2}, {streamFile, 1}, {stateFile, 0}, {streamFile, 0}} {
+		results, err := readIndexSource(add.source)
+		if err != nil {
+			t.Fatalf("%s: %v", add.source, err)
+		}
+		if added := idx.add(results, add.source); added != add.added {
+			t.Errorf("%s added %d results, want %d", add.source, added, add.added)
+		}
+	}
+
+	filename := filepath.Join(dir, "index", "index.json")
+	if err := writeIndexFile(filename, idx); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := readIndexFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx.Results) != 3 {
+		t.Fatalf("indexed %+v, want 3 results", idx.Results)
+	}
+
+	org := idx.Results[0]
+	if org.Name != "Acme" || strings.Join(org.Queries, ",") != "acme,acme corp" || strings.Join(org.Runs, ",") != "run1,run2" ||
+		!org.FirstSeen.Equal(time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)) || !org.LastSeen.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) ||
+		len(org.Sources) != 2 {
+		t.Errorf("organization = %+v, want both sightings merged", org)
+	}
+	if repo := idx.Results[1]; repo.Triage != "interesting" || !repo.FirstSeen.Equal(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("repository = %+v, want the state file's mark and first sighting", repo)
+	}
+
+	names := func(q indexQuery) string {
+		var names []string
+		for _, e := range idx.search(q) {
+			names = append(names, e.Name)
+		}
+		return strings.Join(names, ",")
+	}
+	tests := []struct {
+		query indexQuery
+		want  string
+	}{
+		{indexQuery{}, "Acme,acme/api,mueller-gmbh"},
+		{indexQuery{terms: []string{"ACME"}}, "Acme,acme/api"},
+		{indexQuery{terms: []string{"acme", "corp"}}, "Acme"},
+		{indexQuery{terms: []string{"muller"}}, "mueller-gmbh"},
+		{indexQuery{platform: "GitHub", category: "repository"}, "acme/api"},
+		{indexQuery{tag: "brand"}, "Acme"},
+		{indexQuery{triage: "interesting"}, "acme/api"},
+		{indexQuery{seenSince: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)}, "Acme,mueller-gmbh"},
+	}
+	for _, tt := range tests {
+		if got := names(tt.query); got != tt.want {
+			t.Errorf("search(%+v) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	var out bytes.Buffer
+	printIndexMatches(&out, idx.search(indexQuery{triage: "interesting"}), len(idx.Results))
+	want := "github repository acme/api (acme), first seen 2023-06-01, last seen 2024-01-09 [interesting]\n1 of 3 indexed result(s) match\n"
+	if out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+
+	if _, err := readIndexSource(writeTempFile(t, "acme\n")); err == nil {
+		t.Error("a text file was indexed")
+	}
+}
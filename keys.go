@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// sshFingerprint returns the SHA256 fingerprint of an authorized_keys style
+// public key, as printed by ssh-keygen -l, and its type.
+func sshFingerprint(key string) (string, string, error) {
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return "", "", errors.New("not an SSH public key")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", "", fmt.Errorf("not an SSH public key: %w", err)
+	}
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), fields[0], nil
+}
+
+// describeGPGKey describes a GPG key by its key ID, followed by the IDs of
+// its subkeys, which signatures usually name, and its email addresses.
+func describeGPGKey(key *github.GPGKey) string {
+	var details []string
+	for _, sub := range key.Subkeys {
+		if id := sub.GetKeyID(); id != "" {
+			details = append(details, "subkey "+id)
+		}
+	}
+	for _, email := range key.Emails {
+		if address := email.GetEmail(); address != "" {
+			details = append(details, address)
+		}
+	}
+	if len(details) == 0 {
+		return key.GetKeyID()
+	}
+	return key.GetKeyID() + " (" + strings.Join(details, ", ") + ")"
+}
+
+// enumerateUserKeys lists the public SSH and GPG keys of every GitHub user
+// found so far, reporting SSH keys by fingerprint as "user: SHA256:...
+// (type)" and GPG keys by key ID as "user: KEYID (subkeys, emails)", to
+// match against keys found elsewhere, such as a leaked authorized_keys file
+// or the signature of a commit. A key registered by several of the users
+// found marks the accounts of one person, and is reported as "key: user,
+// user".
+func enumerateUserKeys(client githubKeysService) {
+	var users []string
+	seen := make(map[string]bool)
+	for _, r := range collectedResults {
+		if r.Platform == "github" && r.Category == "user" && !seen[normalizeName(r.Name)] {
+			seen[normalizeName(r.Name)] = true
+			users = append(users, r.Name)
+		}
+	}
+	sort.Strings(users)
+
+	var sshKeys, gpgKeys []string
+	owners := make(map[string][]string)
+	for _, user := range users {
+		verbosePrint("Listing public keys of GitHub user: %s\n", user)
+		keys, _, err := client.ListKeys(context.Background(), user, &github.ListOptions{PerPage: 100})
+		if err != nil {
+			recordSearchError("github", "SSH key listing", user, err)
+		}
+		for _, key := range keys {
+			fingerprint, keyType, err := sshFingerprint(key.GetKey())
+			if err != nil {
+				verbosePrint("Skipping a key of %s: %s\n", user, err)
+				continue
+			}
+			sshKeys = append(sshKeys, fmt.Sprintf("%s: %s (%s)", user, fingerprint, keyType))
+			owners[fingerprint] = append(owners[fingerprint], user)
+		}
+
+		gpg, _, err := client.ListGPGKeys(context.Background(), user, &github.ListOptions{PerPage: 100})
+		if err != nil {
+			recordSearchError("github", "GPG key listing", user, err)
+		}
+		for _, key := range gpg {
+			if key.GetKeyID() == "" {
+				continue
+			}
+			gpgKeys = append(gpgKeys, user+": "+describeGPGKey(key))
+			owners[key.GetKeyID()] = append(owners[key.GetKeyID()], user)
+		}
+	}
+
+	var shared []string
+	for key, keyOwners := range owners {
+		if len(keyOwners) > 1 {
+			shared = append(shared, key+": "+strings.Join(keyOwners, ", "))
+		}
+	}
+	sort.Strings(shared)
+
+	if len(sshKeys) > 0 {
+		emitResults("github", "ssh_key", "", "Public SSH keys of discovered GitHub users", "github_ssh_keys.txt", sshKeys)
+	}
+	if len(gpgKeys) > 0 {
+		emitResults("github", "gpg_key", "", "Public GPG keys of discovered GitHub users", "github_gpg_keys.txt", gpgKeys)
+	}
+	if len(shared) > 0 {
+		emitResults("github", "shared_key", "", "Public keys shared by several discovered GitHub users", "github_shared_keys.txt", shared)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// testSSHKey has the fingerprint ssh-keygen -l prints for it.
+const (
+	testSSHKey            = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJWNB0jM1i7YHBIAz5eqUDZ5n+sMaWC3S7hE2qJsK1uN"
+	testSSHKeyFingerprint = "SHA256:8K0GbNyj1Uiq3/pC22J/FsTAwQMMCLswdEsjeBEwKSU"
+)
+
+type fakeGitHubKeys struct {
+	ssh map[string][]string
+	gpg map[string][]*github.GPGKey
+}
+
+func (f fakeGitHubKeys) ListKeys(ctx context.Context, user string, opts *github.ListOptions) ([]*github.Key, *github.Response, error) {
+	keys, ok := f.ssh[user]
+	if !ok {
+		return nil, nil, errors.New("not found")
+	}
+	var result []*github.Key
+	for _, key := range keys {
+		result = append(result, &github.Key{Key: github.String(key)})
+	}
+	return result, nil, nil
+}
+
+func (f fakeGitHubKeys) ListGPGKeys(ctx context.Context, user string, opts *github.ListOptions) ([]*github.GPGKey, *github.Response, error) {
+	return f.gpg[user], nil, nil
+}
+
+func TestSSHFingerprint(t *testing.T) {
+	fingerprint, keyType, err := sshFingerprint(testSSHKey + " alice@laptop")
+	if err != nil || fingerprint != testSSHKeyFingerprint || keyType != "ssh-ed25519" {
+		t.Errorf("sshFingerprint = %q, %q, %v, want %s ssh-ed25519", fingerprint, keyType, err, testSSHKeyFingerprint)
+	}
+	for _, bad := range []string{"", "ssh-rsa", "ssh-rsa not*base64"} {
+		if _, _, err := sshFingerprint(bad); err == nil {
+			t.Errorf("sshFingerprint(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestEnumerateUserKeys(t *testing.T) {
+	setupRun(t, config{keysFlag: true})
+
+	gpgKey := &github.GPGKey{
+		KeyID:   github.String("3262EEF8ABCD1234"),
+		Subkeys: []*github.GPGKey{{KeyID: github.String("9F1E2D3C4B5A6978")}},
+		Emails:  []*github.GPGEmail{{Email: github.String("alice@acme.com")}},
+	}
+	client := fakeGitHubKeys{
+		ssh: map[string][]string{
+			"alice":     {testSSHKey, "garbage"},
+			"acme-bot":  {testSSHKey},
+			"bob":       {},
+			"unrelated": {testSSHKey},
+		},
+		gpg: map[string][]*github.GPGKey{"alice": {gpgKey}},
+	}
+
+	recordResults("github", "user", "acme", []string{"alice", "bob", "acme-bot", "ghost"})
+	enumerateUserKeys(client)
+
+	if got, want := resultNames("github", "ssh_key"), []string{"acme-bot: " + testSSHKeyFingerprint + " (ssh-ed25519)", "alice: " + testSSHKeyFingerprint + " (ssh-ed25519)"}; !equalStrings(got, want) {
+		t.Errorf("SSH keys = %v, want %v", got, want)
+	}
+	if got, want := resultNames("github", "gpg_key"), []string{"alice: 3262EEF8ABCD1234 (subkey 9F1E2D3C4B5A6978, alice@acme.com)"}; !equalStrings(got, want) {
+		t.Errorf("GPG keys = %v, want %v", got, want)
+	}
+	if got, want := readOutputLines(t, "github_shared_keys.txt"), []string{testSSHKeyFingerprint + ": acme-bot, alice"}; !equalStrings(got, want) {
+		t.Errorf("shared keys = %v, want %v", got, want)
+	}
+	for _, r := range collectedResults {
+		if r.Category == "gpg_key" && r.ID != "github:alice" {
+			t.Errorf("id = %q, want github:alice", r.ID)
+		}
+	}
+	if summary := searchErrorSummary(); len(summary) != 1 || summary[0].Query != "ghost" {
+		t.Errorf("errors = %+v, want ghost's SSH keys only", summary)
+	}
+}
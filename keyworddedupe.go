@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// dedupeKeywords drops the words that only differ from another word of the
+// set by case or Unicode normalization, which the platforms search the same
+// way, so the variants generated by cleaning, whitespace removal and
+// transliteration don't repeat queries. The lower-cased form is kept when
+// present, and the dropped words' tags and seeds move to the kept one. It
+// returns how many words it dropped.
+func dedupeKeywords(words map[string]struct{}) int {
+	groups := make(map[string][]string)
+	for word := range words {
+		key := normalizeName(word)
+		groups[key] = append(groups[key], word)
+	}
+
+	dropped := 0
+	for key, variants := range groups {
+		if len(variants) == 1 {
+			continue
+		}
+		sort.Strings(variants)
+		kept := variants[0]
+		if containsString(variants, key) {
+			kept = key
+		}
+		for _, variant := range variants {
+			if variant == kept {
+				continue
+			}
+			verbosePrint("Skipping '%s': duplicate of '%s'\n", variant, kept)
+			tagWord(kept, keywordTags[variant])
+			mergeKeywordSources(kept, variant)
+			delete(words, variant)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// reportKeywordCount prints how many keywords are searched, after
+// deduplication, on stderr.
+func reportKeywordCount(words map[string]struct{}, dropped int) {
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "Searching %d keywords (%d duplicates dropped)\n",
+			len(words), dropped)
+	} else {
+		fmt.Fprintf(os.Stderr, "Searching %d keywords\n", len(words))
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestDedupeKeywords(t *testing.T) {
+	setupRun(t, config{minWordLengthFlag: 2})
+
+	words := make(map[string]struct{})
+	processWord("Acme Corp#brand", words, flags)
+	processWord("acme corp", words, flags)
+	processWord("globex", words, flags)
+
+	if dropped := dedupeKeywords(words); dropped != 3 {
+		t.Errorf("dropped %d words, want 3", dropped)
+	}
+	if got, want := sortedWords(words), []string{"acme corp", "acme-corp", "acmecorp", "globex"}; !equalStrings(got, want) {
+		t.Errorf("words = %v, want %v", got, want)
+	}
+	if got := keywordTags["acmecorp"]; !equalStrings(got, []string{"brand"}) {
+		t.Errorf("tags of acmecorp = %v, want [brand]", got)
+	}
+
+	// Without case variants only one spelling exists, and it is kept.
+	words = map[string]struct{}{"ACME": {}, "Globex": {}}
+	if dropped := dedupeKeywords(words); dropped != 0 {
+		t.Errorf("dropped %d words, want 0", dropped)
+	}
+	if got, want := sortedWords(words), []string{"ACME", "Globex"}; !equalStrings(got, want) {
+		t.Errorf("words = %v, want %v", got, want)
+	}
+}
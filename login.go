@@ -0,0 +1,492 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// oauthProvider is a platform dorky logs in to with the OAuth device
+// authorization grant (RFC 8628): dorky shows a code, the user enters it in
+// a browser, where SSO and two-factor prompts happen as usual, and dorky
+// gets a token without anyone creating a personal access token.
+type oauthProvider struct {
+	Name      string
+	DeviceURL string
+	TokenURL  string
+	// Scopes are those asked for by default, enough for every search.
+	Scopes string
+	// TokenEnv is the variable taking precedence over a stored token.
+	TokenEnv string
+	// ClientIDEnv holds the client ID of the OAuth app to log in with,
+	// when -client-id doesn't give one.
+	ClientIDEnv string
+	// AppHelp tells how to register that app.
+	AppHelp string
+}
+
+// oauthProviders lists the platforms dorky login supports.
+var oauthProviders = []oauthProvider{
+	{
+		Name:        "github",
+		DeviceURL:   "https://github.com/login/device/code",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		Scopes:      "read:org read:packages",
+		TokenEnv:    "GITHUB_ACCESS_TOKEN",
+		ClientIDEnv: "DORKY_GITHUB_CLIENT_ID",
+		AppHelp:     "register an OAuth app at https://github.com/settings/applications/new with Enable Device Flow checked, or ask your organization for the one it approved",
+	},
+	{
+		Name:        "gitlab",
+		DeviceURL:   "https://gitlab.com/oauth/authorize_device",
+		TokenURL:    "https://gitlab.com/oauth/token",
+		Scopes:      "read_api",
+		TokenEnv:    "GITLAB_ACCESS_TOKEN",
+		ClientIDEnv: "DORKY_GITLAB_CLIENT_ID",
+		AppHelp:     "register an application at https://gitlab.com/-/user_settings/applications with the read_api scope and Confidential unchecked",
+	},
+}
+
+// deviceCodeGrant is the grant type of device flow token requests.
+const deviceCodeGrant = "urn:ietf:params:oauth:grant-type:device_code"
+
+// tokenRefreshMargin is how long before it expires a stored token is
+// refreshed, so it doesn't run out mid-run.
+const tokenRefreshMargin = 5 * time.Minute
+
+func lookupOAuthProvider(name string) (oauthProvider, bool) {
+	for _, p := range oauthProviders {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return oauthProvider{}, false
+}
+
+// storedToken is a token dorky login got, with what it takes to refresh
+// it. Tokens without an expiry, like those of GitHub OAuth apps, last until
+// they're revoked.
+type storedToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Scopes       string    `json:"scopes,omitempty"`
+	ClientID     string    `json:"client_id"`
+	LoggedIn     time.Time `json:"logged_in"`
+}
+
+// expired reports whether the token is due for a refresh at now.
+func (t storedToken) expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && now.Add(tokenRefreshMargin).After(t.ExpiresAt)
+}
+
+// credentialsFile holds the stored tokens by platform. It's readable by its
+// owner only, like an SSH key.
+type credentialsFile struct {
+	Tokens map[string]storedToken `json:"tokens"`
+}
+
+// credentialsPath is where dorky login stores tokens.
+func credentialsPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "credentials.json"), nil
+}
+
+func readCredentials(filename string) (*credentialsFile, error) {
+	creds := &credentialsFile{Tokens: make(map[string]storedToken)}
+	data, err := ioutil.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return creds, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, creds); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if creds.Tokens == nil {
+		creds.Tokens = make(map[string]storedToken)
+	}
+	return creds, nil
+}
+
+// writeCredentials replaces the credentials file, keeping it and its
+// directory private to the user.
+func writeCredentials(filename string, creds *credentialsFile) error {
+	data, err := json.MarshalIndent(creds, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	return writeFileAtomicallyMode(filename, append(data, '\n'), 0600)
+}
+
+// deviceAuthorization is the answer to a device code request.
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// tokenResponse is the answer to a token request: a token, or an error
+// code such as authorization_pending while the user hasn't entered the
+// code yet. GitHub answers errors with 200 OK, GitLab with 400.
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Scope            string `json:"scope"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// postOAuthForm posts form to an OAuth endpoint and decodes its JSON answer
+// into v, whatever the status, since OAuth errors come as JSON too.
+func postOAuthForm(client *http.Client, endpoint string, form url.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	}
+	return nil
+}
+
+// deviceLogin runs the device flow against p: it asks for a code, tells
+// the user on out where to enter it, and polls for the token, waiting with
+// wait between polls, until the user approves, denies or the code expires.
+func deviceLogin(client *http.Client, p oauthProvider, clientID, scopes string, out io.Writer, wait func(time.Duration), now func() time.Time) (storedToken, error) {
+	var auth deviceAuthorization
+	if err := postOAuthForm(client, p.DeviceURL, url.Values{"client_id": {clientID}, "scope": {scopes}}, &auth); err != nil {
+		return storedToken{}, fmt.Errorf("requesting a device code: %w", err)
+	}
+	if auth.DeviceCode == "" || auth.UserCode == "" {
+		return storedToken{}, fmt.Errorf("requesting a device code: no code in the answer, check the client ID and that the app allows the device flow")
+	}
+
+	fmt.Fprintf(out, "Open %s and enter the code %s\n", auth.VerificationURI, auth.UserCode)
+	if auth.VerificationURIComplete != "" {
+		fmt.Fprintf(out, "or open %s\n", auth.VerificationURIComplete)
+	}
+	fmt.Fprintln(out, "Waiting for authorization...")
+
+	interval := time.Duration(auth.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	deadline := now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+	form := url.Values{"client_id": {clientID}, "device_code": {auth.DeviceCode}, "grant_type": {deviceCodeGrant}}
+	for {
+		wait(interval)
+
+		var token tokenResponse
+		if err := postOAuthForm(client, p.TokenURL, form, &token); err != nil {
+			return storedToken{}, fmt.Errorf("polling for the token: %w", err)
+		}
+		switch token.Error {
+		case "":
+			if token.AccessToken == "" {
+				return storedToken{}, errors.New("polling for the token: no token in the answer")
+			}
+			return newStoredToken(token, clientID, scopes, now()), nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "expired_token":
+			return storedToken{}, errors.New("the code expired before it was entered, run dorky login again")
+		case "access_denied":
+			return storedToken{}, errors.New("authorization was denied")
+		default:
+			return storedToken{}, fmt.Errorf("polling for the token: %s %s", token.Error, token.ErrorDescription)
+		}
+		if auth.ExpiresIn > 0 && now().After(deadline) {
+			return storedToken{}, errors.New("the code expired before it was entered, run dorky login again")
+		}
+	}
+}
+
+// newStoredToken keeps the token of a token response received at now.
+func newStoredToken(token tokenResponse, clientID, scopes string, now time.Time) storedToken {
+	stored := storedToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Scopes:       scopes,
+		ClientID:     clientID,
+		LoggedIn:     now.UTC(),
+	}
+	if token.Scope != "" {
+		stored.Scopes = strings.Replace(token.Scope, ",", " ", -1)
+	}
+	if token.ExpiresIn > 0 {
+		stored.ExpiresAt = now.UTC().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return stored
+}
+
+// refreshToken trades the refresh token of an expired token for a new one.
+func refreshToken(client *http.Client, p oauthProvider, token storedToken, now time.Time) (storedToken, error) {
+	if token.RefreshToken == "" {
+		return storedToken{}, errors.New("the token expired and can't be refreshed, run dorky login again")
+	}
+
+	form := url.Values{"client_id": {token.ClientID}, "grant_type": {"refresh_token"}, "refresh_token": {token.RefreshToken}}
+	var answer tokenResponse
+	if err := postOAuthForm(client, p.TokenURL, form, &answer); err != nil {
+		return storedToken{}, fmt.Errorf("refreshing the token: %w", err)
+	}
+	if answer.Error != "" || answer.AccessToken == "" {
+		return storedToken{}, fmt.Errorf("refreshing the token: %s %s, run dorky login again", answer.Error, answer.ErrorDescription)
+	}
+
+	refreshed := newStoredToken(answer, token.ClientID, token.Scopes, now)
+	refreshed.LoggedIn = token.LoggedIn
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	return refreshed, nil
+}
+
+var (
+	platformTokensMu sync.Mutex
+	// platformTokens caches the token of each platform, stored tokens
+	// being refreshed once per process at most.
+	platformTokens = make(map[string]string)
+)
+
+// platformToken returns the token dorky authenticates to a platform with:
+// its environment variable, or else the token stored by dorky login,
+// refreshed first if it expired. It returns "" without either.
+func platformToken(name string) string {
+	p, _ := lookupOAuthProvider(name)
+	if token := os.Getenv(p.TokenEnv); token != "" {
+		return token
+	}
+
+	platformTokensMu.Lock()
+	defer platformTokensMu.Unlock()
+	if token, ok := platformTokens[name]; ok {
+		return token
+	}
+
+	token, err := loadStoredToken(http.DefaultClient, p, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: stored login unusable: %s\n", name, err)
+	}
+	platformTokens[name] = token
+	return token
+}
+
+// loadStoredToken returns the stored token of p, refreshing it and saving
+// the new one if it expired, or "" if there's none.
+func loadStoredToken(client *http.Client, p oauthProvider, now time.Time) (string, error) {
+	filename, err := credentialsPath()
+	if err != nil {
+		return "", err
+	}
+	creds, err := readCredentials(filename)
+	if err != nil {
+		return "", err
+	}
+	token, ok := creds.Tokens[p.Name]
+	if !ok {
+		return "", nil
+	}
+	if !token.expired(now) {
+		return token.AccessToken, nil
+	}
+
+	if token, err = refreshToken(client, p, token, now); err != nil {
+		return "", err
+	}
+	creds.Tokens[p.Name] = token
+	if err := writeCredentials(filename, creds); err != nil {
+		return "", fmt.Errorf("saving the refreshed token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// tokenOrigin describes where the token of a platform comes from, "" when
+// there's none, without refreshing anything.
+func tokenOrigin(name string) string {
+	p, _ := lookupOAuthProvider(name)
+	if os.Getenv(p.TokenEnv) != "" {
+		return p.TokenEnv + " set"
+	}
+	filename, err := credentialsPath()
+	if err != nil {
+		return ""
+	}
+	if creds, err := readCredentials(filename); err == nil {
+		if _, ok := creds.Tokens[name]; ok {
+			return "logged in with dorky login"
+		}
+	}
+	return ""
+}
+
+// resolvedTokens returns the stored tokens the process uses, for redaction.
+func resolvedTokens() []string {
+	platformTokensMu.Lock()
+	defer platformTokensMu.Unlock()
+
+	var tokens []string
+	for _, token := range platformTokens {
+		if token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+func runLoginCommand(args []string) {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	clientID := fs.String("client-id", "", "client ID of the OAuth app to log in with (default $DORKY_GITHUB_CLIENT_ID or $DORKY_GITLAB_CLIENT_ID)")
+	scopes := fs.String("scopes", "", "space-separated scopes to ask for (default read:org read:packages on GitHub, read_api on GitLab)")
+	status := fs.Bool("status", false, "list the stored logins")
+	logout := fs.Bool("logout", false, "forget the stored token of the platform")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dorky login [-client-id id] [-scopes scopes] [github|gitlab]")
+		fmt.Fprintln(fs.Output(), "       dorky login -status")
+		fmt.Fprintln(fs.Output(), "       dorky login -logout [github|gitlab]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	filename, err := credentialsPath()
+	if err != nil {
+		fmt.Printf("Error locating the configuration directory: %s\n", err)
+		os.Exit(1)
+	}
+	creds, err := readCredentials(filename)
+	if err != nil {
+		fmt.Printf("Error reading stored logins: %s\n", err)
+		os.Exit(1)
+	}
+
+	if *status {
+		printLogins(os.Stdout, creds, time.Now())
+		return
+	}
+
+	name := "github"
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if fs.NArg() == 1 {
+		name = strings.ToLower(fs.Arg(0))
+	}
+	p, ok := lookupOAuthProvider(name)
+	if !ok {
+		fmt.Printf("Error: unknown platform %q (supported: github, gitlab)\n", name)
+		os.Exit(1)
+	}
+
+	if *logout {
+		if _, ok := creds.Tokens[name]; !ok {
+			fmt.Printf("Not logged in to %s\n", name)
+			return
+		}
+		delete(creds.Tokens, name)
+		if err := writeCredentials(filename, creds); err != nil {
+			fmt.Printf("Error saving stored logins: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Forgot the %s token. It still works until it expires or is revoked in the platform's settings.\n", name)
+		return
+	}
+
+	id := *clientID
+	if id == "" {
+		id = os.Getenv(p.ClientIDEnv)
+	}
+	if id == "" {
+		fmt.Printf("Error: dorky login needs the client ID of an OAuth app, from -client-id or %s: %s\n", p.ClientIDEnv, p.AppHelp)
+		os.Exit(1)
+	}
+	if *scopes == "" {
+		*scopes = p.Scopes
+	}
+
+	token, err := deviceLogin(http.DefaultClient, p, id, *scopes, os.Stdout, time.Sleep, time.Now)
+	if err != nil {
+		fmt.Printf("Error logging in to %s: %s\n", name, err)
+		os.Exit(1)
+	}
+	creds.Tokens[name] = token
+	if err := writeCredentials(filename, creds); err != nil {
+		fmt.Printf("Error saving the token: %s\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Logged in to %s, token saved to %s", name, filename)
+	if !token.ExpiresAt.IsZero() {
+		fmt.Printf(", refreshed as it expires")
+	}
+	fmt.Println()
+	if os.Getenv(p.TokenEnv) != "" {
+		fmt.Printf("%s is set and takes precedence: unset it to use the login\n", p.TokenEnv)
+	}
+}
+
+// printLogins lists the stored logins, with when they were made and when
+// their tokens expire.
+func printLogins(w io.Writer, creds *credentialsFile, now time.Time) {
+	if len(creds.Tokens) == 0 {
+		fmt.Fprintln(w, "No stored logins")
+		return
+	}
+
+	names := make([]string, 0, len(creds.Tokens))
+	for name := range creds.Tokens {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		token := creds.Tokens[name]
+		fmt.Fprintf(w, "%s: logged in %s, scopes %q", name, token.LoggedIn.Format("2006-01-02"), token.Scopes)
+		switch {
+		case token.ExpiresAt.IsZero():
+			fmt.Fprint(w, ", no expiry")
+		case token.expired(now) && token.RefreshToken == "":
+			fmt.Fprint(w, ", expired")
+		case token.expired(now):
+			fmt.Fprint(w, ", expired, refreshed on next use")
+		default:
+			fmt.Fprintf(w, ", expires %s", token.ExpiresAt.Format(time.RFC3339))
+		}
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeOAuthServer answers device flow requests, returning the answers to
+// token requests in turn, with the statuses GitLab uses.
+func fakeOAuthServer(t *testing.T, tokenAnswers ...string) (oauthProvider, *[]string) {
+	t.Helper()
+	var forms []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		forms = append(forms, r.URL.Path+" "+r.PostForm.Encode())
+		switch r.URL.Path {
+		case "/device":
+			fmt.Fprint(w, `{"device_code": "dev-123", "user_code": "ABCD-1234", "verification_uri": "https://example.com/device", "expires_in": 900, "interval": 1}`)
+		case "/token":
+			answer := tokenAnswers[0]
+			tokenAnswers = tokenAnswers[1:]
+			if strings.Contains(answer, `"error"`) {
+				w.WriteHeader(http.StatusBadRequest)
+			}
+			fmt.Fprint(w, answer)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return oauthProvider{Name: "gitlab", DeviceURL: srv.URL + "/device", TokenURL: srv.URL + "/token", TokenEnv: "GITLAB_ACCESS_TOKEN"}, &forms
+}
+
+func TestDeviceLogin(t *testing.T) {
+	p, forms := fakeOAuthServer(t,
+		`{"error": "authorization_pending"}`,
+		`{"error": "slow_down"}`,
+		`{"access_token": "glpat-new", "refresh_token": "refresh-1", "expires_in": 7200, "scope": "read_api"}`,
+	)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	var waits []time.Duration
+	var out bytes.Buffer
+
+	token, err := deviceLogin(http.DefaultClient, p, "client-1", "read_api", &out, func(d time.Duration) { waits = append(waits, d) }, func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := storedToken{AccessToken: "glpat-new", RefreshToken: "refresh-1", ExpiresAt: now.Add(2 * time.Hour), Scopes: "read_api", ClientID: "client-1", LoggedIn: now}
+	if token != want {
+		t.Errorf("token = %+v, want %+v", token, want)
+	}
+	if got := fmt.Sprint(waits); got != "[1s 1s 6s]" {
+		t.Errorf("waits = %s, want [1s 1s 6s]: slow_down adds 5s", got)
+	}
+	if !strings.Contains(out.String(), "Open https://example.com/device and enter the code ABCD-1234") {
+		t.Errorf("output = %q, want the code and where to enter it", out.String())
+	}
+	if (*forms)[1] != "/token client_id=client-1&device_code=dev-123&grant_type=urn%3Aietf%3Aparams%3Aoauth%3Agrant-type%3Adevice_code" {
+		t.Errorf("token request = %s", (*forms)[1])
+	}
+}
+
+func TestDeviceLoginDenied(t *testing.T) {
+	p, _ := fakeOAuthServer(t, `{"error": "access_denied"}`)
+	_, err := deviceLogin(http.DefaultClient, p, "client-1", "read_api", &bytes.Buffer{}, func(time.Duration) {}, time.Now)
+	if err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("err = %v, want a denial", err)
+	}
+}
+
+func TestLoadStoredTokenRefreshes(t *testing.T) {
+	dir := t.TempDir()
+	setenv(t, "DORKY_CONFIG_DIR", dir)
+	p, forms := fakeOAuthServer(t, `{"access_token": "glpat-refreshed", "refresh_token": "refresh-2", "expires_in": 7200}`)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	filename := filepath.Join(dir, "credentials.json")
+	loggedIn := now.Add(-3 * time.Hour)
+	creds := &credentialsFile{Tokens: map[string]storedToken{
+		"gitlab": {AccessToken: "glpat-old", RefreshToken: "refresh-1", ExpiresAt: now.Add(-time.Hour), Scopes: "read_api", ClientID: "client-1", LoggedIn: loggedIn},
+	}}
+	if err := writeCredentials(filename, creds); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := loadStoredToken(http.DefaultClient, p, now)
+	if err != nil || token != "glpat-refreshed" {
+		t.Fatalf("loadStoredToken = %q, %v, want glpat-refreshed", token, err)
+	}
+	if (*forms)[0] != "/token client_id=client-1&grant_type=refresh_token&refresh_token=refresh-1" {
+		t.Errorf("refresh request = %s", (*forms)[0])
+	}
+
+	saved, err := readCredentials(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := storedToken{AccessToken: "glpat-refreshed", RefreshToken: "refresh-2", ExpiresAt: now.Add(2 * time.Hour), Scopes: "read_api", ClientID: "client-1", LoggedIn: loggedIn}
+	if got := saved.Tokens["gitlab"]; got != want {
+		t.Errorf("saved token = %+v, want %+v", got, want)
+	}
+	if info, err := os.Stat(filename); err != nil || runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("credentials file mode = %v, %v, want 0600", info.Mode().Perm(), err)
+	}
+
+	// A token still valid is used as is.
+	if token, err := loadStoredToken(http.DefaultClient, p, now); err != nil || token != "glpat-refreshed" || len(*forms) != 1 {
+		t.Errorf("loadStoredToken = %q, %v after %d requests, want glpat-refreshed without a request", token, err, len(*forms))
+	}
+}
+
+func TestTokenOrigin(t *testing.T) {
+	setenv(t, "DORKY_CONFIG_DIR", t.TempDir())
+	setenv(t, "GITHUB_ACCESS_TOKEN", "")
+	if got := tokenOrigin("github"); got != "" {
+		t.Errorf("tokenOrigin without a token = %q, want none", got)
+	}
+
+	filename, _ := credentialsPath()
+	if err := writeCredentials(filename, &credentialsFile{Tokens: map[string]storedToken{"github": {AccessToken: "gho_stored"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := tokenOrigin("github"); got != "logged in with dorky login" {
+		t.Errorf("tokenOrigin with a login = %q", got)
+	}
+
+	setenv(t, "GITHUB_ACCESS_TOKEN", "ghp_env")
+	if got := tokenOrigin("github"); got != "GITHUB_ACCESS_TOKEN set" {
+		t.Errorf("tokenOrigin with the variable = %q, want it to take precedence", got)
+	}
+	if got := platformToken("github"); got != "ghp_env" {
+		t.Errorf("platformToken = %q, want ghp_env", got)
+	}
+}
+
+func TestPrintLogins(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	creds := &credentialsFile{Tokens: map[string]storedToken{
+		"github": {AccessToken: "gho_1", Scopes: "read:org", LoggedIn: now},
+		"gitlab": {AccessToken: "gl_1", RefreshToken: "r", Scopes: "read_api", LoggedIn: now, ExpiresAt: now.Add(-time.Minute)},
+	}}
+	var out bytes.Buffer
+	printLogins(&out, creds, now)
+
+	want := "github: logged in 2024-03-01, scopes \"read:org\", no expiry\n" +
+		"gitlab: logged in 2024-03-01, scopes \"read_api\", expired, refreshed on next use\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
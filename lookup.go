@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// lookupTarget is an identifier given to `dorky lookup`: a namespace or
+// repository on a platform.
+type lookupTarget struct {
+	Platform string
+	Path     string
+}
+
+func (t lookupTarget) String() string {
+	return lookupHosts[t.Platform] + "/" + t.Path
+}
+
+// lookupHosts maps the platforms lookup supports to their hosts.
+var lookupHosts = map[string]string{"github": "github.com", "gitlab": "gitlab.com"}
+
+// parseLookupTarget parses identifiers such as github.com/acme,
+// https://github.com/acme/web.git or gitlab.com/acme/platform/api.
+func parseLookupTarget(arg string) (lookupTarget, error) {
+	id := strings.TrimSpace(arg)
+	for _, prefix := range []string{"https://", "http://"} {
+		id = strings.TrimPrefix(id, prefix)
+	}
+	id = strings.TrimSuffix(strings.TrimSuffix(id, "/"), ".git")
+
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return lookupTarget{}, fmt.Errorf("%q: expected host/namespace or host/namespace/repository", arg)
+	}
+
+	var target lookupTarget
+	for platform, host := range lookupHosts {
+		if strings.EqualFold(parts[0], host) || strings.EqualFold(parts[0], "www."+host) {
+			target = lookupTarget{Platform: platform, Path: parts[1]}
+		}
+	}
+	switch {
+	case target.Platform == "":
+		return lookupTarget{}, fmt.Errorf("%q: unsupported host %q (use github.com or gitlab.com)", arg, parts[0])
+	case target.Platform == "github" && strings.Count(target.Path, "/") > 1:
+		return lookupTarget{}, fmt.Errorf("%q: GitHub identifiers are owner or owner/repository", arg)
+	}
+	return target, nil
+}
+
+// runLookupCommand implements `dorky lookup github.com/acme gitlab.com/acme`:
+// without searching anything, it reports the details of the given
+// namespaces and repositories, then runs the usual enrichment and exports
+// on them.
+func runLookupCommand(args []string) {
+	fs := flag.NewFlagSet("lookup", flag.ExitOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dorky lookup [flags] github.com/acme gitlab.com/acme-group github.com/acme/web ...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var targets []lookupTarget
+	for _, arg := range fs.Args() {
+		target, err := parseLookupTarget(arg)
+		if err != nil {
+			fmt.Printf("Error %s\n", err)
+			os.Exit(1)
+		}
+		targets = append(targets, target)
+	}
+	if flags.workspace != "" {
+		if err := applyWorkspace(fs, flags.workspace); err != nil {
+			fmt.Printf("Error %s\n", err)
+			os.Exit(1)
+		}
+	}
+	loadRulesFlag(flags)
+	validateOutputFlags(flags)
+
+	var ghClient *github.Client
+	var glClient *gitlab.Client
+	for _, target := range targets {
+		switch {
+		case target.Platform == "github" && ghClient == nil:
+			httpClient, err := createGitHubHTTPClient()
+			if err != nil {
+				fmt.Printf("Error creating GitHub client: %s\n", err)
+				os.Exit(1)
+			}
+			ghClient = github.NewClient(httpClient)
+		case target.Platform == "gitlab" && glClient == nil:
+			client, err := createGitLabClient()
+			if err != nil {
+				fmt.Printf("Error creating GitLab client: %s\n", err)
+				os.Exit(1)
+			}
+			glClient = client
+		}
+	}
+
+	err := runAndExport(flags, func() {
+		lookupTargets(ghClient, glClient, targets, flags)
+	})
+	if err != nil {
+		fmt.Printf("Error %s\n", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// lookupTargets reports each target as the result it would be found as,
+// with a line of details, then enriches the results like a search would,
+// matching stars against the namespace names.
+func lookupTargets(ghClient *github.Client, glClient *gitlab.Client, targets []lookupTarget, cfg config) {
+	words := make(map[string]struct{})
+	var details []string
+	for _, target := range targets {
+		verbosePrint("Looking up %s\n", target)
+		words[strings.SplitN(target.Path, "/", 2)[0]] = struct{}{}
+
+		var line string
+		var ok bool
+		if target.Platform == "github" {
+			line, ok = lookupGitHub(ghClient, target)
+		} else {
+			line, ok = lookupGitLab(glClient, target)
+		}
+		if ok {
+			details = append(details, target.String()+": "+line)
+		}
+	}
+
+	printResults(os.Stdout, resultBatch{Platform: "all", Category: "lookup_report", Header: "Lookup details", Results: details})
+	saveResults("lookup.txt", details)
+
+	enrichResults(ghClient, glClient, words, cfg)
+}
+
+func lookupGitHub(client *github.Client, target lookupTarget) (string, bool) {
+	ctx := context.Background()
+	query := target.String()
+
+	if owner, name, isRepo := cutPath(target.Path); isRepo {
+		repo, _, err := client.Repositories.Get(ctx, owner, name)
+		if err != nil {
+			recordSearchError("github", "repository lookup", query, err)
+			return "", false
+		}
+		recordRepoTerms(repo.GetFullName(), repo.Topics, repo.GetDescription())
+		emitResults("github", "repository", query, fmt.Sprintf("GitHub repository '%s'", query), "github_repositories.txt", []string{repo.GetFullName()})
+		return describeGitHubRepo(repo), true
+	}
+
+	account, _, err := client.Users.Get(ctx, target.Path)
+	if err != nil {
+		recordSearchError("github", "account lookup", query, err)
+		return "", false
+	}
+	recordAvatar("github", account.GetLogin(), account.GetAvatarURL())
+	category, filename := "user", "github_users.txt"
+	if account.GetType() == "Organization" {
+		category, filename = "organization", "github_organizations.txt"
+	}
+	emitResults("github", category, query, fmt.Sprintf("GitHub %s '%s'", category, query), filename, []string{account.GetLogin()})
+	return describeGitHubAccount(category, account), true
+}
+
+func describeGitHubAccount(category string, account *github.User) string {
+	parts := []string{category, fmt.Sprintf("%d public repos", account.GetPublicRepos()), fmt.Sprintf("%d followers", account.GetFollowers())}
+	if !account.GetCreatedAt().IsZero() {
+		parts = append(parts, "created "+account.GetCreatedAt().UTC().Format("2006-01-02"))
+	}
+	for _, field := range []struct{ label, value string }{{"name", account.GetName()}, {"blog", account.GetBlog()}, {"location", account.GetLocation()}} {
+		if field.value != "" {
+			parts = append(parts, field.label+" "+field.value)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func describeGitHubRepo(repo *github.Repository) string {
+	visibility := "public"
+	if repo.GetPrivate() {
+		visibility = "private"
+	}
+	parts := []string{visibility + " repository", fmt.Sprintf("%d stars", repo.GetStargazersCount())}
+	if repo.GetFork() {
+		parts = append(parts, "fork")
+	}
+	if repo.GetArchived() {
+		parts = append(parts, "archived")
+	}
+	if !repo.GetPushedAt().IsZero() {
+		parts = append(parts, "pushed "+repo.GetPushedAt().UTC().Format("2006-01-02"))
+	}
+	if repo.GetDescription() != "" {
+		parts = append(parts, repo.GetDescription())
+	}
+	return strings.Join(parts, ", ")
+}
+
+// lookupGitLab looks target up as a project, then as a group, then as a
+// user: GitLab paths don't tell a subgroup from a project.
+func lookupGitLab(client *gitlab.Client, target lookupTarget) (string, bool) {
+	query := target.String()
+
+	if strings.Contains(target.Path, "/") {
+		project, _, err := client.Projects.GetProject(target.Path, nil)
+		if err == nil {
+			recordGitLabProject(project)
+			emitResults("gitlab", "project", query, fmt.Sprintf("GitLab project '%s'", query), "gitlab_projects.txt", []string{project.PathWithNamespace})
+			return "project, " + lookupProjectDetails("gitlab", "project", project.PathWithNamespace).String(), true
+		}
+		if classifyError(err) != "not_found" {
+			recordSearchError("gitlab", "project lookup", query, err)
+			return "", false
+		}
+	}
+
+	group, _, err := client.Groups.GetGroup(target.Path)
+	if err == nil {
+		recordAvatar("gitlab", group.FullPath, group.AvatarURL)
+		emitResults("gitlab", "group", query, fmt.Sprintf("GitLab group '%s'", query), "gitlab_groups.txt", []string{group.FullPath})
+		return fmt.Sprintf("group, %s, %s", group.Visibility, group.WebURL), true
+	}
+	if classifyError(err) != "not_found" || strings.Contains(target.Path, "/") {
+		recordSearchError("gitlab", "group lookup", query, err)
+		return "", false
+	}
+
+	users, _, err := client.Users.ListUsers(&gitlab.ListUsersOptions{Username: gitlab.String(target.Path)})
+	if err != nil {
+		recordSearchError("gitlab", "user lookup", query, err)
+		return "", false
+	}
+	if len(users) == 0 {
+		recordSearchError("gitlab", "user lookup", query, &httpStatusError{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: []byte("no group or user " + target.Path)})
+		return "", false
+	}
+	recordAvatar("gitlab", users[0].Username, users[0].AvatarURL)
+	emitResults("gitlab", "user", query, fmt.Sprintf("GitLab user '%s'", query), "gitlab_users.txt", []string{users[0].Username})
+	return fmt.Sprintf("user, name %s, %s", users[0].Name, users[0].WebURL), true
+}
+
+// cutPath splits owner/name, reporting whether path had both.
+func cutPath(path string) (string, string, bool) {
+	parts := strings.SplitN(path, "/", 2)
+	if len(parts) != 2 {
+		return path, "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,76 @@
+package main
+
+import "testing"
+
+func TestParseLookupTarget(t *testing.T) {
+	tests := map[string]lookupTarget{
+		"github.com/acme":                   {"github", "acme"},
+		"https://github.com/acme/web.git":   {"github", "acme/web"},
+		"gitlab.com/acme/platform/api/":     {"gitlab", "acme/platform/api"},
+		"https://www.gitlab.com/acme-group": {"gitlab", "acme-group"},
+	}
+	for arg, want := range tests {
+		if got, err := parseLookupTarget(arg); err != nil || got != want {
+			t.Errorf("parseLookupTarget(%q) = %+v, %v, want %+v", arg, got, err, want)
+		}
+	}
+
+	for _, arg := range []string{"acme", "github.com/", "bitbucket.org/acme", "github.com/acme/web/tree"} {
+		if _, err := parseLookupTarget(arg); err == nil {
+			t.Errorf("parseLookupTarget(%q): expected an error", arg)
+		}
+	}
+}
+
+func TestLookupTargets(t *testing.T) {
+	setupRun(t, config{})
+
+	ghSrv, _ := newFakeAPI(t, map[string]interface{}{
+		"/users/acme":     map[string]interface{}{"login": "acme", "type": "Organization", "public_repos": 12, "followers": 3, "blog": "https://acme.com"},
+		"/repos/acme/web": map[string]interface{}{"full_name": "acme/web", "stargazers_count": 5, "archived": true},
+	})
+	glSrv, _ := newFakeAPI(t, map[string]interface{}{
+		"/api/v4/projects/acme/api": map[string]interface{}{"path_with_namespace": "acme/api", "visibility": "public", "issues_enabled": true},
+		"/api/v4/groups/acme/ops":   map[string]interface{}{"full_path": "acme/ops", "visibility": "internal", "web_url": "https://gitlab.com/groups/acme/ops"},
+		"/api/v4/users":             []map[string]interface{}{{"username": "bob", "name": "Bob", "web_url": "https://gitlab.com/bob"}},
+	})
+
+	var targets []lookupTarget
+	for _, arg := range []string{"github.com/acme", "github.com/acme/web", "gitlab.com/acme/api", "gitlab.com/acme/ops", "gitlab.com/bob", "github.com/missing"} {
+		target, err := parseLookupTarget(arg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		targets = append(targets, target)
+	}
+	lookupTargets(newFakeGitHubClient(t, ghSrv), newFakeGitLabClient(t, glSrv), targets, flags)
+
+	for _, tt := range []struct {
+		platform, category string
+		want               []string
+	}{
+		{"github", "organization", []string{"acme"}},
+		{"github", "repository", []string{"acme/web"}},
+		{"gitlab", "project", []string{"acme/api"}},
+		{"gitlab", "group", []string{"acme/ops"}},
+		{"gitlab", "user", []string{"bob"}},
+	} {
+		if got := resultNames(tt.platform, tt.category); !equalStrings(got, tt.want) {
+			t.Errorf("%s %s = %v, want %v", tt.platform, tt.category, got, tt.want)
+		}
+	}
+
+	want := []string{
+		"github.com/acme: organization, 12 public repos, 3 followers, blog https://acme.com",
+		"github.com/acme/web: public repository, 5 stars, archived",
+		"gitlab.com/acme/api: project, public; issues",
+		"gitlab.com/acme/ops: group, internal, https://gitlab.com/groups/acme/ops",
+		"gitlab.com/bob: user, name Bob, https://gitlab.com/bob",
+	}
+	if got := readOutputLines(t, "lookup.txt"); !equalStrings(got, want) {
+		t.Errorf("lookup.txt = %q, want %q", got, want)
+	}
+	if failedOperations() != 1 {
+		t.Errorf("failed operations = %d, want 1 for github.com/missing", failedOperations())
+	}
+}
@@ -6,10 +6,16 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/go-github/v38/github"
 	"github.com/xanzy/go-gitlab"
@@ -18,54 +24,693 @@ import (
 )
 
 type config struct {
-	orgFlag      bool
-	repoFlag     bool
-	userFlag     bool
-	maxFlag      int
-	cleanFlag    bool
-	ghOnlyFlag   bool
-	glOnlyFlag   bool
-	simpleFlag   bool
-	verboseFlag  bool
+	orgFlag          bool
+	repoFlag         bool
+	userFlag         bool
+	maxFlag          int
+	forceMaxFlag     bool
+	exhaustiveFlag   bool
+	maxTotalFlag     int
+	cleanFlag        bool
+	ghOnlyFlag       bool
+	glOnlyFlag       bool
+	simpleFlag       bool
+	formatFlag       string
+	templateFlag     string
+	allFlag          bool
+	delimiterFlag    string
+	nullFlag         bool
+	prefixFlag       string
+	withKeyword      bool
+	ndjsonFlag       string
+	verboseFlag      bool
+	debugHTTPFlag    string
+	requestTagFlag   string
+	esURLFlag        string
+	esIndexFlag      string
+	pgDSNFlag        string
+	jsonFlag         string
+	sarifFlag        string
+	uploadFlag       string
+	workspace        string
+	outputDirFlag    string
+	categoriesFlag   string
+	targetsFlag      string
+	stdioFlag        bool
+	githubActionFlag bool
+	versionFlag      bool
+	ghAPIFlag        string
+	glSearch         string
+	glTopLevelFlag   bool
+	bbURLFlag        string
+	pluginsFlag      string
+	pastesFlag       bool
+	pastesURL        string
+	stackFlag        bool
+	stackSite        string
+	stateFlag        string
+	rulesFlag        string
+	pruneAfterFlag   string
+	maxRuntimeFlag   time.Duration
+	maxErrorsFlag    string
+	fdOrgFlag        string
+	fdRepoFlag       string
+	fdUserFlag       string
+	keywords         string
+
+	showFalsePositivesFlag bool
+
+	stopWordsFlag     string
+	minWordLengthFlag int
+	transliterateFlag bool
+	generatorsFlag    string
+	confirmFlag       bool
+	concurrencyFlag   int
+	orBatchFlag       int
+
+	discussionsFlag bool
+	wikiFlag        bool
+	releasesFlag    bool
+	orgRollupFlag   bool
+	membershipsFlag bool
+	keysFlag        bool
+	starsFlag       bool
+	collabFlag      bool
+	ciConfigsFlag   bool
+	iacFlag         bool
+	iacSearchFlag   bool
+	employeePattern string
+	relatedFlag     bool
+	urlsFlag        bool
+	fundingFlag     bool
+
+	recurseFlag         bool
+	recurseSearchFlag   bool
+	recurseMinReposFlag int
+
+	checkAvailabilityFlag bool
+	ownedFlag             string
+	targetDomainFlag      string
+	impersonationFlag     bool
+	avatarsFlag           bool
+	locationFlag          string
+	bioContainsFlag       string
+	stripPrefixesFlag     string
+	domainFlag            string
+	ctURLFlag             string
+	fromSubfinderFlag     string
+	fromBrowserFlag       string
+
+	// platforms lists the platforms a -stdio scan is limited to, comma
+	// separated, "" for every platform the flags enable.
+	platforms string
 }
 
 var (
 	flags       = config{}
-	urlRegexp   = regexp.MustCompile(`^https?://(?:www\.)?([^/]+)`)
 	spaceRegexp = regexp.MustCompile(`\s+`)
 )
 
+// defaultStopWords are tokens that match huge numbers of unrelated accounts
+// and repositories, mostly TLDs and legal suffixes left over by cleaning.
+const defaultStopWords = "com,net,org,io,co,uk,www,http,https,the,and,of,inc,ltd,llc"
+
 func init() {
 	flag.BoolVar(&flags.orgFlag, "o", false, "search for organization names")
 	flag.BoolVar(&flags.repoFlag, "r", false, "search for repository names")
 	flag.BoolVar(&flags.userFlag, "u", false, "search for username matches")
-	flag.IntVar(&flags.maxFlag, "max", 10, "maximum search results per category")
+	flag.BoolVar(&flags.discussionsFlag, "d", false, "search GitHub Discussions and report the hosting repositories")
+	flag.BoolVar(&flags.wikiFlag, "w", false, "search wiki content (GitHub code search, GitLab wiki_blobs scope)")
+	flag.StringVar(&flags.categoriesFlag, "categories", "", "comma-separated categories to search (org, repo, user, discussions, wiki, pastes, stackoverflow), on top of -o, -r, -u and the like")
+	flag.BoolVar(&flags.releasesFlag, "releases", false, "enumerate releases and assets of discovered repositories")
+	flag.BoolVar(&flags.membershipsFlag, "memberships", false, "list public org memberships of discovered GitHub users, flagging members of target orgs")
+	flag.BoolVar(&flags.keysFlag, "keys", false, "list the public SSH and GPG keys of discovered GitHub users by fingerprint, flagging keys shared between them")
+	flag.BoolVar(&flags.starsFlag, "stars", false, "report repositories matching the keywords that discovered GitHub users star or watch")
+	flag.BoolVar(&flags.recurseFlag, "recurse", false, "extract candidate keywords from the topics and descriptions of discovered GitHub repositories")
+	flag.BoolVar(&flags.recurseSearchFlag, "recurse-search", false, "search the keywords extracted by -recurse in a second pass")
+	flag.IntVar(&flags.recurseMinReposFlag, "recurse-min-repos", 2, "minimum number of discovered repositories a -recurse keyword must appear in")
+	flag.BoolVar(&flags.ciConfigsFlag, "ci-configs", false, "flag discovered repositories with CI configuration and report the hosts, registries and secret names it references")
+	flag.BoolVar(&flags.iacFlag, "iac", false, "generate IaC code search dorks (Terraform, Serverless, Kubernetes) scoped to discovered GitHub orgs and users")
+	flag.BoolVar(&flags.iacSearchFlag, "iac-search", false, "run the -iac dorks and tag the files found by the providers and endpoints they configure")
+	flag.BoolVar(&flags.collabFlag, "collaborators", false, "list outside collaborators of the public repositories of discovered GitHub organizations the token has access to")
+	flag.StringVar(&flags.employeePattern, "employee-pattern", "", "with -collaborators, flag outside collaborators whose login doesn't match this regular expression")
+	flag.BoolVar(&flags.relatedFlag, "related", false, "suggest organizations related to the -owned GitHub organizations, from their forks, members and pinned repositories, as seeds for the next run")
+	flag.BoolVar(&flags.urlsFlag, "urls", false, "save the homepages of discovered repositories, organizations and users to urls.txt for web tooling such as httpx")
+	flag.BoolVar(&flags.fundingFlag, "funding", false, "read the FUNDING.yml of discovered GitHub repositories and report the sponsored accounts and their Patreon, Open Collective and other funding accounts")
+	flag.BoolVar(&flags.orgRollupFlag, "org-rollup", false, "summarize languages, repositories and contributors of discovered GitHub organizations")
+	flag.BoolVar(&flags.checkAvailabilityFlag, "check-availability", false, "report whether each keyword is available as an org/user/group name")
+	flag.BoolVar(&flags.impersonationFlag, "impersonation", false, "rank third-party held lookalike namespaces of each keyword by impersonation risk")
+	flag.BoolVar(&flags.avatarsFlag, "avatars", false, "flag matched accounts sharing identical avatars")
+	flag.StringVar(&flags.ownedFlag, "owned", "", "comma-separated namespaces known to belong to the target")
+	flag.StringVar(&flags.targetDomainFlag, "target-domain", "", "comma-separated domains whose profiles are considered the target's")
+	flag.StringVar(&flags.locationFlag, "location", "", "only report users whose profile location contains this text")
+	flag.StringVar(&flags.bioContainsFlag, "bio-contains", "", "only report users whose profile bio contains this text")
+	flag.IntVar(&flags.maxFlag, "max", 10, "maximum search results per category, up to 1000 (above 100, only GitHub organization, repository and user searches fetch more than one page)")
+	flag.BoolVar(&flags.exhaustiveFlag, "exhaustive", false, "fetch every GitHub repository matching each keyword, ignoring -max, by slicing broad searches by creation date")
+	flag.BoolVar(&flags.forceMaxFlag, "force-max", false, "allow -max above 1000 by slicing GitHub searches by creation date, at the cost of many more requests")
+	flag.IntVar(&flags.maxTotalFlag, "max-total", 0, "maximum search results of the whole run, shared fairly among keywords (0 for no limit)")
 	flag.BoolVar(&flags.cleanFlag, "c", false, "clean input URLs")
+	flag.StringVar(&flags.stripPrefixesFlag, "strip-prefixes", "www,app,api,portal,mail", "comma-separated generic hostname prefixes stripped when cleaning URLs")
+	flag.StringVar(&flags.domainFlag, "domain", "", "comma-separated domains whose hostnames in certificate transparency logs seed the keywords, instead of stdin")
+	flag.StringVar(&flags.ctURLFlag, "ct-url", defaultCTURL, "crt.sh-style certificate transparency search used by -domain")
+	flag.StringVar(&flags.fromBrowserFlag, "from-browser", "", "HAR file or bookmarks export whose hostnames seed the keywords, instead of stdin (only those under -target-domain, if given)")
+	flag.StringVar(&flags.fromSubfinderFlag, "from-subfinder", "", "subfinder (-oJ) or amass (-json) output whose subdomains seed the keywords, instead of stdin")
+	flag.StringVar(&flags.stopWordsFlag, "stop-words", defaultStopWords, "comma-separated words never searched for, such as TLDs left over by cleaning")
+	flag.IntVar(&flags.minWordLengthFlag, "min-word-length", 2, "minimum length of a word to search for")
+	flag.StringVar(&flags.generatorsFlag, "generators", "", "comma-separated keyword generators chained over each keyword, in order: "+supportedGenerators()+" (-c adds domain first, -transliterate adds transliterate last)")
+	flag.BoolVar(&flags.transliterateFlag, "transliterate", false, "also search ASCII transliterations of non-ASCII keywords (accented Latin, Cyrillic, Greek)")
 	flag.BoolVar(&flags.ghOnlyFlag, "gh", false, "search only GitHub")
+	flag.StringVar(&flags.ghAPIFlag, "gh-api", "rest", "GitHub API backend to search with (rest or graphql)")
 	flag.BoolVar(&flags.glOnlyFlag, "gl", false, "search only GitLab")
-	flag.BoolVar(&flags.simpleFlag, "s", false, "simple output style for piping to another tool")
+	flag.StringVar(&flags.glSearch, "gl-search", "", "comma-separated GitLab search scopes (projects, blobs, commits, milestones, wiki_blobs)")
+	flag.BoolVar(&flags.glTopLevelFlag, "gl-top-level", false, "only report top-level GitLab groups, not subgroups such as company/team/subteam")
+	flag.StringVar(&flags.bbURLFlag, "bb-url", "", "base URL of a Bitbucket Data Center instance to also search")
+	flag.BoolVar(&flags.pastesFlag, "pastes", false, "search a public paste index for the keywords and report paste URLs")
+	flag.StringVar(&flags.pastesURL, "pastes-url", defaultPastesURL, "search endpoint of the psbdmp-style paste index used by -pastes")
+	flag.BoolVar(&flags.stackFlag, "stackoverflow", false, "search Stack Overflow questions and users for the keywords and -target-domain emails")
+	flag.StringVar(&flags.stackSite, "se-site", "stackoverflow", "Stack Exchange site searched by -stackoverflow")
+	flag.StringVar(&flags.pluginsFlag, "plugins", "", "comma-separated plugins to run (default: every plugin installed)")
+	flag.BoolVar(&flags.simpleFlag, "s", false, "simple output style for piping to another tool (same as -format simple)")
+	flag.StringVar(&flags.formatFlag, "format", "text", "console output format (text, simple, json, csv or template)")
+	flag.StringVar(&flags.templateFlag, "template", "", "Go template printed for each result with -format template")
+	flag.BoolVar(&flags.allFlag, "all", false, "with text output, also show the low-confidence accounts and repositories, whose names don't contain the keyword")
+	flag.StringVar(&flags.delimiterFlag, "delimiter", "", "with simple output, end each result with this string instead of a newline (escapes such as \\t are understood)")
+	flag.BoolVar(&flags.nullFlag, "null", false, "with simple output, end each result with a NUL byte, for xargs -0")
+	flag.StringVar(&flags.prefixFlag, "prefix", "", "with simple output, prefix each result with these fields, e.g. platform: or platform:category:")
+	flag.BoolVar(&flags.withKeyword, "with-keyword", false, "append a tab and the keyword that found each result to the lines of output files")
+	flag.StringVar(&flags.fdOrgFlag, "fd-org", "", "also write organizations and groups, one per line, to this file descriptor (3 or more) or named pipe")
+	flag.StringVar(&flags.fdRepoFlag, "fd-repo", "", "also write repositories and projects, one per line, to this file descriptor (3 or more) or named pipe")
+	flag.StringVar(&flags.fdUserFlag, "fd-user", "", "also write users, one per line, to this file descriptor (3 or more) or named pipe")
 	flag.BoolVar(&flags.verboseFlag, "v", false, "enable verbose mode")
+	flag.StringVar(&flags.requestTagFlag, "request-tag", "", "tag sent with every API request, as X-Request-Tag and in the User-Agent, or in the header it names as \"Header: value\"")
+	flag.StringVar(&flags.debugHTTPFlag, "debug-http", "", "dump failed API requests and responses, credentials redacted, to files in this directory")
+	flag.StringVar(&flags.esURLFlag, "es-url", "", "Elasticsearch/OpenSearch URL to bulk-index results into")
+	flag.StringVar(&flags.esIndexFlag, "es-index", "dorky", "Elasticsearch/OpenSearch index name")
+	flag.StringVar(&flags.pgDSNFlag, "pg-dsn", "", "PostgreSQL connection string to persist runs and findings into")
+	flag.StringVar(&flags.jsonFlag, "json", "", "write a JSON report of the run to this file")
+	flag.StringVar(&flags.ndjsonFlag, "ndjson", "", "stream results to this file as newline-delimited JSON as they are found")
+	flag.StringVar(&flags.sarifFlag, "sarif", "", "write code and release asset findings to this file as SARIF")
+	flag.BoolVar(&flags.showFalsePositivesFlag, "show-false-positives", false, "report results marked as false positives in the -state file instead of hiding them")
+	flag.StringVar(&flags.stateFlag, "state", "", "JSON file tracking when each result was first and last found, across runs")
+	flag.StringVar(&flags.pruneAfterFlag, "prune-after", "", "with -state, prune results not found for this long (e.g. 90d) and report them as stale")
+	flag.StringVar(&flags.uploadFlag, "upload", "", "upload output files to object storage (s3://bucket/prefix or gs://bucket/prefix)")
+	flag.DurationVar(&flags.maxRuntimeFlag, "max-runtime", 0, "stop starting new searches after this long (e.g. 30m), export what was found and exit with status 3")
+	flag.StringVar(&flags.maxErrorsFlag, "max-errors", "", "abort once more than this many operations failed, or this percentage of the keywords searched (e.g. 20%), export what was found and exit with status 5")
+	flag.IntVar(&flags.concurrencyFlag, "concurrency", 1, "number of keywords to search in parallel")
+	flag.IntVar(&flags.orBatchFlag, "or-batch", 1, "number of keywords combined into one GitHub REST search with OR (at most 6)")
+	flag.BoolVar(&flags.confirmFlag, "confirm", false, "show the words to search and the estimated API requests, and ask before searching")
+	flag.BoolVar(&flags.versionFlag, "version", false, "print the dorky version and exit")
+	flag.BoolVar(&flags.githubActionFlag, "github-action", false, "run as a GitHub Actions step: read flags and keywords from the step's inputs, write a job summary, set outputs, and fail on new high-confidence findings")
+	flag.BoolVar(&flags.stdioFlag, "stdio", false, "serve JSON-RPC scan requests on stdin and stream results to stdout, for tools driving dorky as a subprocess")
+	flag.StringVar(&flags.targetsFlag, "targets", "", "file of 'label: keyword, keyword' lines to scan as separate targets")
+	flag.StringVar(&flags.rulesFlag, "rules", "", "YAML file of rules tagging results by name, category, platform or metadata")
+	flag.StringVar(&flags.keywords, "keywords", "", "YAML file of per-tag search behavior and, optionally, tagged keywords")
+	flag.StringVar(&flags.workspace, "workspace", "", "run inside the named workspace (see `dorky workspace`)")
+	flag.StringVar(&flags.outputDirFlag, "output-dir", "", "write output files and reports to this directory instead of the current one")
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "monitor":
+			runMonitor("monitor", os.Args[2:], "")
+			return
+		case "serve":
+			runMonitor("serve", os.Args[2:], defaultHealthAddr)
+			return
+		case "workspace":
+			runWorkspaceCommand(os.Args[2:])
+			return
+		case "compare":
+			runCompareCommand(os.Args[2:])
+			return
+		case "schema":
+			runSchemaCommand(os.Args[2:])
+			return
+		case "update":
+			runUpdateCommand(os.Args[2:])
+			return
+		case "enum":
+			runEnumCommand(os.Args[2:])
+			return
+		case "selfscan":
+			runSelfscanCommand(os.Args[2:])
+			return
+		case "platforms":
+			runPlatformsCommand(os.Args[2:])
+			return
+		case "lookup":
+			runLookupCommand(os.Args[2:])
+			return
+		case "triage":
+			runTriageCommand(os.Args[2:])
+			return
+		case "index":
+			runIndexCommand(os.Args[2:])
+			return
+		case "login":
+			runLoginCommand(os.Args[2:])
+			return
+		case "decrypt":
+			runDecryptCommand(os.Args[2:])
+			return
+		}
+	}
+
 	flag.Parse()
+	if flags.versionFlag {
+		fmt.Printf("dorky %s\n", version)
+		return
+	}
+
+	if flags.githubActionFlag {
+		if err := applyActionInputs(flag.CommandLine); err != nil {
+			fmt.Printf("Error %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if flags.workspace != "" {
+		if err := applyWorkspace(flag.CommandLine, flags.workspace); err != nil {
+			fmt.Printf("Error %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if flags.outputDirFlag != "" {
+		outputDir = filepath.Clean(flags.outputDirFlag)
+	}
+	fileKeywords := loadKeywordsFlag(flags)
+	loadRulesFlag(flags)
+	applyCategoriesFlag(&flags)
+
+	if flags.stdioFlag {
+		validateOutputFlags(flags)
+		if err := openCategoryStreams(flags); err != nil {
+			fmt.Fprintf(os.Stderr, "Error %s\n", err)
+			os.Exit(1)
+		}
+		// Only JSON-RPC messages go to stdout; everything dorky prints
+		// for people goes to stderr instead.
+		out := os.Stdout
+		os.Stdout = os.Stderr
+		if err := runStdio(os.Stdin, out, flags); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading requests: %s\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	validateFlags(flags)
+	if err := openCategoryStreams(flags); err != nil {
+		fmt.Printf("Error %s\n", err)
+		os.Exit(1)
+	}
+	defer closeCategoryStreams()
+
+	if flags.targetsFlag != "" {
+		targets, err := readTargetsFile(flags.targetsFlag)
+		if err != nil {
+			fmt.Printf("Error reading targets file: %s\n", err)
+			os.Exit(1)
+		}
+		if flags.confirmFlag {
+			groups := make(map[string]map[string]struct{})
+			for _, target := range targets {
+				groups[target.label] = make(map[string]struct{})
+				for _, keyword := range target.keywords {
+					processWord(keyword, groups[target.label], flags)
+				}
+			}
+			confirmOrExit(groups)
+		}
+		startDeadline(flags)
+		if err := runBatch(targets, flags); err != nil {
+			fmt.Printf("Error %s\n", err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
 
 	verbosePrint("Reading and cleaning words...\n")
-	words := readAndCleanWords(flags, flag.Args())
+	args := flag.Args()
+	if len(args) == 0 && flags.githubActionFlag {
+		args = actionKeywords()
+	}
+	if len(args) == 0 {
+		args = fileKeywords
+	}
+	words := readAndCleanWords(flags, args)
 	verbosePrint("Words cleaned.\n")
 
-	verbosePrint("Searching platforms...\n")
-	searchPlatforms(words, flags)
-	verbosePrint("Platform search completed.\n")
+	if flags.confirmFlag {
+		confirmOrExit(map[string]map[string]struct{}{"": words})
+	}
+
+	startDeadline(flags)
+	if flags.githubActionFlag {
+		scan := func() error { return runScan(words, flags) }
+		if err := runGitHubAction(flags, len(words), scan); err != nil {
+			fmt.Printf("Error %s\n", err)
+			os.Exit(exitCode(err))
+		}
+		return
+	}
+	if err := runScan(words, flags); err != nil {
+		fmt.Printf("Error %s\n", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// confirmOrExit asks for confirmation of the scan and exits unless given.
+func confirmOrExit(groups map[string]map[string]struct{}) {
+	ok, err := confirmScan(groups, flags)
+	if err != nil {
+		fmt.Printf("Error %s\n", err)
+		os.Exit(1)
+	}
+	if !ok {
+		fmt.Println("Aborted.")
+		os.Exit(1)
+	}
+}
+
+// exitCode is 130 for interrupted runs, 3 for runs cut short by
+// -max-runtime, 5 for runs aborted by -max-errors, 2 for runs that completed
+// with some failed searches, and 1 for runs that could not complete.
+func exitCode(err error) int {
+	var interrupted *runInterruptedError
+	if errors.As(err, &interrupted) && interrupted.signal {
+		return 130
+	}
+	var exceeded *runtimeExceededError
+	if errors.As(err, &exceeded) {
+		return 3
+	}
+	var aborted *errorBudgetError
+	if errors.As(err, &aborted) {
+		return 5
+	}
+	var partial *partialFailureError
+	if errors.As(err, &partial) {
+		return 2
+	}
+	var findings *newFindingsError
+	if errors.As(err, &findings) {
+		return 4
+	}
+	return 1
+}
+
+// runScan searches every platform for words and hands the collected results
+// to the configured exporters.
+func runScan(words map[string]struct{}, cfg config) error {
+	reportKeywordCount(words, dedupeKeywords(words))
+	return runAndExport(cfg, func() {
+		verbosePrint("Searching platforms...\n")
+		searchPlatforms(words, cfg)
+		verbosePrint("Platform search completed.\n")
+	})
+}
+
+// runAndExport runs search as a fresh run, then reports its errors and
+// hands the collected results to the configured exporters.
+func runAndExport(cfg config, search func()) error {
+	startRun()
+	startErrorBudget(cfg)
+
+	if cfg.ndjsonFlag != "" {
+		if err := openNDJSON(outputPath(cfg.ndjsonFlag)); err != nil {
+			return fmt.Errorf("opening NDJSON stream: %w", err)
+		}
+		defer closeNDJSON()
+	}
+
+	if err := loadTriageMarks(cfg); err != nil {
+		return fmt.Errorf("reading triage marks: %w", err)
+	}
+	if err := loadCooldowns(cfg, time.Now()); err != nil {
+		return fmt.Errorf("reading rate limit cooldowns: %w", err)
+	}
+
+	interrupted := runSearch(search)
+	if cfg.verboseFlag {
+		printRateLimits()
+	}
+	printErrorReport()
+	reportEmptySearches()
+	exceeded := saveRemainingKeywords()
+
+	if cfg.stateFlag != "" {
+		if err := recordState(cfg, time.Now().UTC()); err != nil {
+			return fmt.Errorf("updating state file: %w", err)
+		}
+	}
+
+	if err := exportResults(cfg, time.Now().UTC()); err != nil {
+		return err
+	}
+
+	if interrupted != nil {
+		return interrupted
+	}
+	if exceeded != nil {
+		return exceeded
+	}
+	if failed := failedOperations(); failed > 0 {
+		return &partialFailureError{failed: failed}
+	}
+	return nil
+}
+
+func exportResults(cfg config, runFinished time.Time) error {
+	prov := runProvenance(runFinished)
+
+	if cfg.jsonFlag != "" {
+		r := report{provenance: prov, RateLimits: rateLimitSummary(), Errors: searchErrorSummary(), OrgRollups: orgRollups, Stale: staleResults, EmptySearches: emptySearches(), Partial: runPartial(), Results: collectedResults}
+		if err := writeJSONReport(outputPath(cfg.jsonFlag), r); err != nil {
+			return fmt.Errorf("writing JSON report: %w", err)
+		}
+	}
+
+	if cfg.checkAvailabilityFlag {
+		if err := writeReservations(runStarted); err != nil {
+			return fmt.Errorf("writing reservations: %w", err)
+		}
+	}
+
+	if cfg.sarifFlag != "" {
+		if err := writeSARIFReport(outputPath(cfg.sarifFlag), collectedResults); err != nil {
+			return fmt.Errorf("writing SARIF report: %w", err)
+		}
+	}
+
+	if cfg.esURLFlag != "" {
+		verbosePrint("Exporting results to Elasticsearch...\n")
+		if err := exportToElasticsearch(cfg.esURLFlag, cfg.esIndexFlag, prov, collectedResults); err != nil {
+			return fmt.Errorf("exporting to Elasticsearch: %w", err)
+		}
+		verbosePrint("Elasticsearch export completed.\n")
+	}
+
+	if cfg.pgDSNFlag != "" {
+		verbosePrint("Storing results in PostgreSQL...\n")
+		if err := storeInPostgres(cfg.pgDSNFlag, prov, collectedResults); err != nil {
+			return fmt.Errorf("storing results in PostgreSQL: %w", err)
+		}
+		verbosePrint("PostgreSQL storage completed.\n")
+	}
+
+	if cfg.uploadFlag != "" {
+		verbosePrint("Uploading output files...\n")
+		if err := uploadOutputs(cfg.uploadFlag, outputFiles); err != nil {
+			return fmt.Errorf("uploading output files: %w", err)
+		}
+		verbosePrint("Upload completed.\n")
+	}
+
+	return nil
 }
 
+// maxResultsPerSearch is the largest page the search APIs return. Above
+// it, the GitHub organization, repository and user searches fetch -max
+// results over several pages, and the other searches stop at one page.
+const maxResultsPerSearch = 100
+
 func validateFlags(cfg config) {
-	if !(cfg.orgFlag || cfg.repoFlag || cfg.userFlag) {
-		fmt.Println("At least one search flag (-o, -r, or -u) must be specified")
+	exitOnConfigProblems(append(searchFlagProblems(cfg), outputFlagProblems(cfg)...))
+}
+
+// validateOutputFlags checks the flags shared by every kind of run, which
+// control how results are fetched and reported rather than what is
+// searched.
+func validateOutputFlags(cfg config) {
+	exitOnConfigProblems(outputFlagProblems(cfg))
+}
+
+// exitOnConfigProblems reports every problem found with the effective
+// configuration at once, so they can all be fixed before the next attempt,
+// and exits if there are any.
+func exitOnConfigProblems(problems []string) {
+	if len(problems) == 0 {
+		verbosePrint("Flags validated.\n")
+		return
+	}
+
+	fmt.Println("Invalid configuration:")
+	for _, problem := range problems {
+		fmt.Printf("- %s\n", problem)
+	}
+	os.Exit(1)
+}
+
+// searchFlagProblems checks what a search run is asked to search, including
+// combinations of flags that would silently search nothing or ignore part
+// of the request.
+func searchFlagProblems(cfg config) []string {
+	var problems []string
+	if !(cfg.orgFlag || cfg.repoFlag || cfg.userFlag || cfg.discussionsFlag || cfg.wikiFlag || cfg.glSearch != "" || cfg.pastesFlag || cfg.stackFlag || cfg.checkAvailabilityFlag || cfg.impersonationFlag || tagsDefineSearches()) {
+		problems = append(problems, "at least one search flag (-categories, -o, -r, -u, -d, -w, -gl-search, -pastes, -stackoverflow, -check-availability or -impersonation) or a tag with searches of its own must be specified")
+	}
+	if cfg.ghOnlyFlag && cfg.glOnlyFlag {
+		problems = append(problems, "-gh and -gl are mutually exclusive: drop both to search every platform")
+	}
+	if cfg.githubActionFlag && cfg.targetsFlag != "" {
+		problems = append(problems, "-github-action runs a single scan of its keywords input, which -targets can't replace")
+	}
+	if _, err := generatorNames(cfg); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if (cfg.domainFlag != "" || cfg.fromSubfinderFlag != "" || cfg.fromBrowserFlag != "") && cfg.targetsFlag != "" {
+		problems = append(problems, "-domain, -from-subfinder and -from-browser can't be combined with -targets")
+	}
+	if cfg.glOnlyFlag && cfg.discussionsFlag {
+		problems = append(problems, "-d searches GitHub Discussions, which -gl leaves out")
+	}
+	if cfg.ghOnlyFlag && cfg.glSearch != "" {
+		problems = append(problems, "-gl-search searches GitLab, which -gh leaves out")
+	}
+	if cfg.ghOnlyFlag && cfg.glTopLevelFlag {
+		problems = append(problems, "-gl-top-level filters GitLab groups, which -gh leaves out")
+	}
+	if cfg.ghOnlyFlag || cfg.glOnlyFlag {
+		only := "-gh"
+		if cfg.glOnlyFlag {
+			only = "-gl"
+		}
+		if cfg.bbURLFlag != "" {
+			problems = append(problems, "-bb-url searches Bitbucket, which "+only+" leaves out")
+		}
+		if cfg.pastesFlag {
+			problems = append(problems, "-pastes searches a paste index, which "+only+" leaves out")
+		}
+		if cfg.stackFlag {
+			problems = append(problems, "-stackoverflow searches Stack Exchange, which "+only+" leaves out")
+		}
+	}
+	if cfg.minWordLengthFlag < 1 {
+		problems = append(problems, "-min-word-length must be at least 1")
+	}
+	return problems
+}
+
+// outputFlagProblems checks the flags shared by every kind of run.
+func outputFlagProblems(cfg config) []string {
+	var problems []string
+	if _, err := parseGitLabScopes(cfg.glSearch); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if cfg.ghAPIFlag != "rest" && cfg.ghAPIFlag != "graphql" {
+		problems = append(problems, "-gh-api must be either rest or graphql")
+	}
+	if cfg.maxFlag < 1 {
+		problems = append(problems, "-max must be at least 1")
+	} else if cfg.maxFlag > githubSearchLimit && !cfg.forceMaxFlag {
+		problems = append(problems, fmt.Sprintf("-max must be at most %d, the most results GitHub search returns for a query: add -force-max to go further by slicing GitHub searches by creation date", githubSearchLimit))
+	}
+	if cfg.exhaustiveFlag && !cfg.repoFlag {
+		problems = append(problems, "-exhaustive applies to GitHub repository searches, which need -r")
+	}
+	if cfg.exhaustiveFlag && (cfg.ghAPIFlag == "graphql" || cfg.orBatchFlag > 1) {
+		problems = append(problems, "-exhaustive pages through REST searches of a single keyword: drop -gh-api graphql and -or-batch")
+	}
+	if cfg.maxTotalFlag < 0 {
+		problems = append(problems, "-max-total must not be negative")
+	}
+	if cfg.maxRuntimeFlag < 0 {
+		problems = append(problems, "-max-runtime must not be negative")
+	}
+	if _, err := parseErrorBudget(cfg.maxErrorsFlag); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for name, target := range streamFlags(cfg) {
+		if _, err := parseStreamTarget(name, target); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if passphrase, err := outputPassphrase(); err != nil {
+		problems = append(problems, err.Error())
+	} else if passphrase != "" && cfg.ndjsonFlag != "" {
+		problems = append(problems, "-ndjson streams results to disk unencrypted: drop it, or unset "+passphraseEnv+" and "+passphraseFileEnv)
+	}
+	if cfg.concurrencyFlag < 1 {
+		problems = append(problems, "-concurrency must be at least 1")
+	}
+	if cfg.orBatchFlag < 1 || cfg.orBatchFlag > maxORKeywords {
+		problems = append(problems, fmt.Sprintf("-or-batch must be between 1 and %d", maxORKeywords))
+	}
+	if cfg.employeePattern != "" {
+		if !cfg.collabFlag {
+			problems = append(problems, "-employee-pattern requires -collaborators")
+		}
+		if _, err := regexp.Compile(cfg.employeePattern); err != nil {
+			problems = append(problems, fmt.Sprintf("-employee-pattern: %s", err))
+		}
+	}
+	if cfg.relatedFlag && cfg.ownedFlag == "" {
+		problems = append(problems, "-related requires -owned, the organizations known to belong to the target")
+	}
+	if cfg.iacSearchFlag && !cfg.iacFlag {
+		problems = append(problems, "-iac-search requires -iac")
+	}
+	if cfg.recurseSearchFlag && !cfg.recurseFlag {
+		problems = append(problems, "-recurse-search requires -recurse")
+	}
+	if cfg.recurseMinReposFlag < 1 {
+		problems = append(problems, "-recurse-min-repos must be at least 1")
+	}
+	if cfg.pruneAfterFlag != "" {
+		if cfg.stateFlag == "" {
+			problems = append(problems, "-prune-after requires -state")
+		}
+		if _, err := parseAge(cfg.pruneAfterFlag); err != nil {
+			problems = append(problems, fmt.Sprintf("-prune-after: %s", err))
+		}
+	}
+	if _, _, err := parseRequestTag(cfg.requestTagFlag); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if cfg.stdioFlag && (cfg.confirmFlag || cfg.targetsFlag != "") {
+		problems = append(problems, "-stdio reads scan requests from stdin: drop -confirm and -targets")
+	}
+	if _, err := newEncoder(cfg); err != nil {
+		problems = append(problems, err.Error())
+	}
+	return problems
+}
+
+// loadKeywordsFlag applies the tag behaviors of the -keywords file and
+// returns the keywords it lists.
+func loadKeywordsFlag(cfg config) []string {
+	if cfg.keywords == "" {
+		return nil
+	}
+
+	kf, err := loadKeywordFile(cfg.keywords)
+	if err != nil {
+		fmt.Printf("Error reading keywords file: %s\n", err)
 		os.Exit(1)
 	}
-	verbosePrint("Flags validated.\n")
+	applyKeywordFile(kf)
+
+	return kf.Keywords
 }
 
 func verbosePrint(format string, a ...interface{}) {
@@ -75,14 +720,26 @@ func verbosePrint(format string, a ...interface{}) {
 }
 
 func readAndCleanWords(cfg config, args []string) map[string]struct{} {
+	resetKeywordSources()
 	words := make(map[string]struct{})
 
-	if len(args) > 0 {
+	// Hostname sources replace stdin as the input.
+	if len(args) > 0 || cfg.domainFlag != "" || cfg.fromSubfinderFlag != "" || cfg.fromBrowserFlag != "" {
 		for _, word := range args {
 			processWord(word, words, cfg)
 		}
+		addDomainKeywords(words, cfg)
+		addReconKeywords(words, cfg)
+		addBrowserKeywords(words, cfg)
 	} else {
-		scanner := bufio.NewScanner(os.Stdin)
+		input := io.Reader(os.Stdin)
+		if cfg.workspace != "" {
+			if f, err := workspaceKeywords(cfg.workspace); err == nil {
+				defer f.Close()
+				input = f
+			}
+		}
+		scanner := newLineScanner(input)
 
 		for scanner.Scan() {
 			word := strings.TrimSpace(scanner.Text())
@@ -95,19 +752,40 @@ func readAndCleanWords(cfg config, args []string) map[string]struct{} {
 }
 
 func processWord(word string, words map[string]struct{}, cfg config) {
-	if cfg.cleanFlag {
-		word = cleanWord(word)
+	word, tags := splitTags(word)
+	source := word
+	chain, err := generatorChain(cfg)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
 	}
 
-	addWordToMap(words, word)
-	word = removeWhitespace(word)
-	wordLines := strings.Split(word, "\n")
-
-	for _, w := range wordLines {
+	for _, w := range expandKeyword(word, chain) {
+		if reason := junkWordReason(w, cfg); reason != "" {
+			verbosePrint("Skipping '%s': %s\n", w, reason)
+			continue
+		}
 		addWordToMap(words, w)
+		tagWord(w, tags)
+		recordKeywordSource(w, source)
 	}
 }
 
+// junkWordReason explains why word is not worth searching for, or returns
+// "" if it is.
+func junkWordReason(word string, cfg config) string {
+	word = searchText(word)
+	if utf8.RuneCountInString(word) < cfg.minWordLengthFlag {
+		return fmt.Sprintf("shorter than %d characters", cfg.minWordLengthFlag)
+	}
+	for _, stopWord := range splitList(cfg.stopWordsFlag) {
+		if foldName(word) == foldName(stopWord) {
+			return "stop word"
+		}
+	}
+	return ""
+}
+
 func addWordToMap(words map[string]struct{}, word string) {
 	if _, exists := words[word]; !exists {
 		words[word] = struct{}{}
@@ -116,42 +794,304 @@ func addWordToMap(words map[string]struct{}, word string) {
 
 func checkScannerError(scanner *bufio.Scanner) {
 	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading stdin: %s\n", err)
+		fmt.Printf("Error reading input: %s\n", err)
 		os.Exit(1)
 	}
 }
 
 func searchPlatforms(words map[string]struct{}, cfg config) {
-	ghClient, ghErr := createGitHubClient()
-	glClient, glErr := createGitLabClient()
+	var ghHTTPClient *http.Client
+	var glClient *gitlab.Client
+	ghErr, glErr := errPlatformNotSelected, errPlatformNotSelected
+	if platformSelected(cfg, "github") {
+		ghHTTPClient, ghErr = createGitHubHTTPClient()
+	}
+	if platformSelected(cfg, "gitlab") {
+		glClient, glErr = createGitLabClient()
+	}
+
+	var ghClient *github.Client
+	if ghErr == nil {
+		ghClient = github.NewClient(ghHTTPClient)
+	}
 
-	if ghErr != nil {
+	if ghErr != nil && ghErr != errPlatformNotSelected {
 		fmt.Printf("Error creating GitHub client: %s\n", ghErr)
 	}
 
 	if glErr != nil {
-		fmt.Printf("Error creating GitLab client: %s\n", glErr)
+		if glErr != errPlatformNotSelected {
+			fmt.Printf("Error creating GitLab client: %s\n", glErr)
+		}
+	} else if !cfg.ghOnlyFlag {
+		printGitLabMode()
+	}
+
+	var bbClient *bitbucketClient
+	if cfg.bbURLFlag != "" && !cfg.ghOnlyFlag && !cfg.glOnlyFlag {
+		var bbErr error
+		if bbClient, bbErr = createBitbucketClient(cfg.bbURLFlag); bbErr != nil {
+			fmt.Printf("Error creating Bitbucket client: %s\n", bbErr)
+		}
+	}
+
+	var pastes *pasteClient
+	if cfg.pastesFlag && !cfg.ghOnlyFlag && !cfg.glOnlyFlag {
+		var pastesErr error
+		if pastes, pastesErr = createPasteClient(cfg.pastesURL); pastesErr != nil {
+			fmt.Printf("Error creating paste index client: %s\n", pastesErr)
+		}
+	}
+
+	var stack *stackExchangeClient
+	if cfg.stackFlag && !cfg.ghOnlyFlag && !cfg.glOnlyFlag {
+		stack = createStackExchangeClient(cfg.stackSite)
+	}
+
+	plugins := loadPlugins(cfg)
+
+	var enabled []string
+	if !cfg.glOnlyFlag && ghErr == nil {
+		enabled = append(enabled, "github")
+	}
+	if !cfg.ghOnlyFlag && glErr == nil {
+		enabled = append(enabled, "gitlab")
+	}
+	if bbClient != nil {
+		enabled = append(enabled, "bitbucket")
 	}
+	reportCategoryGaps(cfg, enabled, plugins)
+
+	ordered := prioritizeWords(weightWords(sortedWords(words)), lastQueried)
+	streams := startKeywordStreams(ordered, os.Stdout)
+	if cfg.maxTotalFlag > 0 {
+		budget = newResultBudget(cfg.maxTotalFlag, ordered)
+	}
+
+	useGraphQL := cfg.ghAPIFlag == "graphql"
+	if useGraphQL && !cfg.glOnlyFlag && ghErr == nil {
+		searchGitHubGraphQL(ghHTTPClient, words, cfg)
+	}
+
+	// orBatched holds the words whose GitHub org, repository and user
+	// searches were combined into OR queries.
+	orBatched := make(map[string]bool)
+	batchGitHub := func(ordered []string) {
+		if !useGraphQL && cfg.orBatchFlag > 1 && !cfg.glOnlyFlag && ghErr == nil {
+			for word := range searchGitHubORBatches(ghClient.Search, ghClient.Users, ordered, cfg.orBatchFlag, cfg) {
+				orBatched[word] = true
+			}
+		}
+	}
+	batchGitHub(ordered)
+
+	searchWord := func(word string) {
+		if budget != nil {
+			defer budget.release(word)
+		}
+		if runPartial() != "" {
+			// The run was interrupted or cancelled.
+			return
+		}
+		if runtimeExceeded() || errorBudgetExceeded() {
+			recordUnsearched(word)
+			return
+		}
 
-	for word := range words {
-		if !cfg.glOnlyFlag && ghErr == nil {
+		if cfg.checkAvailabilityFlag {
+			checkAvailability(ghClient, glClient, word, cfg)
+		}
+
+		wordCfg := configForWord(cfg, word)
+
+		if !cfg.glOnlyFlag && ghErr == nil && !useGraphQL {
 			verbosePrint("Searching GitHub for word: %s\n", word)
-			searchGitHub(ghClient, word, cfg)
+			ghCfg := wordCfg
+			if orBatched[word] {
+				ghCfg.orgFlag, ghCfg.repoFlag, ghCfg.userFlag = false, false, false
+			}
+			searchGitHub(ghClient, word, ghCfg)
+
+			if wordCfg.discussionsFlag {
+				searchGitHubDiscussions(ghHTTPClient, word, wordCfg.maxFlag)
+			}
+		} else if !cfg.glOnlyFlag && ghErr == nil && wordCfg.wikiFlag {
+			// Code search has no GraphQL equivalent.
+			searchGitHubWikis(ghClient.Search, word, wordCfg.maxFlag)
 		}
 
 		if !cfg.ghOnlyFlag && glErr == nil {
 			verbosePrint("Searching GitLab for word: %s\n", word)
-			searchGitLab(glClient, word, cfg)
+			searchGitLab(glClient, word, wordCfg)
+		}
+
+		if bbClient != nil {
+			verbosePrint("Searching Bitbucket for word: %s\n", word)
+			searchBitbucket(bbClient, word, wordCfg)
+		}
+
+		if pastes != nil {
+			verbosePrint("Searching pastes for word: %s\n", word)
+			searchPastes(pastes, word, wordCfg.maxFlag)
+		}
+
+		if stack != nil {
+			verbosePrint("Searching Stack Exchange for word: %s\n", word)
+			searchStackExchange(stack, word, wordCfg.maxFlag)
+		}
+
+		for _, p := range plugins {
+			verbosePrint("Searching %s for word: %s\n", p.Name, word)
+			searchPlugin(p, word, wordCfg)
+		}
+		recordQueried(word)
+	}
+	searchKeywords(ordered, cfg.concurrencyFlag, streams, searchWord)
+	streams.stop()
+
+	if runPartial() != "" {
+		return
+	}
+	if runtimeExceeded() {
+		verbosePrint("-max-runtime reached, skipping the remaining searches and lookups\n")
+		skipRemaining()
+		return
+	}
+	if errorBudgetExceeded() {
+		verbosePrint("-max-errors reached, skipping the remaining searches and lookups\n")
+		skipRemaining()
+		return
+	}
+
+	if cfg.recurseFlag {
+		if next := recurse(words, cfg); len(next) > 0 {
+			verbosePrint("Searching %d keywords found by recursion...\n", len(next))
+			ordered := sortedWords(next)
+			streams := startKeywordStreams(ordered, os.Stdout)
+			if budget != nil {
+				budget.add(ordered)
+			}
+			if useGraphQL && !cfg.glOnlyFlag && ghErr == nil {
+				searchGitHubGraphQL(ghHTTPClient, next, cfg)
+			}
+			batchGitHub(ordered)
+			searchKeywords(ordered, cfg.concurrencyFlag, streams, searchWord)
+			streams.stop()
+		}
+	}
+
+	if stack != nil && cfg.targetDomainFlag != "" {
+		searchStackExchangeDomains(stack, splitList(cfg.targetDomainFlag), cfg.maxFlag)
+	}
+
+	if cfg.impersonationFlag {
+		reportImpersonation(buildImpersonationReport(ghClient, glClient, words, cfg))
+	}
+
+	enrichResults(ghClient, glClient, words, cfg)
+}
+
+// enrichResults runs the lookups that build on the results found so far:
+// releases, CI configurations, IaC files, memberships, stars, outside
+// collaborators, organization rollups, related namespaces, funding files,
+// homepages and avatars. Stars are matched against words.
+func enrichResults(ghClient *github.Client, glClient *gitlab.Client, words map[string]struct{}, cfg config) {
+	if cfg.releasesFlag {
+		enumerateReleases(ghClient, glClient)
+	}
+
+	if cfg.ciConfigsFlag {
+		discoverCIConfigs(ghClient, glClient)
+	}
+
+	if cfg.iacFlag && ghClient != nil {
+		discoverIaC(ghClient.Search, ghClient.Repositories, cfg.iacSearchFlag, cfg.maxFlag)
+	}
+
+	if cfg.membershipsFlag && ghClient != nil {
+		crossCheckMemberships(ghClient.Organizations, cfg)
+	}
+
+	if cfg.keysFlag && ghClient != nil {
+		enumerateUserKeys(ghClient.Users)
+	}
+
+	if cfg.starsFlag && ghClient != nil {
+		pivotStarredRepos(ghClient.Activity, words)
+	}
+
+	if cfg.collabFlag && ghClient != nil {
+		auditCollaborators(ghClient.Repositories, compileEmployeePattern(cfg.employeePattern))
+	}
+
+	if cfg.orgRollupFlag && ghClient != nil {
+		rollupOrganizations(ghClient.Organizations, ghClient.Repositories)
+	}
+
+	if cfg.relatedFlag && ghClient != nil {
+		suggestRelatedNamespaces(ghClient.Repositories, ghClient.Organizations, ghClient.Client(), cfg)
+	}
+
+	if cfg.fundingFlag && ghClient != nil {
+		discoverFunding(ghClient.Repositories)
+	}
+
+	if cfg.urlsFlag {
+		var ghUsers githubUsersService
+		var glUsers gitlabUsersService
+		if ghClient != nil {
+			ghUsers = ghClient.Users
 		}
+		if glClient != nil {
+			glUsers = glClient.Users
+		}
+		harvestHomepages(ghUsers, glUsers)
+	}
+
+	if cfg.avatarsFlag {
+		correlateAvatars()
 	}
 }
 
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanWord reduces a URL or bare hostname to its lower-cased hostname,
+// dropping the scheme, credentials, port, path, query and fragment, along
+// with IPv6 brackets. IP addresses make no useful keywords, so they yield
+// an empty string. Input containing whitespace is a phrase rather than a
+// URL and is returned unchanged.
 func cleanWord(word string) string {
-	match := urlRegexp.FindStringSubmatch(word)
-	if len(match) > 1 {
-		return match[1]
+	word = strings.TrimSpace(word)
+	if word == "" || strings.ContainsAny(word, " \t") {
+		return word
+	}
+
+	raw := word
+	switch {
+	case strings.HasPrefix(raw, "//"):
+		raw = "http:" + raw
+	case !strings.Contains(raw, "://"):
+		raw = "http://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Hostname() == "" {
+		return word
 	}
-	return word
+
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+
+	return host
 }
 
 func removeWhitespace(word string) string {
@@ -166,15 +1106,19 @@ func searchGitHub(client *github.Client, query string, cfg config) {
 	}
 
 	if cfg.orgFlag {
-		searchGitHubOrganizations(client, query, cfg.maxFlag)
+		searchGitHubOrganizations(client.Search, query, cfg.maxFlag)
 	}
 
 	if cfg.repoFlag {
-		searchGitHubRepositories(client, query, cfg.maxFlag)
+		searchGitHubRepositories(client.Search, query, cfg.maxFlag)
 	}
 
 	if cfg.userFlag {
-		searchGitHubUsers(client, query, cfg.maxFlag)
+		searchGitHubUsers(client.Search, client.Users, query, cfg.maxFlag)
+	}
+
+	if cfg.wikiFlag {
+		searchGitHubWikis(client.Search, query, cfg.maxFlag)
 	}
 }
 
@@ -184,224 +1128,197 @@ func searchGitLab(client *gitlab.Client, query string, cfg config) {
 	}
 
 	if cfg.orgFlag || cfg.userFlag {
-		searchGitLabGroupsAndUsers(client, query, cfg.maxFlag)
+		searchGitLabGroupsAndUsers(client, query, cfg)
 	}
 
 	if cfg.repoFlag {
-		searchGitLabProjects(client, query, cfg.maxFlag)
+		searchGitLabProjects(client.Projects, query, cfg.maxFlag)
+	}
+
+	scopes, _ := parseGitLabScopes(cfg.glSearch)
+	if cfg.wikiFlag && !containsString(scopes, "wiki_blobs") {
+		scopes = append(scopes, "wiki_blobs")
+	}
+	if len(scopes) > 0 && !gitlabAnonymous {
+		searchGitLabScopes(client, query, scopes, cfg.maxFlag)
 	}
 }
 
-func searchGitHubOrganizations(client *github.Client, query string, maxResults int) {
+func searchGitHubOrganizations(client githubSearchService, query string, maxResults int) {
 	ctx := context.Background()
 
-	opt := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: maxResults}}
-	results, _, err := client.Search.Users(ctx, "type:org "+query, opt)
+	orgLogins, err := collectGitHubSearch("type:org "+query, maxResults, func(q string, opt *github.SearchOptions) ([]string, int, error) {
+		results, _, err := client.Users(ctx, q, opt)
+		if err != nil {
+			return nil, 0, err
+		}
+		logins := make([]string, len(results.Users))
+		for i, org := range results.Users {
+			logins[i] = *org.Login
+			recordAvatar("github", *org.Login, org.GetAvatarURL())
+		}
+		return logins, results.GetTotal(), nil
+	})
 	if err != nil {
-		fmt.Printf("Error searching organizations: %s\n", err)
-		return
-	}
-
-	orgLogins := make([]string, len(results.Users))
-	for i, org := range results.Users {
-		orgLogins[i] = *org.Login
+		recordSearchError("github", "organization search", query, err)
+		if len(orgLogins) == 0 {
+			return
+		}
 	}
 
-	printResults(fmt.Sprintf("GitHub organizations matching '%s'", query), orgLogins)
-	
-	// Save the content of orgLogins to a file called "organizations.txt"
-	f, err := os.Create("github_organizations.txt")
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	defer f.Close()
-	for _, org := range orgLogins {
-		f.WriteString(org + "\n")
-	}
+	emitResults("github", "organization", query, fmt.Sprintf("GitHub organizations matching '%s'", query), "github_organizations.txt", orgLogins)
 }
 
-func searchGitHubRepositories(client *github.Client, query string, maxResults int) {
+func searchGitHubRepositories(client githubSearchService, query string, maxResults int) {
 	ctx := context.Background()
 
-	opt := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: maxResults}}
-	results, _, err := client.Search.Repositories(ctx, query, opt)
-	if err != nil {
-		fmt.Printf("Error searching repositories: %s\n", err)
-		return
+	collect := func(q string, fetch githubSearchPage) ([]string, error) {
+		return collectGitHubSearch(q, maxResults, fetch)
 	}
-
-	repoNames := make([]string, len(results.Repositories))
-	for i, repo := range results.Repositories {
-		repoNames[i] = *repo.FullName
+	if flags.exhaustiveFlag {
+		collect = exhaustiveGitHubSearch
 	}
 
-	printResults(fmt.Sprintf("GitHub repositories matching '%s'", query), repoNames)
-
-	// Save the content of repoNames to a file called "repositories.txt"
-	f, err := os.Create("github_repositories.txt")
+	repoNames, err := collect(query, func(q string, opt *github.SearchOptions) ([]string, int, error) {
+		results, _, err := client.Repositories(ctx, q, opt)
+		if err != nil {
+			return nil, 0, err
+		}
+		names := make([]string, len(results.Repositories))
+		for i, repo := range results.Repositories {
+			names[i] = *repo.FullName
+			recordRepoTerms(repo.GetFullName(), repo.Topics, repo.GetDescription())
+			recordHomepage("github", repo.GetFullName(), repo.GetHomepage())
+		}
+		return names, results.GetTotal(), nil
+	})
 	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	defer f.Close()
-	for _, repo := range repoNames {
-		f.WriteString(repo + "\n")
+		recordSearchError("github", "repository search", query, err)
+		if len(repoNames) == 0 {
+			return
+		}
 	}
+
+	emitResults("github", "repository", query, fmt.Sprintf("GitHub repositories matching '%s'", query), "github_repositories.txt", repoNames)
 }
 
-func searchGitHubUsers(client *github.Client, query string, maxResults int) {
+func searchGitHubUsers(client githubSearchService, users githubUsersService, query string, maxResults int) {
 	ctx := context.Background()
 
-	opt := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: maxResults}}
-	results, _, err := client.Search.Users(ctx, "type:user "+query, opt)
+	userLogins, err := collectGitHubSearch("type:user "+query+githubUserQualifiers(), maxResults, func(q string, opt *github.SearchOptions) ([]string, int, error) {
+		results, _, err := client.Users(ctx, q, opt)
+		if err != nil {
+			return nil, 0, err
+		}
+		logins := make([]string, len(results.Users))
+		for i, user := range results.Users {
+			logins[i] = *user.Login
+			recordAvatar("github", *user.Login, user.GetAvatarURL())
+		}
+		return logins, results.GetTotal(), nil
+	})
 	if err != nil {
-		fmt.Printf("Error searching users: %s\n", err)
-		return
-	}
-
-	userLogins := make([]string, len(results.Users))
-	for i, user := range results.Users {
-		userLogins[i] = *user.Login
+		recordSearchError("github", "user search", query, err)
+		if len(userLogins) == 0 {
+			return
+		}
 	}
+	userLogins = filterGitHubUsersByBio(users, userLogins)
 
-	printResults(fmt.Sprintf("GitHub users matching '%s'", query), userLogins)
-
-	// Save the content of userLogins to a file called "users.txt"
-	f, err := os.Create("github_users.txt")
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	defer f.Close()
-	for _, user := range userLogins {
-		f.WriteString(user + "\n")
-	}
+	emitResults("github", "user", query, fmt.Sprintf("GitHub users matching '%s'", query), "github_users.txt", userLogins)
 }
 
-func createGitHubClient() (*github.Client, error) {
+// createGitHubHTTPClient returns an authenticated, rate-limited HTTP client
+// for the GitHub APIs.
+func createGitHubHTTPClient() (*http.Client, error) {
 	ctx := context.Background()
-	token := os.Getenv("GITHUB_ACCESS_TOKEN")
+	token := platformToken("github")
 	if token == "" {
-		return nil, errors.New("GITHUB_ACCESS_TOKEN environment variable is not set")
+		return nil, errors.New("GITHUB_ACCESS_TOKEN environment variable is not set, and dorky login wasn't run")
 	}
 
 	ts := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
-	tc.Transport = &rateLimitedTransport{
-		transport: tc.Transport,
-		limiter:   rate.NewLimiter(rate.Every(10), 10),
-	}
-
-	client := github.NewClient(tc)
+	tc.Transport = newProviderTransport("github", tc.Transport, newAdaptiveLimiter("github", 10, rate.Every(time.Minute), githubResource))
 
-	return client, nil
-}
-
-type rateLimitedTransport struct {
-	transport http.RoundTripper
-	limiter   *rate.Limiter
+	return tc, nil
 }
 
-func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	if err := t.limiter.Wait(context.Background()); err != nil {
-		return nil, err
+func searchGitLabGroupsAndUsers(client *gitlab.Client, query string, cfg config) {
+	opt := &gitlab.ListGroupsOptions{Search: gitlab.String(searchText(query)), ListOptions: gitlab.ListOptions{PerPage: pageSize(cfg.maxFlag)}}
+	if cfg.glTopLevelFlag {
+		// Subgroups matching the keyword would otherwise use up -max.
+		opt.TopLevelOnly = gitlab.Bool(true)
 	}
-
-	return t.transport.RoundTrip(req)
-}
-
-func searchGitLabGroupsAndUsers(client *gitlab.Client, query string, maxResults int) {
-	opt := &gitlab.ListGroupsOptions{Search: gitlab.String(query), ListOptions: gitlab.ListOptions{PerPage: maxResults}}
 	groups, _, err := client.Groups.ListGroups(opt)
 	if err != nil {
-		fmt.Printf("Error searching GitLab groups: %s\n", err)
+		recordSearchError("gitlab", "group search", query, err)
 		return
 	}
 
-	if flags.orgFlag {
+	if cfg.orgFlag {
 		groupFullPaths := make([]string, len(groups))
 		for i, group := range groups {
 			groupFullPaths[i] = group.FullPath
+			recordAvatar("gitlab", group.FullPath, group.AvatarURL)
 		}
 
-		printResults(fmt.Sprintf("GitLab groups matching '%s'", query), groupFullPaths)
-
-		// Save the content of groupFullPaths to a file called "groups.txt"
-		f, err := os.Create("gitlab_groups.txt")
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		defer f.Close()
-		for _, group := range groupFullPaths {
-			f.WriteString(group + "\n")
-		}
+		emitResults("gitlab", "group", query, fmt.Sprintf("GitLab groups matching '%s'", query), "gitlab_groups.txt", groupFullPaths)
 	}
 
-	users, _, err := client.Users.ListUsers(&gitlab.ListUsersOptions{Search: gitlab.String(query), ListOptions: gitlab.ListOptions{PerPage: maxResults}})
+	users, _, err := client.Users.ListUsers(&gitlab.ListUsersOptions{Search: gitlab.String(searchText(query)), ListOptions: gitlab.ListOptions{PerPage: pageSize(cfg.maxFlag)}})
 	if err != nil {
-		fmt.Printf("Error searching GitLab users: %s\n", err)
+		recordSearchError("gitlab", "user search", query, err)
 		return
 	}
 
-	if flags.userFlag {
+	if cfg.userFlag {
+		users = filterGitLabUsers(client, users)
 		userUsernames := make([]string, len(users))
 		for i, user := range users {
 			userUsernames[i] = user.Username
+			recordAvatar("gitlab", user.Username, user.AvatarURL)
+			recordHomepage("gitlab", user.Username, user.WebsiteURL)
 		}
 
-		printResults(fmt.Sprintf("GitLab users matching '%s'", query), userUsernames)
-
-		// Save the content of userUsernames to a file called "users.txt"
-		f, err := os.Create("gitlab_users.txt")
-		if err != nil {
-			fmt.Println(err)
-			return
-		}
-		defer f.Close()
-		for _, user := range userUsernames {
-			f.WriteString(user + "\n")
-		}
+		emitResults("gitlab", "user", query, fmt.Sprintf("GitLab users matching '%s'", query), "gitlab_users.txt", userUsernames)
 	}
 }
 
-func searchGitLabProjects(client *gitlab.Client, query string, maxResults int) {
-	opt := &gitlab.ListProjectsOptions{Search: gitlab.String(query), ListOptions: gitlab.ListOptions{PerPage: maxResults}}
-	projects, _, err := client.Projects.ListProjects(opt)
+func searchGitLabProjects(client gitlabProjectsService, query string, maxResults int) {
+	opt := &gitlab.ListProjectsOptions{Search: gitlab.String(searchText(query)), ListOptions: gitlab.ListOptions{PerPage: pageSize(maxResults)}}
+	projects, _, err := client.ListProjects(opt)
 	if err != nil {
-		fmt.Printf("Error searching GitLab projects: %s\n", err)
+		recordSearchError("gitlab", "project search", query, err)
 		return
 	}
 
 	projectFullPaths := make([]string, len(projects))
 	for i, project := range projects {
 		projectFullPaths[i] = project.PathWithNamespace
+		recordGitLabProject(project)
 	}
 
-	printResults(fmt.Sprintf("GitLab projects matching '%s'", query), projectFullPaths)
-
-	// Save the content of projectFullPaths to a file called "projects.txt"
-	f, err := os.Create("gitlab_projects.txt")
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-	defer f.Close()
-	for _, project := range projectFullPaths {
-		f.WriteString(project + "\n")
-	}
+	emitResults("gitlab", "project", query, fmt.Sprintf("GitLab projects matching '%s'", query), "gitlab_projects.txt", projectFullPaths)
 }
 
+// createGitLabClient returns a GitLab client authenticated with
+// GITLAB_ACCESS_TOKEN or the token of dorky login or, without either, an
+// anonymous client limited to public data.
 func createGitLabClient() (*gitlab.Client, error) {
-	token := os.Getenv("GITLAB_ACCESS_TOKEN")
-	if token == "" {
-		return nil, errors.New("GITLAB_ACCESS_TOKEN environment variable is not set")
+	token := platformToken("gitlab")
+	gitlabAnonymous = token == ""
+
+	// go-gitlab retries server errors itself; throttled requests are paced
+	// and retried by the shared stack, like those of every provider.
+	transport := newProviderTransport("gitlab", nil, newAdaptiveLimiter("gitlab", 10, rate.Every(time.Minute), func(*http.Request) string { return "api" }))
+	if gitlabAnonymous {
+		transport = anonymousTransport{transport: transport}
 	}
-
-	client, err := gitlab.NewClient(token)
+	httpClient := &http.Client{Transport: transport}
+	client, err := gitlab.NewClient(token, gitlab.WithHTTPClient(httpClient))
 	if err != nil {
 		return nil, err
 	}
@@ -409,15 +1326,21 @@ func createGitLabClient() (*gitlab.Client, error) {
 	return client, nil
 }
 
-func printResults(header string, results []string) {
-	if flags.simpleFlag {
-		for _, result := range results {
-			fmt.Println(result)
-		}
-	} else {
-		fmt.Printf("\n%s:\n", header)
-		for _, result := range results {
-			fmt.Printf("- %s\n", result)
-		}
+// printResults prints batch to w with the run's Encoder.
+func printResults(w io.Writer, batch resultBatch) {
+	if err := consoleEncoder.Encode(w, batch); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// saveResults writes lines to an output file. The first write in a run
+// replaces the file, later writes add to it; every write replaces the file
+// atomically.
+func saveResults(filename string, lines []string) {
+	filename = outputPath(filename)
+	if err := appendOutput(filename, lines); err != nil {
+		fmt.Println(err)
+		return
 	}
-}
\ No newline at end of file
+	trackOutputFile(filename)
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+)
+
+func TestCleanWord(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"acme.com", "acme.com"},
+		{"https://www.Example.com:8443/a?b=c#d", "www.example.com"},
+		{"user:secret@acme.com/login", "acme.com"},
+		{"//cdn.acme.io/x", "cdn.acme.io"},
+		{"ftp://files.acme.org", "files.acme.org"},
+		{"acme.com.", "acme.com"},
+		{"http://[::1]:80/", ""},
+		{"10.0.0.1:22", ""},
+		{"codingo dot com", "codingo dot com"},
+		{"codingo", "codingo"},
+	}
+
+	for _, tt := range tests {
+		if got := cleanWord(tt.in); got != tt.want {
+			t.Errorf("cleanWord(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestProcessWord(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		cfg  config
+		want []string
+	}{
+		{
+			name: "phrase",
+			in:   "codingo dot com",
+			want: []string{"codingo dot com", "codingo-dot-com", "codingodotcom"},
+		},
+		{
+			name: "url cleaned",
+			in:   "https://api.acme.com/v1",
+			cfg:  config{cleanFlag: true, stripPrefixesFlag: "www,api"},
+			want: []string{"acme", "acme.com", "api.acme.com"},
+		},
+		{
+			name: "ip skipped",
+			in:   "https://10.1.2.3/",
+			cfg:  config{cleanFlag: true},
+			want: nil,
+		},
+		{
+			name: "empty",
+			in:   "",
+			want: nil,
+		},
+		{
+			name: "stop word",
+			in:   "The",
+			cfg:  config{stopWordsFlag: defaultStopWords, minWordLengthFlag: 2},
+			want: nil,
+		},
+		{
+			name: "too short",
+			in:   "x",
+			cfg:  config{stopWordsFlag: defaultStopWords, minWordLengthFlag: 2},
+			want: nil,
+		},
+		{
+			name: "transliterated",
+			in:   "Müller Bäckerei",
+			cfg:  config{transliterateFlag: true},
+			want: []string{"Müller Bäckerei", "Müller-Bäckerei", "MüllerBäckerei", "mueller baeckerei", "mueller-baeckerei", "muellerbaeckerei", "muller backerei", "muller-backerei", "mullerbackerei"},
+		},
+		{
+			name: "junk host labels",
+			in:   "https://www.io.co.uk/",
+			cfg:  config{cleanFlag: true, stripPrefixesFlag: "www", stopWordsFlag: defaultStopWords, minWordLengthFlag: 2},
+			want: []string{"io.co.uk", "www.io.co.uk"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words := make(map[string]struct{})
+			processWord(tt.in, words, tt.cfg)
+
+			var got []string
+			for word := range words {
+				got = append(got, word)
+			}
+			if !equalStrings(sortedCopy(got), tt.want) {
+				t.Errorf("processWord(%q) = %v, want %v", tt.in, sortedCopy(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchGitHubOrganizations(t *testing.T) {
+	setupRun(t, config{orgFlag: true})
+	search := &fakeGitHubSearch{users: map[string][]string{"type:org acme": {"acme", "acme-labs"}}}
+
+	searchGitHubOrganizations(search, "acme", 10)
+
+	if want := []string{"type:org acme"}; !equalStrings(search.queries, want) {
+		t.Errorf("queries = %v, want %v", search.queries, want)
+	}
+	if got, want := resultNames("github", "organization"), []string{"acme", "acme-labs"}; !equalStrings(got, want) {
+		t.Errorf("recorded = %v, want %v", got, want)
+	}
+	if got, want := readOutputLines(t, "github_organizations.txt"), []string{"acme", "acme-labs"}; !equalStrings(got, want) {
+		t.Errorf("saved = %v, want %v", got, want)
+	}
+}
+
+func TestSearchGitHubRepositoriesAccumulatesAcrossKeywords(t *testing.T) {
+	setupRun(t, config{repoFlag: true})
+	search := &fakeGitHubSearch{repos: map[string][]string{
+		"acme":      {"acme/api", "acme/web"},
+		"acme-corp": {"ACME/API", "acme-corp/site"},
+	}}
+
+	searchGitHubRepositories(search, "acme", 10)
+	searchGitHubRepositories(search, "acme-corp", 10)
+
+	want := []string{"acme/api", "acme/web", "acme-corp/site"}
+	if got := resultNames("github", "repository"); !equalStrings(got, want) {
+		t.Errorf("recorded = %v, want %v", got, want)
+	}
+	if got := readOutputLines(t, "github_repositories.txt"); !equalStrings(got, want) {
+		t.Errorf("saved = %v, want %v", got, want)
+	}
+}
+
+func TestSearchGitHubUsersBioFilter(t *testing.T) {
+	setupRun(t, config{userFlag: true, bioContainsFlag: "ACME"})
+	search := &fakeGitHubSearch{users: map[string][]string{"type:user acme": {"alice", "bob"}}}
+	users := fakeGitHubUsers{
+		"alice": {Login: github.String("alice"), Bio: github.String("Engineer at Acme")},
+		"bob":   {Login: github.String("bob"), Bio: github.String("Gardener")},
+	}
+
+	searchGitHubUsers(search, users, "acme", 10)
+
+	if got, want := resultNames("github", "user"), []string{"alice"}; !equalStrings(got, want) {
+		t.Errorf("recorded = %v, want %v", got, want)
+	}
+}
+
+func TestSearchGitHubUsersLocationQualifier(t *testing.T) {
+	setupRun(t, config{userFlag: true, locationFlag: `San "Francisco"`})
+	search := &fakeGitHubSearch{}
+
+	searchGitHubUsers(search, fakeGitHubUsers{}, "acme", 10)
+
+	if want := []string{`type:user acme location:"San Francisco"`}; !equalStrings(search.queries, want) {
+		t.Errorf("queries = %v, want %v", search.queries, want)
+	}
+}
+
+func TestSearchGitHubError(t *testing.T) {
+	setupRun(t, config{orgFlag: true})
+	search := &fakeGitHubSearch{err: errors.New("rate limited")}
+
+	searchGitHubOrganizations(search, "acme", 10)
+
+	if len(collectedResults) != 0 {
+		t.Errorf("recorded %d results after an error, want none", len(collectedResults))
+	}
+	if failed := failedOperations(); failed != 1 {
+		t.Errorf("failedOperations = %d, want 1", failed)
+	}
+}
+
+func TestSearchGitHubWikis(t *testing.T) {
+	setupRun(t, config{wikiFlag: true})
+	search := &fakeGitHubSearch{code: map[string][]string{"acme path:wiki": {"acme/docs:wiki/Home.md"}}}
+
+	searchGitHubWikis(search, "acme", 10)
+
+	if got, want := resultNames("github", "wiki"), []string{"acme/docs:wiki/Home.md"}; !equalStrings(got, want) {
+		t.Errorf("recorded = %v, want %v", got, want)
+	}
+}
+
+func TestSearchGitLabProjects(t *testing.T) {
+	setupRun(t, config{repoFlag: true})
+	projects := &fakeGitLabProjects{projects: map[string][]string{"acme": {"acme/infra", "acme/web"}}}
+
+	searchGitLabProjects(projects, "acme", 10)
+
+	if got, want := resultNames("gitlab", "project"), []string{"acme/infra", "acme/web"}; !equalStrings(got, want) {
+		t.Errorf("recorded = %v, want %v", got, want)
+	}
+	if got, want := readOutputLines(t, "gitlab_projects.txt"), []string{"acme/infra", "acme/web"}; !equalStrings(got, want) {
+		t.Errorf("saved = %v, want %v", got, want)
+	}
+}
+
+func TestContainsString(t *testing.T) {
+	if !containsString([]string{"a", "b"}, "b") {
+		t.Error("containsString missed an element")
+	}
+	if containsString(nil, "a") {
+		t.Error("containsString found an element in an empty list")
+	}
+}
+
+func TestConfigProblems(t *testing.T) {
+	setupRun(t, config{})
+
+	valid := config{orgFlag: true, ghAPIFlag: "rest", maxFlag: 10, concurrencyFlag: 1, orBatchFlag: 1, recurseMinReposFlag: 2, minWordLengthFlag: 2}
+	if problems := append(searchFlagProblems(valid), outputFlagProblems(valid)...); len(problems) != 0 {
+		t.Fatalf("valid config has problems: %v", problems)
+	}
+
+	cfg := valid
+	cfg.ghOnlyFlag, cfg.glOnlyFlag = true, true
+	cfg.pastesFlag = true
+	cfg.maxFlag = 1001
+	cfg.concurrencyFlag = 0
+	cfg.employeePattern = "acme("
+	problems := append(searchFlagProblems(cfg), outputFlagProblems(cfg)...)
+	want := []string{
+		"-gh and -gl are mutually exclusive: drop both to search every platform",
+		"-pastes searches a paste index, which -gl leaves out",
+		"-max must be at most 1000, the most results GitHub search returns for a query: add -force-max to go further by slicing GitHub searches by creation date",
+		"-concurrency must be at least 1",
+		"-employee-pattern requires -collaborators",
+		"-employee-pattern: error parsing regexp: missing closing ): `acme(`",
+	}
+	if !equalStrings(problems, want) {
+		t.Errorf("problems = %q, want %q", problems, want)
+	}
+
+	cfg = valid
+	cfg.orgFlag, cfg.ghOnlyFlag, cfg.glSearch = false, true, "blobs"
+	if problems := searchFlagProblems(cfg); !equalStrings(problems, []string{"-gl-search searches GitLab, which -gh leaves out"}) {
+		t.Errorf("problems = %q", problems)
+	}
+}
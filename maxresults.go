@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// githubSearchLimit is the most results GitHub search returns for a query,
+// however many pages are requested: -max can't go beyond it without
+// -force-max.
+const githubSearchLimit = 1000
+
+// githubEpoch is the creation date of the first GitHub accounts, where
+// -force-max starts slicing searches.
+var githubEpoch = time.Date(2008, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// githubSearchPage runs one page of a GitHub search, returning the names
+// found and the total number of results of query.
+type githubSearchPage func(query string, opt *github.SearchOptions) (names []string, total int, err error)
+
+// pageSize is how many results a single search request returns for -max:
+// searches without pagination return no more than that.
+func pageSize(maxResults int) int {
+	if maxResults > maxResultsPerSearch {
+		return maxResultsPerSearch
+	}
+	return maxResults
+}
+
+// collectGitHubSearch fetches the results of a GitHub search page by page
+// until maxResults are found or the search runs out, which it does after
+// githubSearchLimit results. With -force-max, a query matching more is
+// sliced by creation date (created:2008-01-01..2016-07-01 and so on, halved
+// until each slice is within the limit) and the slices searched in turn.
+// The names found before an error are returned with it.
+func collectGitHubSearch(query string, maxResults int, fetch githubSearchPage) ([]string, error) {
+	if !flags.forceMaxFlag || maxResults <= githubSearchLimit {
+		return paginateGitHubSearch(query, maxResults, nil, fetch)
+	}
+	names, err := sliceGitHubSearch(query, githubEpoch, time.Now().UTC().Truncate(24*time.Hour), maxResults, fetch)
+	return uniqueNames(names), err
+}
+
+// exhaustiveGitHubSearch fetches every result of a GitHub search, for
+// -exhaustive: a query matching more than githubSearchLimit results is
+// sliced by creation date until every slice is within the limit, and the
+// union of the slices returned without duplicates, since results created
+// or updated while the slices are searched may turn up in two of them.
+func exhaustiveGitHubSearch(query string, fetch githubSearchPage) ([]string, error) {
+	names, err := sliceGitHubSearch(query, githubEpoch, time.Now().UTC().Truncate(24*time.Hour), math.MaxInt32, fetch)
+	return uniqueNames(names), err
+}
+
+// uniqueNames drops the names repeating an earlier one, whatever their case.
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, name := range names {
+		if key := normalizeName(name); !seen[key] {
+			seen[key] = true
+			unique = append(unique, name)
+		}
+	}
+	return unique
+}
+
+// paginateGitHubSearch fetches the pages of query, starting with first
+// when it's already been fetched.
+func paginateGitHubSearch(query string, maxResults int, first []string, fetch githubSearchPage) ([]string, error) {
+	perPage := pageSize(maxResults)
+	names := first
+	page := 1
+	if first == nil {
+		batch, _, err := fetch(query, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: perPage, Page: page}})
+		if err != nil {
+			return nil, err
+		}
+		names = batch
+	}
+
+	for len(names) < maxResults && len(names) == page*perPage && (page+1)*perPage <= githubSearchLimit {
+		page++
+		batch, _, err := fetch(query, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: perPage, Page: page}})
+		if err != nil {
+			return names, err
+		}
+		names = append(names, batch...)
+	}
+
+	if len(names) > maxResults {
+		names = names[:maxResults]
+	}
+	return names, nil
+}
+
+// sliceGitHubSearch collects up to maxResults results of query among the
+// accounts or repositories created from one day to another, splitting the
+// range in two while it matches more than githubSearchLimit results.
+func sliceGitHubSearch(query string, from, to time.Time, maxResults int, fetch githubSearchPage) ([]string, error) {
+	sliced := fmt.Sprintf("%s created:%s..%s", query, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	perPage := pageSize(maxResults)
+	first, total, err := fetch(sliced, &github.SearchOptions{ListOptions: github.ListOptions{PerPage: perPage, Page: 1}})
+	if err != nil {
+		return nil, err
+	}
+
+	days := int(to.Sub(from).Hours() / 24)
+	if total <= githubSearchLimit || days == 0 {
+		if total > githubSearchLimit {
+			verbosePrint("%s still matches %d results in a single day, keeping the first %d\n", sliced, total, githubSearchLimit)
+		}
+		return paginateGitHubSearch(sliced, maxResults, first, fetch)
+	}
+
+	verbosePrint("%s matches %d results, splitting it by creation date\n", sliced, total)
+	middle := from.AddDate(0, 0, days/2)
+	names, err := sliceGitHubSearch(query, from, middle, maxResults, fetch)
+	if err != nil || len(names) >= maxResults {
+		return names, err
+	}
+	more, err := sliceGitHubSearch(query, middle.AddDate(0, 0, 1), to, maxResults-len(names), fetch)
+	return append(names, more...), err
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+)
+
+var createdRangeRegexp = regexp.MustCompile(`created:(\d{4}-\d{2}-\d{2})\.\.(\d{4}-\d{2}-\d{2})`)
+
+// fakeSearchPages serves count results created one a day from githubEpoch,
+// like GitHub search: at most githubSearchLimit of them per query, paged,
+// filtered by created: ranges. It records the queries it ran.
+type fakeSearchPages struct {
+	count   int
+	queries []string
+}
+
+func (f *fakeSearchPages) fetch(query string, opt *github.SearchOptions) ([]string, int, error) {
+	f.queries = append(f.queries, fmt.Sprintf("%s page %d", query, opt.Page))
+
+	from, to := githubEpoch, githubEpoch.AddDate(100, 0, 0)
+	if m := createdRangeRegexp.FindStringSubmatch(query); m != nil {
+		from, _ = time.Parse("2006-01-02", m[1])
+		to, _ = time.Parse("2006-01-02", m[2])
+	}
+
+	var matches []string
+	for i := 0; i < f.count; i++ {
+		if created := githubEpoch.AddDate(0, 0, i); !created.Before(from) && !created.After(to) {
+			matches = append(matches, fmt.Sprintf("repo%d", i))
+		}
+	}
+
+	start, end := (opt.Page-1)*opt.PerPage, opt.Page*opt.PerPage
+	if end > githubSearchLimit {
+		return nil, 0, fmt.Errorf("only the first %d search results are available", githubSearchLimit)
+	}
+	if start > len(matches) {
+		start = len(matches)
+	}
+	if end > len(matches) {
+		end = len(matches)
+	}
+	return matches[start:end], len(matches), nil
+}
+
+func TestCollectGitHubSearch(t *testing.T) {
+	setupRun(t, config{})
+
+	f := &fakeSearchPages{count: 3000}
+	names, err := collectGitHubSearch("acme", 250, f.fetch)
+	if err != nil || len(names) != 250 || names[249] != "repo249" || len(f.queries) != 3 {
+		t.Errorf("-max 250 gave %d names after %d requests (%v), want 250 after 3", len(names), len(f.queries), err)
+	}
+
+	f = &fakeSearchPages{count: 3000}
+	if names, err := collectGitHubSearch("acme", 1000, f.fetch); err != nil || len(names) != 1000 || len(f.queries) != 10 {
+		t.Errorf("-max 1000 gave %d names after %d requests (%v), want 1000 after 10", len(names), len(f.queries), err)
+	}
+
+	f = &fakeSearchPages{count: 30}
+	if names, err := collectGitHubSearch("acme", 500, f.fetch); err != nil || len(names) != 30 || len(f.queries) != 1 {
+		t.Errorf("30 results gave %d names after %d requests (%v), want 30 after 1", len(names), len(f.queries), err)
+	}
+}
+
+func TestCollectGitHubSearchForceMax(t *testing.T) {
+	setupRun(t, config{forceMaxFlag: true})
+
+	f := &fakeSearchPages{count: 3000}
+	names, err := collectGitHubSearch("acme", 2500, f.fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2500 {
+		t.Fatalf("got %d names, want 2500", len(names))
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			t.Fatalf("%s found twice: slices must not overlap", name)
+		}
+		seen[name] = true
+	}
+	if !seen["repo0"] || !seen["repo2499"] {
+		t.Errorf("names run from %s to %s, want the oldest 2500", names[0], names[len(names)-1])
+	}
+	if got := f.queries[0]; !createdRangeRegexp.MatchString(got) {
+		t.Errorf("first query %q isn't sliced by creation date", got)
+	}
+}
+
+func TestExhaustiveGitHubSearch(t *testing.T) {
+	setupRun(t, config{exhaustiveFlag: true})
+
+	f := &fakeSearchPages{count: 3500}
+	names, err := exhaustiveGitHubSearch("acme", f.fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3500 || names[0] != "repo0" || names[3499] != "repo3499" {
+		t.Errorf("got %d names, from %s to %s, want repo0 to repo3499", len(names), names[0], names[len(names)-1])
+	}
+
+	f = &fakeSearchPages{count: 40}
+	if names, _ := exhaustiveGitHubSearch("acme", f.fetch); len(names) != 40 || len(f.queries) != 1 {
+		t.Errorf("40 results gave %d names after %d requests, want 40 after 1", len(names), len(f.queries))
+	}
+
+	if got, want := uniqueNames([]string{"acme/api", "Acme/API", "acme/cli"}), []string{"acme/api", "acme/cli"}; !equalStrings(got, want) {
+		t.Errorf("uniqueNames = %v, want %v", got, want)
+	}
+}
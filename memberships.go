@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// crossCheckMemberships lists the public organization memberships of every
+// GitHub user found so far, and flags the users belonging to a target
+// organization: one found by this run, or listed in -owned. Membership of
+// the target's organization is strong evidence that a personal account
+// really belongs to the target.
+func crossCheckMemberships(orgs githubOrganizationsService, cfg config) {
+	targetOrgs := make(map[string]bool)
+	for _, owned := range splitList(cfg.ownedFlag) {
+		targetOrgs[normalizeName(owned)] = true
+	}
+
+	var users []string
+	seen := make(map[string]bool)
+	for _, r := range collectedResults {
+		if r.Platform != "github" {
+			continue
+		}
+		switch r.Category {
+		case "organization":
+			targetOrgs[normalizeName(r.Name)] = true
+		case "user":
+			if !seen[normalizeName(r.Name)] {
+				seen[normalizeName(r.Name)] = true
+				users = append(users, r.Name)
+			}
+		}
+	}
+	sort.Strings(users)
+
+	var memberships, targetMembers []string
+	for _, user := range users {
+		verbosePrint("Listing organization memberships of GitHub user: %s\n", user)
+		userOrgs, _, err := orgs.List(context.Background(), user, &github.ListOptions{PerPage: 100})
+		if err != nil {
+			recordSearchError("github", "membership listing", user, err)
+			continue
+		}
+		if len(userOrgs) == 0 {
+			continue
+		}
+
+		var all, targets []string
+		for _, org := range userOrgs {
+			all = append(all, org.GetLogin())
+			if targetOrgs[normalizeName(org.GetLogin())] {
+				targets = append(targets, org.GetLogin())
+			}
+		}
+
+		memberships = append(memberships, user+": "+strings.Join(all, ", "))
+		if len(targets) > 0 {
+			targetMembers = append(targetMembers, user+": "+strings.Join(targets, ", "))
+		}
+	}
+
+	if len(memberships) > 0 {
+		emitResults("github", "membership", "", "GitHub organization memberships of discovered users", "github_memberships.txt", memberships)
+	}
+	if len(targetMembers) > 0 {
+		emitResults("github", "target_member", "", "GitHub users belonging to a target organization", "github_target_members.txt", targetMembers)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import "testing"
+
+func TestCrossCheckMemberships(t *testing.T) {
+	cfg := config{ownedFlag: "acme-labs"}
+	setupRun(t, cfg)
+
+	orgs := fakeGitHubOrganizations{memberships: map[string][]string{
+		"alice": {"acme", "rustlang"},
+		"bob":   {"ACME-Labs"},
+		"carol": {"rustlang"},
+		"dave":  {},
+	}}
+
+	recordResults("github", "organization", "acme", []string{"Acme"})
+	recordResults("github", "user", "acme", []string{"carol", "alice", "bob", "dave", "ghost"})
+	recordResults("gitlab", "user", "acme", []string{"erin"})
+
+	crossCheckMemberships(orgs, cfg)
+
+	if got, want := resultNames("github", "membership"), []string{"alice: acme, rustlang", "bob: ACME-Labs", "carol: rustlang"}; !equalStrings(got, want) {
+		t.Errorf("memberships = %v, want %v", got, want)
+	}
+	if got, want := resultNames("github", "target_member"), []string{"alice: acme", "bob: ACME-Labs"}; !equalStrings(got, want) {
+		t.Errorf("target members = %v, want %v", got, want)
+	}
+	if got, want := readOutputLines(t, "github_target_members.txt"), []string{"alice: acme", "bob: ACME-Labs"}; !equalStrings(got, want) {
+		t.Errorf("saved = %v, want %v", got, want)
+	}
+
+	for _, r := range collectedResults {
+		if r.Category == "target_member" && r.Name == "alice: acme" && r.ID != "github:alice" {
+			t.Errorf("id = %q, want github:alice", r.ID)
+		}
+	}
+	if summary := searchErrorSummary(); len(summary) != 1 || summary[0].Query != "ghost" {
+		t.Errorf("errors = %+v", summary)
+	}
+}
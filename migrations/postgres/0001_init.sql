@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS runs (
+    id          TEXT PRIMARY KEY,
+    started_at  TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS findings (
+    id       BIGSERIAL PRIMARY KEY,
+    run_id   TEXT NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+    platform TEXT NOT NULL,
+    category TEXT NOT NULL,
+    query    TEXT NOT NULL,
+    name     TEXT NOT NULL,
+    found_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS findings_run_id_idx ON findings (run_id);
+CREATE INDEX IF NOT EXISTS findings_identity_idx ON findings (platform, category, name);
@@ -0,0 +1,2 @@
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS tool_version TEXT NOT NULL DEFAULT '';
+ALTER TABLE runs ADD COLUMN IF NOT EXISTS config JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+ALTER TABLE findings ADD COLUMN IF NOT EXISTS canonical_id TEXT;
+
+CREATE INDEX IF NOT EXISTS findings_canonical_id_idx ON findings (canonical_id);
@@ -0,0 +1 @@
+ALTER TABLE findings ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1 @@
+ALTER TABLE findings ADD COLUMN IF NOT EXISTS project JSONB;
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// monitorConfig is the file format accepted by `dorky monitor -config`.
+type monitorConfig struct {
+	Groups []targetGroup `json:"groups"`
+	Notify notifyConfig  `json:"notify"`
+}
+
+// targetGroup is a set of keywords scanned on its own cron schedule. Each
+// group writes its output files into a directory named after the group.
+type targetGroup struct {
+	Name     string   `json:"name"`
+	Schedule string   `json:"schedule"`
+	Keywords []string `json:"keywords"`
+
+	cron  *cronSchedule
+	words map[string]struct{}
+
+	// sources holds the seeds each of words was derived from, which
+	// keywordSources is set to for the group's scans.
+	sources map[string]map[string]bool
+
+	// seen holds the results of the group's earlier scans, nil before
+	// the first one.
+	seen map[string]bool
+
+	// present holds the organizations, repositories, groups and projects
+	// found by the group's latest scan, to notice those that disappear.
+	present map[string]result
+
+	// lastQueried holds when each keyword of the group was last queried,
+	// loaded from its keyword history before the first scan.
+	lastQueried map[string]time.Time
+
+	// lastScan is when the group's latest scan ended and lastError why it
+	// failed, if it did, for /healthz.
+	lastScan  time.Time
+	lastError string
+}
+
+// scanMu serializes scans: groups are scheduled independently, but they
+// share the per-run state and the platforms' rate limits.
+var scanMu sync.Mutex
+
+// runMonitor runs `dorky monitor`, or `dorky serve` when serving health
+// checks on healthAddr by default.
+func runMonitor(name string, args []string, healthAddr string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	configFile := fs.String("config", "", "JSON file defining scheduled target groups")
+	schedule := fs.String("schedule", "", "cron expression for keywords given as arguments or on stdin, and the default for groups without one")
+	slackWebhook := fs.String("slack-webhook", "", "Slack incoming webhook notified of new results, overriding the config's")
+	webhook := fs.String("webhook", "", "URL new and vanished results are posted to as structured JSON, with severities from -rules, overriding the config's")
+	health := fs.String("health-addr", healthAddr, "address to serve /healthz on, such as :8080; none if empty")
+	fs.Parse(args)
+	if flags.workspace != "" {
+		if err := applyWorkspace(fs, flags.workspace); err != nil {
+			fmt.Printf("Error %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if flags.outputDirFlag != "" {
+		outputDir = filepath.Clean(flags.outputDirFlag)
+	}
+	fileKeywords := loadKeywordsFlag(flags)
+	loadRulesFlag(flags)
+	applyCategoriesFlag(&flags)
+	validateFlags(flags)
+
+	// argGroups holds the group given on the command line, which reloads
+	// keep.
+	var groups, argGroups []*targetGroup
+	var notify notifyConfig
+	if *configFile != "" {
+		loaded, loadedNotify, err := loadMonitorConfig(*configFile, *schedule)
+		if err != nil {
+			fmt.Printf("Error loading monitor config: %s\n", err)
+			os.Exit(1)
+		}
+		groups = append(groups, loaded...)
+		notify = loadedNotify
+	}
+	if *slackWebhook != "" {
+		notify.SlackWebhook = *slackWebhook
+	}
+	if *webhook != "" {
+		notify.Webhook = *webhook
+	}
+
+	if *configFile == "" || fs.NArg() > 0 {
+		if *schedule == "" {
+			fmt.Println("Monitor mode requires -schedule or -config")
+			os.Exit(1)
+		}
+
+		cron, err := parseCron(*schedule)
+		if err != nil {
+			fmt.Printf("Error parsing schedule: %s\n", err)
+			os.Exit(1)
+		}
+
+		args := fs.Args()
+		if len(args) == 0 {
+			args = fileKeywords
+		}
+		argGroups = append(argGroups, &targetGroup{
+			Name:     "default",
+			Schedule: *schedule,
+			cron:     cron,
+			words:    readAndCleanWords(flags, args),
+			sources:  keywordSources,
+		})
+		groups = append(groups, argGroups...)
+	}
+
+	svc := newMonitorService(outputDir)
+	svc.start(groups, notify)
+
+	var server *http.Server
+	if *health != "" {
+		server = &http.Server{Addr: *health, Handler: svc.handler()}
+		go func() {
+			if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				fmt.Printf("Error serving health checks: %s\n", err)
+				os.Exit(1)
+			}
+		}()
+		fmt.Printf("Serving health checks on %s/healthz\n", *health)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	for sig := range signals {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		if *configFile == "" {
+			fmt.Println("Nothing to reload: monitor mode was started without -config")
+			continue
+		}
+		loaded, loadedNotify, err := loadMonitorConfig(*configFile, *schedule)
+		if err != nil {
+			fmt.Printf("Error reloading monitor config, keeping the current one: %s\n", err)
+			continue
+		}
+		if *slackWebhook != "" {
+			loadedNotify.SlackWebhook = *slackWebhook
+		}
+		if *webhook != "" {
+			loadedNotify.Webhook = *webhook
+		}
+		svc.reload(append(loaded, argGroups...), loadedNotify)
+		fmt.Printf("Reloaded %s\n", *configFile)
+	}
+
+	// Stop scheduling scans and wait for any in progress, so its exports
+	// aren't cut short.
+	fmt.Println("Stopping monitor...")
+	svc.stop()
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		server.Shutdown(ctx)
+		cancel()
+	}
+	fmt.Println("Monitor stopped.")
+}
+
+func loadMonitorConfig(filename, defaultSchedule string) ([]*targetGroup, notifyConfig, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, notifyConfig{}, err
+	}
+
+	var cfg monitorConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, notifyConfig{}, err
+	}
+
+	if err := cfg.Notify.validate(); err != nil {
+		return nil, notifyConfig{}, err
+	}
+	// Result tags are lower-cased, like keyword tags.
+	for _, route := range cfg.Notify.Routes {
+		for i, tag := range route.Tags {
+			route.Tags[i] = strings.ToLower(tag)
+		}
+	}
+
+	if len(cfg.Groups) == 0 {
+		return nil, notifyConfig{}, errors.New("no target groups defined")
+	}
+
+	seen := make(map[string]bool)
+	groups := make([]*targetGroup, 0, len(cfg.Groups))
+	for i := range cfg.Groups {
+		group := cfg.Groups[i]
+
+		if !validDirName(group.Name) {
+			return nil, notifyConfig{}, fmt.Errorf("group %d: invalid name %q", i+1, group.Name)
+		}
+		if seen[group.Name] {
+			return nil, notifyConfig{}, fmt.Errorf("group '%s' defined more than once", group.Name)
+		}
+		seen[group.Name] = true
+
+		if group.Schedule == "" {
+			group.Schedule = defaultSchedule
+		}
+		if group.Schedule == "" {
+			return nil, notifyConfig{}, fmt.Errorf("group '%s' has no schedule", group.Name)
+		}
+
+		if group.cron, err = parseCron(group.Schedule); err != nil {
+			return nil, notifyConfig{}, fmt.Errorf("group '%s': %w", group.Name, err)
+		}
+
+		if len(group.Keywords) == 0 {
+			return nil, notifyConfig{}, fmt.Errorf("group '%s' has no keywords", group.Name)
+		}
+
+		resetKeywordSources()
+		group.words = make(map[string]struct{})
+		for _, keyword := range group.Keywords {
+			processWord(strings.TrimSpace(keyword), group.words, flags)
+		}
+		group.sources = keywordSources
+
+		groups = append(groups, &group)
+	}
+
+	return groups, cfg.Notify, nil
+}
+
+// monitorGroup runs the scans of group on its schedule until stop is
+// closed.
+func (s *monitorService) monitorGroup(group *targetGroup, notify notifyConfig, stop <-chan struct{}) {
+	for {
+		next := group.cron.next(time.Now())
+		if next.IsZero() {
+			fmt.Printf("Schedule for group '%s' never fires again, stopping it\n", group.Name)
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		scanMu.Lock()
+		// The schedule may have been stopped while an earlier scan ran.
+		select {
+		case <-stop:
+			scanMu.Unlock()
+			return
+		default:
+		}
+		verbosePrint("Starting scheduled scan of group '%s'\n", group.Name)
+		outputDir = filepath.Join(s.baseDir, group.Name)
+		keywordSources = group.sources
+		recordRotation := rotateKeywords(group)
+		err := runScan(group.words, flags)
+		recordRotation()
+		if err != nil {
+			fmt.Printf("Error in scan of group '%s': %s\n", group.Name, err)
+		}
+		notifyNewResults(group, notify)
+		reportVanished(group, s.checker, notify, err == nil)
+		s.recordScan(group, time.Now().UTC(), err)
+		verbosePrint("Scan of group '%s' completed (run %s)\n", group.Name, runID)
+		scanMu.Unlock()
+	}
+}
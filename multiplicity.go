@@ -0,0 +1,58 @@
+package main
+
+import "sort"
+
+// keywordSources maps every search word to the seeds it was derived from:
+// the input lines, hostnames and other keywords processWord was given,
+// normalized like names. A word several seeds led to independently, such as
+// acme from acme.com, www.acme.com and "Acme" on stdin, is the more likely
+// to be relevant, so it's searched first.
+var keywordSources = make(map[string]map[string]bool)
+
+// resetKeywordSources forgets the seeds of the words built so far, before
+// building the keywords of another run.
+func resetKeywordSources() {
+	keywordSources = make(map[string]map[string]bool)
+}
+
+// recordKeywordSource notes that word was derived from source.
+func recordKeywordSource(word, source string) {
+	if keywordSources[word] == nil {
+		keywordSources[word] = make(map[string]bool)
+	}
+	keywordSources[word][normalizeName(source)] = true
+}
+
+// mergeKeywordSources adds the seeds of a word dropped as a duplicate to
+// those of the word kept.
+func mergeKeywordSources(kept, dropped string) {
+	for source := range keywordSources[dropped] {
+		recordKeywordSource(kept, source)
+	}
+}
+
+// keywordMultiplicity is how many distinct seeds word was derived from, at
+// least 1.
+func keywordMultiplicity(word string) int {
+	if n := len(keywordSources[word]); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// weightWords orders words from the one derived from the most seeds, so the
+// keywords most likely to be relevant are searched before -max-total,
+// -max-runtime or -max-errors run out. Ties keep the order of words.
+func weightWords(words []string) []string {
+	weighted := append([]string(nil), words...)
+	sort.SliceStable(weighted, func(i, j int) bool {
+		return keywordMultiplicity(weighted[i]) > keywordMultiplicity(weighted[j])
+	})
+
+	for _, word := range weighted {
+		if n := keywordMultiplicity(word); n > 1 {
+			verbosePrint("'%s' was derived from %d seeds, searching it early\n", word, n)
+		}
+	}
+	return weighted
+}
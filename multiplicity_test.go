@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestKeywordMultiplicity(t *testing.T) {
+	setupRun(t, config{cleanFlag: true, minWordLengthFlag: 2})
+	oldSources := keywordSources
+	keywordSources = make(map[string]map[string]bool)
+	t.Cleanup(func() { keywordSources = oldSources })
+
+	// acme is derived from three seeds, the repeated line counting once,
+	// and Acme folds into it as a duplicate.
+	words := make(map[string]struct{})
+	for _, seed := range []string{"acme.com", "www.acme.com", "shop.globex.com", "Acme", "acme.com#brand"} {
+		processWord(seed, words, flags)
+	}
+	dedupeKeywords(words)
+
+	for word, want := range map[string]int{"acme": 3, "acme.com": 1, "globex": 1, "unknown": 1} {
+		if got := keywordMultiplicity(word); got != want {
+			t.Errorf("keywordMultiplicity(%s) = %d, want %d", word, got, want)
+		}
+	}
+
+	want := []string{"acme", "acme.com", "globex", "shop", "shop.globex.com", "www", "www.acme.com"}
+	if got := weightWords(sortedWords(words)); !equalStrings(got, want) {
+		t.Errorf("weightWords = %v, want %v", got, want)
+	}
+}
+
+func TestWeightWordsKeepsTies(t *testing.T) {
+	oldSources := keywordSources
+	keywordSources = make(map[string]map[string]bool)
+	t.Cleanup(func() { keywordSources = oldSources })
+
+	recordKeywordSource("zeta", "zeta.io")
+	recordKeywordSource("zeta", "zeta.dev")
+	recordKeywordSource("beta", "beta")
+
+	want := []string{"zeta", "alpha", "beta", "gamma"}
+	if got := weightWords([]string{"alpha", "beta", "gamma", "zeta"}); !equalStrings(got, want) {
+		t.Errorf("weightWords = %v, want %v", got, want)
+	}
+}
+
+func TestKeywordSourcesPerRun(t *testing.T) {
+	setupRun(t, config{cleanFlag: true, minWordLengthFlag: 2})
+	oldSources := keywordSources
+	t.Cleanup(func() { keywordSources = oldSources })
+
+	// The seeds of one run's keywords don't weigh on the next run's.
+	readAndCleanWords(flags, []string{"acme.com", "www.acme.com"})
+	if got := keywordMultiplicity("acme"); got != 2 {
+		t.Fatalf("keywordMultiplicity(acme) = %d, want 2", got)
+	}
+	readAndCleanWords(flags, []string{"acme.com"})
+	if got := keywordMultiplicity("acme"); got != 1 {
+		t.Errorf("keywordMultiplicity(acme) in the next run = %d, want 1", got)
+	}
+}
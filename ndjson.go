@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ndjsonFile receives every result of the run as one JSON line the moment
+// it is recorded, with -ndjson, so other tools can follow a scan while it
+// runs. Guarded by stateMu.
+var ndjsonFile *os.File
+
+func openNDJSON(filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	stateMu.Lock()
+	trackOutputFile(filename)
+	ndjsonFile = f
+	stateMu.Unlock()
+
+	return nil
+}
+
+func closeNDJSON() {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	if ndjsonFile != nil {
+		ndjsonFile.Close()
+		ndjsonFile = nil
+	}
+}
+
+// streamResult writes r to the NDJSON stream, if one is open. stateMu must
+// be held.
+func streamResult(r result) {
+	if ndjsonFile == nil {
+		return
+	}
+
+	line, err := json.Marshal(r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if _, err := ndjsonFile.Write(append(line, '\n')); err != nil {
+		fmt.Println(err)
+	}
+}
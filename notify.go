@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxNotifiedResults caps the results listed in a single notification.
+const maxNotifiedResults = 50
+
+// notifyConfig is the "notify" section of the monitor config: where new
+// results are sent, and which of them.
+type notifyConfig struct {
+	SlackWebhook string        `json:"slack_webhook"`
+	Webhook      string        `json:"webhook"`
+	Routes       []notifyRoute `json:"routes"`
+}
+
+// notifyRoute selects the results notified. A result matches a route
+// if it meets every condition the route sets; each list matches any of its
+// values. With no routes, every new result is sent.
+type notifyRoute struct {
+	Tags       []string `json:"tags"`
+	Platforms  []string `json:"platforms"`
+	Categories []string `json:"categories"`
+}
+
+func (r notifyRoute) matches(res result) bool {
+	if len(r.Tags) > 0 && !containsAnyString(res.Tags, r.Tags) {
+		return false
+	}
+	if len(r.Platforms) > 0 && !containsString(r.Platforms, res.Platform) {
+		return false
+	}
+	if len(r.Categories) > 0 && !containsString(r.Categories, res.Category) {
+		return false
+	}
+	return true
+}
+
+// validate rejects routes that would match every result, which is surely a
+// typo rather than a way to spell "no routes".
+func (c notifyConfig) validate() error {
+	for i, route := range c.Routes {
+		if len(route.Tags) == 0 && len(route.Platforms) == 0 && len(route.Categories) == 0 {
+			return fmt.Errorf("notify route %d has no conditions", i+1)
+		}
+	}
+	return nil
+}
+
+// routeResults returns the results matching at least one route.
+func routeResults(results []result, routes []notifyRoute) []result {
+	if len(routes) == 0 {
+		return results
+	}
+
+	var routed []result
+	for _, res := range results {
+		for _, route := range routes {
+			if route.matches(res) {
+				routed = append(routed, res)
+				break
+			}
+		}
+	}
+	return routed
+}
+
+// newGroupResults returns the results of the scan that weren't found by any
+// earlier scan of group, and remembers them. The first scan only records a
+// baseline, so starting the monitor doesn't notify everything it finds.
+func newGroupResults(group *targetGroup, results []result) []result {
+	first := group.seen == nil
+	if first {
+		group.seen = make(map[string]bool)
+	}
+
+	var fresh []result
+	for _, res := range results {
+		key := res.Platform + "\x00" + res.Category + "\x00" + normalizeName(res.Name)
+		if group.seen[key] {
+			continue
+		}
+		group.seen[key] = true
+		if !first {
+			fresh = append(fresh, res)
+		}
+	}
+	return fresh
+}
+
+// notifySlack posts results to a Slack incoming webhook as a single message.
+func notifySlack(webhook, groupName string, results []result) error {
+	lines := []string{fmt.Sprintf("dorky found %d new results for '%s':", len(results), groupName)}
+	for i, res := range results {
+		if i == maxNotifiedResults {
+			lines = append(lines, fmt.Sprintf("...and %d more", len(results)-maxNotifiedResults))
+			break
+		}
+		line := fmt.Sprintf("- [%s %s] %s", res.Platform, res.Category, res.Name)
+		if len(res.Tags) > 0 {
+			line += " #" + strings.Join(res.Tags, " #")
+		}
+		lines = append(lines, line)
+	}
+
+	return postSlack(webhook, strings.Join(lines, "\n"))
+}
+
+// postSlack posts a plain text message to a Slack incoming webhook.
+func postSlack(webhook, text string) error {
+	return postJSON(webhook, map[string]string{"text": text})
+}
+
+// postJSON posts v as JSON to a webhook.
+func postJSON(webhook string, v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(webhook, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// webhookPayload is what the generic webhook receives: one event about the
+// results of a group's scan, with the fields a SOAR platform needs to
+// triage them without parsing text.
+type webhookPayload struct {
+	Source    string          `json:"source"`
+	Event     string          `json:"event"`
+	Group     string          `json:"group"`
+	RunID     string          `json:"run_id"`
+	Timestamp time.Time       `json:"timestamp"`
+	Severity  string          `json:"severity"`
+	Results   []webhookResult `json:"results"`
+}
+
+// Webhook events.
+const (
+	webhookNewResults      = "new_results"
+	webhookVanishedResults = "vanished_results"
+)
+
+type webhookResult struct {
+	Platform   string    `json:"platform"`
+	Category   string    `json:"category"`
+	Identifier string    `json:"identifier,omitempty"`
+	Name       string    `json:"name"`
+	Query      string    `json:"query"`
+	Confidence string    `json:"confidence,omitempty"`
+	Tags       []string  `json:"tags"`
+	FirstSeen  time.Time `json:"first_seen"`
+	Severity   string    `json:"severity"`
+
+	// Outcome and RenamedTo tell what became of vanished results.
+	Outcome   string `json:"outcome,omitempty"`
+	RenamedTo string `json:"renamed_to,omitempty"`
+}
+
+// newWebhookPayload describes results for the webhook. firstSeen holds when
+// the -state file first recorded each result; results it doesn't hold were
+// first seen by the scan. The event's severity is that of its most severe
+// result.
+func newWebhookPayload(event, groupName string, results []result, firstSeen map[string]time.Time) webhookPayload {
+	payload := webhookPayload{Source: "dorky", Event: event, Group: groupName, RunID: runID, Timestamp: time.Now().UTC(), Severity: "info", Results: []webhookResult{}}
+	for _, res := range results {
+		tags := res.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		seen, ok := firstSeen[stateKey(res.Platform, res.Category, res.Name)]
+		if !ok {
+			seen = res.Timestamp
+		}
+		wr := webhookResult{
+			Platform:   res.Platform,
+			Category:   res.Category,
+			Identifier: res.ID,
+			Name:       res.Name,
+			Query:      res.Query,
+			Confidence: resultConfidence(res.Category, res.Query, res.Name),
+			Tags:       tags,
+			FirstSeen:  seen,
+			Severity:   resultSeverity(res.Tags),
+		}
+		if severityLevels[wr.Severity] > severityLevels[payload.Severity] {
+			payload.Severity = wr.Severity
+		}
+		payload.Results = append(payload.Results, wr)
+	}
+	return payload
+}
+
+// stateFirstSeen returns when the -state file first recorded each result,
+// by state key, nil without one.
+func stateFirstSeen(cfg config) map[string]time.Time {
+	if cfg.stateFlag == "" {
+		return nil
+	}
+	state, err := readStateFile(outputPath(cfg.stateFlag))
+	if err != nil {
+		verbosePrint("Not reading first-seen dates from the state file: %s\n", err)
+		return nil
+	}
+	firstSeen := make(map[string]time.Time)
+	for _, s := range state.Results {
+		firstSeen[stateKey(s.Platform, s.Category, s.Name)] = s.FirstSeen
+	}
+	return firstSeen
+}
+
+// notifyNewResults sends the new results of a group's scan that match the
+// routes to Slack and the webhook. Every result is still exported as usual:
+// routes only keep noisy keywords out of notifications.
+func notifyNewResults(group *targetGroup, notify notifyConfig) {
+	fresh := newGroupResults(group, collectedResults)
+	if notify.SlackWebhook == "" && notify.Webhook == "" {
+		return
+	}
+
+	routed := routeResults(fresh, notify.Routes)
+	verbosePrint("Group '%s': %d new results, %d routed to notifications\n", group.Name, len(fresh), len(routed))
+	if len(routed) == 0 {
+		return
+	}
+	if notify.SlackWebhook != "" {
+		if err := notifySlack(notify.SlackWebhook, group.Name, routed); err != nil {
+			fmt.Printf("Error notifying Slack for group '%s': %s\n", group.Name, err)
+		}
+	}
+	if notify.Webhook != "" {
+		payload := newWebhookPayload(webhookNewResults, group.Name, routed, stateFirstSeen(flags))
+		if err := postJSON(notify.Webhook, payload); err != nil {
+			fmt.Printf("Error notifying the webhook for group '%s': %s\n", group.Name, err)
+		}
+	}
+}
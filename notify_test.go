@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteResults(t *testing.T) {
+	results := []result{
+		{Platform: "github", Category: "repository", Name: "acme/db-backup", Tags: []string{"high-risk"}},
+		{Platform: "github", Category: "user", Name: "acme-fan"},
+		{Platform: "gitlab", Category: "project", Name: "acme/web"},
+		{Platform: "pastes", Category: "paste", Name: "https://pastebin.com/Ab12Cd34"},
+	}
+	routes := []notifyRoute{
+		{Tags: []string{"high-risk"}},
+		{Platforms: []string{"gitlab", "pastes"}, Categories: []string{"paste"}},
+	}
+
+	var names []string
+	for _, r := range routeResults(results, routes) {
+		names = append(names, r.Name)
+	}
+	if want := []string{"acme/db-backup", "https://pastebin.com/Ab12Cd34"}; !equalStrings(names, want) {
+		t.Errorf("routed = %v, want %v", names, want)
+	}
+
+	if got := routeResults(results, nil); len(got) != len(results) {
+		t.Errorf("no routes routed %d results, want all %d", len(got), len(results))
+	}
+
+	if err := (notifyConfig{Routes: []notifyRoute{{}}}).validate(); err == nil {
+		t.Error("a route without conditions was accepted")
+	}
+}
+
+func TestNotifyNewResults(t *testing.T) {
+	setupRun(t, config{})
+
+	var messages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		messages = append(messages, payload.Text)
+	}))
+	t.Cleanup(srv.Close)
+
+	group := &targetGroup{Name: "acme"}
+	notify := notifyConfig{SlackWebhook: srv.URL, Routes: []notifyRoute{{Categories: []string{"repository"}}}}
+
+	// The first scan is the baseline.
+	collectedResults = []result{{Platform: "github", Category: "repository", Name: "acme/api"}}
+	notifyNewResults(group, notify)
+	if len(messages) != 0 {
+		t.Fatalf("the first scan notified %v", messages)
+	}
+
+	collectedResults = []result{
+		{Platform: "github", Category: "repository", Name: "ACME/api"},
+		{Platform: "github", Category: "repository", Name: "acme/db-backup", Tags: []string{"high-risk"}},
+		{Platform: "github", Category: "user", Name: "acme-fan"},
+	}
+	notifyNewResults(group, notify)
+	if len(messages) != 1 {
+		t.Fatalf("got %d notifications, want 1", len(messages))
+	}
+	if want := "dorky found 1 new results for 'acme':\n- [github repository] acme/db-backup #high-risk"; messages[0] != want {
+		t.Errorf("message = %q, want %q", messages[0], want)
+	}
+
+	// Results routed away are still remembered, and not notified later.
+	notifyNewResults(group, notify)
+	if len(messages) != 1 || strings.Contains(strings.Join(messages, "\n"), "acme-fan") {
+		t.Errorf("messages = %v", messages)
+	}
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	setupRun(t, config{stateFlag: "state.json"})
+	riskRules = []riskRule{{Tag: "high-risk", Severity: "high"}}
+
+	var payloads []webhookPayload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		payloads = append(payloads, payload)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+
+	firstSeen := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := writeStateFile(outputPath("state.json"), &stateFile{Results: []resultState{{Platform: "github", Category: "repository", Name: "acme/db-backup", FirstSeen: firstSeen}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	group := &targetGroup{Name: "acme", seen: map[string]bool{}}
+	found := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	collectedResults = []result{
+		{Platform: "github", Category: "repository", Query: "acme", Name: "ACME/db-backup", ID: "github:acme/db-backup", Tags: []string{"high-risk"}, Timestamp: found},
+		{Platform: "github", Category: "user", Query: "acme", Name: "Acme", ID: "github:acme", Timestamp: found},
+	}
+	notifyNewResults(group, notifyConfig{Webhook: srv.URL})
+	if len(payloads) != 1 {
+		t.Fatalf("got %d payloads, want 1", len(payloads))
+	}
+
+	p := payloads[0]
+	if p.Source != "dorky" || p.Event != "new_results" || p.Group != "acme" || p.RunID != runID || p.Severity != "high" {
+		t.Errorf("payload = %+v, want a high severity new_results event of acme", p)
+	}
+	want := []webhookResult{
+		{Platform: "github", Category: "repository", Identifier: "github:acme/db-backup", Name: "ACME/db-backup", Query: "acme", Confidence: "medium", Tags: []string{"high-risk"}, FirstSeen: firstSeen, Severity: "high"},
+		{Platform: "github", Category: "user", Identifier: "github:acme", Name: "Acme", Query: "acme", Confidence: "high", Tags: []string{}, FirstSeen: found, Severity: "info"},
+	}
+	if !reflect.DeepEqual(p.Results, want) {
+		t.Errorf("results = %+v, want %+v", p.Results, want)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+)
+
+const (
+	// rollupRecentRepos is how many of an organization's most recently
+	// pushed repositories a rollup lists, and whose contributors it counts.
+	rollupRecentRepos = 5
+
+	// rollupTopContributors is how many contributors a rollup lists.
+	rollupTopContributors = 5
+)
+
+// orgRollup summarizes the footprint of a discovered GitHub organization.
+type orgRollup struct {
+	Org string `json:"org"`
+	ID  string `json:"id"`
+
+	// TotalRepos counts the public repositories, and the private ones if
+	// the token can see them.
+	TotalRepos int `json:"total_repos"`
+
+	// Languages counts the repositories by primary language, over the 100
+	// most recently pushed.
+	Languages map[string]int `json:"languages"`
+
+	RecentRepos     []rollupRepo        `json:"recent_repos"`
+	TopContributors []rollupContributor `json:"top_contributors"`
+}
+
+type rollupRepo struct {
+	Name     string    `json:"name"`
+	Language string    `json:"language,omitempty"`
+	Stars    int       `json:"stars"`
+	PushedAt time.Time `json:"pushed_at"`
+}
+
+// rollupContributor sums a user's commits to the organization's most
+// recently pushed repositories.
+type rollupContributor struct {
+	Login         string `json:"login"`
+	Contributions int    `json:"contributions"`
+}
+
+// orgRollups holds the rollups of the current run.
+var orgRollups []orgRollup
+
+// rollupOrganizations rolls up every GitHub organization found so far.
+func rollupOrganizations(orgs githubOrganizationsService, repos githubRepositoriesService) {
+	seen := make(map[string]bool)
+	discovered := append([]result(nil), collectedResults...)
+
+	for _, r := range discovered {
+		if r.Platform != "github" || r.Category != "organization" || seen[normalizeName(r.Name)] {
+			continue
+		}
+		seen[normalizeName(r.Name)] = true
+
+		rollup, ok := rollupGitHubOrganization(orgs, repos, r.Name)
+		if !ok {
+			continue
+		}
+		orgRollups = append(orgRollups, *rollup)
+
+		lines := rollup.format()
+		printResults(os.Stdout, resultBatch{
+			Platform: "github",
+			Category: "org_rollup",
+			Query:    rollup.Org,
+			Header:   fmt.Sprintf("GitHub organization rollup of '%s'", rollup.Org),
+			Results:  lines,
+		})
+		saveResults("github_org_rollups.txt", append([]string{rollup.Org}, lines...))
+	}
+}
+
+func rollupGitHubOrganization(orgs githubOrganizationsService, repos githubRepositoriesService, org string) (*orgRollup, bool) {
+	ctx := context.Background()
+	verbosePrint("Rolling up GitHub organization: %s\n", org)
+
+	details, _, err := orgs.Get(ctx, org)
+	if err != nil {
+		recordSearchError("github", "organization rollup", org, err)
+		return nil, false
+	}
+
+	opt := &github.RepositoryListByOrgOptions{Sort: "pushed", Direction: "desc", ListOptions: github.ListOptions{PerPage: 100}}
+	orgRepos, _, err := repos.ListByOrg(ctx, org, opt)
+	if err != nil {
+		recordSearchError("github", "organization rollup", org, err)
+		return nil, false
+	}
+
+	rollup := &orgRollup{
+		Org:        org,
+		ID:         canonicalID("github", "organization", org, org),
+		TotalRepos: details.GetPublicRepos() + details.GetTotalPrivateRepos(),
+		Languages:  make(map[string]int),
+	}
+
+	for _, repo := range orgRepos {
+		if language := repo.GetLanguage(); language != "" {
+			rollup.Languages[language]++
+		}
+	}
+
+	contributions := make(map[string]int)
+	for i, repo := range orgRepos {
+		if i == rollupRecentRepos {
+			break
+		}
+		rollup.RecentRepos = append(rollup.RecentRepos, rollupRepo{
+			Name:     repo.GetFullName(),
+			Language: repo.GetLanguage(),
+			Stars:    repo.GetStargazersCount(),
+			PushedAt: repo.GetPushedAt().Time,
+		})
+
+		contributors, _, err := repos.ListContributors(ctx, org, repo.GetName(), &github.ListContributorsOptions{ListOptions: github.ListOptions{PerPage: 100}})
+		if err != nil {
+			recordSearchError("github", "contributor listing", repo.GetFullName(), err)
+			continue
+		}
+		for _, contributor := range contributors {
+			if contributor.GetLogin() != "" {
+				contributions[contributor.GetLogin()] += contributor.GetContributions()
+			}
+		}
+	}
+
+	for login, count := range contributions {
+		rollup.TopContributors = append(rollup.TopContributors, rollupContributor{Login: login, Contributions: count})
+	}
+	sort.Slice(rollup.TopContributors, func(i, j int) bool {
+		a, b := rollup.TopContributors[i], rollup.TopContributors[j]
+		if a.Contributions != b.Contributions {
+			return a.Contributions > b.Contributions
+		}
+		return a.Login < b.Login
+	})
+	if len(rollup.TopContributors) > rollupTopContributors {
+		rollup.TopContributors = rollup.TopContributors[:rollupTopContributors]
+	}
+
+	return rollup, true
+}
+
+// format renders the rollup as result lines for the console and the
+// output file.
+func (r orgRollup) format() []string {
+	languages := make([]string, 0, len(r.Languages))
+	for language := range r.Languages {
+		languages = append(languages, language)
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		a, b := languages[i], languages[j]
+		if r.Languages[a] != r.Languages[b] {
+			return r.Languages[a] > r.Languages[b]
+		}
+		return a < b
+	})
+	for i, language := range languages {
+		languages[i] = fmt.Sprintf("%s (%d)", language, r.Languages[language])
+	}
+
+	recent := make([]string, len(r.RecentRepos))
+	for i, repo := range r.RecentRepos {
+		recent[i] = fmt.Sprintf("%s (%s)", repo.Name, repo.PushedAt.Format("2006-01-02"))
+	}
+
+	contributors := make([]string, len(r.TopContributors))
+	for i, contributor := range r.TopContributors {
+		contributors[i] = fmt.Sprintf("%s (%d)", contributor.Login, contributor.Contributions)
+	}
+
+	return []string{
+		fmt.Sprintf("repositories: %d", r.TotalRepos),
+		"languages: " + strings.Join(languages, ", "),
+		"recently active: " + strings.Join(recent, ", "),
+		"top contributors: " + strings.Join(contributors, ", "),
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v38/github"
+)
+
+type fakeGitHubOrganizations struct {
+	orgs        map[string]*github.Organization
+	memberships map[string][]string
+}
+
+func (f fakeGitHubOrganizations) Get(ctx context.Context, org string) (*github.Organization, *github.Response, error) {
+	if o, ok := f.orgs[org]; ok {
+		return o, nil, nil
+	}
+	return nil, nil, errors.New("not found")
+}
+
+func (f fakeGitHubOrganizations) List(ctx context.Context, user string, opts *github.ListOptions) ([]*github.Organization, *github.Response, error) {
+	memberships, ok := f.memberships[user]
+	if !ok {
+		return nil, nil, errors.New("not found")
+	}
+
+	var orgs []*github.Organization
+	for _, login := range memberships {
+		orgs = append(orgs, &github.Organization{Login: github.String(login)})
+	}
+	return orgs, nil, nil
+}
+
+type fakeGitHubRepositories struct {
+	repos        map[string][]*github.Repository
+	contributors map[string][]*github.Contributor
+}
+
+func (f fakeGitHubRepositories) ListByOrg(ctx context.Context, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
+	if opts.Sort != "pushed" || opts.Direction != "desc" {
+		return nil, nil, errors.New("repositories must be listed most recently pushed first")
+	}
+	return f.repos[org], nil, nil
+}
+
+func (f fakeGitHubRepositories) ListContributors(ctx context.Context, owner, repository string, opts *github.ListContributorsOptions) ([]*github.Contributor, *github.Response, error) {
+	return f.contributors[owner+"/"+repository], nil, nil
+}
+
+func fakeRepo(org, name, language string, pushed time.Time) *github.Repository {
+	return &github.Repository{
+		Name:     github.String(name),
+		FullName: github.String(org + "/" + name),
+		Language: github.String(language),
+		PushedAt: &github.Timestamp{Time: pushed},
+	}
+}
+
+func fakeContributor(login string, contributions int) *github.Contributor {
+	return &github.Contributor{Login: github.String(login), Contributions: github.Int(contributions)}
+}
+
+func TestRollupOrganizations(t *testing.T) {
+	setupRun(t, config{})
+
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var repos []*github.Repository
+	for i, name := range []string{"api", "web", "cli", "docs", "infra", "legacy"} {
+		language := "Go"
+		if name == "web" || name == "docs" {
+			language = "TypeScript"
+		}
+		repos = append(repos, fakeRepo("acme", name, language, day.AddDate(0, 0, -i)))
+	}
+
+	orgs := fakeGitHubOrganizations{orgs: map[string]*github.Organization{
+		"acme": {PublicRepos: github.Int(40), TotalPrivateRepos: github.Int(2)},
+	}}
+	repoService := fakeGitHubRepositories{
+		repos: map[string][]*github.Repository{"acme": repos},
+		contributors: map[string][]*github.Contributor{
+			"acme/api":    {fakeContributor("alice", 50), fakeContributor("bob", 10)},
+			"acme/web":    {fakeContributor("bob", 45), fakeContributor("carol", 5)},
+			"acme/legacy": {fakeContributor("mallory", 999)},
+		},
+	}
+
+	recordResults("github", "organization", "acme", []string{"acme", "ACME", "ghost"})
+	recordResults("github", "repository", "acme", []string{"acme/api"})
+
+	rollupOrganizations(orgs, repoService)
+
+	if len(orgRollups) != 1 {
+		t.Fatalf("got %d rollups, want 1: %+v", len(orgRollups), orgRollups)
+	}
+	r := orgRollups[0]
+
+	if r.Org != "acme" || r.ID != "github:acme" || r.TotalRepos != 42 {
+		t.Errorf("rollup = %+v", r)
+	}
+	if r.Languages["Go"] != 4 || r.Languages["TypeScript"] != 2 {
+		t.Errorf("languages = %v", r.Languages)
+	}
+	if len(r.RecentRepos) != rollupRecentRepos || r.RecentRepos[0].Name != "acme/api" || !r.RecentRepos[0].PushedAt.Equal(day) {
+		t.Errorf("recent repos = %+v", r.RecentRepos)
+	}
+	// legacy is not among the recent repositories, so mallory doesn't count.
+	want := []rollupContributor{{"bob", 55}, {"alice", 50}, {"carol", 5}}
+	if len(r.TopContributors) != len(want) {
+		t.Fatalf("top contributors = %+v, want %+v", r.TopContributors, want)
+	}
+	for i := range want {
+		if r.TopContributors[i] != want[i] {
+			t.Errorf("top contributors = %+v, want %+v", r.TopContributors, want)
+		}
+	}
+
+	if summary := searchErrorSummary(); len(summary) != 1 || summary[0].Query != "ghost" || summary[0].Operation != "organization rollup" {
+		t.Errorf("errors = %+v", summary)
+	}
+
+	lines := readOutputLines(t, "github_org_rollups.txt")
+	wantLines := []string{
+		"acme",
+		"repositories: 42",
+		"languages: Go (4), TypeScript (2)",
+		"recently active: acme/api (2024-03-01), acme/web (2024-02-29), acme/cli (2024-02-28), acme/docs (2024-02-27), acme/infra (2024-02-26)",
+		"top contributors: bob (55), alice (50), carol (5)",
+	}
+	if !equalStrings(lines, wantLines) {
+		t.Errorf("output file = %q, want %q", lines, wantLines)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// partialReason says why the current run ended before its searches did,
+// after an interrupt or a fatal error; empty for a run that completed.
+// Once set, searches still under way record no more results, so the
+// reports are written from a stable set. Guarded by stateMu.
+var partialReason string
+
+// cancelSearch, when set, ends the current run as if interrupted once it
+// is closed, for callers driving dorky programmatically such as -stdio.
+var cancelSearch chan struct{}
+
+// cancelledReason is the reason of a run ended through cancelSearch.
+const cancelledReason = "cancelled"
+
+// runInterruptedError is returned by a run that ended early but still
+// reported and exported the results it had gathered, marked as partial.
+type runInterruptedError struct {
+	reason string
+	signal bool
+}
+
+func (e *runInterruptedError) Error() string {
+	return fmt.Sprintf("run %s, its reports are partial", e.reason)
+}
+
+// runSearch runs search until it returns, the process is interrupted or
+// search panics. It returns the error ending the run early, if any, after
+// which the run only reports what it found so far. A second interrupt
+// kills the process as usual.
+func runSearch(search func()) *runInterruptedError {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	done := make(chan *runInterruptedError, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- &runInterruptedError{reason: fmt.Sprintf("failed with a fatal error: %v", r)}
+			}
+		}()
+		search()
+		done <- nil
+	}()
+
+	var interrupted *runInterruptedError
+	select {
+	case interrupted = <-done:
+	case sig := <-stop:
+		interrupted = &runInterruptedError{reason: "interrupted by " + sig.String(), signal: true}
+	case <-cancelSearch:
+		interrupted = &runInterruptedError{reason: cancelledReason}
+	}
+	if interrupted != nil {
+		markPartial(interrupted.reason)
+	}
+	return interrupted
+}
+
+// markPartial stops the recording of results and marks the run's reports
+// as partial.
+func markPartial(reason string) {
+	stateMu.Lock()
+	partialReason = reason
+	count := len(collectedResults)
+	stateMu.Unlock()
+
+	fmt.Fprintf(os.Stderr, "\nRun %s: reporting the %d results found so far as partial\n", reason, count)
+}
+
+// runPartial returns why the run ended early, or "" if it completed.
+func runPartial() string {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	return partialReason
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFatalErrorReportsPartialResults(t *testing.T) {
+	setupRun(t, config{jsonFlag: "report.json"})
+
+	err := runAndExport(flags, func() {
+		emitResults("github", "organization", "acme", "GitHub organizations matching 'acme'", "github_organizations.txt", []string{"acme"})
+		panic("boom")
+	})
+
+	if code := exitCode(err); code != 1 {
+		t.Errorf("exitCode = %d, want 1 (err: %v)", code, err)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(outputDir, "report.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r report
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Partial != "failed with a fatal error: boom" {
+		t.Errorf("partial = %q", r.Partial)
+	}
+	if len(r.Results) != 1 || r.Results[0].Name != "acme" {
+		t.Errorf("results = %+v, want acme", r.Results)
+	}
+}
+
+func TestInterruptReportsPartialResults(t *testing.T) {
+	setupRun(t, config{sarifFlag: "report.sarif"})
+
+	release := make(chan struct{})
+	defer close(release)
+
+	err := runAndExport(flags, func() {
+		emitResults("github", "organization", "acme", "GitHub organizations matching 'acme'", "github_organizations.txt", []string{"acme"})
+		if p, err := os.FindProcess(os.Getpid()); err == nil {
+			p.Signal(os.Interrupt)
+		}
+		<-release
+	})
+
+	if code := exitCode(err); code != 130 {
+		t.Errorf("exitCode = %d, want 130 (err: %v)", code, err)
+	}
+
+	// A search still under way records nothing more.
+	emitResults("github", "organization", "globex", "GitHub organizations matching 'globex'", "github_organizations.txt", []string{"globex"})
+	if got := resultNames("github", "organization"); !equalStrings(got, []string{"acme"}) {
+		t.Errorf("results = %v, want [acme]", got)
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(outputDir, "report.sarif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	if inv := log.Runs[0].Invocations; len(inv) != 1 || inv[0].ExecutionSuccessful {
+		t.Errorf("invocations = %+v, want one unsuccessful", inv)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// defaultPastesURL is the search endpoint of psbdmp, a public index of
+// Pastebin dumps.
+const defaultPastesURL = "https://psbdmp.ws/api/v3/search"
+
+// pasteClient searches a psbdmp-style paste index: GET <baseURL>/<keyword>
+// answers a JSON list of pastes, or an object holding it under "data".
+type pasteClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+type indexedPaste struct {
+	ID   string `json:"id"`
+	URL  string `json:"url"`
+	Time string `json:"time"`
+}
+
+// url links to the paste itself. Indexes that don't give one index Pastebin.
+func (p indexedPaste) url() string {
+	if p.URL != "" {
+		return p.URL
+	}
+	return "https://pastebin.com/" + p.ID
+}
+
+func createPasteClient(baseURL string) (*pasteClient, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid paste index URL %q", baseURL)
+	}
+
+	// Paste indexes are run on a shoestring, so they're asked gently.
+	transport := newProviderTransport("pastes", nil, newAdaptiveLimiter("pastes", 2, rate.Every(time.Minute), func(*http.Request) string { return "search" }))
+
+	return &pasteClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Transport: transport, Timeout: time.Minute},
+	}, nil
+}
+
+func (c *pasteClient) search(query string) ([]indexedPaste, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/"+url.PathEscape(query), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	}
+
+	var pastes []indexedPaste
+	if err := json.Unmarshal(body, &pastes); err == nil {
+		return pastes, nil
+	}
+	var wrapped struct {
+		Data []indexedPaste `json:"data"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err != nil {
+		return nil, err
+	}
+	return wrapped.Data, nil
+}
+
+// searchPastes reports the URLs of indexed pastes mentioning query. Leaks
+// often surface on paste sites before the repository they came from is
+// noticed.
+func searchPastes(client *pasteClient, query string, maxResults int) {
+	pastes, err := client.search(searchText(query))
+	if err != nil {
+		recordSearchError("pastes", "paste search", query, err)
+		return
+	}
+
+	var urls []string
+	for _, paste := range pastes {
+		if len(urls) == maxResults {
+			break
+		}
+		if paste.ID != "" || paste.URL != "" {
+			urls = append(urls, paste.url())
+		}
+	}
+
+	emitResults("pastes", "paste", query, fmt.Sprintf("Pastes mentioning '%s'", query), "pastes.txt", urls)
+}
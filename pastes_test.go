@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func TestSearchPastes(t *testing.T) {
+	setupRun(t, config{pastesFlag: true})
+
+	srv, _ := newFakeAPI(t, map[string]interface{}{
+		"/api/v3/search/acme": []map[string]string{
+			{"id": "Ab12Cd34", "time": "2024-03-01 12:00:00"},
+			{"id": "Ef56Gh78", "time": "2024-02-01 12:00:00"},
+			{"id": "Ij90Kl12", "time": "2024-01-01 12:00:00"},
+		},
+		"/api/v3/search/acme corp": map[string]interface{}{
+			"data": []map[string]string{{"url": "https://paste.example.com/raw/xyz"}},
+		},
+	})
+
+	client, err := createPasteClient(srv.URL + "/api/v3/search/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	searchPastes(client, "acme", 2)
+	searchPastes(client, "acme corp", 2)
+	searchPastes(client, "globex", 2)
+
+	want := []string{"https://pastebin.com/Ab12Cd34", "https://pastebin.com/Ef56Gh78", "https://paste.example.com/raw/xyz"}
+	if got := resultNames("pastes", "paste"); !equalStrings(got, want) {
+		t.Errorf("pastes = %v, want %v", got, want)
+	}
+	if got := readOutputLines(t, "pastes.txt"); !equalStrings(got, want) {
+		t.Errorf("saved = %v, want %v", got, want)
+	}
+	if len(searchErrors) != 1 {
+		t.Errorf("errors = %d, want the failed globex search", len(searchErrors))
+	}
+}
+
+func TestCreatePasteClientRejectsBadURL(t *testing.T) {
+	if _, err := createPasteClient("psbdmp.ws"); err == nil {
+		t.Error("accepted a URL without a scheme")
+	}
+}
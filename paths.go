@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// lineEnding ends the lines of the text output files: CRLF on Windows,
+// where Notepad and batch scripts expect it. Every input dorky reads
+// accepts either.
+var lineEnding = "\n"
+
+func init() {
+	if runtime.GOOS == "windows" {
+		lineEnding = "\r\n"
+	}
+}
+
+// configDir is dorky's configuration directory, holding plugins and
+// workspaces: $DORKY_CONFIG_DIR if set, %USERPROFILE%\.dorky on Windows,
+// and dorky under the user's config directory (~/.config/dorky on Linux)
+// elsewhere.
+func configDir() (string, error) {
+	if dir := os.Getenv("DORKY_CONFIG_DIR"); dir != "" {
+		return dir, nil
+	}
+	if runtime.GOOS == "windows" {
+		if profile := os.Getenv("USERPROFILE"); profile != "" {
+			return filepath.Join(profile, ".dorky"), nil
+		}
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "dorky"), nil
+}
+
+// reservedDirNameRegexp matches the device names Windows reserves, with or
+// without an extension: a directory can't be named CON or nul.txt there.
+var reservedDirNameRegexp = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[1-9]|lpt[1-9])(\..*)?$`)
+
+// portableDirName reports whether name is also a valid directory name on
+// Windows, so workspaces and batch output stay portable.
+func portableDirName(name string) bool {
+	if strings.ContainsAny(name, `<>:"|?*`) || strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return false
+	}
+	for _, r := range name {
+		if r < 0x20 {
+			return false
+		}
+	}
+	return !reservedDirNameRegexp.MatchString(name)
+}
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// newLineScanner scans the lines of r, dropping the byte order mark
+// Notepad and PowerShell put at the start of UTF-8 files. Lines keep no
+// trailing CR.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	first := true
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if first {
+			if len(data) < len(utf8BOM) && !atEOF && bytes.HasPrefix(utf8BOM, data) {
+				return 0, nil, nil
+			}
+			first = false
+			if bytes.HasPrefix(data, utf8BOM) {
+				return len(utf8BOM), nil, nil
+			}
+		}
+		return bufio.ScanLines(data, atEOF)
+	})
+	return scanner
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineScannerAcceptsWindowsFiles(t *testing.T) {
+	scanner := newLineScanner(strings.NewReader("\xef\xbb\xbfacme\r\nglobex\r\n\r\ninitech"))
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"acme", "globex", "", "initech"}; !equalStrings(lines, want) {
+		t.Errorf("lines = %q, want %q", lines, want)
+	}
+}
+
+func TestConfigDirOverride(t *testing.T) {
+	dir := t.TempDir()
+	setenv(t, "DORKY_CONFIG_DIR", dir)
+	setenv(t, "DORKY_PLUGINS", "")
+	setenv(t, "DORKY_WORKSPACES", "")
+
+	if got, _ := pluginDir(); got != filepath.Join(dir, "plugins") {
+		t.Errorf("pluginDir() = %s, want it under %s", got, dir)
+	}
+	if got, _ := workspaceRoot(); got != filepath.Join(dir, "workspaces") {
+		t.Errorf("workspaceRoot() = %s, want it under %s", got, dir)
+	}
+}
+
+func TestOutputFilesUseLineEnding(t *testing.T) {
+	setupRun(t, config{})
+	old := lineEnding
+	lineEnding = "\r\n"
+	t.Cleanup(func() { lineEnding = old })
+
+	saveResults("github_organizations.txt", []string{"acme", "globex"})
+
+	data, err := ioutil.ReadFile(filepath.Join(outputDir, "github_organizations.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "acme\r\nglobex\r\n"; got != want {
+		t.Errorf("file = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import "strings"
+
+// A keyword in double quotes, such as "acme widget", is a phrase: a single
+// search term searched as it's written. It isn't also searched without its
+// spaces or with hyphens instead, nor parsed as a hostname, and GitHub
+// matches it as an exact phrase. Platforms matching names by substring
+// search its text, without the quotes.
+
+// isPhrase reports whether keyword is a phrase.
+func isPhrase(keyword string) bool {
+	return len(keyword) > 2 && strings.HasPrefix(keyword, `"`) && strings.HasSuffix(keyword, `"`) &&
+		!strings.Contains(keyword[1:len(keyword)-1], `"`)
+}
+
+// quotePhrase makes a phrase of text.
+func quotePhrase(text string) string {
+	return `"` + text + `"`
+}
+
+// normalizePhrase collapses the whitespace of a phrase, which searches
+// ignore, so "acme  widget" and " acme widget" are one keyword. Other
+// keywords are returned as they are.
+func normalizePhrase(keyword string) string {
+	if !isPhrase(keyword) {
+		return keyword
+	}
+	return quotePhrase(strings.Join(strings.Fields(searchText(keyword)), " "))
+}
+
+// searchText returns the text searched for keyword by the platforms without
+// phrase search: a phrase without its quotes, other keywords as they are.
+func searchText(keyword string) string {
+	if isPhrase(keyword) {
+		return keyword[1 : len(keyword)-1]
+	}
+	return keyword
+}
+
+// generatePhrase runs g on a phrase. Hostname generators leave it alone,
+// while the others run on its text and their keywords are phrases too.
+func generatePhrase(g keywordGenerator, phrase string) []string {
+	switch g.(type) {
+	case domainGenerator, ctGenerator:
+		return []string{phrase}
+	}
+
+	var phrases []string
+	for _, text := range g.generate(searchText(phrase)) {
+		if text = strings.TrimSpace(text); text != "" {
+			phrases = append(phrases, quotePhrase(text))
+		}
+	}
+	return phrases
+}
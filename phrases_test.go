@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+func TestIsPhrase(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    bool
+	}{
+		{`"acme widget"`, true},
+		{`"acme"`, true},
+		{`acme widget`, false},
+		{`""`, false},
+		{`"`, false},
+		{`"acme" "widget"`, false},
+		{`"acme widget`, false},
+	}
+	for _, tt := range tests {
+		if got := isPhrase(tt.keyword); got != tt.want {
+			t.Errorf("isPhrase(%s) = %v, want %v", tt.keyword, got, tt.want)
+		}
+	}
+
+	if got := searchText(`"acme widget"`); got != "acme widget" {
+		t.Errorf(`searchText("acme widget") = %q, want acme widget`, got)
+	}
+	if got := normalizePhrase(`"  acme   widget "`); got != `"acme widget"` {
+		t.Errorf("normalizePhrase = %s, want \"acme widget\"", got)
+	}
+}
+
+func TestProcessWordPhrases(t *testing.T) {
+	// Phrases are searched as written: not joined or hyphenated, and not
+	// parsed as hostnames by -c.
+	setupRun(t, config{cleanFlag: true, minWordLengthFlag: 1})
+	words := make(map[string]struct{})
+	processWord(`"acme  widget"#brand`, words, flags)
+	processWord(`"acme.com"`, words, flags)
+	processWord("acme tools", words, flags)
+
+	want := []string{`"acme widget"`, `"acme.com"`, "acme tools", "acme-tools", "acmetools"}
+	if got := sortedWords(words); !equalStrings(got, want) {
+		t.Errorf("words = %v, want %v", got, want)
+	}
+	if got := keywordTags[`"acme widget"`]; !equalStrings(got, []string{"brand"}) {
+		t.Errorf("tags = %v, want [brand]", got)
+	}
+}
+
+func TestPhraseGenerators(t *testing.T) {
+	setupRun(t, config{generatorsFlag: "acronyms,permutations", minWordLengthFlag: 3, stopWordsFlag: "corp"})
+	words := make(map[string]struct{})
+	processWord(`"acme widget corp"`, words, flags)
+
+	want := []string{`"acme widget corp"`, `"awc"`}
+	for _, affix := range permutationAffixes {
+		want = append(want, `"awc`+affix+`"`)
+	}
+	if got := sortedWords(words); !equalStrings(got, want) {
+		t.Errorf("words = %v, want %v", got, want)
+	}
+
+	// Length and stop words apply to the text of phrases.
+	for _, phrase := range []string{`"ab"`, `"Corp"`} {
+		if junkWordReason(phrase, flags) == "" {
+			t.Errorf("junkWordReason(%s) = \"\", want a reason", phrase)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// enrichment is a lookup run on the results of a search, with the
+// platforms whose results it looks into.
+type enrichment struct {
+	Flag      string
+	Platforms []string
+}
+
+// enrichments lists the enrichment flags and their platforms, in the order
+// enrichResults runs them, followed by the keyword reports.
+var enrichments = []enrichment{
+	{"releases", []string{"github", "gitlab"}},
+	{"ci-configs", []string{"github", "gitlab"}},
+	{"iac", []string{"github"}},
+	{"memberships", []string{"github"}},
+	{"keys", []string{"github"}},
+	{"stars", []string{"github"}},
+	{"collaborators", []string{"github"}},
+	{"org-rollup", []string{"github"}},
+	{"related", []string{"github"}},
+	{"funding", []string{"github"}},
+	{"urls", []string{"github", "gitlab"}},
+	{"avatars", []string{"github", "gitlab"}},
+	{"check-availability", []string{"github", "gitlab"}},
+	{"impersonation", []string{"github", "gitlab"}},
+}
+
+// platformNames are the platforms dorky searches without plugins, by the
+// names `dorky platforms` lists them under.
+var platformNames = []string{"github", "gitlab", "bitbucket", "pastes", "stackexchange"}
+
+// errPlatformNotSelected stands for the client of a platform a -stdio scan
+// leaves out.
+var errPlatformNotSelected = errors.New("platform not selected")
+
+// platformSelected reports whether a run with cfg may search platform, a
+// built-in platform or a plugin.
+func platformSelected(cfg config, platform string) bool {
+	return cfg.platforms == "" || containsString(splitList(cfg.platforms), platform)
+}
+
+// platformStatus is a platform dorky can search, and whether this run
+// would search it.
+type platformStatus struct {
+	Name       string
+	Configured bool
+	Note       string
+}
+
+// platformCategories returns the -categories searched on platform. Plugins
+// share the "plugins" entries.
+func platformCategories(platform string, plugin bool) []string {
+	if plugin {
+		platform = "plugins"
+	}
+	var names []string
+	for _, category := range searchCategories {
+		if containsString(category.Platforms, platform) {
+			names = append(names, category.Name)
+		}
+	}
+	return names
+}
+
+func platformEnrichments(platform string) []string {
+	var names []string
+	for _, e := range enrichments {
+		if containsString(e.Platforms, platform) {
+			names = append(names, "-"+e.Flag)
+		}
+	}
+	return names
+}
+
+// platformStatuses describes the configuration of every built-in platform
+// and of the installed plugins.
+func platformStatuses(cfg config, plugins []plugin) []platformStatus {
+	github := platformStatus{Name: "github", Note: "needs GITHUB_ACCESS_TOKEN or dorky login"}
+	if origin := tokenOrigin("github"); origin != "" {
+		github = platformStatus{Name: "github", Configured: true, Note: origin}
+	}
+	gitlab := platformStatus{Name: "gitlab", Configured: true, Note: "anonymous without GITLAB_ACCESS_TOKEN or dorky login: no wiki or -gl-search"}
+	if origin := tokenOrigin("gitlab"); origin != "" {
+		gitlab.Note = origin
+	}
+	bitbucket := platformStatus{Name: "bitbucket", Note: "needs -bb-url and BITBUCKET_ACCESS_TOKEN"}
+	if cfg.bbURLFlag != "" && os.Getenv("BITBUCKET_ACCESS_TOKEN") != "" {
+		bitbucket = platformStatus{Name: "bitbucket", Configured: true, Note: cfg.bbURLFlag}
+	}
+
+	statuses := []platformStatus{
+		github,
+		gitlab,
+		bitbucket,
+		{Name: "pastes", Configured: true, Note: cfg.pastesURL},
+		{Name: "stackexchange", Configured: true, Note: cfg.stackSite},
+	}
+	for _, p := range plugins {
+		statuses = append(statuses, platformStatus{Name: p.Name, Configured: true, Note: "plugin " + p.Path})
+	}
+	return statuses
+}
+
+// printPlatforms writes the capability matrix: for each platform, whether
+// it's configured and the categories and enrichments it supports.
+func printPlatforms(w io.Writer, statuses []platformStatus, plugins []plugin) {
+	byName := make(map[string]plugin)
+	for _, p := range plugins {
+		byName[p.Name] = p
+	}
+
+	for i, status := range statuses {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		state := "configured"
+		if !status.Configured {
+			state = "not configured"
+		}
+		fmt.Fprintf(w, "%s: %s (%s)\n", status.Name, state, status.Note)
+
+		p, isPlugin := byName[status.Name]
+		categories := platformCategories(status.Name, isPlugin)
+		if isPlugin {
+			// Plugins may search only some of them.
+			var supported []string
+			for _, name := range categories {
+				if p.supports(pluginRequestCategories[name]) {
+					supported = append(supported, name)
+				}
+			}
+			categories = supported
+		}
+		if len(categories) == 0 {
+			categories = []string{"none"}
+		}
+		enriched := platformEnrichments(status.Name)
+		if len(enriched) == 0 {
+			enriched = []string{"none"}
+		}
+		fmt.Fprintf(w, "  categories:  %s\n", strings.Join(categories, ", "))
+		fmt.Fprintf(w, "  enrichments: %s\n", strings.Join(enriched, ", "))
+	}
+}
+
+// runPlatformsCommand implements `dorky platforms`, listing the platforms
+// dorky can search with the categories and enrichments each supports, so a
+// search that found nothing on a platform can be told apart from one the
+// platform doesn't have.
+func runPlatformsCommand(args []string) {
+	fs := flag.NewFlagSet("platforms", flag.ExitOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dorky platforms [-bb-url url] [-plugins name,...]")
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var plugins []plugin
+	dir, err := pluginDir()
+	if err == nil {
+		plugins, err = discoverPlugins(dir, splitList(flags.pluginsFlag))
+	}
+	negotiatePlugins(plugins)
+	if err != nil {
+		fmt.Printf("Error loading plugins: %s\n", err)
+	}
+
+	printPlatforms(os.Stdout, platformStatuses(flags, plugins), plugins)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPrintPlatforms(t *testing.T) {
+	setenv(t, "GITHUB_ACCESS_TOKEN", "")
+	setenv(t, "GITLAB_ACCESS_TOKEN", "")
+	setenv(t, "BITBUCKET_ACCESS_TOKEN", "secret")
+	setenv(t, "DORKY_CONFIG_DIR", t.TempDir())
+
+	plugins := []plugin{{Name: "gitea", Path: "/plugins/gitea"}, {Name: "gogs", Path: "/plugins/gogs", Categories: []string{"repository"}}}
+	var out bytes.Buffer
+	printPlatforms(&out, platformStatuses(config{bbURLFlag: "https://bb.example.com"}, plugins), plugins)
+	got := out.String()
+
+	for _, want := range []string{
+		"github: not configured (needs GITHUB_ACCESS_TOKEN or dorky login)\n  categories:  org, repo, user, discussions, wiki\n",
+		"gitlab: configured (anonymous without GITLAB_ACCESS_TOKEN or dorky login: no wiki or -gl-search)\n  categories:  org, repo, user, wiki\n  enrichments: -releases, -ci-configs, -urls, -avatars, -check-availability, -impersonation\n",
+		"bitbucket: configured (https://bb.example.com)\n  categories:  org, repo, user\n  enrichments: none\n",
+		"gitea: configured (plugin /plugins/gitea)\n  categories:  org, repo, user\n",
+		"gogs: configured (plugin /plugins/gogs)\n  categories:  repo\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lacks %q:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Plugins search platforms dorky doesn't know about. A plugin is an
+// executable in the plugin directory, named after the platform it searches.
+// It is run once per keyword, reads a single JSON request line on stdin:
+//
+//	{"keyword": "acme", "categories": ["organization", "repository", "user"], "max": 10}
+//
+// and writes one JSON line per result, or per error, on stdout:
+//
+//	{"category": "repository", "name": "acme/api"}
+//	{"error": "rate limited"}
+
+// pluginTimeout bounds a single plugin run.
+const pluginTimeout = 2 * time.Minute
+
+var pluginNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// builtinPlatforms can't be taken over by a plugin.
+var builtinPlatforms = append([]string{"all"}, platformNames...)
+
+type plugin struct {
+	Name string
+	Path string
+
+	// Categories are the request categories the plugin declared it
+	// searches, nil if it didn't declare any (see capabilities.go).
+	Categories []string
+}
+
+type pluginRequest struct {
+	Keyword    string   `json:"keyword"`
+	Phrase     bool     `json:"phrase,omitempty"`
+	Categories []string `json:"categories"`
+	Max        int      `json:"max"`
+}
+
+type pluginLine struct {
+	Category string `json:"category"`
+	Name     string `json:"name"`
+	Error    string `json:"error"`
+}
+
+func pluginDir() (string, error) {
+	if dir := os.Getenv("DORKY_PLUGINS"); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "plugins"), nil
+}
+
+// discoverPlugins lists the executables in dir, keeping only those named
+// in enabled if it isn't empty. A missing directory means no plugins.
+func discoverPlugins(dir string, enabled []string) ([]plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var plugins []plugin
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if !pluginNameRegexp.MatchString(name) || containsString(builtinPlatforms, name) {
+			verbosePrint("Skipping plugin '%s': invalid or reserved name\n", entry.Name())
+			continue
+		}
+		if len(enabled) > 0 && !containsString(enabled, name) {
+			continue
+		}
+		plugins = append(plugins, plugin{Name: name, Path: filepath.Join(dir, entry.Name())})
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+
+	return plugins, nil
+}
+
+// loadPlugins returns the plugins enabled by cfg.
+func loadPlugins(cfg config) []plugin {
+	if cfg.ghOnlyFlag || cfg.glOnlyFlag {
+		return nil
+	}
+
+	dir, err := pluginDir()
+	if err == nil {
+		var plugins []plugin
+		if plugins, err = discoverPlugins(dir, splitList(cfg.pluginsFlag)); err == nil {
+			var selected []plugin
+			for _, p := range plugins {
+				if !platformSelected(cfg, p.Name) {
+					continue
+				}
+				verbosePrint("Loaded plugin: %s (%s)\n", p.Name, p.Path)
+				selected = append(selected, p)
+			}
+			negotiatePlugins(selected)
+			return selected
+		}
+	}
+	fmt.Printf("Error loading plugins: %s\n", err)
+	return nil
+}
+
+// pluginCategoryFiles names the output file of each plugin category, as
+// <plugin>_<name>.txt.
+var pluginCategoryFiles = map[string]string{
+	"organization": "organizations",
+	"repository":   "repositories",
+	"user":         "users",
+}
+
+// pluginCategories are the categories cfg asks p to search: those selected
+// that it searches.
+func pluginCategories(p plugin, cfg config) []string {
+	var categories []string
+	for _, c := range []struct {
+		selected bool
+		name     string
+	}{{cfg.orgFlag, "organization"}, {cfg.repoFlag, "repository"}, {cfg.userFlag, "user"}} {
+		if c.selected && p.supports(c.name) {
+			categories = append(categories, c.name)
+		}
+	}
+	return categories
+}
+
+// searchPlugin runs p for query and reports its results like those of a
+// built-in platform.
+func searchPlugin(p plugin, query string, cfg config) {
+	categories := pluginCategories(p, cfg)
+	if len(categories) == 0 {
+		return
+	}
+
+	request, err := json.Marshal(pluginRequest{Keyword: searchText(query), Phrase: isPhrase(query), Categories: categories, Max: cfg.maxFlag})
+	if err != nil {
+		recordSearchError(p.Name, "plugin search", query, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.Path)
+	cmd.Stdin = bytes.NewReader(append(request, '\n'))
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		recordSearchError(p.Name, "plugin search", query, err)
+		return
+	}
+
+	byCategory := make(map[string][]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var line pluginLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			recordSearchError(p.Name, "plugin search", query, fmt.Errorf("malformed output: %w", err))
+			return
+		}
+		switch {
+		case line.Error != "":
+			recordSearchError(p.Name, "plugin search", query, errors.New(line.Error))
+		case containsString(categories, line.Category) && line.Name != "":
+			byCategory[line.Category] = append(byCategory[line.Category], line.Name)
+		default:
+			verbosePrint("Ignoring plugin %s result %q in category %q\n", p.Name, line.Name, line.Category)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		recordSearchError(p.Name, "plugin search", query, err)
+	}
+
+	for _, category := range categories {
+		header := fmt.Sprintf("%s %s matching '%s'", p.Name, pluginCategoryFiles[category], query)
+		emitResults(p.Name, category, query, header, fmt.Sprintf("%s_%s.txt", p.Name, pluginCategoryFiles[category]), byCategory[category])
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePlugin(t *testing.T, dir, name, script string, mode os.FileMode) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script), mode); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDiscoverPlugins(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "gitea", "", 0755)
+	writePlugin(t, dir, "pastebin.sh", "", 0755)
+	writePlugin(t, dir, "notes", "", 0644)
+	writePlugin(t, dir, "github", "", 0755)
+
+	plugins, err := discoverPlugins(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, p := range plugins {
+		names = append(names, p.Name)
+	}
+	if want := []string{"gitea", "pastebin"}; !equalStrings(names, want) {
+		t.Errorf("plugins = %v, want %v", names, want)
+	}
+
+	plugins, err = discoverPlugins(dir, []string{"pastebin"})
+	if err != nil || len(plugins) != 1 || plugins[0].Path != filepath.Join(dir, "pastebin.sh") {
+		t.Errorf("enabled plugins = %+v, %v, want pastebin.sh", plugins, err)
+	}
+
+	if plugins, err := discoverPlugins(filepath.Join(dir, "missing"), nil); err != nil || plugins != nil {
+		t.Errorf("missing directory = %v, %v, want no plugins", plugins, err)
+	}
+}
+
+func TestSearchPlugin(t *testing.T) {
+	cfg := config{orgFlag: true, repoFlag: true, maxFlag: 5}
+	setupRun(t, cfg)
+
+	dir := t.TempDir()
+	gitea := writePlugin(t, dir, "gitea", `read request
+case "$request" in
+*'"keyword":"acme"'*'"categories":["organization","repository"]'*'"max":5'*) ;;
+*) echo "unexpected request: $request" >&2; exit 1 ;;
+esac
+echo '{"category": "organization", "name": "acme"}'
+echo '{"category": "repository", "name": "acme/api"}'
+echo '{"category": "user", "name": "not-requested"}'
+echo '{"error": "second page unavailable"}'
+`, 0755)
+
+	searchPlugin(plugin{Name: "gitea", Path: gitea}, "acme", cfg)
+
+	if got, want := resultNames("gitea", "organization"), []string{"acme"}; !equalStrings(got, want) {
+		t.Errorf("organizations = %v, want %v", got, want)
+	}
+	if got, want := resultNames("gitea", "repository"), []string{"acme/api"}; !equalStrings(got, want) {
+		t.Errorf("repositories = %v, want %v", got, want)
+	}
+	if got := resultNames("gitea", "user"); len(got) != 0 {
+		t.Errorf("users = %v, want none", got)
+	}
+	if got, want := readOutputLines(t, "gitea_repositories.txt"), []string{"acme/api"}; !equalStrings(got, want) {
+		t.Errorf("saved = %v, want %v", got, want)
+	}
+	if len(searchErrors) != 1 {
+		t.Errorf("errors = %d, want the reported one", len(searchErrors))
+	}
+
+	broken := writePlugin(t, dir, "broken", "echo 'no token' >&2\nexit 3\n", 0755)
+	searchPlugin(plugin{Name: "broken", Path: broken}, "acme", cfg)
+	if len(searchErrors) != 2 {
+		t.Errorf("errors = %d, want the failed run recorded", len(searchErrors))
+	}
+}
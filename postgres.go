@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+//go:embed migrations/postgres/*.sql
+var pgMigrations embed.FS
+
+// pgMigrationLock is the advisory lock key held while migrating, so several
+// dorky instances pointed at the same database don't race each other.
+const pgMigrationLock = 0x646f726b79
+
+// storeInPostgres persists the run and its findings into PostgreSQL,
+// applying any pending schema migrations first.
+func storeInPostgres(dsn string, prov provenance, findings []result) error {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := migratePostgres(db); err != nil {
+		return fmt.Errorf("migrating schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	configJSON, err := json.Marshal(prov.Config)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO runs (id, started_at, finished_at, tool_version, config) VALUES ($1, $2, $3, $4, $5)`,
+		prov.RunID, prov.StartedAt, prov.FinishedAt, prov.ToolVersion, string(configJSON)); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO findings (run_id, platform, category, query, name, canonical_id, tags, project, found_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range findings {
+		canonicalID := sql.NullString{String: f.ID, Valid: f.ID != ""}
+		tags := f.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		var project sql.NullString
+		if f.Project != nil {
+			data, err := json.Marshal(f.Project)
+			if err != nil {
+				return err
+			}
+			project = sql.NullString{String: string(data), Valid: true}
+		}
+		if _, err := stmt.Exec(f.RunID, f.Platform, f.Category, f.Query, f.Name, canonicalID, pq.Array(tags), project, f.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func migratePostgres(db *sql.DB) error {
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, pgMigrationLock); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, pgMigrationLock)
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	)`); err != nil {
+		return err
+	}
+
+	names, err := pgMigrations.ReadDir("migrations/postgres")
+	if err != nil {
+		return err
+	}
+
+	versions := make([]string, 0, len(names))
+	for _, entry := range names {
+		versions = append(versions, entry.Name())
+	}
+	sort.Strings(versions)
+
+	for _, name := range versions {
+		version := strings.TrimSuffix(name, ".sql")
+
+		var applied bool
+		if err := conn.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		script, err := pgMigrations.ReadFile(path.Join("migrations/postgres", name))
+		if err != nil {
+			return err
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, string(script)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		verbosePrint("Applied PostgreSQL migration %s.\n", version)
+	}
+
+	return nil
+}
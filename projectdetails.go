@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// projectDetails describes a GitLab project result well enough to decide
+// whether it is worth inspecting by hand.
+type projectDetails struct {
+	Visibility      string     `json:"visibility"`
+	IssuesEnabled   bool       `json:"issues_enabled"`
+	WikiEnabled     bool       `json:"wiki_enabled"`
+	SnippetsEnabled bool       `json:"snippets_enabled"`
+	LastActivityAt  *time.Time `json:"last_activity_at,omitempty"`
+}
+
+// projectInfo maps "platform:name" of every project found in the current
+// run to its details.
+var projectInfo = make(map[string]projectDetails)
+
+func recordGitLabProject(project *gitlab.Project) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	projectInfo["gitlab:"+normalizeName(project.PathWithNamespace)] = projectDetails{
+		Visibility:      string(project.Visibility),
+		IssuesEnabled:   project.IssuesEnabled,
+		WikiEnabled:     project.WikiEnabled,
+		SnippetsEnabled: project.SnippetsEnabled,
+		LastActivityAt:  project.LastActivityAt,
+	}
+}
+
+// lookupProjectDetails returns the details recorded for a project result.
+func lookupProjectDetails(platform, category, name string) *projectDetails {
+	if category != "project" && category != "projects" {
+		return nil
+	}
+
+	details, ok := projectInfo[platform+":"+normalizeName(name)]
+	if !ok {
+		return nil
+	}
+	return &details
+}
+
+// String renders the details as e.g. "private; issues, wiki; active 2024-03-01".
+func (d projectDetails) String() string {
+	var features []string
+	for _, f := range []struct {
+		name    string
+		enabled bool
+	}{{"issues", d.IssuesEnabled}, {"wiki", d.WikiEnabled}, {"snippets", d.SnippetsEnabled}} {
+		if f.enabled {
+			features = append(features, f.name)
+		}
+	}
+	if len(features) == 0 {
+		features = []string{"no issues, wiki or snippets"}
+	}
+
+	parts := []string{d.Visibility, strings.Join(features, ", ")}
+	if d.LastActivityAt != nil {
+		parts = append(parts, "active "+d.LastActivityAt.UTC().Format("2006-01-02"))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// annotateResults appends the recorded project details and the tags of
+// each result to the names shown by the text format.
+func annotateResults(platform, category, query string, names []string) []string {
+	annotated := make([]string, len(names))
+	for i, name := range names {
+		annotated[i] = name
+		if details := lookupProjectDetails(platform, category, name); details != nil {
+			annotated[i] = fmt.Sprintf("%s (%s)", name, details)
+		}
+		if tags := resultTags(platform, category, query, name); len(tags) > 0 {
+			annotated[i] += " #" + strings.Join(tags, " #")
+		}
+		if mark := triageMark(platform, category, name); mark != "" {
+			annotated[i] += " [" + mark + "]"
+		}
+	}
+	return annotated
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+func TestGitLabProjectDetails(t *testing.T) {
+	setupRun(t, config{repoFlag: true})
+
+	active := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	projects := &fakeGitLabProjects{
+		projects: map[string][]string{"acme": {"acme/infra", "acme/web"}},
+		details: map[string]*gitlab.Project{
+			"acme/infra": {
+				PathWithNamespace: "acme/infra",
+				Visibility:        gitlab.InternalVisibility,
+				IssuesEnabled:     true,
+				WikiEnabled:       true,
+				LastActivityAt:    &active,
+			},
+		},
+	}
+
+	searchGitLabProjects(projects, "acme", 10)
+
+	if len(collectedResults) != 2 {
+		t.Fatalf("got %d results, want 2", len(collectedResults))
+	}
+	infra, web := collectedResults[0], collectedResults[1]
+	if infra.Project == nil || infra.Project.Visibility != "internal" || !infra.Project.IssuesEnabled ||
+		!infra.Project.WikiEnabled || infra.Project.SnippetsEnabled || !infra.Project.LastActivityAt.Equal(active) {
+		t.Errorf("acme/infra details = %+v", infra.Project)
+	}
+	if web.Project == nil || web.Project.Visibility != "" || web.Project.LastActivityAt != nil {
+		t.Errorf("acme/web details = %+v", web.Project)
+	}
+
+	if got, want := annotateResults("gitlab", "project", "acme", []string{"ACME/Infra"}), "ACME/Infra (internal; issues, wiki; active 2024-03-01)"; got[0] != want {
+		t.Errorf("annotated = %q, want %q", got[0], want)
+	}
+	if got := annotateResults("gitlab", "blobs", "acme", []string{"acme/infra"}); got[0] != "acme/infra" {
+		t.Errorf("blob result annotated: %q", got[0])
+	}
+
+	var out bytes.Buffer
+	simpleEncoder{}.Encode(&out, resultBatch{Platform: "gitlab", Category: "project", Results: []string{"acme/infra"}})
+	if got := out.String(); got != "acme/infra\n" {
+		t.Errorf("simple output annotated: %q", got)
+	}
+}
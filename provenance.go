@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// version is the dorky release, overridden at build time with
+// -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+var dsnPasswordRegexp = regexp.MustCompile(`(password=)\S+`)
+
+// provenance identifies the scan that produced a set of results, so findings
+// reviewed later can be traced to the exact run and settings.
+type provenance struct {
+	RunID       string            `json:"run_id"`
+	ToolVersion string            `json:"tool_version"`
+	StartedAt   time.Time         `json:"started_at"`
+	FinishedAt  time.Time         `json:"finished_at"`
+	Config      map[string]string `json:"config"`
+}
+
+func runProvenance(finished time.Time) provenance {
+	return provenance{
+		RunID:       runID,
+		ToolVersion: version,
+		StartedAt:   runStarted,
+		FinishedAt:  finished,
+		Config:      configSnapshot(),
+	}
+}
+
+// configSnapshot returns the effective value of every flag, with credentials
+// embedded in connection strings redacted: the password of -pg-dsn and of
+// any URL, such as the user:pass@ of -es-url or -bb-url.
+func configSnapshot() map[string]string {
+	snapshot := make(map[string]string)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if f.Name == "pg-dsn" || strings.Contains(value, "://") {
+			value = redactDSN(value)
+		}
+		snapshot[f.Name] = value
+	})
+	return snapshot
+}
+
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), "REDACTED")
+			return u.String()
+		}
+		return dsn
+	}
+	return dsnPasswordRegexp.ReplaceAllString(dsn, "${1}REDACTED")
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// maxORKeywords is the most keywords one GitHub search can OR together:
+// queries are limited to five AND, OR and NOT operators.
+const maxORKeywords = 6
+
+// orQuery joins words into one search query, quoting multi-word keywords
+// so the OR applies to whole keywords.
+func orQuery(words []string) string {
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = word
+		if strings.ContainsAny(word, " \t") {
+			terms[i] = `"` + word + `"`
+		}
+	}
+	return strings.Join(terms, " OR ")
+}
+
+// squash folds text for demultiplexing: lower-cased, without the separators
+// platforms use in place of spaces, so "acme corp" finds acme-corp.
+func squash(text string) string {
+	return strings.NewReplacer(" ", "", "-", "", "_", "", ".", "").Replace(normalizeName(text))
+}
+
+// demuxResults attributes the results of an OR query back to the words
+// whose text they contain. A result matching several words is attributed
+// to each of them; results matching none, found through a field that isn't
+// reported, are attributed to the whole query. texts holds the text matched
+// against each name.
+func demuxResults(words, names []string, texts map[string]string) (map[string][]string, []string) {
+	byWord := make(map[string][]string)
+	var unmatched []string
+	for _, name := range names {
+		text := squash(name + " " + texts[name])
+		matched := false
+		for _, word := range words {
+			if strings.Contains(text, squash(word)) {
+				byWord[word] = append(byWord[word], name)
+				matched = true
+			}
+		}
+		if !matched {
+			unmatched = append(unmatched, name)
+		}
+	}
+	return byWord, unmatched
+}
+
+// orBatchable returns the words whose GitHub searches can be batched: those
+// without tag behaviors, which would change what's searched for them.
+func orBatchable(words []string) []string {
+	var batchable []string
+	for _, word := range words {
+		if _, ok := wordBehavior(word); !ok {
+			batchable = append(batchable, word)
+		}
+	}
+	return batchable
+}
+
+// searchGitHubORBatches runs the organization, repository and user searches
+// of words in OR queries of up to size keywords, cutting the requests of
+// large keyword sets several-fold. It returns the words it searched.
+func searchGitHubORBatches(client githubSearchService, users githubUsersService, words []string, size int, cfg config) map[string]bool {
+	if size > maxORKeywords {
+		size = maxORKeywords
+	}
+
+	searched := make(map[string]bool)
+	batchable := orBatchable(words)
+	for start := 0; start < len(batchable); start += size {
+		end := start + size
+		if end > len(batchable) {
+			end = len(batchable)
+		}
+		chunk := batchable[start:end]
+		verbosePrint("Searching GitHub for %d words in one OR query\n", len(chunk))
+
+		if cfg.orgFlag {
+			searchGitHubAccountsOR(client, nil, chunk, "organization", cfg.maxFlag)
+		}
+		if cfg.repoFlag {
+			searchGitHubRepositoriesOR(client, chunk, cfg.maxFlag)
+		}
+		if cfg.userFlag {
+			searchGitHubAccountsOR(client, users, chunk, "user", cfg.maxFlag)
+		}
+		for _, word := range chunk {
+			searched[word] = true
+		}
+	}
+	return searched
+}
+
+// orPerPage asks for enough results to give every word of the query its
+// share of -max, within the API's page size.
+func orPerPage(words, maxResults int) int {
+	perPage := maxResults * words
+	if perPage > 100 {
+		perPage = 100
+	}
+	return perPage
+}
+
+func searchGitHubAccountsOR(client githubSearchService, users githubUsersService, words []string, category string, maxResults int) {
+	query := orQuery(words)
+	qualifier, qualifiers, files := "type:org ", "", "github_organizations.txt"
+	if category == "user" {
+		qualifier, qualifiers, files = "type:user ", githubUserQualifiers(), "github_users.txt"
+	}
+
+	opt := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: orPerPage(len(words), maxResults)}}
+	results, _, err := client.Users(context.Background(), qualifier+query+qualifiers, opt)
+	if err != nil {
+		recordSearchError("github", category+" search", query, err)
+		return
+	}
+
+	logins := make([]string, len(results.Users))
+	for i, account := range results.Users {
+		logins[i] = account.GetLogin()
+		recordAvatar("github", account.GetLogin(), account.GetAvatarURL())
+	}
+	if category == "user" {
+		logins = filterGitHubUsersByBio(users, logins)
+	}
+
+	emitORResults("github", category, words, query, "GitHub "+category+"s matching '%s'", files, logins, nil, maxResults)
+}
+
+func searchGitHubRepositoriesOR(client githubSearchService, words []string, maxResults int) {
+	query := orQuery(words)
+	opt := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: orPerPage(len(words), maxResults)}}
+	results, _, err := client.Repositories(context.Background(), query, opt)
+	if err != nil {
+		recordSearchError("github", "repository search", query, err)
+		return
+	}
+
+	names := make([]string, len(results.Repositories))
+	descriptions := make(map[string]string)
+	for i, repo := range results.Repositories {
+		names[i] = repo.GetFullName()
+		descriptions[repo.GetFullName()] = repo.GetDescription() + " " + strings.Join(repo.Topics, " ")
+		recordRepoTerms(repo.GetFullName(), repo.Topics, repo.GetDescription())
+		recordHomepage("github", repo.GetFullName(), repo.GetHomepage())
+	}
+
+	emitORResults("github", "repository", words, query, "GitHub repositories matching '%s'", "github_repositories.txt", names, descriptions, maxResults)
+}
+
+// emitORResults reports the results of an OR query under the words they're
+// attributed to, at most maxResults each. Results attributed to no word
+// are reported under the query itself.
+func emitORResults(platform, category string, words []string, query, headerFormat, filename string, names []string, texts map[string]string, maxResults int) {
+	byWord, unmatched := demuxResults(words, names, texts)
+	for _, word := range words {
+		matched := byWord[word]
+		if len(matched) > maxResults {
+			matched = matched[:maxResults]
+		}
+		emitResults(platform, category, word, fmt.Sprintf(headerFormat, word), filename, matched)
+	}
+	if len(unmatched) > 0 {
+		emitResults(platform, category, query, fmt.Sprintf(headerFormat, query), filename, unmatched)
+	}
+}
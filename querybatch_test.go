@@ -0,0 +1,60 @@
+package main
+
+import "testing"
+
+func TestOrQuery(t *testing.T) {
+	if got, want := orQuery([]string{"acme", "acme corp", "globex"}), `acme OR "acme corp" OR globex`; got != want {
+		t.Errorf("orQuery = %q, want %q", got, want)
+	}
+}
+
+func TestSearchGitHubORBatches(t *testing.T) {
+	cfg := config{orgFlag: true, repoFlag: true, maxFlag: 1}
+	setupRun(t, cfg)
+	tagBehaviors = map[string]tagBehavior{"brand": {Exact: true}}
+	tagWord("initech", []string{"brand"})
+
+	search := &fakeGitHubSearch{
+		users: map[string][]string{
+			`type:org acme OR "acme corp"`: {"ACME", "acme-corp", "acmecorp"},
+			"type:org globex":              {"globex"},
+		},
+		repos: map[string][]string{
+			`acme OR "acme corp"`: {"someone/tools", "acme/api", "acme/web"},
+			"globex":              {"globex/site"},
+		},
+		homepages: map[string]string{"globex/site": "https://globex.com"},
+	}
+
+	searched := searchGitHubORBatches(search, nil, []string{"acme", "acme corp", "globex", "initech"}, 2, cfg)
+
+	// Two OR queries per category instead of three searches, and none for
+	// the tagged keyword.
+	if len(search.queries) != 4 {
+		t.Errorf("queries = %q, want 4", search.queries)
+	}
+	if searched["initech"] || !searched["acme"] || !searched["globex"] {
+		t.Errorf("searched = %v, want every untagged word", searched)
+	}
+
+	// acme-corp and acmecorp match both keywords, and -max caps each
+	// keyword's share.
+	if got, want := resultNames("github", "organization"), []string{"ACME", "acme-corp", "globex"}; !equalStrings(got, want) {
+		t.Errorf("organizations = %v, want %v", got, want)
+	}
+	// A result matching no keyword is kept under the query.
+	if got, want := resultNames("github", "repository"), []string{"someone/tools", "acme/api", "globex/site"}; !equalStrings(sortedCopy(got), sortedCopy(want)) {
+		t.Errorf("repositories = %v, want %v", got, want)
+	}
+	if got := homepageURLs["github:globex/site"]; got != "https://globex.com" {
+		t.Errorf("homepage of globex/site = %q, want it recorded for -urls", got)
+	}
+	for _, r := range collectedResults {
+		if r.Name == "someone/tools" && r.Query != `acme OR "acme corp"` {
+			t.Errorf("someone/tools attributed to %q, want the whole query", r.Query)
+		}
+		if r.Name == "acme/api" && r.Query != "acme" {
+			t.Errorf("acme/api attributed to %q, want acme", r.Query)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// searchParams are the query parameters providers take search text in:
+// GitHub's and Stack Exchange's q, GitLab's search, Bitbucket's name and
+// filter and Stack Exchange's inname.
+var searchParams = []string{"q", "search", "name", "filter", "inname"}
+
+// queryCacheEntry is a response the run already got, or is getting: done
+// is closed once it is known. A failed request leaves ok unset.
+type queryCacheEntry struct {
+	done   chan struct{}
+	ok     bool
+	status string
+	code   int
+	header http.Header
+	body   []byte
+}
+
+var (
+	queryCacheMu sync.Mutex
+	// queryCache holds the successful GET responses of the current run,
+	// keyed by cacheKey.
+	queryCache = make(map[string]*queryCacheEntry)
+)
+
+// normalizeQuery folds a search query so equivalent spellings share a
+// cache entry: terms are lower-cased, except the AND, OR and NOT
+// operators, and qualifiers such as org:acme are sorted after the free
+// text. Quoted phrases are kept whole. A query with OR or NOT keeps its
+// order, since moving a qualifier would change what the operator applies
+// to.
+func normalizeQuery(query string) string {
+	terms := splitQueryTerms(query)
+	for _, term := range terms {
+		if term == "OR" || term == "NOT" {
+			folded := make([]string, len(terms))
+			for i, term := range terms {
+				folded[i] = term
+				if term != "AND" && term != "OR" && term != "NOT" {
+					folded[i] = strings.ToLower(term)
+				}
+			}
+			return strings.Join(folded, " ")
+		}
+	}
+
+	var text, qualifiers []string
+	for _, term := range terms {
+		switch {
+		case term == "AND":
+			text = append(text, term)
+		case !strings.HasPrefix(term, `"`) && strings.Contains(term, ":"):
+			qualifiers = append(qualifiers, strings.ToLower(term))
+		default:
+			text = append(text, strings.ToLower(term))
+		}
+	}
+	sort.Strings(qualifiers)
+	return strings.Join(append(text, qualifiers...), " ")
+}
+
+// splitQueryTerms splits query on whitespace outside double quotes.
+func splitQueryTerms(query string) []string {
+	var terms []string
+	var term strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			term.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if term.Len() > 0 {
+				terms = append(terms, term.String())
+				term.Reset()
+			}
+		default:
+			term.WriteRune(r)
+		}
+	}
+	if term.Len() > 0 {
+		terms = append(terms, term.String())
+	}
+	return terms
+}
+
+// cacheKey identifies the response of a GET request: the platform, path and
+// query parameters, with search text normalized. Other requests aren't
+// cached.
+func cacheKey(platform string, req *http.Request) (string, bool) {
+	if req.Method != http.MethodGet {
+		return "", false
+	}
+
+	params := req.URL.Query()
+	for _, name := range searchParams {
+		if values, ok := params[name]; ok {
+			for i, value := range values {
+				values[i] = normalizeQuery(value)
+			}
+		}
+	}
+	return platform + " " + strings.ToLower(req.URL.Host) + req.URL.EscapedPath() + "?" + params.Encode(), true
+}
+
+// cacheTransport answers a GET request the run already made from the
+// response it got then, so keyword variants differing only in case or
+// qualifier order, and enrichment revisiting a repository, cost one API
+// call. Concurrent identical requests wait for the first one's response.
+// Only successful responses are kept, so failures are tried again.
+type cacheTransport struct {
+	platform string
+	next     http.RoundTripper
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key, ok := cacheKey(t.platform, req)
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	queryCacheMu.Lock()
+	entry, found := queryCache[key]
+	if !found {
+		entry = &queryCacheEntry{done: make(chan struct{})}
+		queryCache[key] = entry
+	}
+	queryCacheMu.Unlock()
+
+	if found {
+		select {
+		case <-entry.done:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		if entry.ok {
+			verbosePrint("Reusing the %s response to %s\n", t.platform, redactURL(req.URL))
+			return entry.response(req), nil
+		}
+		return t.next.RoundTrip(req)
+	}
+
+	defer close(entry.done)
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.forget(key, entry)
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.forget(key, entry)
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	entry.ok, entry.status, entry.code, entry.header, entry.body = true, resp.Status, resp.StatusCode, resp.Header.Clone(), body
+	return resp, nil
+}
+
+func (t *cacheTransport) forget(key string, entry *queryCacheEntry) {
+	queryCacheMu.Lock()
+	defer queryCacheMu.Unlock()
+	if queryCache[key] == entry {
+		delete(queryCache, key)
+	}
+}
+
+func (e *queryCacheEntry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        e.status,
+		StatusCode:    e.code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+// resetQueryCache forgets the responses of the previous run.
+func resetQueryCache() {
+	queryCacheMu.Lock()
+	defer queryCacheMu.Unlock()
+	queryCache = make(map[string]*queryCacheEntry)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeQuery(t *testing.T) {
+	tests := map[string]string{
+		"Acme":                         "acme",
+		"type:org  ACME":               "acme type:org",
+		"Acme OR Globex":               "acme OR globex",
+		`"Acme Corp" org:Acme in:name`: `"acme corp" in:name org:acme`,
+		`filename:main.tf "Acme:Prod"`: `"acme:prod" filename:main.tf`,
+		"NOT org:Acme Globex":          "NOT org:acme globex",
+		"NOT Globex org:Acme":          "NOT globex org:acme",
+		"org:Acme Globex OR Initech":   "org:acme globex OR initech",
+	}
+	for query, want := range tests {
+		if got := normalizeQuery(query); got != want {
+			t.Errorf("normalizeQuery(%q) = %q, want %q", query, got, want)
+		}
+	}
+}
+
+func TestCacheTransportReusesEquivalentQueries(t *testing.T) {
+	setupRun(t, config{})
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("q") == "fail" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"total_count":1}`))
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newProviderTransport("github", nil, nil)}
+	get := func(query string) string {
+		resp, err := client.Get(srv.URL + "/search/users?q=" + url.QueryEscape(query))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	for _, query := range []string{"type:org acme", "ACME type:org", "Acme  type:ORG"} {
+		if body := get(query); !strings.Contains(body, "total_count") {
+			t.Errorf("%q: body = %q", query, body)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("sent %d requests for equivalent queries, want 1", requests)
+	}
+
+	get("fail")
+	get("fail")
+	if requests != 3 {
+		t.Errorf("sent %d requests, want failed ones retried", requests)
+	}
+
+	// A new run starts with an empty cache.
+	startRun()
+	get("type:org acme")
+	if requests != 4 {
+		t.Errorf("sent %d requests, want the new run to ask again", requests)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimitUsage is what a run learned about one API quota: how much of it
+// is left, when it resets, and how long the client-side limiter held
+// requests back.
+type rateLimitUsage struct {
+	Platform     string    `json:"platform"`
+	Resource     string    `json:"resource,omitempty"`
+	Requests     int       `json:"requests"`
+	Limit        int       `json:"limit,omitempty"`
+	Remaining    *int      `json:"remaining,omitempty"`
+	Reset        time.Time `json:"reset,omitempty"`
+	Waits        int       `json:"waits"`
+	SleptSeconds float64   `json:"slept_seconds"`
+
+	slept time.Duration
+}
+
+// minLimiterWait is how long a limiter wait must last to count as the
+// limiter actually holding a request back.
+const minLimiterWait = time.Millisecond
+
+var (
+	rateLimitsMu sync.Mutex
+	rateLimits   = make(map[string]*rateLimitUsage)
+)
+
+func rateLimitEntry(platform, resource string) *rateLimitUsage {
+	key := platform + "/" + resource
+	usage, ok := rateLimits[key]
+	if !ok {
+		usage = &rateLimitUsage{Platform: platform, Resource: resource}
+		rateLimits[key] = usage
+	}
+	return usage
+}
+
+func recordLimiterWait(platform string, waited time.Duration) {
+	if waited < minLimiterWait {
+		return
+	}
+	hooks.rateLimit(rateLimitEvent{Platform: platform, Waited: waited})
+
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+
+	usage := rateLimitEntry(platform, "")
+	usage.Waits++
+	usage.slept += waited
+}
+
+// recordRateLimitHeaders notes the quota reported by a response. GitHub
+// sends X-RateLimit-* headers and names the quota in X-RateLimit-Resource,
+// as search, core and GraphQL are limited separately; GitLab sends
+// RateLimit-* headers for a single quota.
+func recordRateLimitHeaders(platform string, header http.Header) {
+	prefix := "RateLimit-"
+	if header.Get("X-RateLimit-Remaining") != "" {
+		prefix = "X-RateLimit-"
+	}
+
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+
+	usage := rateLimitEntry(platform, header.Get("X-RateLimit-Resource"))
+	usage.Requests++
+
+	if remaining, err := strconv.Atoi(header.Get(prefix + "Remaining")); err == nil {
+		usage.Remaining = &remaining
+	}
+	if limit, err := strconv.Atoi(header.Get(prefix + "Limit")); err == nil {
+		usage.Limit = limit
+	}
+	if reset, err := strconv.ParseInt(header.Get(prefix+"Reset"), 10, 64); err == nil {
+		usage.Reset = time.Unix(reset, 0).UTC()
+	}
+}
+
+// rateLimitSummary returns the usage recorded so far in the current run,
+// ordered by platform and resource.
+func rateLimitSummary() []rateLimitUsage {
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+
+	summary := make([]rateLimitUsage, 0, len(rateLimits))
+	for _, usage := range rateLimits {
+		u := *usage
+		u.SleptSeconds = u.slept.Seconds()
+		summary = append(summary, u)
+	}
+
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Platform != summary[j].Platform {
+			return summary[i].Platform < summary[j].Platform
+		}
+		return summary[i].Resource < summary[j].Resource
+	})
+
+	return summary
+}
+
+func resetRateLimits() {
+	rateLimitsMu.Lock()
+	defer rateLimitsMu.Unlock()
+
+	rateLimits = make(map[string]*rateLimitUsage)
+}
+
+func printRateLimits() {
+	summary := rateLimitSummary()
+	if len(summary) == 0 {
+		return
+	}
+
+	fmt.Printf("\nRate limits:\n")
+	for _, usage := range summary {
+		name := usage.Platform
+		if usage.Resource != "" {
+			name += "/" + usage.Resource
+		}
+
+		line := fmt.Sprintf("- %s: %d requests", name, usage.Requests)
+		if usage.Remaining != nil {
+			if usage.Limit > 0 {
+				line += fmt.Sprintf(", %d/%d remaining", *usage.Remaining, usage.Limit)
+			} else {
+				line += fmt.Sprintf(", %d remaining", *usage.Remaining)
+			}
+		}
+		if !usage.Reset.IsZero() {
+			line += fmt.Sprintf(", resets at %s", usage.Reset.Local().Format("15:04:05"))
+		}
+		if usage.Waits > 0 {
+			line += fmt.Sprintf(", limiter slept %s over %d waits", usage.slept.Round(time.Millisecond), usage.Waits)
+		}
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRecordRateLimitHeaders(t *testing.T) {
+	setupRun(t, config{})
+
+	github := http.Header{}
+	github.Set("X-RateLimit-Resource", "search")
+	github.Set("X-RateLimit-Limit", "30")
+	github.Set("X-RateLimit-Remaining", "28")
+	github.Set("X-RateLimit-Reset", "1700000000")
+	recordRateLimitHeaders("github", github)
+	github.Set("X-RateLimit-Remaining", "27")
+	recordRateLimitHeaders("github", github)
+
+	gitlab := http.Header{}
+	gitlab.Set("RateLimit-Limit", "2000")
+	gitlab.Set("RateLimit-Remaining", "1999")
+	recordRateLimitHeaders("gitlab", gitlab)
+	recordRateLimitHeaders("gitlab", http.Header{})
+
+	recordLimiterWait("github", 250*time.Millisecond)
+	recordLimiterWait("github", time.Microsecond)
+
+	summary := rateLimitSummary()
+	if len(summary) != 3 {
+		t.Fatalf("got %d entries, want 3: %+v", len(summary), summary)
+	}
+
+	waits, search, gl := summary[0], summary[1], summary[2]
+	if waits.Resource != "" || waits.Waits != 1 || waits.SleptSeconds != 0.25 {
+		t.Errorf("limiter entry = %+v", waits)
+	}
+	if search.Resource != "search" || search.Requests != 2 || search.Limit != 30 || *search.Remaining != 27 ||
+		!search.Reset.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("GitHub search entry = %+v", search)
+	}
+	if gl.Platform != "gitlab" || gl.Requests != 2 || gl.Limit != 2000 || *gl.Remaining != 1999 {
+		t.Errorf("GitLab entry = %+v", gl)
+	}
+}
+
+func TestStartRunResetsRateLimits(t *testing.T) {
+	setupRun(t, config{})
+
+	recordRateLimitHeaders("github", http.Header{})
+	startRun()
+
+	if summary := rateLimitSummary(); len(summary) != 0 {
+		t.Errorf("startRun kept rate limits: %+v", summary)
+	}
+}
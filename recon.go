@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// reconRecord is a line of subfinder (-oJ) or amass (-json) output, which
+// name the subdomain found "host" and "name" respectively.
+type reconRecord struct {
+	Host string `json:"host"`
+	Name string `json:"name"`
+}
+
+// readReconHostnames returns the hostnames of a subfinder or amass JSON
+// lines file, in order, without duplicates.
+func readReconHostnames(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	seen := make(map[string]bool)
+	var hosts []string
+	scanner := newLineScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var record reconRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		host := record.Host
+		if host == "" {
+			host = record.Name
+		}
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if host == "" {
+			return nil, fmt.Errorf("line %d: no host or name field", lineNo)
+		}
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return hosts, nil
+}
+
+// addReconKeywords seeds words with the keywords of every subdomain of the
+// -from-subfinder file, run through the same cleaning as -c.
+func addReconKeywords(words map[string]struct{}, cfg config) {
+	if cfg.fromSubfinderFlag == "" {
+		return
+	}
+
+	hosts, err := readReconHostnames(cfg.fromSubfinderFlag)
+	if err != nil {
+		fmt.Printf("Error reading %s: %s\n", cfg.fromSubfinderFlag, err)
+		os.Exit(1)
+	}
+	verbosePrint("Read %d hostnames from %s\n", len(hosts), cfg.fromSubfinderFlag)
+
+	cleanCfg := cfg
+	cleanCfg.cleanFlag = true
+	for _, host := range hosts {
+		processWord(host, words, cleanCfg)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestReadReconHostnames(t *testing.T) {
+	filename := writeTempFile(t, `{"host":"api.acme.com","input":"acme.com","source":"crtsh"}
+{"host":"API.acme.com","input":"acme.com","source":"dnsdumpster"}
+
+{"name":"jenkins.build.acme.com.","domain":"acme.com","addresses":[{"ip":"10.0.0.1"}]}
+`)
+
+	hosts, err := readReconHostnames(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"api.acme.com", "jenkins.build.acme.com"}; !equalStrings(hosts, want) {
+		t.Errorf("hosts = %v, want %v", hosts, want)
+	}
+
+	for _, content := range []string{"api.acme.com\n", `{"ip":"10.0.0.1"}` + "\n"} {
+		if _, err := readReconHostnames(writeTempFile(t, content)); err == nil {
+			t.Errorf("%q: expected an error", content)
+		}
+	}
+}
+
+func TestReconKeywords(t *testing.T) {
+	filename := writeTempFile(t, `{"host":"vpn.acme.com"}`+"\n"+`{"host":"www.acme.com"}`+"\n")
+	setupRun(t, config{fromSubfinderFlag: filename, stripPrefixesFlag: "www", minWordLengthFlag: 2})
+
+	words := readAndCleanWords(flags, nil)
+
+	if got, want := sortedWords(words), []string{"acme", "acme.com", "vpn", "vpn.acme.com", "www.acme.com"}; !equalStrings(got, want) {
+		t.Errorf("words = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// repoTerms maps every GitHub repository found by a repository search
+// to the lower-cased topics and description words it carries, for -recurse.
+// Guarded by stateMu.
+var repoTerms = make(map[string][]string)
+
+var descriptionWordRegexp = regexp.MustCompile(`[a-z0-9][a-z0-9-]*[a-z0-9]`)
+
+// descriptionFillers are words too common in repository descriptions to
+// identify anyone, on top of the -stop-words.
+var descriptionFillers = map[string]bool{
+	"a": true, "an": true, "for": true, "with": true, "to": true, "in": true, "on": true,
+	"by": true, "from": true, "is": true, "it": true, "its": true, "this": true, "that": true,
+	"your": true, "our": true, "my": true, "as": true, "be": true, "are": true, "or": true,
+	"using": true, "use": true, "used": true, "based": true, "via": true, "into": true,
+	"simple": true, "small": true, "tool": true, "tools": true, "library": true,
+	"repository": true, "repo": true, "project": true, "app": true, "application": true,
+	"code": true, "source": true, "example": true, "examples": true, "demo": true,
+	"test": true, "official": true, "new": true, "all": true, "not": true, "can": true,
+}
+
+// recordRepoTerms keeps the topics and description words of a discovered
+// repository.
+func recordRepoTerms(name string, topics []string, description string) {
+	seen := make(map[string]bool)
+	var terms []string
+	add := func(term string) {
+		if term != "" && !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+
+	for _, topic := range topics {
+		add(strings.ToLower(topic))
+	}
+	for _, word := range descriptionWordRegexp.FindAllString(strings.ToLower(description), -1) {
+		if !descriptionFillers[word] {
+			add(word)
+		}
+	}
+
+	stateMu.Lock()
+	repoTerms[normalizeName(name)] = terms
+	stateMu.Unlock()
+}
+
+// recursionKeywords returns the terms carried by at least minRepos of the
+// discovered repositories that aren't among words or rejected by the word
+// filters, most frequent first.
+func recursionKeywords(words map[string]struct{}, minRepos int, cfg config) []string {
+	searched := make(map[string]bool)
+	for word := range words {
+		searched[foldName(word)] = true
+	}
+
+	counts := make(map[string]int)
+	for _, terms := range repoTerms {
+		for _, term := range terms {
+			counts[term]++
+		}
+	}
+
+	var candidates []string
+	for term, count := range counts {
+		if count < minRepos || searched[foldName(term)] {
+			continue
+		}
+		if reason := junkWordReason(term, cfg); reason != "" {
+			verbosePrint("Skipping recursion keyword '%s': %s\n", term, reason)
+			continue
+		}
+		candidates = append(candidates, term)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		return a < b
+	})
+
+	for _, term := range candidates {
+		verbosePrint("Recursion keyword '%s' appears in %d repositories\n", term, counts[term])
+	}
+	return candidates
+}
+
+// recurse reports the keywords extracted from the discovered repositories
+// and, with -recurse-search, returns them as the words of a second search
+// pass.
+func recurse(words map[string]struct{}, cfg config) map[string]struct{} {
+	candidates := recursionKeywords(words, cfg.recurseMinReposFlag, cfg)
+	emitResults("github", "recursion_keyword", "", "Candidate keywords from repository topics and descriptions", "recursion_keywords.txt", candidates)
+
+	if !cfg.recurseSearchFlag || len(candidates) == 0 {
+		return nil
+	}
+
+	next := make(map[string]struct{})
+	for _, term := range candidates {
+		addWordToMap(next, term)
+	}
+	return next
+}
@@ -0,0 +1,46 @@
+package main
+
+import "testing"
+
+func TestRecursionKeywords(t *testing.T) {
+	cfg := config{recurseFlag: true, recurseMinReposFlag: 2, minWordLengthFlag: 3, stopWordsFlag: defaultStopWords}
+	setupRun(t, cfg)
+
+	recordRepoTerms("acme/infra", []string{"Roadrunner", "terraform"}, "Terraform modules for the Acme platform")
+	recordRepoTerms("acme/deploy", []string{"roadrunner"}, "Deploy tool for Roadrunner services, using Terraform")
+	recordRepoTerms("acme-labs/ui", []string{"roadrunner"}, "A small UI kit")
+	// Finding a repository again doesn't count it twice.
+	recordRepoTerms("ACME/Infra", []string{"roadrunner", "terraform"}, "Terraform modules for the Acme platform")
+
+	words := map[string]struct{}{"acme": {}}
+	if got, want := recursionKeywords(words, 2, cfg), []string{"roadrunner", "terraform"}; !equalStrings(got, want) {
+		t.Errorf("recursionKeywords = %v, want %v", got, want)
+	}
+	if got, want := recursionKeywords(words, 3, cfg), []string{"roadrunner"}; !equalStrings(got, want) {
+		t.Errorf("recursionKeywords with 3 repos = %v, want %v", got, want)
+	}
+
+	if next := recurse(words, cfg); next != nil {
+		t.Errorf("recurse without -recurse-search = %v, want no second pass", next)
+	}
+	if got, want := readOutputLines(t, "recursion_keywords.txt"), []string{"roadrunner", "terraform"}; !equalStrings(got, want) {
+		t.Errorf("saved = %v, want %v", got, want)
+	}
+
+	cfg.recurseSearchFlag = true
+	next := recurse(words, cfg)
+	if _, ok := next["terraform"]; len(next) != 2 || !ok {
+		t.Errorf("second pass words = %v, want roadrunner and terraform", next)
+	}
+}
+
+func TestSearchGitHubRepositoriesRecordsTerms(t *testing.T) {
+	setupRun(t, config{repoFlag: true})
+
+	search := &fakeGitHubSearch{repos: map[string][]string{"acme": {"acme/infra"}}}
+	searchGitHubRepositories(search, "acme", 10)
+
+	if _, ok := repoTerms["acme/infra"]; !ok {
+		t.Errorf("repoTerms = %v, want acme/infra recorded", repoTerms)
+	}
+}
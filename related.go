@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+const (
+	// maxRelatedForks caps the forks of a target organization whose
+	// upstream is looked up.
+	maxRelatedForks = 20
+
+	// maxForkedRepos is how many of a target organization's most forked
+	// repositories have their forks listed.
+	maxForkedRepos = 5
+
+	// maxRelatedMembers caps the public members of a target organization
+	// whose other organizations are listed.
+	maxRelatedMembers = 30
+
+	// minSharedMembers is how many members of a target organization
+	// another organization needs for the membership to be telling rather
+	// than one employee's side project.
+	minSharedMembers = 2
+)
+
+// relatedNamespaces collects the organizations related to a target
+// organization, with why.
+type relatedNamespaces struct {
+	reasons map[string][]string
+	logins  map[string]string
+}
+
+func (r *relatedNamespaces) add(login, reason string) {
+	key := normalizeName(login)
+	if _, ok := r.logins[key]; !ok {
+		r.logins[key] = login
+	}
+	if !containsString(r.reasons[key], reason) {
+		r.reasons[key] = append(r.reasons[key], reason)
+	}
+}
+
+// suggestRelatedNamespaces looks for the organizations related to the
+// GitHub organizations listed in -owned, which are known to belong to the
+// target: the upstreams of their forks, the organizations forking their
+// most forked repositories or those of their pinned repositories, and the
+// organizations several of their public members belong to. These are often
+// subsidiaries and acquired startups. The organizations not found by the
+// run are reported with the evidence, most evidence first, and saved to
+// suggested_seeds.txt as keywords for the next run.
+func suggestRelatedNamespaces(repos githubRelatedReposService, orgs githubOrgMembersService, graphQL *http.Client, cfg config) {
+	known := make(map[string]bool)
+	targets := splitList(cfg.ownedFlag)
+	for _, owned := range targets {
+		known[normalizeName(owned)] = true
+	}
+	for _, r := range collectedResults {
+		if r.Platform == "github" && (r.Category == "organization" || r.Category == "user") {
+			known[normalizeName(r.Name)] = true
+		}
+	}
+
+	var seeds []string
+	for _, org := range targets {
+		verbosePrint("Looking for namespaces related to GitHub organization: %s\n", org)
+		related := &relatedNamespaces{reasons: make(map[string][]string), logins: make(map[string]string)}
+		relateForks(repos, org, related)
+		relateMembers(orgs, org, related)
+		if graphQL != nil {
+			relatePinned(graphQL, org, related)
+		}
+
+		var keys []string
+		for key := range related.reasons {
+			if !known[key] {
+				keys = append(keys, key)
+			}
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a, b := related.reasons[keys[i]], related.reasons[keys[j]]
+			if len(a) != len(b) {
+				return len(a) > len(b)
+			}
+			return keys[i] < keys[j]
+		})
+
+		lines := make([]string, len(keys))
+		for i, key := range keys {
+			lines[i] = fmt.Sprintf("%s (%s)", related.logins[key], strings.Join(related.reasons[key], "; "))
+			seeds = append(seeds, related.logins[key])
+			known[key] = true
+		}
+		if len(lines) > 0 {
+			emitResults("github", "related_namespace", org, fmt.Sprintf("Namespaces related to GitHub organization '%s'", org), "github_related_namespaces.txt", lines)
+		}
+	}
+
+	if len(seeds) > 0 {
+		saveResults("suggested_seeds.txt", seeds)
+	}
+}
+
+// relateForks relates org to the organizations its forks come from, and to
+// those forking its most forked repositories.
+func relateForks(repos githubRelatedReposService, org string, related *relatedNamespaces) {
+	ctx := context.Background()
+	list, _, err := repos.ListByOrg(ctx, org, &github.RepositoryListByOrgOptions{Type: "public", ListOptions: github.ListOptions{PerPage: 100}})
+	if err != nil {
+		recordSearchError("github", "related namespace repository listing", org, err)
+		return
+	}
+
+	var forks, sources []*github.Repository
+	for _, repo := range list {
+		if repo.GetFork() {
+			forks = append(forks, repo)
+		} else if repo.GetForksCount() > 0 {
+			sources = append(sources, repo)
+		}
+	}
+
+	for i, fork := range forks {
+		if i == maxRelatedForks {
+			break
+		}
+		repo, _, err := repos.Get(ctx, org, fork.GetName())
+		if err != nil {
+			recordSearchError("github", "related namespace fork lookup", fork.GetFullName(), err)
+			continue
+		}
+		if owner := repo.GetParent().GetOwner(); owner.GetType() == "Organization" && !strings.EqualFold(owner.GetLogin(), org) {
+			related.add(owner.GetLogin(), "upstream of "+repo.GetFullName())
+		}
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool { return sources[i].GetForksCount() > sources[j].GetForksCount() })
+	for i, source := range sources {
+		if i == maxForkedRepos {
+			break
+		}
+		forks, _, err := repos.ListForks(ctx, org, source.GetName(), &github.RepositoryListForksOptions{ListOptions: github.ListOptions{PerPage: 100}})
+		if err != nil {
+			recordSearchError("github", "related namespace fork listing", source.GetFullName(), err)
+			continue
+		}
+		for _, fork := range forks {
+			if owner := fork.GetOwner(); owner.GetType() == "Organization" && !strings.EqualFold(owner.GetLogin(), org) {
+				related.add(owner.GetLogin(), "forked "+source.GetFullName())
+			}
+		}
+	}
+}
+
+// relateMembers relates org to the organizations at least minSharedMembers
+// of its public members belong to.
+func relateMembers(orgs githubOrgMembersService, org string, related *relatedNamespaces) {
+	ctx := context.Background()
+	members, _, err := orgs.ListMembers(ctx, org, &github.ListMembersOptions{PublicOnly: true, ListOptions: github.ListOptions{PerPage: 100}})
+	if err != nil {
+		recordSearchError("github", "related namespace member listing", org, err)
+		return
+	}
+
+	shared := make(map[string]int)
+	logins := make(map[string]string)
+	for i, member := range members {
+		if i == maxRelatedMembers {
+			break
+		}
+		memberOrgs, _, err := orgs.List(ctx, member.GetLogin(), &github.ListOptions{PerPage: 100})
+		if err != nil {
+			recordSearchError("github", "related namespace membership listing", member.GetLogin(), err)
+			continue
+		}
+		for _, other := range memberOrgs {
+			if strings.EqualFold(other.GetLogin(), org) {
+				continue
+			}
+			shared[normalizeName(other.GetLogin())]++
+			logins[normalizeName(other.GetLogin())] = other.GetLogin()
+		}
+	}
+
+	for key, count := range shared {
+		if count >= minSharedMembers {
+			related.add(logins[key], fmt.Sprintf("%d members of %s", count, org))
+		}
+	}
+}
+
+// pinnedReposQuery lists the repositories an organization pins to its
+// profile, which only GraphQL exposes.
+const pinnedReposQuery = `query($login: String!) {
+  organization(login: $login) {
+    pinnedItems(first: 6, types: REPOSITORY) {
+      nodes { ... on Repository { nameWithOwner owner { __typename login } parent { owner { __typename login } } } }
+    }
+  }
+}`
+
+type pinnedOwner struct {
+	Typename string `json:"__typename"`
+	Login    string `json:"login"`
+}
+
+// relatePinned relates org to the organizations owning the repositories it
+// pins, or the upstreams of the forks it pins.
+func relatePinned(client *http.Client, org string, related *relatedNamespaces) {
+	body, err := json.Marshal(map[string]interface{}{"query": pinnedReposQuery, "variables": map[string]string{"login": org}})
+	if err != nil {
+		return
+	}
+	req, err := http.NewRequest(http.MethodPost, githubGraphQLURL, bytes.NewReader(body))
+	if err != nil {
+		recordSearchError("github", "related namespace pinned repositories", org, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		recordSearchError("github", "related namespace pinned repositories", org, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err == nil && resp.StatusCode != http.StatusOK {
+		err = &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: respBody}
+	}
+	var result struct {
+		Data struct {
+			Organization struct {
+				PinnedItems struct {
+					Nodes []struct {
+						NameWithOwner string      `json:"nameWithOwner"`
+						Owner         pinnedOwner `json:"owner"`
+						Parent        *struct {
+							Owner pinnedOwner `json:"owner"`
+						} `json:"parent"`
+					} `json:"nodes"`
+				} `json:"pinnedItems"`
+			} `json:"organization"`
+		} `json:"data"`
+	}
+	if err == nil {
+		err = json.Unmarshal(respBody, &result)
+	}
+	if err != nil {
+		recordSearchError("github", "related namespace pinned repositories", org, err)
+		return
+	}
+
+	for _, node := range result.Data.Organization.PinnedItems.Nodes {
+		if node.Owner.Typename == "Organization" && !strings.EqualFold(node.Owner.Login, org) {
+			related.add(node.Owner.Login, "pinned by "+org)
+		}
+		if node.Parent != nil && node.Parent.Owner.Typename == "Organization" && !strings.EqualFold(node.Parent.Owner.Login, org) {
+			related.add(node.Parent.Owner.Login, "upstream of pinned "+node.NameWithOwner)
+		}
+	}
+}
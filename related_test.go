@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestSuggestRelatedNamespaces(t *testing.T) {
+	cfg := config{ownedFlag: "acme", relatedFlag: true}
+	setupRun(t, cfg)
+
+	org := func(login string) map[string]string { return map[string]string{"login": login, "type": "Organization"} }
+	srv, _ := newFakeAPI(t, map[string]interface{}{
+		"/orgs/acme/repos": []map[string]interface{}{
+			{"name": "sdk", "full_name": "acme/sdk", "fork": true},
+			{"name": "api", "full_name": "acme/api", "forks_count": 12},
+			{"name": "web", "full_name": "acme/web"},
+		},
+		"/repos/acme/sdk": map[string]interface{}{
+			"full_name": "acme/sdk",
+			"parent":    map[string]interface{}{"full_name": "widgetco/sdk", "owner": org("WidgetCo")},
+		},
+		"/repos/acme/api/forks": []map[string]interface{}{
+			{"full_name": "acme-eu/api", "owner": org("acme-eu")},
+			{"full_name": "bob/api", "owner": map[string]string{"login": "bob", "type": "User"}},
+			{"full_name": "globex/api", "owner": org("globex")},
+		},
+		"/orgs/acme/public_members": []map[string]string{{"login": "alice"}, {"login": "bob"}, {"login": "carol"}},
+		"/users/alice/orgs":         []map[string]string{{"login": "acme"}, {"login": "widgetco"}, {"login": "rustlang"}},
+		"/users/bob/orgs":           []map[string]string{{"login": "widgetco"}},
+		"/users/carol/orgs":         []map[string]string{{"login": "chessclub"}},
+		"/graphql": map[string]interface{}{"data": map[string]interface{}{"organization": map[string]interface{}{
+			"pinnedItems": map[string]interface{}{"nodes": []map[string]interface{}{
+				{"nameWithOwner": "acme/api", "owner": map[string]string{"__typename": "Organization", "login": "acme"}},
+				{"nameWithOwner": "acme/parser", "owner": map[string]string{"__typename": "Organization", "login": "acme"},
+					"parent": map[string]interface{}{"owner": map[string]string{"__typename": "Organization", "login": "initech"}}},
+			}},
+		}}},
+	})
+	oldURL := githubGraphQLURL
+	githubGraphQLURL = srv.URL + "/graphql"
+	t.Cleanup(func() { githubGraphQLURL = oldURL })
+
+	// Organizations the run already found aren't suggested again.
+	recordResults("github", "organization", "acme", []string{"Globex"})
+
+	client := newFakeGitHubClient(t, srv)
+	suggestRelatedNamespaces(client.Repositories, client.Organizations, srv.Client(), cfg)
+
+	want := []string{
+		"WidgetCo (upstream of acme/sdk; 2 members of acme)",
+		"acme-eu (forked acme/api)",
+		"initech (upstream of pinned acme/parser)",
+	}
+	if got := resultNames("github", "related_namespace"); !equalStrings(got, want) {
+		t.Errorf("related = %q, want %q", got, want)
+	}
+	if got, want := readOutputLines(t, "suggested_seeds.txt"), []string{"WidgetCo", "acme-eu", "initech"}; !equalStrings(got, want) {
+		t.Errorf("seeds = %v, want %v", got, want)
+	}
+	for _, r := range collectedResults {
+		if r.Category == "related_namespace" && r.Name == want[0] && r.ID != "github:widgetco" {
+			t.Errorf("id = %q, want github:widgetco", r.ID)
+		}
+	}
+	if summary := searchErrorSummary(); len(summary) != 0 {
+		t.Errorf("errors = %+v", summary)
+	}
+}
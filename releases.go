@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+var (
+	// sensitiveAssetRegexp matches asset names hinting at leaked data or
+	// configuration rather than a regular build.
+	sensitiveAssetRegexp = regexp.MustCompile(`(?i)(backup|bak|dump|config|conf|secret|credential|passw|database|db|\.env|private|key)`)
+
+	// archiveOrBinaryRegexp matches downloadable archives and binaries.
+	archiveOrBinaryRegexp = regexp.MustCompile(`(?i)\.(zip|tar|gz|tgz|bz2|xz|7z|rar|sql|bak|dump|db|sqlite|exe|bin|dll|so|jar|war|apk|dmg|iso|img)$`)
+)
+
+func isSensitiveAsset(name string) bool {
+	return archiveOrBinaryRegexp.MatchString(name) && sensitiveAssetRegexp.MatchString(name)
+}
+
+// enumerateReleases lists the releases and downloadable assets of every
+// repository found so far, flagging sensitive-looking archives and binaries.
+func enumerateReleases(ghClient *github.Client, glClient *gitlab.Client) {
+	seen := make(map[string]bool)
+	discovered := append([]result(nil), collectedResults...)
+
+	for _, r := range discovered {
+		key := r.Platform + ":" + r.Name
+		if seen[key] {
+			continue
+		}
+
+		switch {
+		case r.Platform == "github" && r.Category == "repository" && ghClient != nil:
+			seen[key] = true
+			enumerateGitHubReleases(ghClient, r.Name)
+		case r.Platform == "gitlab" && r.Category == "project" && glClient != nil:
+			seen[key] = true
+			enumerateGitLabReleases(glClient, r.Name)
+		}
+	}
+}
+
+func enumerateGitHubReleases(client *github.Client, fullName string) {
+	parts := strings.SplitN(fullName, "/", 2)
+	if len(parts) != 2 {
+		return
+	}
+
+	verbosePrint("Enumerating releases of GitHub repository: %s\n", fullName)
+	releases, _, err := client.Repositories.ListReleases(context.Background(), parts[0], parts[1], &github.ListOptions{PerPage: 100})
+	if err != nil {
+		recordSearchError("github", "release listing", fullName, err)
+		return
+	}
+
+	var assets []releaseAsset
+	for _, release := range releases {
+		for _, asset := range release.Assets {
+			assets = append(assets, releaseAsset{tag: release.GetTagName(), name: asset.GetName(), url: asset.GetBrowserDownloadURL()})
+		}
+	}
+
+	reportReleaseAssets("github", "GitHub", fullName, assets)
+}
+
+func enumerateGitLabReleases(client *gitlab.Client, pathWithNamespace string) {
+	verbosePrint("Enumerating releases of GitLab project: %s\n", pathWithNamespace)
+	releases, _, err := client.Releases.ListReleases(pathWithNamespace, nil)
+	if err != nil {
+		recordSearchError("gitlab", "release listing", pathWithNamespace, err)
+		return
+	}
+
+	var assets []releaseAsset
+	for _, release := range releases {
+		for _, link := range release.Assets.Links {
+			assets = append(assets, releaseAsset{tag: release.TagName, name: link.Name, url: link.URL})
+		}
+	}
+
+	reportReleaseAssets("gitlab", "GitLab", pathWithNamespace, assets)
+}
+
+type releaseAsset struct {
+	tag, name, url string
+}
+
+func (a releaseAsset) format(repo string) string {
+	return fmt.Sprintf("%s@%s:%s %s", repo, a.tag, a.name, a.url)
+}
+
+func reportReleaseAssets(platform, platformName, repo string, assets []releaseAsset) {
+	if len(assets) == 0 {
+		return
+	}
+
+	var all, sensitive []string
+	for _, asset := range assets {
+		all = append(all, asset.format(repo))
+		if isSensitiveAsset(asset.name) {
+			sensitive = append(sensitive, asset.format(repo))
+		}
+	}
+
+	emitResults(platform, "release_asset", repo, fmt.Sprintf("%s release assets of '%s'", platformName, repo), platform+"_release_assets.txt", all)
+
+	if len(sensitive) > 0 {
+		emitResults(platform, "sensitive_release_asset", repo, fmt.Sprintf("%s sensitive-looking release assets of '%s'", platformName, repo), platform+"_sensitive_release_assets.txt", sensitive)
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestIsSensitiveAsset(t *testing.T) {
+	tests := map[string]bool{
+		"db-backup-2023.sql.gz": true,
+		"config.zip":            true,
+		"prod.dump":             true,
+		"acme-cli-linux.tar.gz": false,
+		"backup-notes.md":       false,
+		"setup.exe":             false,
+	}
+
+	for name, want := range tests {
+		if got := isSensitiveAsset(name); got != want {
+			t.Errorf("isSensitiveAsset(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// githubWebURL is the GitHub web root, a variable so tests can point it at
+// a fake server.
+var githubWebURL = "https://github.com/"
+
+// reservedProfilePaths are single-segment redirect targets that are pages
+// rather than namespaces, such as the login page of a private instance.
+var reservedProfilePaths = map[string]bool{
+	"login": true, "session": true, "signup": true, "join": true,
+}
+
+// redirectClient reports redirects instead of following them.
+var redirectClient = &http.Client{
+	Transport: &identifyTransport{platform: "github", next: http.DefaultTransport},
+	Timeout:   30 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// followRename fetches the profile page of name under webURL and returns
+// the namespace it permanently redirects to, if any. Platforms keep such
+// redirects after a rename, so they reveal rebrands and acquisitions even
+// when the old name is free again.
+func followRename(webURL, name string) (string, error) {
+	base, err := url.Parse(webURL)
+	if err != nil {
+		return "", err
+	}
+	profile := base.ResolveReference(&url.URL{Path: name})
+
+	resp, err := redirectClient.Get(profile.String())
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return "", nil
+	}
+
+	location, err := resp.Location()
+	if err != nil || !strings.EqualFold(location.Host, profile.Host) {
+		return "", nil
+	}
+
+	target := strings.Trim(location.Path, "/")
+	if target == "" || strings.Contains(target, "/") || reservedProfilePaths[strings.ToLower(target)] ||
+		!namespaceRegexp.MatchString(target) || strings.EqualFold(target, name) {
+		return "", nil
+	}
+	return target, nil
+}
+
+// gitLabWebURL derives the web root of a GitLab instance from its API URL.
+func gitLabWebURL(client *gitlab.Client) string {
+	return strings.TrimSuffix(client.BaseURL().String(), "api/v4/")
+}
+
+// checkRenames looks up where the free namespaces among checks redirect to,
+// since an API lookup of a renamed name just finds nothing.
+func checkRenames(ghWebURL, glWebURL string, checks []availabilityCheck) {
+	for i := range checks {
+		check := &checks[i]
+		if check.Status != statusAvailable || check.RenamedTo != "" {
+			continue
+		}
+
+		webURL := ghWebURL
+		if check.Platform == "gitlab" {
+			webURL = glWebURL
+		}
+		if webURL == "" {
+			continue
+		}
+
+		renamedTo, err := followRename(webURL, check.Name)
+		if err != nil {
+			recordSearchError(check.Platform, "rename check", check.Name, err)
+			continue
+		}
+		check.RenamedTo = renamedTo
+	}
+}
+
+// reportRename emits the namespace a checked name was renamed to.
+func reportRename(check availabilityCheck, platformName string) {
+	if check.RenamedTo == "" {
+		return
+	}
+
+	emitResults(check.Platform, "rename", check.Name, fmt.Sprintf("%s renames of '%s'", platformName, check.Name),
+		check.Platform+"_renames.txt", []string{check.Name + " -> " + check.RenamedTo})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFollowRename(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/acme-old":
+			http.Redirect(w, r, "/acme-corp", http.StatusMovedPermanently)
+		case "/private":
+			http.Redirect(w, r, "/users/sign_in", http.StatusFound)
+		case "/login-wall":
+			http.Redirect(w, r, "/login", http.StatusFound)
+		case "/elsewhere":
+			http.Redirect(w, r, "https://example.com/acme", http.StatusMovedPermanently)
+		case "/ACME":
+			http.Redirect(w, r, "/acme", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := map[string]string{
+		"acme-old":   "acme-corp",
+		"private":    "",
+		"login-wall": "",
+		"elsewhere":  "",
+		"ACME":       "",
+		"missing":    "",
+	}
+	for name, want := range tests {
+		got, err := followRename(srv.URL+"/", name)
+		if err != nil {
+			t.Fatalf("followRename(%q): %s", name, err)
+		}
+		if got != want {
+			t.Errorf("followRename(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCheckRenames(t *testing.T) {
+	setupRun(t, config{})
+
+	srv := httptest.NewServer(http.RedirectHandler("/acme-corp", http.StatusMovedPermanently))
+	defer srv.Close()
+
+	checks := []availabilityCheck{
+		{Platform: "github", Name: "acme", Status: statusAvailable},
+		{Platform: "github", Name: "acme", Status: statusThirdParty},
+		{Platform: "gitlab", Name: "acme", Status: statusAvailable},
+	}
+	checkRenames(srv.URL+"/", "", checks)
+
+	if checks[0].RenamedTo != "acme-corp" || checks[1].RenamedTo != "" || checks[2].RenamedTo != "" {
+		t.Errorf("checks = %+v", checks)
+	}
+
+	reportRename(checks[0], "GitHub")
+	if got, want := resultNames("github", "rename"), []string{"acme -> acme-corp"}; !equalStrings(got, want) {
+		t.Errorf("recorded = %v, want %v", got, want)
+	}
+	if id := collectedResults[0].ID; id != "github:acme-corp" {
+		t.Errorf("id = %q, want github:acme-corp", id)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+)
+
+// report is the JSON document written by -json, describing a whole run.
+type report struct {
+	// Schema is the $id of the JSON Schema the report conforms to, as
+	// printed by `dorky schema`.
+	Schema string `json:"$schema"`
+
+	provenance
+	RateLimits []rateLimitUsage `json:"rate_limits"`
+
+	// Errors lists the searches that failed, so an empty Results can be
+	// told apart from a run that was rate limited or denied access.
+	Errors []searchError `json:"errors"`
+
+	// OrgRollups summarizes the discovered GitHub organizations, with
+	// -org-rollup.
+	OrgRollups []orgRollup `json:"org_rollups,omitempty"`
+
+	// Stale lists the results pruned from the -state file by -prune-after.
+	Stale []resultState `json:"stale,omitempty"`
+
+	// EmptySearches lists the searches that completed without results.
+	EmptySearches []emptySearch `json:"empty_searches,omitempty"`
+
+	// Partial says why the run ended before its searches did, after an
+	// interrupt or a fatal error: its results are incomplete.
+	Partial string   `json:"partial,omitempty"`
+	Results []result `json:"results"`
+}
+
+func writeJSONReport(filename string, r report) error {
+	r.Schema = reportSchemaID
+	if r.Results == nil {
+		r.Results = []result{}
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomically(filename, append(data, '\n')); err != nil {
+		return err
+	}
+	trackOutputFile(filename)
+
+	return nil
+}
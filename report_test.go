@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteJSONReportIncludesErrors(t *testing.T) {
+	setupRun(t, config{})
+	recordSearchError("github", "user search", "acme", errors.New("boom"))
+
+	filename := filepath.Join(outputDir, "report.json")
+	r := report{provenance: provenance{RunID: runID}, Errors: searchErrorSummary()}
+	if err := writeJSONReport(filename, r); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded struct {
+		Errors  []map[string]interface{} `json:"errors"`
+		Results []interface{}            `json:"results"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.Results == nil || len(decoded.Results) != 0 {
+		t.Errorf("results = %v, want an empty list", decoded.Results)
+	}
+	if len(decoded.Errors) != 1 {
+		t.Fatalf("got %d errors, want 1", len(decoded.Errors))
+	}
+	e := decoded.Errors[0]
+	if e["type"] != "other" || e["platform"] != "github" || e["query"] != "acme" || e["message"] != "boom" {
+		t.Errorf("error event = %v", e)
+	}
+}
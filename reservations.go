@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reservation is a namespace the availability check found free, with the
+// pages to register it from, for brand teams to claim before a squatter
+// does.
+type reservation struct {
+	Platform string
+	Name     string
+
+	// RenamedTo is the namespace a free name redirects to: registering it
+	// breaks the redirect of old links, which the target may rely on.
+	RenamedTo string
+
+	OrganizationSignupURL string
+	UserSignupURL         string
+}
+
+// reservations holds the free namespaces of the run, guarded by stateMu.
+var reservations []reservation
+
+// recordReservation notes a free namespace, with the signup pages of its
+// platform under webURL, the platform's web root ending in a slash.
+func recordReservation(check availabilityCheck, webURL string) {
+	r := reservation{Platform: check.Platform, Name: check.Name, RenamedTo: check.RenamedTo}
+	switch check.Platform {
+	case "github":
+		r.OrganizationSignupURL = webURL + "account/organizations/new"
+		r.UserSignupURL = webURL + "signup"
+	case "gitlab":
+		r.OrganizationSignupURL = webURL + "groups/new"
+		r.UserSignupURL = webURL + "users/sign_up"
+	}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+	reservations = append(reservations, r)
+}
+
+// writeReservations saves the free namespaces of the run to one CSV file
+// per platform, <platform>_reservations.csv, listing each name with the
+// pages to register it as an organization (a group on GitLab) or a user.
+func writeReservations(checkedAt time.Time) error {
+	stateMu.Lock()
+	byPlatform := make(map[string][]reservation)
+	for _, r := range reservations {
+		byPlatform[r.Platform] = append(byPlatform[r.Platform], r)
+	}
+	stateMu.Unlock()
+
+	for platform, list := range byPlatform {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.UseCRLF = lineEnding == "\r\n"
+		w.Write([]string{"platform", "name", "organization_signup_url", "user_signup_url", "renamed_to", "checked_at"})
+		seen := make(map[string]bool)
+		for _, r := range list {
+			if seen[strings.ToLower(r.Name)] {
+				continue
+			}
+			seen[strings.ToLower(r.Name)] = true
+			w.Write([]string{r.Platform, r.Name, r.OrganizationSignupURL, r.UserSignupURL, r.RenamedTo, checkedAt.Format(time.RFC3339)})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return err
+		}
+
+		filename := outputPath(platform + "_reservations.csv")
+		if err := writeFileAtomically(filename, buf.Bytes()); err != nil {
+			return err
+		}
+		trackOutputFile(filename)
+	}
+	return nil
+}
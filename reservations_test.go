@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWriteReservations(t *testing.T) {
+	setupRun(t, config{})
+
+	recordReservation(availabilityCheck{Platform: "github", Name: "acme-labs", Status: statusAvailable}, "https://github.com/")
+	recordReservation(availabilityCheck{Platform: "github", Name: "acme-cloud", Status: statusAvailable, RenamedTo: "acme"}, "https://github.com/")
+	recordReservation(availabilityCheck{Platform: "github", Name: "acme-labs", Status: statusAvailable}, "https://github.com/")
+	recordReservation(availabilityCheck{Platform: "gitlab", Name: "acme-labs", Status: statusAvailable}, "https://gitlab.example.com/")
+
+	if err := writeReservations(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"platform,name,organization_signup_url,user_signup_url,renamed_to,checked_at",
+		"github,acme-cloud,https://github.com/account/organizations/new,https://github.com/signup,acme,2024-06-01T12:00:00Z",
+		"github,acme-labs,https://github.com/account/organizations/new,https://github.com/signup,,2024-06-01T12:00:00Z",
+	}
+	if got := readOutputLines(t, "github_reservations.csv"); !equalStrings(got, want) {
+		t.Errorf("github_reservations.csv = %q, want %q", got, want)
+	}
+
+	want = []string{
+		"platform,name,organization_signup_url,user_signup_url,renamed_to,checked_at",
+		"gitlab,acme-labs,https://gitlab.example.com/groups/new,https://gitlab.example.com/users/sign_up,,2024-06-01T12:00:00Z",
+	}
+	if got := readOutputLines(t, "gitlab_reservations.csv"); !equalStrings(got, want) {
+		t.Errorf("gitlab_reservations.csv = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// result is a single match found on a platform, tagged with the run that
+// produced it so structured exports can be correlated over time.
+type result struct {
+	RunID     string    `json:"run_id"`
+	Platform  string    `json:"platform"`
+	Category  string    `json:"category"`
+	Query     string    `json:"query"`
+	Name      string    `json:"name"`
+	Timestamp time.Time `json:"@timestamp"`
+
+	// ID is the provider-neutral identifier of the account or repository
+	// the result is about, so results can be joined across categories,
+	// runs and exports.
+	ID string `json:"id,omitempty"`
+
+	// Tags are the tags of the keyword that found the result.
+	Tags []string `json:"tags,omitempty"`
+
+	// Project holds the visibility, features and activity of GitLab
+	// project results.
+	Project *projectDetails `json:"project,omitempty"`
+
+	// Triage is the mark the result was given with `dorky triage`.
+	Triage string `json:"triage,omitempty"`
+}
+
+var (
+	runID            = newRunID()
+	runStarted       = time.Now().UTC()
+	collectedResults []result
+	outputFiles      []string
+
+	// seenResults holds the normalized identity of every result emitted in
+	// the current run, so one account found via several keywords (or in a
+	// different case) is only reported once.
+	seenResults = make(map[string]bool)
+
+	// outputDir is prepended to relative output file names. It is empty for
+	// plain runs, which write into the working directory.
+	outputDir string
+)
+
+func newRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().UTC().Format("20060102T150405Z")
+	}
+	return hex.EncodeToString(b)
+}
+
+// startRun resets the per-run state so several scans can happen in one
+// process, as in monitor mode.
+func startRun() {
+	runID = newRunID()
+	runStarted = time.Now().UTC()
+	collectedResults = nil
+	outputFiles = nil
+	seenResults = make(map[string]bool)
+	searchErrors = make(map[string]*searchError)
+	orgRollups = nil
+	projectInfo = make(map[string]projectDetails)
+	repoTerms = make(map[string][]string)
+	staleResults = nil
+	searchCounts = make(map[emptySearch]int)
+	unsearchedWords, cutShort = nil, false
+	findingTags = make(map[string][]string)
+	queriedWords = make(map[string]time.Time)
+	homepageURLs = make(map[string]string)
+	reservations = nil
+	triageMarks, hiddenResults = make(map[string]string), nil
+	budget = nil
+	partialReason = ""
+	resetOutputContents()
+	resetQueryCache()
+	resetRateLimits()
+	resetCooldowns()
+
+	// validateFlags has already rejected an invalid format.
+	if enc, err := newEncoder(flags); err == nil {
+		consoleEncoder = enc
+	}
+}
+
+func outputPath(filename string) string {
+	if outputDir == "" || filepath.IsAbs(filename) {
+		return filename
+	}
+	return filepath.Join(outputDir, filename)
+}
+
+// validDirName reports whether name can be used as a single directory name
+// for workspaces, target groups and batch targets, on any platform.
+func validDirName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`) && portableDirName(name)
+}
+
+// normalizeName folds a result name for comparison: Unicode NFC followed by
+// full case folding, so Straße and STRASSE compare equal, since platforms
+// treat namespaces case-insensitively. Diacritics are kept: müller and
+// muller are different accounts, which foldName matches as one spelling.
+func normalizeName(name string) string {
+	return norm.NFC.String(cases.Fold().String(norm.NFC.String(name)))
+}
+
+// dedupeResults drops names already emitted for the same platform and
+// category in this run, and duplicates within names itself.
+func dedupeResults(platform, category string, names []string) []string {
+	var unique []string
+	for _, name := range names {
+		key := platform + "\x00" + category + "\x00" + normalizeName(name)
+		if seenResults[key] {
+			continue
+		}
+		seenResults[key] = true
+		unique = append(unique, name)
+	}
+	return unique
+}
+
+// canonicalID returns the identifier of the account or repository a result
+// is about as platform:namespace/name, lower-cased like the platforms
+// compare them, e.g. github:acme, gitlab:acme/platform/api. Results not
+// about a single account or repository, like avatar matches or commits,
+// have none.
+func canonicalID(platform, category, query, name string) string {
+	var subject string
+	switch category {
+	case "organization", "user", "group", "project", "projects", "repository", "discussion", "starred", "watched":
+		subject = name
+	case "wiki", "blobs", "milestones", "membership", "target_member", "atlassian_link", "pages", "outside_collaborator", "unexpected_collaborator", "secret_dork",
+		"ci_config", "ci_host", "ci_registry", "ci_secret", "container_image", "image_namespace", "iac_file", "funding_user", "funding_account",
+		"ssh_key", "gpg_key":
+		// "namespace/repo:path", "namespace/repo:title", "user: orgs",
+		// "project/repo: url", "org/repo: login", "namespace/repo: value"
+		// and "user: key".
+		subject = strings.SplitN(name, ":", 2)[0]
+	case "related_namespace":
+		// "login (evidence)".
+		subject = strings.SplitN(name, " ", 2)[0]
+	case "package":
+		// "owner/name (type)", about the owning account.
+		subject = strings.SplitN(name, "/", 2)[0]
+	case "release_asset", "sensitive_release_asset":
+		// "namespace/repo@tag:asset url".
+		subject = strings.SplitN(name, "@", 2)[0]
+	case "availability":
+		subject = query
+	case "rename":
+		// "old -> new", about the account now holding the new name.
+		if i := strings.Index(name, " -> "); i >= 0 {
+			subject = name[i+len(" -> "):]
+		}
+	}
+
+	if subject == "" {
+		return ""
+	}
+	return platform + ":" + normalizeName(subject)
+}
+
+// emitResults sends a batch of results to every sink: the console, the
+// collected run results and the category's output file.
+func emitResults(platform, category, query, header, filename string, names []string) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	if partialReason != "" {
+		return
+	}
+	names = applyExactMatch(category, query, names)
+	countSearch(platform, category, query, len(names))
+	names = dedupeResults(platform, category, names)
+	names = hideFalsePositives(platform, category, query, names)
+	if budget != nil {
+		names = names[:budget.take(query, len(names))]
+	}
+
+	printResults(consoleFor(query), resultBatch{Platform: platform, Category: category, Query: query, Header: header, Results: names})
+	recordResults(platform, category, query, names)
+	saveResults(filename, withKeyword(query, names))
+}
+
+// withKeyword appends the keyword that found each result to the lines
+// saved to output files, with -with-keyword, so the files of a
+// multi-keyword run can be traced back to their keywords.
+func withKeyword(query string, names []string) []string {
+	if !flags.withKeyword {
+		return names
+	}
+
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = name + "\t" + query
+	}
+	return lines
+}
+
+func recordResults(platform, category, query string, names []string) {
+	now := time.Now().UTC()
+	for _, name := range names {
+		collectedResults = append(collectedResults, result{
+			RunID:     runID,
+			Platform:  platform,
+			Category:  category,
+			Query:     query,
+			Name:      name,
+			Timestamp: now,
+			ID:        canonicalID(platform, category, query, name),
+			Tags:      resultTags(platform, category, query, name),
+			Project:   lookupProjectDetails(platform, category, name),
+			Triage:    triageMark(platform, category, name),
+		})
+		streamResult(collectedResults[len(collectedResults)-1])
+		routeResult(collectedResults[len(collectedResults)-1])
+		hooks.result(collectedResults[len(collectedResults)-1])
+	}
+}
+
+func trackOutputFile(filename string) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	for _, existing := range outputFiles {
+		if existing == filename {
+			return
+		}
+	}
+	outputFiles = append(outputFiles, filename)
+}
@@ -0,0 +1,108 @@
+package main
+
+import "testing"
+
+func TestDedupeResults(t *testing.T) {
+	setupRun(t, config{})
+
+	first := dedupeResults("github", "user", []string{"Acme", "acme", "M\u00fcller"})
+	if want := []string{"Acme", "M\u00fcller"}; !equalStrings(first, want) {
+		t.Errorf("first batch = %v, want %v", first, want)
+	}
+
+	// The decomposed and precomposed forms of "ü" are the same name.
+	second := dedupeResults("github", "user", []string{"ACME", "Mu\u0308ller", "bob"})
+	if want := []string{"bob"}; !equalStrings(second, want) {
+		t.Errorf("second batch = %v, want %v", second, want)
+	}
+
+	other := dedupeResults("gitlab", "user", []string{"acme"})
+	if want := []string{"acme"}; !equalStrings(other, want) {
+		t.Errorf("other platform = %v, want %v", other, want)
+	}
+}
+
+func TestStartRunResetsState(t *testing.T) {
+	setupRun(t, config{})
+	previous := runID
+
+	emitResults("github", "user", "acme", "header", "users.txt", []string{"acme"})
+	startRun()
+
+	if runID == previous {
+		t.Error("startRun kept the previous run ID")
+	}
+	if len(collectedResults) != 0 || len(outputFiles) != 0 {
+		t.Error("startRun kept results from the previous run")
+	}
+	if got := dedupeResults("github", "user", []string{"acme"}); len(got) != 1 {
+		t.Error("startRun kept the previous run's deduplication state")
+	}
+}
+
+func TestValidDirName(t *testing.T) {
+	tests := map[string]bool{
+		"acme":     true,
+		"acme-inc": true,
+		"":         false,
+		".":        false,
+		"..":       false,
+		"a/b":      false,
+		`a\b`:      false,
+		"a:b":      false,
+		"acme.":    false,
+		"CON":      false,
+		"nul.txt":  false,
+		"console":  true,
+	}
+
+	for name, want := range tests {
+		if got := validDirName(name); got != want {
+			t.Errorf("validDirName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCanonicalID(t *testing.T) {
+	tests := []struct {
+		platform, category, query, name string
+		want                            string
+	}{
+		{"github", "organization", "acme", "ACME", "github:acme"},
+		{"github", "repository", "acme", "Acme/API", "github:acme/api"},
+		{"gitlab", "group", "acme", "acme/Platform", "gitlab:acme/platform"},
+		{"gitlab", "project", "acme", "acme/platform/api", "gitlab:acme/platform/api"},
+		{"bitbucket", "project", "acme", "ACME", "bitbucket:acme"},
+		{"github", "wiki", "acme", "acme/docs:wiki/Home.md", "github:acme/docs"},
+		{"gitlab", "blobs", "acme", "acme/infra:deploy/prod.env", "gitlab:acme/infra"},
+		{"github", "release_asset", "acme/cli", "acme/cli@v1.0:cli.tar.gz https://example.com/cli.tar.gz", "github:acme/cli"},
+		{"gitlab", "availability", "Acme", "Acme: available", "gitlab:acme"},
+		{"github", "rename", "acme", "acme -> Acme-Corp", "github:acme-corp"},
+		{"bitbucket", "atlassian_link", "acme", "ACME/web: https://acme.atlassian.net/browse/WEB-1", "bitbucket:acme/web"},
+		{"github", "pages", "acme", "acme/api: https://acme.github.io/api/", "github:acme/api"},
+		{"github", "package", "acme", "Acme/api (container)", "github:acme"},
+		{"gitlab", "commits", "acme", "1a2b3c4d Bump acme", ""},
+		{"all", "avatar_match", "", "0123456789ab: github:acme, gitlab:acme", ""},
+	}
+
+	for _, tt := range tests {
+		if got := canonicalID(tt.platform, tt.category, tt.query, tt.name); got != tt.want {
+			t.Errorf("canonicalID(%q, %q, %q) = %q, want %q", tt.platform, tt.category, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEmitResultsWithKeyword(t *testing.T) {
+	setupRun(t, config{withKeyword: true, simpleFlag: true})
+
+	emitResults("github", "organization", "acme", "header", "github_organizations.txt", []string{"acme-corp", "acme-labs"})
+	emitResults("github", "organization", "acme labs", "header", "github_organizations.txt", []string{"acme-labs", "acmelabs"})
+
+	want := []string{"acme-corp\tacme", "acme-labs\tacme", "acmelabs\tacme labs"}
+	if got := readOutputLines(t, "github_organizations.txt"); !equalStrings(got, want) {
+		t.Errorf("saved = %q, want %q", got, want)
+	}
+	if got, want := resultNames("github", "organization"), []string{"acme-corp", "acme-labs", "acmelabs"}; !equalStrings(got, want) {
+		t.Errorf("recorded = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// keywordHistoryFile, in each monitor group's output directory, records
+// when each keyword of the group was last queried, so the rotation carries
+// on where it left off after a restart.
+const keywordHistoryFile = "keyword_history.json"
+
+var (
+	// lastQueried, set by monitor mode for the duration of a scan, orders
+	// the keywords searched from the least recently queried, so a scan cut
+	// short by -max-runtime or an exhausted quota leaves out the keywords
+	// queried most recently rather than the end of the alphabet.
+	lastQueried map[string]time.Time
+
+	// queriedWords holds when each keyword of the run finished searching,
+	// guarded by stateMu.
+	queriedWords = make(map[string]time.Time)
+)
+
+// prioritizeWords orders words from the least recently queried in history,
+// keywords never queried first. Ties keep the order of words.
+func prioritizeWords(words []string, history map[string]time.Time) []string {
+	if history == nil {
+		return words
+	}
+
+	prioritized := append([]string(nil), words...)
+	sort.SliceStable(prioritized, func(i, j int) bool {
+		return history[prioritized[i]].Before(history[prioritized[j]])
+	})
+	return prioritized
+}
+
+// recordQueried notes that every search of word ran.
+func recordQueried(word string) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	queriedWords[word] = time.Now().UTC()
+}
+
+// queriedThisRun returns the keywords the run queried, leaving out those
+// with a rate limited search: they're due again as soon as the quota allows.
+func queriedThisRun() map[string]time.Time {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	queried := make(map[string]time.Time, len(queriedWords))
+	for word, at := range queriedWords {
+		queried[word] = at
+	}
+	for _, entry := range searchErrors {
+		if entry.Class == "rate_limited" {
+			delete(queried, entry.Query)
+		}
+	}
+	return queried
+}
+
+func loadKeywordHistory(filename string) (map[string]time.Time, error) {
+	history := make(map[string]time.Time)
+
+	data, err := readOutputFile(filename)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return history, nil
+}
+
+func saveKeywordHistory(filename string, history map[string]time.Time) error {
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(filename, append(data, '\n'))
+}
+
+// rotateKeywords loads the group's keyword history before its first scan
+// and makes it order the scan's keywords. It returns the function to call
+// once the scan is over, which records the keywords it queried, forgets
+// those no longer in the group and saves the history.
+func rotateKeywords(group *targetGroup) func() {
+	filename := outputPath(keywordHistoryFile)
+	if group.lastQueried == nil {
+		history, err := loadKeywordHistory(filename)
+		if err != nil {
+			fmt.Printf("Error reading keyword history of group '%s': %s\n", group.Name, err)
+			history = make(map[string]time.Time)
+		}
+		group.lastQueried = history
+	}
+	lastQueried = group.lastQueried
+
+	return func() {
+		lastQueried = nil
+		for word, at := range queriedThisRun() {
+			group.lastQueried[word] = at
+		}
+		for word := range group.lastQueried {
+			if _, ok := group.words[word]; !ok {
+				delete(group.lastQueried, word)
+			}
+		}
+		if err := saveKeywordHistory(filename, group.lastQueried); err != nil {
+			fmt.Printf("Error saving keyword history of group '%s': %s\n", group.Name, err)
+		}
+	}
+}
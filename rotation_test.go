@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPrioritizeWords(t *testing.T) {
+	now := time.Now()
+	history := map[string]time.Time{
+		"acme":    now.Add(-time.Hour),
+		"globex":  now.Add(-48 * time.Hour),
+		"initech": now,
+	}
+
+	words := []string{"acme", "globex", "hooli", "initech", "umbrella"}
+	want := []string{"hooli", "umbrella", "globex", "acme", "initech"}
+	if got := prioritizeWords(words, history); !equalStrings(got, want) {
+		t.Errorf("prioritizeWords = %v, want %v", got, want)
+	}
+	if got := prioritizeWords(words, nil); !equalStrings(got, words) {
+		t.Errorf("prioritizeWords without history = %v, want %v", got, words)
+	}
+}
+
+func TestRotateKeywords(t *testing.T) {
+	setupRun(t, config{})
+
+	old := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := saveKeywordHistory(outputPath(keywordHistoryFile), map[string]time.Time{"globex": old, "initech": old, "gone": old}); err != nil {
+		t.Fatal(err)
+	}
+
+	group := &targetGroup{Name: "acme", words: map[string]struct{}{"acme": {}, "globex": {}, "initech": {}}}
+	done := rotateKeywords(group)
+	if got := prioritizeWords([]string{"globex", "initech", "acme"}, lastQueried); got[0] != "acme" {
+		t.Errorf("scan order = %v, want the never queried keyword first", got)
+	}
+
+	// acme completes, globex is throttled and initech is never reached.
+	recordQueried("acme")
+	recordQueried("globex")
+	stateMu.Lock()
+	searchErrors["throttled"] = &searchError{Query: "globex", Class: "rate_limited"}
+	stateMu.Unlock()
+	done()
+
+	if lastQueried != nil {
+		t.Error("lastQueried still set after the scan")
+	}
+	history, err := loadKeywordHistory(outputPath(keywordHistoryFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Errorf("history = %v, want acme, globex and initech", history)
+	}
+	if !history["acme"].After(old) || !history["globex"].Equal(old) || !history["initech"].Equal(old) {
+		t.Errorf("history = %v, want only acme updated", history)
+	}
+	if got := prioritizeWords([]string{"acme", "globex", "initech"}, history); got[2] != "acme" {
+		t.Errorf("next scan order = %v, want acme last", got)
+	}
+}
+
+func TestLoadKeywordHistoryMissing(t *testing.T) {
+	history, err := loadKeywordHistory(filepath.Join(t.TempDir(), keywordHistoryFile))
+	if err != nil || len(history) != 0 {
+		t.Errorf("loadKeywordHistory = %v, %v, want an empty history", history, err)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rulesFile is the format accepted by -rules: rules tagging the results
+// they match, e.g.
+//
+//	rules:
+//	  - tag: high-risk
+//	    name: backup|dump|config
+//	    categories: [repository, project]
+//	  - tag: exposed
+//	    platforms: [gitlab]
+//	    visibility: public
+//	    severity: critical
+type rulesFile struct {
+	Rules []riskRule `yaml:"rules"`
+}
+
+// riskRule tags the results matching all of its conditions. Name and Query
+// are case-insensitive regular expressions; the lists match any of their
+// values.
+type riskRule struct {
+	Tag         string   `yaml:"tag"`
+	Name        string   `yaml:"name"`
+	Query       string   `yaml:"query"`
+	Platforms   []string `yaml:"platforms"`
+	Categories  []string `yaml:"categories"`
+	KeywordTags []string `yaml:"keyword_tags"`
+
+	// Visibility matches the visibility of GitLab project results.
+	Visibility string `yaml:"visibility"`
+
+	// Severity is how urgent the results the rule tags are, in
+	// notifications: low, medium (the default), high or critical.
+	Severity string `yaml:"severity"`
+
+	name, query *regexp.Regexp
+}
+
+// severityLevels ranks severities, from results no rule tagged up.
+var severityLevels = map[string]int{"info": 0, "low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// defaultRuleSeverity is the severity of rules that don't set one.
+const defaultRuleSeverity = "medium"
+
+// riskRules holds the rules loaded from -rules.
+var riskRules []riskRule
+
+// findingTags holds the tags enrichment attaches to individual results from
+// what it learned about them, keyed by platform, category and normalized
+// name.
+var findingTags = make(map[string][]string)
+
+// tagFinding attaches tags to the result name will be reported as.
+func tagFinding(platform, category, name string, tags ...string) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	key := platform + "\x00" + category + "\x00" + normalizeName(name)
+	for _, tag := range tags {
+		if !containsString(findingTags[key], tag) {
+			findingTags[key] = append(findingTags[key], tag)
+		}
+	}
+}
+
+func loadRulesFile(filename string) ([]riskRule, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var rf rulesFile
+	if err := yaml.Unmarshal(data, &rf); err != nil {
+		return nil, err
+	}
+	if len(rf.Rules) == 0 {
+		return nil, errors.New("no rules defined")
+	}
+
+	for i := range rf.Rules {
+		rule := &rf.Rules[i]
+		if !tagRegexp.MatchString(rule.Tag) {
+			return nil, fmt.Errorf("rule %d: invalid tag name %q", i+1, rule.Tag)
+		}
+		rule.Tag = strings.ToLower(rule.Tag)
+		for j := range rule.KeywordTags {
+			rule.KeywordTags[j] = strings.ToLower(rule.KeywordTags[j])
+		}
+		if rule.Name == "" && rule.Query == "" && len(rule.Platforms) == 0 && len(rule.Categories) == 0 && len(rule.KeywordTags) == 0 && rule.Visibility == "" {
+			return nil, fmt.Errorf("rule %d (%s): no conditions, it would tag every result", i+1, rule.Tag)
+		}
+		rule.Severity = strings.ToLower(rule.Severity)
+		if rule.Severity == "" {
+			rule.Severity = defaultRuleSeverity
+		}
+		if _, ok := severityLevels[rule.Severity]; !ok || rule.Severity == "info" {
+			return nil, fmt.Errorf("rule %d (%s): invalid severity %q (use low, medium, high or critical)", i+1, rule.Tag, rule.Severity)
+		}
+		if rule.name, err = compileRulePattern(rule.Name); err != nil {
+			return nil, fmt.Errorf("rule %d (%s): name: %w", i+1, rule.Tag, err)
+		}
+		if rule.query, err = compileRulePattern(rule.Query); err != nil {
+			return nil, fmt.Errorf("rule %d (%s): query: %w", i+1, rule.Tag, err)
+		}
+	}
+
+	return rf.Rules, nil
+}
+
+func compileRulePattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile("(?i)" + pattern)
+}
+
+// loadRulesFlag makes the rules of the -rules file effective.
+func loadRulesFlag(cfg config) {
+	if cfg.rulesFlag == "" {
+		return
+	}
+
+	rules, err := loadRulesFile(cfg.rulesFlag)
+	if err != nil {
+		fmt.Printf("Error reading rules file: %s\n", err)
+		os.Exit(1)
+	}
+	riskRules = rules
+}
+
+func (r riskRule) matches(platform, category, query, name string) bool {
+	if r.name != nil && !r.name.MatchString(name) {
+		return false
+	}
+	if r.query != nil && !r.query.MatchString(query) {
+		return false
+	}
+	if len(r.Platforms) > 0 && !containsString(r.Platforms, platform) {
+		return false
+	}
+	if len(r.Categories) > 0 && !containsString(r.Categories, category) {
+		return false
+	}
+	if len(r.KeywordTags) > 0 && !containsAnyString(keywordTags[query], r.KeywordTags) {
+		return false
+	}
+	if r.Visibility != "" {
+		details := lookupProjectDetails(platform, category, name)
+		if details == nil || !strings.EqualFold(details.Visibility, r.Visibility) {
+			return false
+		}
+	}
+	return true
+}
+
+// resultTags returns the tags of a result: those of the keyword that found
+// it, those attached to it by enrichment, and those of the rules it matches.
+func resultTags(platform, category, query, name string) []string {
+	tags := append([]string(nil), keywordTags[query]...)
+	for _, tag := range findingTags[platform+"\x00"+category+"\x00"+normalizeName(name)] {
+		if !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	for _, rule := range riskRules {
+		if rule.matches(platform, category, query, name) && !containsString(tags, rule.Tag) {
+			tags = append(tags, rule.Tag)
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// resultSeverity returns the highest severity of the rules that tagged a
+// result, from its tags, or info when none did.
+func resultSeverity(tags []string) string {
+	severity := "info"
+	for _, rule := range riskRules {
+		if containsString(tags, rule.Tag) && severityLevels[rule.Severity] > severityLevels[severity] {
+			severity = rule.Severity
+		}
+	}
+	return severity
+}
+
+func containsAnyString(list, values []string) bool {
+	for _, value := range values {
+		if containsString(list, value) {
+			return true
+		}
+	}
+	return false
+}
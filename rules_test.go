@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func writeRulesFile(t *testing.T, content string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := ioutil.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestLoadRulesFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"no rules":      "rules: []\n",
+		"invalid tag":   "rules:\n  - tag: high risk\n    name: backup\n",
+		"no conditions": "rules:\n  - tag: everything\n",
+		"bad pattern":   "rules:\n  - tag: high-risk\n    name: \"backup(\"\n",
+		"bad severity":  "rules:\n  - tag: high-risk\n    name: backup\n    severity: urgent\n",
+	}
+
+	for name, content := range tests {
+		if _, err := loadRulesFile(writeRulesFile(t, content)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestRiskRulesTagResults(t *testing.T) {
+	setupRun(t, config{})
+
+	rules, err := loadRulesFile(writeRulesFile(t, `
+rules:
+  - tag: High-Risk
+    name: backup|dump|config
+    categories: [repository, project]
+  - tag: exposed
+    platforms: [gitlab]
+    visibility: public
+  - tag: brand-user
+    categories: [user]
+    keyword_tags: [Brand]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	riskRules = rules
+	tagWord("acme", []string{"brand"})
+	projectInfo["gitlab:acme/db-dump"] = projectDetails{Visibility: "public"}
+
+	tests := []struct {
+		platform, category, query, name string
+		want                            []string
+	}{
+		{"github", "repository", "acme", "acme/Config-Backup", []string{"brand", "high-risk"}},
+		{"github", "organization", "acme", "acme-backup", []string{"brand"}},
+		{"gitlab", "project", "payments", "acme/db-dump", []string{"exposed", "high-risk"}},
+		{"gitlab", "project", "payments", "acme/web", nil},
+		{"github", "user", "acme", "alice", []string{"brand", "brand-user"}},
+		{"github", "user", "payments", "bob", nil},
+	}
+
+	for _, tt := range tests {
+		if got := resultTags(tt.platform, tt.category, tt.query, tt.name); !equalStrings(got, tt.want) {
+			t.Errorf("resultTags(%s, %s, %s, %s) = %v, want %v", tt.platform, tt.category, tt.query, tt.name, got, tt.want)
+		}
+	}
+
+	// Tags reach the recorded results and the console.
+	var out bytes.Buffer
+	consoleEncoder = textEncoder{}
+	printResults(&out, resultBatch{Platform: "github", Category: "repository", Query: "acme", Header: "Repositories", Results: []string{"acme/db-backup"}})
+	if got, want := out.String(), "\nRepositories:\n- acme/db-backup #brand #high-risk\n"; got != want {
+		t.Errorf("console = %q, want %q", got, want)
+	}
+
+	recordResults("github", "repository", "acme", []string{"acme/db-backup"})
+	if got := collectedResults[0].Tags; !equalStrings(got, []string{"brand", "high-risk"}) {
+		t.Errorf("recorded tags = %v, want [brand high-risk]", got)
+	}
+}
+
+func TestResultSeverity(t *testing.T) {
+	setupRun(t, config{})
+
+	rules, err := loadRulesFile(writeRulesFile(t, `
+rules:
+  - tag: high-risk
+    name: backup
+  - tag: exposed
+    platforms: [gitlab]
+    severity: Critical
+  - tag: noted
+    categories: [user]
+    severity: low
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	riskRules = rules
+
+	tests := map[string][]string{
+		"info":     nil,
+		"low":      {"noted", "brand"},
+		"medium":   {"high-risk", "noted"},
+		"critical": {"exposed", "high-risk"},
+	}
+	for want, tags := range tests {
+		if got := resultSeverity(tags); got != want {
+			t.Errorf("resultSeverity(%v) = %s, want %s", tags, got, want)
+		}
+	}
+}
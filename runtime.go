@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// runDeadline is when -max-runtime runs out; zero means never.
+var runDeadline time.Time
+
+var (
+	unsearchedMu sync.Mutex
+	// unsearchedWords holds the keywords of the run skipped because
+	// -max-runtime ran out.
+	unsearchedWords []string
+	// cutShort is set once the run skips anything because -max-runtime
+	// ran out.
+	cutShort bool
+)
+
+// runtimeExceededError is returned by a run cut short by -max-runtime. It
+// counts the keywords that weren't started before the deadline, which
+// saveRemainingKeywords wrote to remaining_keywords.txt.
+type runtimeExceededError struct {
+	unsearched int
+}
+
+func (e *runtimeExceededError) Error() string {
+	return fmt.Sprintf("-max-runtime reached, %d keywords were not searched", e.unsearched)
+}
+
+// startDeadline starts the -max-runtime clock.
+func startDeadline(cfg config) {
+	if cfg.maxRuntimeFlag > 0 {
+		runDeadline = time.Now().Add(cfg.maxRuntimeFlag)
+	}
+}
+
+// runtimeExceeded reports whether -max-runtime has run out, after which no
+// new searches are started.
+func runtimeExceeded() bool {
+	return !runDeadline.IsZero() && time.Now().After(runDeadline)
+}
+
+func recordUnsearched(word string) {
+	unsearchedMu.Lock()
+	defer unsearchedMu.Unlock()
+	unsearchedWords = append(unsearchedWords, word)
+	cutShort = true
+}
+
+// skipRemaining records that the run skipped lookups other than keyword
+// searches, such as enrichment, because -max-runtime ran out.
+func skipRemaining() {
+	unsearchedMu.Lock()
+	defer unsearchedMu.Unlock()
+	cutShort = true
+}
+
+// wasCutShort reports whether the run skipped anything because
+// -max-runtime ran out.
+func wasCutShort() bool {
+	unsearchedMu.Lock()
+	defer unsearchedMu.Unlock()
+	return cutShort
+}
+
+// remainingKeywords returns the keywords the run didn't search, sorted.
+func remainingKeywords() []string {
+	unsearchedMu.Lock()
+	defer unsearchedMu.Unlock()
+
+	remaining := append([]string(nil), unsearchedWords...)
+	sort.Strings(remaining)
+	return remaining
+}
+
+// saveRemainingKeywords writes the keywords the run didn't search to
+// remaining_keywords.txt, one per line, to be fed to the next run. It
+// returns an errorBudgetError if the run went over -max-errors, and a
+// runtimeExceededError if it was cut short.
+func saveRemainingKeywords() error {
+	if errorBudgetExceeded() {
+		remaining := remainingKeywords()
+		printErrorBudgetSummary(len(remaining))
+		if len(remaining) > 0 {
+			saveResults("remaining_keywords.txt", remaining)
+		}
+		failed, _, _ := errorCounts()
+		return &errorBudgetError{failed: failed, unsearched: len(remaining)}
+	}
+	if !wasCutShort() {
+		return nil
+	}
+
+	remaining := remainingKeywords()
+	fmt.Printf("\n-max-runtime reached: %d keywords were not searched\n", len(remaining))
+	if len(remaining) > 0 {
+		saveResults("remaining_keywords.txt", remaining)
+	}
+	return &runtimeExceededError{unsearched: len(remaining)}
+}
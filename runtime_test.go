@@ -0,0 +1,50 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMaxRuntimeWindsDown(t *testing.T) {
+	setupRun(t, config{})
+	runDeadline = time.Now().Add(-time.Second)
+	t.Cleanup(func() { runDeadline = time.Time{} })
+
+	err := runAndExport(flags, func() {
+		emitResults("github", "organization", "acme", "GitHub organizations matching 'acme'", "github_organizations.txt", []string{"acme"})
+		if !runtimeExceeded() {
+			t.Error("runtimeExceeded() = false past the deadline")
+		}
+		recordUnsearched("zeta")
+		recordUnsearched("beta")
+	})
+
+	if code := exitCode(err); code != 3 {
+		t.Errorf("exitCode = %d, want 3 (err: %v)", code, err)
+	}
+	if got := readOutputLines(t, "github_organizations.txt"); !equalStrings(got, []string{"acme"}) {
+		t.Errorf("saved results = %v, want [acme]", got)
+	}
+	if got := readOutputLines(t, "remaining_keywords.txt"); !equalStrings(got, []string{"beta", "zeta"}) {
+		t.Errorf("remaining keywords = %v, want [beta zeta]", got)
+	}
+}
+
+func TestSaveRemainingTargets(t *testing.T) {
+	setupRun(t, config{})
+
+	err := saveRemainingTargets("acme", []string{"acme corp"}, []batchTarget{{label: "globex", keywords: []string{"globex", "initech"}}})
+	if code := exitCode(err); code != 3 {
+		t.Errorf("exitCode = %d, want 3 (err: %v)", code, err)
+	}
+
+	targets, err := readTargetsFile(filepath.Join(outputDir, "remaining_targets.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[0].label != "acme" || !equalStrings(targets[0].keywords, []string{"acme corp"}) ||
+		targets[1].label != "globex" || !equalStrings(targets[1].keywords, []string{"globex", "initech"}) {
+		t.Errorf("remaining targets = %+v", targets)
+	}
+}
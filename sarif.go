@@ -0,0 +1,218 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The subset of SARIF 2.1.0 written by -sarif, enough for GitHub code
+// scanning and other SARIF consumers to import code findings.
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool              sarifTool              `json:"tool"`
+	AutomationDetails sarifAutomationDetails `json:"automationDetails"`
+	Invocations       []sarifInvocation      `json:"invocations,omitempty"`
+	Results           []sarifResult          `json:"results"`
+}
+
+// sarifInvocation marks the SARIF of a partial run as such, so consumers
+// don't close the alerts of findings the run didn't get to.
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string            `json:"id"`
+	Name                 string            `json:"name"`
+	ShortDescription     sarifMessage      `json:"shortDescription"`
+	DefaultConfiguration sarifRuleDefaults `json:"defaultConfiguration"`
+}
+
+type sarifRuleDefaults struct {
+	Level string `json:"level"`
+}
+
+type sarifAutomationDetails struct {
+	ID string `json:"id"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          sarifProperties   `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifProperties struct {
+	Platform string   `json:"platform"`
+	Query    string   `json:"query"`
+	Finding  string   `json:"finding"`
+	Tags     []string `json:"tags,omitempty"`
+}
+
+// sarifRules are the rules findings are reported under, keyed by result
+// category. Results of other categories aren't code findings and are left
+// out of SARIF output.
+var sarifRules = map[string]sarifRule{
+	"blobs": {
+		ID: "dorky/code-match", Name: "KeywordInCode",
+		ShortDescription:     sarifMessage{"Keyword found in repository code"},
+		DefaultConfiguration: sarifRuleDefaults{"warning"},
+	},
+	"wiki": {
+		ID: "dorky/wiki-match", Name: "KeywordInWiki",
+		ShortDescription:     sarifMessage{"Keyword found in wiki content"},
+		DefaultConfiguration: sarifRuleDefaults{"warning"},
+	},
+	"secret_dork": {
+		ID: "dorky/secret-dork", Name: "PossibleSecretInCode",
+		ShortDescription:     sarifMessage{"Code matching a secret dork in an organization's own repositories"},
+		DefaultConfiguration: sarifRuleDefaults{"error"},
+	},
+	"sensitive_release_asset": {
+		ID: "dorky/sensitive-release-asset", Name: "SensitiveReleaseAsset",
+		ShortDescription:     sarifMessage{"Release asset named like a backup, dump, configuration or credentials"},
+		DefaultConfiguration: sarifRuleDefaults{"error"},
+	},
+}
+
+// sarifRuleCategory maps result categories sharing a rule to its key.
+func sarifRuleCategory(category string) string {
+	if category == "wiki_blobs" {
+		return "wiki"
+	}
+	return category
+}
+
+// sarifLocationURI links a code finding to the file it was found in, when
+// its name says enough to build the link.
+func sarifLocationURI(r result) string {
+	switch r.Category {
+	case "wiki", "blobs", "secret_dork":
+		parts := strings.SplitN(r.Name, ":", 2)
+		if len(parts) != 2 {
+			return ""
+		}
+		if r.Platform == "github" {
+			return "https://github.com/" + parts[0] + "/blob/HEAD/" + parts[1]
+		}
+		return "https://gitlab.com/" + parts[0] + "/-/blob/HEAD/" + parts[1]
+	case "sensitive_release_asset":
+		// "namespace/repo@tag:asset url".
+		if i := strings.LastIndex(r.Name, " "); i >= 0 {
+			return r.Name[i+1:]
+		}
+	}
+	return ""
+}
+
+func buildSARIF(results []result) sarifLog {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "dorky",
+			Version:        version,
+			InformationURI: "https://github.com/codingo/dorky",
+		}},
+		AutomationDetails: sarifAutomationDetails{ID: "dorky/" + runID},
+		Results:           []sarifResult{},
+	}
+
+	used := make(map[string]bool)
+	for _, r := range results {
+		key := sarifRuleCategory(r.Category)
+		rule, ok := sarifRules[key]
+		if !ok {
+			continue
+		}
+		if !used[key] {
+			used[key] = true
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+		}
+
+		sum := sha256.Sum256([]byte(r.Platform + "\x00" + r.Category + "\x00" + normalizeName(r.Name)))
+		sr := sarifResult{
+			RuleID:              rule.ID,
+			Level:               rule.DefaultConfiguration.Level,
+			Message:             sarifMessage{fmt.Sprintf("%s: '%s' matched %s", rule.ShortDescription.Text, r.Query, r.Name)},
+			PartialFingerprints: map[string]string{"dorkyFinding/v1": hex.EncodeToString(sum[:])},
+			Properties:          sarifProperties{Platform: r.Platform, Query: r.Query, Finding: r.Name, Tags: r.Tags},
+		}
+		if uri := sarifLocationURI(r); uri != "" {
+			sr.Locations = []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}}}}
+		}
+		run.Results = append(run.Results, sr)
+	}
+	if run.Tool.Driver.Rules == nil {
+		run.Tool.Driver.Rules = []sarifRule{}
+	}
+	if reason := runPartial(); reason != "" {
+		run.Invocations = []sarifInvocation{{
+			ToolExecutionNotifications: []sarifNotification{{Level: "error", Message: sarifMessage{"Partial results: the run " + reason}}},
+		}}
+	}
+
+	return sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+}
+
+// writeSARIFReport writes the code findings among results as SARIF.
+func writeSARIFReport(filename string, results []result) error {
+	data, err := json.MarshalIndent(buildSARIF(results), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileAtomically(filename, append(data, '\n')); err != nil {
+		return err
+	}
+	trackOutputFile(filename)
+
+	return nil
+}
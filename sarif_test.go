@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildSARIF(t *testing.T) {
+	setupRun(t, config{})
+
+	recordResults("github", "repository", "acme", []string{"acme/web"})
+	recordResults("github", "wiki", "acme", []string{"acme/docs:wiki/Home.md"})
+	recordResults("gitlab", "blobs", "acme", []string{"acme/infra:deploy/prod.env"})
+	recordResults("gitlab", "wiki_blobs", "acme", []string{"onboarding"})
+	recordResults("github", "sensitive_release_asset", "acme/cli", []string{"acme/cli@v1.0:db-backup.sql.gz https://example.com/db-backup.sql.gz"})
+
+	log := buildSARIF(collectedResults)
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log = %+v", log)
+	}
+	run := log.Runs[0]
+
+	var ruleIDs []string
+	for _, rule := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	if want := []string{"dorky/wiki-match", "dorky/code-match", "dorky/sensitive-release-asset"}; !equalStrings(ruleIDs, want) {
+		t.Errorf("rules = %v, want %v", ruleIDs, want)
+	}
+
+	if len(run.Results) != 4 {
+		t.Fatalf("got %d results, want 4: %+v", len(run.Results), run.Results)
+	}
+	var uris []string
+	for _, r := range run.Results {
+		uri := ""
+		if len(r.Locations) > 0 {
+			uri = r.Locations[0].PhysicalLocation.ArtifactLocation.URI
+		}
+		uris = append(uris, uri)
+	}
+	want := []string{
+		"https://github.com/acme/docs/blob/HEAD/wiki/Home.md",
+		"https://gitlab.com/acme/infra/-/blob/HEAD/deploy/prod.env",
+		"",
+		"https://example.com/db-backup.sql.gz",
+	}
+	if !equalStrings(uris, want) {
+		t.Errorf("locations = %q, want %q", uris, want)
+	}
+	if run.Results[3].Level != "error" || run.Results[2].RuleID != "dorky/wiki-match" {
+		t.Errorf("results = %+v", run.Results)
+	}
+
+	again := buildSARIF(collectedResults)
+	if again.Runs[0].Results[0].PartialFingerprints["dorkyFinding/v1"] != run.Results[0].PartialFingerprints["dorkyFinding/v1"] {
+		t.Error("fingerprints are not stable")
+	}
+}
+
+func TestWriteSARIFReportWithoutFindings(t *testing.T) {
+	setupRun(t, config{})
+	filename := filepath.Join(outputDir, "findings.sarif")
+
+	if err := writeSARIFReport(filename, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	run := doc["runs"].([]interface{})[0].(map[string]interface{})
+	if results, ok := run["results"].([]interface{}); !ok || len(results) != 0 {
+		t.Errorf("results = %v, want an empty list", run["results"])
+	}
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+)
+
+// reportSchema is the JSON Schema of the -json report, whose results are
+// also the documents exported to Elasticsearch. Changes that could break
+// consumers get a new version, with a new $id.
+//
+//go:embed schema/report.schema.json
+var reportSchema []byte
+
+// reportSchemaID is the $id of reportSchema, recorded in every report.
+const reportSchemaID = "https://github.com/codingo/dorky/schema/v1/report.schema.json"
+
+// runSchemaCommand implements `dorky schema`, printing the report schema
+// for downstream integrations to validate against or generate code from.
+func runSchemaCommand(args []string) {
+	if len(args) > 0 {
+		fmt.Println("Usage: dorky schema")
+		os.Exit(1)
+	}
+	os.Stdout.Write(reportSchema)
+}
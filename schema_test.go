@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// checkSchema verifies value against the parts of JSON Schema the report
+// schema uses: $ref, type, required, properties and items. It reports
+// every property the schema doesn't describe, so the schema can't silently
+// fall behind the Go types.
+func checkSchema(t *testing.T, path string, value interface{}, node, root map[string]interface{}) {
+	t.Helper()
+
+	if ref, ok := node["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/$defs/")
+		node = root["$defs"].(map[string]interface{})[name].(map[string]interface{})
+	}
+
+	if types, ok := node["type"]; ok && !schemaTypeMatches(types, value) {
+		t.Errorf("%s: %v does not have type %v", path, value, types)
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, required := range asList(node["required"]) {
+			if _, ok := v[required.(string)]; !ok {
+				t.Errorf("%s: missing required property %s", path, required)
+			}
+		}
+		properties, hasProperties := node["properties"].(map[string]interface{})
+		additional, hasAdditional := node["additionalProperties"].(map[string]interface{})
+		for key, child := range v {
+			switch {
+			case hasProperties && properties[key] != nil:
+				checkSchema(t, path+"."+key, child, properties[key].(map[string]interface{}), root)
+			case hasAdditional:
+				checkSchema(t, path+"."+key, child, additional, root)
+			default:
+				t.Errorf("%s: property %s is not in the schema", path, key)
+			}
+		}
+	case []interface{}:
+		if items, ok := node["items"].(map[string]interface{}); ok {
+			for i, child := range v {
+				checkSchema(t, fmt.Sprintf("%s[%d]", path, i), child, items, root)
+			}
+		}
+	}
+}
+
+func asList(v interface{}) []interface{} {
+	list, _ := v.([]interface{})
+	return list
+}
+
+func schemaTypeMatches(types, value interface{}) bool {
+	names := asList(types)
+	if name, ok := types.(string); ok {
+		names = []interface{}{name}
+	}
+
+	for _, name := range names {
+		switch value.(type) {
+		case nil:
+			if name == "null" {
+				return true
+			}
+		case bool:
+			if name == "boolean" {
+				return true
+			}
+		case float64:
+			if name == "number" || name == "integer" && value.(float64) == float64(int64(value.(float64))) {
+				return true
+			}
+		case string:
+			if name == "string" {
+				return true
+			}
+		case []interface{}:
+			if name == "array" {
+				return true
+			}
+		case map[string]interface{}:
+			if name == "object" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestReportMatchesSchema(t *testing.T) {
+	setupRun(t, config{})
+
+	var schema map[string]interface{}
+	if err := json.Unmarshal(reportSchema, &schema); err != nil {
+		t.Fatalf("embedded schema is not valid JSON: %s", err)
+	}
+	if schema["$id"] != reportSchemaID {
+		t.Errorf("$id = %v, want %s", schema["$id"], reportSchemaID)
+	}
+
+	active := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	projectInfo["gitlab:acme/infra"] = projectDetails{Visibility: "internal", WikiEnabled: true, LastActivityAt: &active}
+	keywordTags["acme"] = []string{"brand"}
+	recordResults("gitlab", "project", "acme", []string{"acme/infra"})
+	recordResults("all", "avatar_match", "", []string{"0123456789ab: github:acme, gitlab:acme"})
+	recordSearchError("github", "user search", "acme", errors.New("boom"))
+	remaining := 10
+	rollup := orgRollup{
+		Org: "acme", ID: "github:acme", TotalRepos: 3, Languages: map[string]int{"Go": 2},
+		RecentRepos:     []rollupRepo{{Name: "acme/api", Language: "Go", Stars: 4, PushedAt: active}},
+		TopContributors: []rollupContributor{{Login: "alice", Contributions: 7}},
+	}
+
+	r := report{
+		provenance:    runProvenance(time.Now().UTC()),
+		RateLimits:    []rateLimitUsage{{Platform: "github", Resource: "search", Requests: 2, Limit: 30, Remaining: &remaining}},
+		Errors:        searchErrorSummary(),
+		OrgRollups:    []orgRollup{rollup},
+		Stale:         []resultState{{Platform: "github", Category: "user", Name: "acme-old", ID: "github:acme-old", FirstSeen: active, LastSeen: active}},
+		EmptySearches: []emptySearch{{Platform: "gitlab", Category: "group", Query: "acme"}},
+		Results:       collectedResults,
+	}
+	filename := filepath.Join(outputDir, "report.json")
+	if err := writeJSONReport(filename, r); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded["$schema"] != reportSchemaID {
+		t.Errorf("$schema = %v, want %s", decoded["$schema"], reportSchemaID)
+	}
+	checkSchema(t, "report", decoded, schema, schema)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// searchError aggregates the failures of one operation for one query,
+// keeping the latest message of each error class.
+type searchError struct {
+	Platform  string `json:"platform"`
+	Query     string `json:"query"`
+	Operation string `json:"operation"`
+	Class     string `json:"type"`
+	Message   string `json:"message"`
+	Count     int    `json:"count"`
+}
+
+// searchErrors holds the failures of the current run, keyed by platform,
+// operation, query and class.
+var searchErrors = make(map[string]*searchError)
+
+// partialFailureError is returned by a run that completed and exported its
+// results even though some searches failed, so the results are incomplete.
+type partialFailureError struct {
+	failed int
+}
+
+func (e *partialFailureError) Error() string {
+	return fmt.Sprintf("%d operations failed, results are incomplete", e.failed)
+}
+
+// httpStatusError is an unexpected HTTP status from an API called without
+// an SDK.
+type httpStatusError struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("request failed with status %s: %s", e.Status, e.Body)
+}
+
+// recordSearchError notes a failed operation for the end-of-run error
+// report. The error itself is only printed in verbose mode, to keep it out
+// of the result stream.
+func recordSearchError(platform, operation, query string, err error) {
+	class := classifyError(err)
+	verbosePrint("Error in %s %s for '%s' (%s): %s\n", platform, operation, query, class, err)
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	key := platform + "\x00" + operation + "\x00" + query + "\x00" + class
+	entry, ok := searchErrors[key]
+	if !ok {
+		entry = &searchError{Platform: platform, Query: query, Operation: operation, Class: class}
+		searchErrors[key] = entry
+	}
+	entry.Count++
+	entry.Message = err.Error()
+	hooks.error(*entry)
+}
+
+// classifyError sorts an error into a coarse class that tells users what to
+// do about it: wait, fix credentials, or retry.
+func classifyError(err error) string {
+	var rateErr *github.RateLimitError
+	var abuseErr *github.AbuseRateLimitError
+	if errors.As(err, &rateErr) || errors.As(err, &abuseErr) {
+		return "rate_limited"
+	}
+
+	status := 0
+	var ghErr *github.ErrorResponse
+	var glErr *gitlab.ErrorResponse
+	var statusErr *httpStatusError
+	switch {
+	case errors.As(err, &ghErr) && ghErr.Response != nil:
+		status = ghErr.Response.StatusCode
+	case errors.As(err, &glErr) && glErr.Response != nil:
+		status = glErr.Response.StatusCode
+	case errors.As(err, &statusErr):
+		status = statusErr.StatusCode
+	}
+
+	switch {
+	case status == http.StatusTooManyRequests:
+		return "rate_limited"
+	case status == http.StatusUnauthorized:
+		return "unauthorized"
+	case status == http.StatusForbidden:
+		return "forbidden"
+	case status == http.StatusNotFound:
+		return "not_found"
+	case status >= 500:
+		return "server_error"
+	case status >= 400:
+		return "client_error"
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &netErr):
+		return "network"
+	}
+
+	return "other"
+}
+
+// searchErrorSummary returns the errors of the current run, ordered by
+// platform, query and operation.
+func searchErrorSummary() []searchError {
+	summary := make([]searchError, 0, len(searchErrors))
+	for _, entry := range searchErrors {
+		summary = append(summary, *entry)
+	}
+
+	sort.Slice(summary, func(i, j int) bool {
+		a, b := summary[i], summary[j]
+		if a.Platform != b.Platform {
+			return a.Platform < b.Platform
+		}
+		if a.Query != b.Query {
+			return a.Query < b.Query
+		}
+		if a.Operation != b.Operation {
+			return a.Operation < b.Operation
+		}
+		return a.Class < b.Class
+	})
+
+	return summary
+}
+
+func failedOperations() int {
+	failed := 0
+	for _, entry := range searchErrors {
+		failed += entry.Count
+	}
+	return failed
+}
+
+// printErrorReport writes the run's errors to stderr, so they never mix
+// with results piped from stdout.
+func printErrorReport() {
+	summary := searchErrorSummary()
+	if len(summary) == 0 {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "\nErrors (%d failed operations):\n", failedOperations())
+	for _, e := range summary {
+		fmt.Fprintf(os.Stderr, "- %s %s '%s': %s x%d: %s\n", e.Platform, e.Operation, e.Query, e.Class, e.Count, e.Message)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// apiResponse is a response as the SDKs attach it to their errors, whose
+// Error methods describe the request.
+func apiResponse(status int) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Request:    &http.Request{Method: http.MethodGet, URL: &url.URL{Scheme: "https", Host: "api.example.com"}},
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{&github.RateLimitError{Response: apiResponse(http.StatusForbidden)}, "rate_limited"},
+		{&github.AbuseRateLimitError{Response: apiResponse(http.StatusForbidden)}, "rate_limited"},
+		{&github.ErrorResponse{Response: apiResponse(http.StatusUnauthorized)}, "unauthorized"},
+		{&github.ErrorResponse{Response: apiResponse(http.StatusUnprocessableEntity)}, "client_error"},
+		{&gitlab.ErrorResponse{Response: apiResponse(http.StatusTooManyRequests)}, "rate_limited"},
+		{&gitlab.ErrorResponse{Response: apiResponse(http.StatusBadGateway)}, "server_error"},
+		{fmt.Errorf("wrapped: %w", &httpStatusError{StatusCode: http.StatusForbidden}), "forbidden"},
+		{fmt.Errorf("wrapped: %w", context.DeadlineExceeded), "timeout"},
+		{errors.New("boom"), "other"},
+	}
+
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("classifyError(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRecordSearchErrorAggregates(t *testing.T) {
+	setupRun(t, config{})
+
+	recordSearchError("github", "user search", "acme", &github.RateLimitError{Response: apiResponse(http.StatusForbidden), Message: "first"})
+	recordSearchError("github", "user search", "acme", &github.RateLimitError{Response: apiResponse(http.StatusForbidden), Message: "second"})
+	recordSearchError("gitlab", "group search", "acme", errors.New("boom"))
+
+	summary := searchErrorSummary()
+	if len(summary) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(summary), summary)
+	}
+	if gh := summary[0]; gh.Platform != "github" || gh.Class != "rate_limited" || gh.Count != 2 {
+		t.Errorf("GitHub entry = %+v", gh)
+	}
+	if failed := failedOperations(); failed != 3 {
+		t.Errorf("failedOperations = %d, want 3", failed)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	partial := fmt.Errorf("targets acme are incomplete: %w", &partialFailureError{failed: 3})
+	if got := exitCode(partial); got != 2 {
+		t.Errorf("exitCode(partial failure) = %d, want 2", got)
+	}
+	if got := exitCode(errors.New("writing JSON report: disk full")); got != 1 {
+		t.Errorf("exitCode(fatal error) = %d, want 1", got)
+	}
+}
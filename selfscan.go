@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// secretDorks are GitHub code searches for credentials committed by
+// mistake, run by `dorky selfscan` against the organization's own code.
+var secretDorks = []string{
+	"filename:.env",
+	"filename:.npmrc _auth",
+	"filename:id_rsa",
+	"extension:pem private",
+	"aws_secret_access_key",
+	"api_key",
+	"secret_key",
+	"password",
+}
+
+// Priorities of self-scan findings, most urgent first.
+const (
+	priorityHigh   = "high"
+	priorityMedium = "medium"
+	priorityLow    = "low"
+)
+
+var priorityRanks = map[string]int{priorityHigh: 0, priorityMedium: 1, priorityLow: 2}
+
+// selfscanFinding is one line of the self-scan report.
+type selfscanFinding struct {
+	Priority string
+	Kind     string
+	Detail   string
+}
+
+// runSelfscanCommand implements `dorky selfscan -org acme`: a scan of an
+// organization's own namespaces for defenders. It checks who holds the
+// organization's name on each platform, looks for typosquats of it, lists
+// the gists of its members and runs secret dorks against its code, then
+// ranks everything in a prioritized report.
+func runSelfscanCommand(args []string) {
+	fs := flag.NewFlagSet("selfscan", flag.ExitOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	orgs := fs.String("org", "", "comma-separated GitHub organizations of your own to scan")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dorky selfscan -org acme[,...] [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 || *orgs == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if flags.workspace != "" {
+		if err := applyWorkspace(fs, flags.workspace); err != nil {
+			fmt.Printf("Error %s\n", err)
+			os.Exit(1)
+		}
+	}
+	loadRulesFlag(flags)
+	validateOutputFlags(flags)
+
+	httpClient, err := createGitHubHTTPClient()
+	if err != nil {
+		fmt.Printf("Error creating GitHub client: %s\n", err)
+		os.Exit(1)
+	}
+	ghClient := github.NewClient(httpClient)
+
+	glClient, err := createGitLabClient()
+	if err != nil {
+		fmt.Printf("Error creating GitLab client: %s\n", err)
+	}
+
+	// The organizations scanned are the target's by definition.
+	cfg := flags
+	cfg.ownedFlag = strings.Join(append(splitList(cfg.ownedFlag), splitList(*orgs)...), ",")
+
+	err = runAndExport(cfg, func() {
+		selfscan(ghClient, glClient, splitList(*orgs), cfg)
+	})
+	if err != nil {
+		fmt.Printf("Error %s\n", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// selfscan runs every self-scan check of orgs and reports the findings.
+func selfscan(ghClient *github.Client, glClient *gitlab.Client, orgs []string, cfg config) {
+	words := make(map[string]struct{})
+	for _, org := range orgs {
+		words[org] = struct{}{}
+	}
+
+	for _, org := range orgs {
+		verbosePrint("Self-scanning GitHub organization: %s\n", org)
+		checkAvailability(ghClient, glClient, org, cfg)
+
+		for _, member := range enumerateGitHubMembers(ghClient, org) {
+			enumerateGitHubGists(ghClient, member)
+		}
+		searchSecretDorks(ghClient.Search, org, cfg.maxFlag)
+	}
+
+	candidates := buildImpersonationReport(ghClient, glClient, words, cfg)
+	if len(candidates) > 0 {
+		reportImpersonation(candidates)
+	}
+
+	reportSelfscan(selfscanFindings(availabilityChecks, candidates, collectedResults))
+}
+
+// searchSecretDorks runs the secret dorks against org's code, reporting
+// matches as "owner/repo:path".
+func searchSecretDorks(client githubSearchService, org string, maxResults int) {
+	for _, dork := range secretDorks {
+		query := dork + " org:" + org
+		opt := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: maxResults}}
+		results, _, err := client.Code(context.Background(), query, opt)
+		if err != nil {
+			recordSearchError("github", "secret dork", query, err)
+			continue
+		}
+
+		files := make([]string, len(results.CodeResults))
+		for i, file := range results.CodeResults {
+			files[i] = file.GetRepository().GetFullName() + ":" + file.GetPath()
+		}
+		emitResults("github", "secret_dork", org, fmt.Sprintf("GitHub code of '%s' matching '%s'", org, dork), "github_secret_dorks.txt", files)
+	}
+}
+
+// selfscanFindings ranks what a self-scan found: committed secrets and
+// convincing typosquats first, then the organization's name held by others,
+// then names still free to register and gists worth a review.
+func selfscanFindings(checks []availabilityCheck, candidates []impersonationCandidate, results []result) []selfscanFinding {
+	var findings []selfscanFinding
+
+	for _, r := range results {
+		switch r.Category {
+		case "secret_dork":
+			findings = append(findings, selfscanFinding{priorityHigh, "possible secret", r.Name})
+		case "gist":
+			findings = append(findings, selfscanFinding{priorityLow, "member gist", r.Query + ": " + r.Name})
+		}
+	}
+
+	for _, c := range candidates {
+		priority := priorityMedium
+		if c.Risk >= 70 {
+			priority = priorityHigh
+		}
+		detail := fmt.Sprintf("%s:%s lookalike of '%s', risk %.1f", c.Platform, c.Name, c.Keyword, c.Risk)
+		findings = append(findings, selfscanFinding{priority, "typosquat", detail})
+	}
+
+	for _, check := range checks {
+		switch check.Status {
+		case statusThirdParty:
+			findings = append(findings, selfscanFinding{priorityMedium, "name held by third party", check.Platform + ":" + check.Name})
+		case statusAvailable:
+			findings = append(findings, selfscanFinding{priorityLow, "name unclaimed", check.Platform + ":" + check.Name})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return priorityRanks[findings[i].Priority] < priorityRanks[findings[j].Priority]
+	})
+	return findings
+}
+
+// reportSelfscan prints the findings, most urgent first, and saves them to
+// selfscan_report.txt.
+func reportSelfscan(findings []selfscanFinding) {
+	lines := make([]string, len(findings))
+	for i, f := range findings {
+		lines[i] = fmt.Sprintf("[%s] %s: %s", strings.ToUpper(f.Priority), f.Kind, f.Detail)
+	}
+
+	printResults(os.Stdout, resultBatch{Platform: "all", Category: "selfscan_report", Header: "Self-scan findings (most urgent first)", Results: lines})
+	saveResults("selfscan_report.txt", lines)
+}
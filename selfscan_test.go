@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSearchSecretDorks(t *testing.T) {
+	setupRun(t, config{})
+	search := &fakeGitHubSearch{code: map[string][]string{
+		"filename:.env org:acme": {"acme/api:.env"},
+		"password org:acme":      {"acme/web:config/db.yml"},
+	}}
+
+	searchSecretDorks(search, "acme", 10)
+
+	if len(search.queries) != len(secretDorks) {
+		t.Errorf("ran %d queries, want %d", len(search.queries), len(secretDorks))
+	}
+	if got, want := resultNames("github", "secret_dork"), []string{"acme/api:.env", "acme/web:config/db.yml"}; !equalStrings(got, want) {
+		t.Errorf("secret dork matches = %v, want %v", got, want)
+	}
+}
+
+func TestSelfscanFindingsPriorities(t *testing.T) {
+	checks := []availabilityCheck{
+		{Platform: "github", Name: "acme", Status: statusTarget},
+		{Platform: "gitlab", Name: "acme", Status: statusThirdParty},
+	}
+	candidates := []impersonationCandidate{
+		{availabilityCheck: availabilityCheck{Platform: "github", Name: "acme-inc"}, Keyword: "acme", Risk: 82.5},
+		{availabilityCheck: availabilityCheck{Platform: "github", Name: "acrne"}, Keyword: "acme", Risk: 40},
+	}
+	results := []result{
+		{Platform: "github", Category: "gist", Query: "alice", Name: "https://gist.github.com/alice/1 notes"},
+		{Platform: "github", Category: "secret_dork", Query: "acme", Name: "acme/api:.env"},
+	}
+
+	var got []string
+	for _, f := range selfscanFindings(checks, candidates, results) {
+		got = append(got, f.Priority+" "+f.Kind+": "+f.Detail)
+	}
+	want := []string{
+		"high possible secret: acme/api:.env",
+		"high typosquat: github:acme-inc lookalike of 'acme', risk 82.5",
+		"medium typosquat: github:acrne lookalike of 'acme', risk 40.0",
+		"medium name held by third party: gitlab:acme",
+		"low member gist: alice: https://gist.github.com/alice/1 notes",
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("findings =\n%v\nwant\n%v", got, want)
+	}
+}
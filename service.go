@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultHealthAddr is where `dorky serve` serves /healthz.
+const defaultHealthAddr = ":8080"
+
+// shutdownTimeout bounds how long a stopping monitor waits for health
+// check requests under way.
+const shutdownTimeout = 5 * time.Second
+
+// monitorService schedules the target groups of monitor mode, swaps them
+// on reloads and reports on them to health checks.
+type monitorService struct {
+	baseDir string
+	checker vanishChecker
+	started time.Time
+
+	mu       sync.Mutex
+	groups   []*targetGroup
+	halt     chan struct{}
+	stopping bool
+}
+
+func newMonitorService(baseDir string) *monitorService {
+	return &monitorService{baseDir: baseDir, checker: newVanishChecker(), started: time.Now().UTC()}
+}
+
+// start schedules groups, notifying new results as configured by notify.
+func (s *monitorService) start(groups []*targetGroup, notify notifyConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups = groups
+	s.halt = make(chan struct{})
+	for _, group := range groups {
+		fmt.Printf("Scheduled target group '%s' (%s), next run at %s\n",
+			group.Name, group.Schedule, group.cron.next(time.Now()).Format(time.RFC3339))
+		go s.monitorGroup(group, notify, s.halt)
+	}
+}
+
+// reload replaces the scheduled groups with groups, once any scan in
+// progress is over. Groups keeping their name keep what their earlier scans
+// found, so reloading neither notifies old results again nor resets the
+// keyword rotation.
+func (s *monitorService) reload(groups []*targetGroup, notify notifyConfig) {
+	scanMu.Lock()
+	defer scanMu.Unlock()
+
+	s.mu.Lock()
+	close(s.halt)
+	old := make(map[string]*targetGroup)
+	for _, group := range s.groups {
+		old[group.Name] = group
+	}
+	for _, group := range groups {
+		if previous, ok := old[group.Name]; ok && previous != group {
+			group.seen, group.present, group.lastQueried = previous.seen, previous.present, previous.lastQueried
+			group.lastScan, group.lastError = previous.lastScan, previous.lastError
+		}
+	}
+	s.mu.Unlock()
+
+	s.start(groups, notify)
+}
+
+// stop stops scheduling scans and waits for any in progress. Health checks
+// fail from then on.
+func (s *monitorService) stop() {
+	s.mu.Lock()
+	s.stopping = true
+	close(s.halt)
+	s.mu.Unlock()
+
+	scanMu.Lock()
+}
+
+// recordScan records the outcome of a scan of group ended at finished.
+func (s *monitorService) recordScan(group *targetGroup, finished time.Time, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group.lastScan, group.lastError = finished, ""
+	if err != nil {
+		group.lastError = err.Error()
+	}
+}
+
+// groupHealth is the status of a target group, as reported by /healthz.
+type groupHealth struct {
+	Name      string     `json:"name"`
+	Schedule  string     `json:"schedule"`
+	NextScan  *time.Time `json:"next_scan,omitempty"`
+	LastScan  *time.Time `json:"last_scan,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// serviceHealth is the response of /healthz.
+type serviceHealth struct {
+	Status    string        `json:"status"`
+	StartedAt time.Time     `json:"started_at"`
+	Groups    []groupHealth `json:"groups"`
+}
+
+// health reports on the service at now.
+func (s *monitorService) health(now time.Time) serviceHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h := serviceHealth{Status: "ok", StartedAt: s.started, Groups: []groupHealth{}}
+	if s.stopping {
+		h.Status = "stopping"
+	}
+	for _, group := range s.groups {
+		g := groupHealth{Name: group.Name, Schedule: group.Schedule, LastError: group.lastError}
+		if next := group.cron.next(now).UTC(); !next.IsZero() {
+			g.NextScan = &next
+		}
+		if !group.lastScan.IsZero() {
+			last := group.lastScan
+			g.LastScan = &last
+		}
+		h.Groups = append(h.Groups, g)
+	}
+	sort.Slice(h.Groups, func(i, j int) bool { return h.Groups[i].Name < h.Groups[j].Name })
+	return h
+}
+
+// handler serves /healthz: 200 while the service runs, whatever its scans'
+// outcome, which the body details, and 503 once it's stopping, so
+// orchestrators stop routing to it and don't restart it mid-scan for
+// failed searches.
+func (s *monitorService) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		h := s.health(time.Now())
+		w.Header().Set("Content-Type", "application/json")
+		if h.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(h)
+	})
+	return mux
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testGroup(t *testing.T, name string) *targetGroup {
+	t.Helper()
+	cron, err := parseCron("@yearly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &targetGroup{Name: name, Schedule: "@yearly", cron: cron, words: map[string]struct{}{name: {}}}
+}
+
+func TestServiceHealth(t *testing.T) {
+	svc := newMonitorService(t.TempDir())
+	acme, globex := testGroup(t, "acme"), testGroup(t, "globex")
+	svc.start([]*targetGroup{globex, acme}, notifyConfig{})
+	finished := time.Date(2024, 3, 1, 6, 0, 0, 0, time.UTC)
+	svc.recordScan(acme, finished, errors.New("2 operations failed, results are incomplete"))
+
+	get := func() (int, serviceHealth) {
+		rec := httptest.NewRecorder()
+		svc.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		var h serviceHealth
+		if err := json.Unmarshal(rec.Body.Bytes(), &h); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, h
+	}
+
+	// Failed scans don't make the service unhealthy: restarting it
+	// wouldn't fix the searches.
+	code, h := get()
+	if code != http.StatusOK || h.Status != "ok" || len(h.Groups) != 2 {
+		t.Fatalf("got %d %+v, want 200 with both groups", code, h)
+	}
+	a := h.Groups[0]
+	if a.Name != "acme" || a.LastScan == nil || !a.LastScan.Equal(finished) || a.LastError == "" || a.NextScan == nil {
+		t.Errorf("acme = %+v, want its failed scan and next scan", a)
+	}
+	if g := h.Groups[1]; g.LastScan != nil || g.LastError != "" {
+		t.Errorf("globex = %+v, want no scan yet", g)
+	}
+
+	svc.stop()
+	defer scanMu.Unlock()
+	if code, h := get(); code != http.StatusServiceUnavailable || h.Status != "stopping" {
+		t.Errorf("got %d %q while stopping, want 503", code, h.Status)
+	}
+}
+
+func TestServiceReload(t *testing.T) {
+	svc := newMonitorService(t.TempDir())
+	acme := testGroup(t, "acme")
+	acme.seen = map[string]bool{"github\x00organization\x00acme": true}
+	svc.start([]*targetGroup{acme, testGroup(t, "globex")}, notifyConfig{})
+	halt := svc.halt
+
+	reloaded, initech := testGroup(t, "acme"), testGroup(t, "initech")
+	svc.reload([]*targetGroup{reloaded, initech}, notifyConfig{})
+
+	select {
+	case <-halt:
+	default:
+		t.Error("the previous groups' schedules weren't stopped")
+	}
+	if !reloaded.seen["github\x00organization\x00acme"] {
+		t.Errorf("reloaded acme seen = %v, want the results found before the reload", reloaded.seen)
+	}
+	if initech.seen != nil {
+		t.Errorf("initech seen = %v, want none", initech.seen)
+	}
+	if h := svc.health(time.Now()); len(h.Groups) != 2 || h.Groups[0].Name != "acme" || h.Groups[1].Name != "initech" {
+		t.Errorf("groups = %+v, want acme and initech", h.Groups)
+	}
+
+	svc.stop()
+	scanMu.Unlock()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	// outputMu serializes the writes of output files, which happen from
+	// every keyword's goroutine and not always with stateMu held.
+	outputMu sync.Mutex
+
+	// outputContents holds what the run wrote to each output file so far,
+	// keyed by path. Guarded by outputMu.
+	outputContents = make(map[string][]byte)
+)
+
+// resetOutputContents forgets what the previous run wrote, so the first
+// write of the next run starts each file over.
+func resetOutputContents() {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	outputContents = make(map[string][]byte)
+}
+
+// appendOutput adds lines to what the run wrote to filename and rewrites the
+// file atomically, so a crash leaves either the previous contents or the new
+// ones and readers never see a half-written line. The first write in a run
+// replaces whatever an earlier run left.
+func appendOutput(filename string, lines []string) error {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+
+	var b strings.Builder
+	b.Write(outputContents[filename])
+	for _, line := range lines {
+		b.WriteString(line + lineEnding)
+	}
+	data := []byte(b.String())
+
+	if err := writeFileAtomically(filename, data); err != nil {
+		return err
+	}
+	outputContents[filename] = data
+	return nil
+}
+
+// writeFileAtomically replaces filename with data by writing a temporary
+// file next to it and renaming it over, so the file is never truncated or
+// partially written, even if dorky is killed mid-write. The file is
+// encrypted when a passphrase is set, see encrypt.go.
+func writeFileAtomically(filename string, data []byte) error {
+	data, err := sealOutput(filename, data)
+	if err != nil {
+		return fmt.Errorf("encrypting %s: %w", filename, err)
+	}
+	return writeFileAtomicallyMode(filename, data, 0644)
+}
+
+// writeFileAtomicallyMode is writeFileAtomically for a file with the
+// permissions perm.
+func writeFileAtomicallyMode(filename string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	// TempFile creates the file readable by its owner only.
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
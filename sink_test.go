@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestSaveResultsConcurrentWriters(t *testing.T) {
+	setupRun(t, config{})
+
+	const writers, batches = 8, 20
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for b := 0; b < batches; b++ {
+				saveResults("shared.txt", []string{fmt.Sprintf("writer %d batch %d line 1", w, b), fmt.Sprintf("writer %d batch %d line 2", w, b)})
+				saveResults(fmt.Sprintf("writer_%d.txt", w), []string{fmt.Sprintf("batch %d", b)})
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	lines := readOutputLines(t, "shared.txt")
+	if len(lines) != writers*batches*2 {
+		t.Fatalf("shared.txt has %d lines, want %d", len(lines), writers*batches*2)
+	}
+	for i := 0; i < len(lines); i += 2 {
+		var w, b int
+		if _, err := fmt.Sscanf(lines[i], "writer %d batch %d line 1", &w, &b); err != nil {
+			t.Fatalf("line %d = %q, want the first line of a batch", i, lines[i])
+		}
+		if want := fmt.Sprintf("writer %d batch %d line 2", w, b); lines[i+1] != want {
+			t.Fatalf("line %d = %q, want %q right after its batch's first line", i+1, lines[i+1], want)
+		}
+	}
+
+	for w := 0; w < writers; w++ {
+		if got := readOutputLines(t, fmt.Sprintf("writer_%d.txt", w)); len(got) != batches {
+			t.Errorf("writer_%d.txt has %d lines, want %d", w, len(got), batches)
+		}
+	}
+
+	entries, err := ioutil.ReadDir(outputDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	if len(names) != writers+1 {
+		t.Errorf("output directory holds %v, want no temporary files left", names)
+	}
+}
+
+func TestSaveResultsReplacesPreviousRun(t *testing.T) {
+	setupRun(t, config{})
+
+	saveResults("repos.txt", []string{"acme/old"})
+	startRun()
+	saveResults("repos.txt", []string{"acme/api"})
+	saveResults("repos.txt", []string{"acme/web"})
+
+	if got, want := readOutputLines(t, "repos.txt"), []string{"acme/api", "acme/web"}; !equalStrings(got, want) {
+		t.Errorf("repos.txt = %v, want %v", got, want)
+	}
+
+	info, err := os.Stat(filepath.Join(outputDir, "repos.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm&0044 == 0 {
+		t.Errorf("repos.txt mode = %v, want readable like any output file", perm)
+	}
+}
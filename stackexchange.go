@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// stackExchangeAPI is the base URL of the Stack Exchange API.
+var stackExchangeAPI = "https://api.stackexchange.com/2.3"
+
+// stackExchangeClient searches one Stack Exchange site, Stack Overflow by
+// default. STACKEXCHANGE_KEY, if set, raises the daily quota from 300
+// requests.
+type stackExchangeClient struct {
+	baseURL    string
+	site       string
+	key        string
+	httpClient *http.Client
+
+	// The API asks clients to hold off for "backoff" seconds after some
+	// responses.
+	mu        sync.Mutex
+	notBefore time.Time
+}
+
+// stackExchangeResponse is the wrapper of every API response. Items are
+// questions or users, depending on the endpoint.
+type stackExchangeResponse struct {
+	Items []struct {
+		Title string `json:"title"`
+		Link  string `json:"link"`
+		Owner struct {
+			Link string `json:"link"`
+		} `json:"owner"`
+	} `json:"items"`
+	Backoff int `json:"backoff"`
+}
+
+func createStackExchangeClient(site string) *stackExchangeClient {
+	transport := newProviderTransport("stackexchange", nil, newAdaptiveLimiter("stackexchange", 10, rate.Every(time.Minute), func(*http.Request) string { return "api" }))
+
+	return &stackExchangeClient{
+		baseURL:    stackExchangeAPI,
+		site:       site,
+		key:        os.Getenv("STACKEXCHANGE_KEY"),
+		httpClient: &http.Client{Transport: transport, Timeout: time.Minute},
+	}
+}
+
+func (c *stackExchangeClient) get(path string, params url.Values) (*stackExchangeResponse, error) {
+	params.Set("site", c.site)
+	if c.key != "" {
+		params.Set("key", c.key)
+	}
+
+	c.mu.Lock()
+	wait := time.Until(c.notBefore)
+	c.mu.Unlock()
+	if wait > 0 {
+		verbosePrint("Stack Exchange asked to back off, waiting %s\n", wait.Round(time.Second))
+		time.Sleep(wait)
+	}
+
+	resp, err := c.httpClient.Get(c.baseURL + path + "?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Status: resp.Status, Body: body}
+	}
+
+	var page stackExchangeResponse
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, err
+	}
+	if page.Backoff > 0 {
+		c.mu.Lock()
+		c.notBefore = time.Now().Add(time.Duration(page.Backoff) * time.Second)
+		c.mu.Unlock()
+	}
+	return &page, nil
+}
+
+// searchStackExchange reports the questions mentioning query and the
+// profiles of their authors, and the users whose display name contains
+// query.
+func searchStackExchange(client *stackExchangeClient, query string, maxResults int) {
+	searchStackExchangeQuestions(client, query, query, maxResults)
+
+	page, err := client.get("/users", url.Values{"inname": {searchText(query)}, "pagesize": {strconv.Itoa(pageSize(maxResults))}})
+	if err != nil {
+		recordSearchError("stackexchange", "user search", query, err)
+		return
+	}
+
+	var profiles []string
+	for _, item := range page.Items {
+		if item.Link != "" {
+			profiles = append(profiles, item.Link)
+		}
+	}
+	emitResults("stackexchange", "user", query, fmt.Sprintf("Stack Exchange users matching '%s'", query), "stackexchange_users.txt", profiles)
+}
+
+// searchStackExchangeDomains reports the questions mentioning an email
+// address at one of the target domains, and their authors.
+func searchStackExchangeDomains(client *stackExchangeClient, domains []string, maxResults int) {
+	for _, domain := range domains {
+		searchStackExchangeQuestions(client, domain, `"@`+domain+`"`, maxResults)
+	}
+}
+
+// searchStackExchangeQuestions runs a full-text question search for text,
+// reporting results under query. Questions are reported as "link title",
+// and their authors as profile links: developers who mention internal
+// systems and the accounts tied to them.
+func searchStackExchangeQuestions(client *stackExchangeClient, query, text string, maxResults int) {
+	params := url.Values{"q": {text}, "order": {"desc"}, "sort": {"relevance"}, "pagesize": {strconv.Itoa(pageSize(maxResults))}}
+	page, err := client.get("/search/advanced", params)
+	if err != nil {
+		recordSearchError("stackexchange", "question search", query, err)
+		return
+	}
+
+	var questions, authors []string
+	for _, item := range page.Items {
+		if item.Link == "" {
+			continue
+		}
+		questions = append(questions, item.Link+" "+html.UnescapeString(item.Title))
+		if item.Owner.Link != "" {
+			authors = append(authors, item.Owner.Link)
+		}
+	}
+
+	emitResults("stackexchange", "question", query, fmt.Sprintf("Stack Exchange questions mentioning '%s'", query), "stackexchange_questions.txt", questions)
+	emitResults("stackexchange", "user", query, fmt.Sprintf("Stack Exchange authors of questions mentioning '%s'", query), "stackexchange_users.txt", authors)
+}
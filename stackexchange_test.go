@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestSearchStackExchange(t *testing.T) {
+	setupRun(t, config{stackFlag: true})
+
+	srv, requests := newFakeAPI(t, map[string]interface{}{
+		"/search/advanced": map[string]interface{}{
+			"items": []map[string]interface{}{
+				{"title": "Connecting to acme&#39;s internal Jenkins", "link": "https://stackoverflow.com/q/1", "owner": map[string]string{"link": "https://stackoverflow.com/users/10/jane"}},
+				{"title": "acme SDK timeout", "link": "https://stackoverflow.com/q/2", "owner": map[string]string{"link": "https://stackoverflow.com/users/10/jane"}},
+			},
+		},
+		"/users": map[string]interface{}{
+			"items": []map[string]string{{"link": "https://stackoverflow.com/users/20/acme-dev"}},
+		},
+	})
+
+	old := stackExchangeAPI
+	stackExchangeAPI = srv.URL
+	t.Cleanup(func() { stackExchangeAPI = old })
+	setenv(t, "STACKEXCHANGE_KEY", "")
+
+	client := createStackExchangeClient("stackoverflow")
+	searchStackExchange(client, "acme", 5)
+	searchStackExchangeDomains(client, []string{"acme.com"}, 5)
+
+	questions := []string{"https://stackoverflow.com/q/1 Connecting to acme's internal Jenkins", "https://stackoverflow.com/q/2 acme SDK timeout"}
+	if got := resultNames("stackexchange", "question"); !equalStrings(got, questions) {
+		t.Errorf("questions = %v, want %v", got, questions)
+	}
+	users := []string{"https://stackoverflow.com/users/10/jane", "https://stackoverflow.com/users/20/acme-dev"}
+	if got := resultNames("stackexchange", "user"); !equalStrings(got, users) {
+		t.Errorf("users = %v, want %v", got, users)
+	}
+
+	var texts []string
+	for _, query := range *requests {
+		if query.Get("site") != "stackoverflow" {
+			t.Errorf("request for site %q", query.Get("site"))
+		}
+		if q := query.Get("q"); q != "" {
+			texts = append(texts, q)
+		}
+	}
+	if want := []string{"acme", `"@acme.com"`}; !equalStrings(texts, want) {
+		t.Errorf("question searches = %q, want %q", texts, want)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// pivotStarredRepos inspects the repositories every GitHub user found so far
+// stars and watches, and reports those whose name contains one of words.
+// People star and watch their employer's repositories, including mirrors
+// and forks of internal tooling that no keyword search ranks highly.
+func pivotStarredRepos(activity githubActivityService, words map[string]struct{}) {
+	var users []string
+	seen := make(map[string]bool)
+	for _, r := range collectedResults {
+		if r.Platform == "github" && r.Category == "user" && !seen[normalizeName(r.Name)] {
+			seen[normalizeName(r.Name)] = true
+			users = append(users, r.Name)
+		}
+	}
+	sort.Strings(users)
+
+	ctx := context.Background()
+	for _, user := range users {
+		verbosePrint("Inspecting starred and watched repositories of GitHub user: %s\n", user)
+
+		starred, _, err := activity.ListStarred(ctx, user, &github.ActivityListStarredOptions{ListOptions: github.ListOptions{PerPage: 100}})
+		if err != nil {
+			recordSearchError("github", "starred listing", user, err)
+		} else {
+			var names []string
+			for _, s := range starred {
+				names = append(names, s.GetRepository().GetFullName())
+			}
+			if matches := matchingRepos(names, words); len(matches) > 0 {
+				emitResults("github", "starred", user, fmt.Sprintf("Repositories starred by GitHub user '%s' matching the keywords", user), "github_starred.txt", matches)
+			}
+		}
+
+		watched, _, err := activity.ListWatched(ctx, user, &github.ListOptions{PerPage: 100})
+		if err != nil {
+			recordSearchError("github", "watched listing", user, err)
+		} else {
+			var names []string
+			for _, repo := range watched {
+				names = append(names, repo.GetFullName())
+			}
+			if matches := matchingRepos(names, words); len(matches) > 0 {
+				emitResults("github", "watched", user, fmt.Sprintf("Repositories watched by GitHub user '%s' matching the keywords", user), "github_watched.txt", matches)
+			}
+		}
+	}
+}
+
+// matchingRepos returns the repository names containing any of words,
+// whatever their case and diacritics.
+func matchingRepos(names []string, words map[string]struct{}) []string {
+	var matches []string
+	for _, name := range names {
+		folded := foldName(name)
+		for word := range words {
+			if strings.Contains(folded, foldName(word)) {
+				matches = append(matches, name)
+				break
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+)
+
+type fakeGitHubActivity struct {
+	starred map[string][]string
+	watched map[string][]string
+}
+
+func (f fakeGitHubActivity) ListStarred(ctx context.Context, user string, opts *github.ActivityListStarredOptions) ([]*github.StarredRepository, *github.Response, error) {
+	names, ok := f.starred[user]
+	if !ok {
+		return nil, nil, errors.New("not found")
+	}
+
+	var starred []*github.StarredRepository
+	for _, name := range names {
+		starred = append(starred, &github.StarredRepository{Repository: &github.Repository{FullName: github.String(name)}})
+	}
+	return starred, nil, nil
+}
+
+func (f fakeGitHubActivity) ListWatched(ctx context.Context, user string, opts *github.ListOptions) ([]*github.Repository, *github.Response, error) {
+	names, ok := f.watched[user]
+	if !ok {
+		return nil, nil, errors.New("not found")
+	}
+
+	var watched []*github.Repository
+	for _, name := range names {
+		watched = append(watched, &github.Repository{FullName: github.String(name)})
+	}
+	return watched, nil, nil
+}
+
+func TestPivotStarredRepos(t *testing.T) {
+	setupRun(t, config{})
+
+	activity := fakeGitHubActivity{
+		starred: map[string][]string{
+			"alice": {"golang/go", "alice/ACME-internal-mirror", "acme/sdk"},
+			"bob":   {"acme/sdk", "kubernetes/kubernetes"},
+		},
+		watched: map[string][]string{
+			"alice": {"widgetco/deploy-tools"},
+		},
+	}
+	words := map[string]struct{}{"acme": {}, "widgetco": {}}
+
+	recordResults("github", "user", "acme", []string{"bob", "alice"})
+
+	pivotStarredRepos(activity, words)
+
+	if got, want := resultNames("github", "starred"), []string{"alice/ACME-internal-mirror", "acme/sdk"}; !equalStrings(got, want) {
+		t.Errorf("starred = %v, want %v", got, want)
+	}
+	if got, want := resultNames("github", "watched"), []string{"widgetco/deploy-tools"}; !equalStrings(got, want) {
+		t.Errorf("watched = %v, want %v", got, want)
+	}
+	if got, want := readOutputLines(t, "github_starred.txt"), []string{"alice/ACME-internal-mirror", "acme/sdk"}; !equalStrings(got, want) {
+		t.Errorf("saved = %v, want %v", got, want)
+	}
+	if summary := searchErrorSummary(); len(summary) != 1 || summary[0].Query != "bob" || summary[0].Operation != "watched listing" {
+		t.Errorf("errors = %+v", summary)
+	}
+}
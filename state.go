@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// stateFile is the format of -state: every result found by the runs
+// sharing it, with when it was first and last found, and the API quotas
+// still paused when the last run ended.
+type stateFile struct {
+	Results   []resultState       `json:"results"`
+	Cooldowns []rateLimitCooldown `json:"cooldowns,omitempty"`
+}
+
+type resultState struct {
+	Platform  string    `json:"platform"`
+	Category  string    `json:"category"`
+	Name      string    `json:"name"`
+	ID        string    `json:"id,omitempty"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+
+	// Triage is the mark an analyst gave the result with `dorky triage`:
+	// interesting, false-positive or done.
+	Triage    string     `json:"triage,omitempty"`
+	Note      string     `json:"note,omitempty"`
+	TriagedAt *time.Time `json:"triaged_at,omitempty"`
+}
+
+// staleResults holds the results the current run pruned from the state
+// file, with -prune-after.
+var staleResults []resultState
+
+// parseAge parses a -prune-after age: a Go duration, or a whole number of
+// days or weeks such as 90d or 2w.
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, suffix)); err == nil && strings.HasSuffix(s, suffix) {
+			if n < 0 {
+				return 0, fmt.Errorf("negative age %q", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 90d, 2w or 36h)", s)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative age %q", s)
+	}
+	return d, nil
+}
+
+func readStateFile(filename string) (*stateFile, error) {
+	data, err := readOutputFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return &stateFile{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state stateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &state, nil
+}
+
+// updateState records results as seen at now in the state file, with the
+// quotas still paused, whether by this run or an earlier one. With a
+// non-zero pruneAfter, results last seen longer ago than that are removed
+// and returned. Results marked as triaged are never pruned, so a known
+// false positive stays hidden however rarely it turns up.
+func updateState(filename string, results []result, now time.Time, pruneAfter time.Duration) ([]resultState, error) {
+	state, err := readStateFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*resultState)
+	for i := range state.Results {
+		s := &state.Results[i]
+		byKey[stateKey(s.Platform, s.Category, s.Name)] = s
+	}
+
+	for _, r := range results {
+		k := stateKey(r.Platform, r.Category, r.Name)
+		if s, ok := byKey[k]; ok {
+			s.LastSeen = now
+			continue
+		}
+		byKey[k] = &resultState{Platform: r.Platform, Category: r.Category, Name: r.Name, ID: r.ID, FirstSeen: now, LastSeen: now}
+	}
+
+	var kept, stale []resultState
+	for _, s := range byKey {
+		if pruneAfter > 0 && now.Sub(s.LastSeen) > pruneAfter && s.Triage == "" {
+			stale = append(stale, *s)
+		} else {
+			kept = append(kept, *s)
+		}
+	}
+	sortStates(stale)
+
+	if err := writeStateFile(filename, &stateFile{Results: kept, Cooldowns: activeCooldowns(state.Cooldowns, now)}); err != nil {
+		return nil, err
+	}
+	return stale, nil
+}
+
+// stateKey identifies a result in the state file, whatever the case of its
+// name.
+func stateKey(platform, category, name string) string {
+	return platform + "\x00" + category + "\x00" + normalizeName(name)
+}
+
+// writeStateFile replaces the state file atomically, so an interrupted run
+// can't lose the history.
+func writeStateFile(filename string, state *stateFile) error {
+	sortStates(state.Results)
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomically(filename, append(data, '\n'))
+}
+
+func sortStates(states []resultState) {
+	sort.Slice(states, func(i, j int) bool {
+		a, b := states[i], states[j]
+		return a.Platform+"\x00"+a.Category+"\x00"+a.Name < b.Platform+"\x00"+b.Category+"\x00"+b.Name
+	})
+}
+
+// recordState updates the -state file with the run's results. Results are
+// only pruned after a run without failed or skipped searches, since a
+// missing search doesn't mean its results are gone.
+func recordState(cfg config, now time.Time) error {
+	var pruneAfter time.Duration
+	if cfg.pruneAfterFlag != "" {
+		pruneAfter, _ = parseAge(cfg.pruneAfterFlag)
+	}
+	if pruneAfter > 0 && failedOperations() > 0 {
+		verbosePrint("Not pruning the state file: some searches failed\n")
+		pruneAfter = 0
+	}
+	if pruneAfter > 0 && (wasCutShort() || runPartial() != "") {
+		verbosePrint("Not pruning the state file: some keywords weren't searched\n")
+		pruneAfter = 0
+	}
+
+	// Hidden false positives were still found.
+	results := append(append([]result(nil), collectedResults...), hiddenResults...)
+	stale, err := updateState(outputPath(cfg.stateFlag), results, now, pruneAfter)
+	if err != nil {
+		return err
+	}
+	staleResults = stale
+	if len(stale) == 0 {
+		return nil
+	}
+
+	lines := make([]string, len(stale))
+	for i, s := range stale {
+		lines[i] = fmt.Sprintf("%s %s %s (last seen %s)", s.Platform, s.Category, s.Name, s.LastSeen.Format("2006-01-02"))
+	}
+	printResults(os.Stdout, resultBatch{
+		Platform: "all",
+		Category: "stale",
+		Header:   fmt.Sprintf("Results not seen for %s, pruned from the state file", cfg.pruneAfterFlag),
+		Results:  lines,
+	})
+	saveResults("stale_results.txt", lines)
+	return nil
+}
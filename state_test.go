@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := map[string]time.Duration{
+		"90d": 90 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"36h": 36 * time.Hour,
+		"0d":  0,
+	}
+	for s, want := range tests {
+		if got, err := parseAge(s); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+
+	for _, s := range []string{"", "90", "d", "-3d", "soon"} {
+		if _, err := parseAge(s); err == nil {
+			t.Errorf("parseAge(%q) succeeded", s)
+		}
+	}
+}
+
+func TestUpdateStatePrunes(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "state", "results.json")
+	day := 24 * time.Hour
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	first := []result{
+		{Platform: "github", Category: "user", Name: "acme-dev", ID: "github:acme-dev"},
+		{Platform: "github", Category: "user", Name: "acme-old", ID: "github:acme-old"},
+	}
+	if stale, err := updateState(filename, first, start, 90*day); err != nil || len(stale) != 0 {
+		t.Fatalf("first run = %v, %v, want nothing stale", stale, err)
+	}
+
+	// Found again, in another case, 60 days later: still the same result.
+	second := []result{{Platform: "github", Category: "user", Name: "ACME-Dev"}}
+	if stale, err := updateState(filename, second, start.Add(60*day), 90*day); err != nil || len(stale) != 0 {
+		t.Fatalf("second run = %v, %v, want nothing stale", stale, err)
+	}
+
+	stale, err := updateState(filename, second, start.Add(100*day), 90*day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].Name != "acme-old" || !stale[0].LastSeen.Equal(start) {
+		t.Errorf("stale = %+v, want acme-old last seen at the start", stale)
+	}
+
+	state, err := readStateFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Results) != 1 {
+		t.Fatalf("state = %+v, want only acme-dev", state.Results)
+	}
+	if s := state.Results[0]; s.Name != "acme-dev" || !s.FirstSeen.Equal(start) || !s.LastSeen.Equal(start.Add(100*day)) {
+		t.Errorf("acme-dev = %+v, want first seen at the start and last seen now", s)
+	}
+}
+
+func TestRecordStateKeepsResultsAfterFailures(t *testing.T) {
+	cfg := config{stateFlag: "state.json", pruneAfterFlag: "1d"}
+	setupRun(t, cfg)
+
+	old := []result{{Platform: "github", Category: "user", Name: "acme-old"}}
+	if _, err := updateState(outputPath("state.json"), old, time.Now().UTC().Add(-48*time.Hour), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	recordSearchError("github", "user search", "acme", errors.New("boom"))
+	if err := recordState(cfg, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if len(staleResults) != 0 {
+		t.Errorf("pruned %+v after a failed search", staleResults)
+	}
+
+	startRun()
+	if err := recordState(cfg, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if len(staleResults) != 1 {
+		t.Errorf("stale = %+v, want acme-old pruned", staleResults)
+	}
+	if got := readOutputLines(t, "stale_results.txt"); len(got) != 1 {
+		t.Errorf("saved %v, want acme-old", got)
+	}
+}
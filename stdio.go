@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcServerError    = -32000
+)
+
+// maxRPCLine caps the size of a single request read by -stdio.
+const maxRPCLine = 16 << 20
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// scanParams are the parameters of the scan method. Unset fields keep the
+// value of the flags dorky was started with.
+type scanParams struct {
+	Keywords      []string `json:"keywords"`
+	Organizations bool     `json:"organizations"`
+	Repositories  bool     `json:"repositories"`
+	Users         bool     `json:"users"`
+	MaxResults    int      `json:"max_results"`
+	Clean         bool     `json:"clean"`
+	Platforms     []string `json:"platforms"`
+}
+
+// scanSummary is the result of the scan method, sent once the scan is over
+// and its results exported.
+type scanSummary struct {
+	RunID       string    `json:"run_id"`
+	StartedAt   time.Time `json:"started_at"`
+	FinishedAt  time.Time `json:"finished_at"`
+	ResultCount int       `json:"result_count"`
+	ErrorCount  int       `json:"error_count"`
+	Cancelled   bool      `json:"cancelled"`
+	Partial     string    `json:"partial,omitempty"`
+	Unsearched  []string  `json:"unsearched,omitempty"`
+}
+
+// stdioServer serves JSON-RPC 2.0 over a pair of streams, one message per
+// line, for tools driving dorky as a subprocess. One scan runs at a time;
+// while it does, its results, errors and progress are streamed as
+// notifications and other requests, such as cancel, are still answered.
+type stdioServer struct {
+	cfg config
+
+	// scan runs the searches of a scan; searchPlatforms outside tests.
+	scan func(words map[string]struct{}, cfg config)
+
+	outMu sync.Mutex
+	out   *json.Encoder
+
+	mu      sync.Mutex
+	running bool
+	runID   string
+	cancel  chan struct{}
+	wg      sync.WaitGroup
+}
+
+// runStdio serves the JSON-RPC requests read from in until it is closed,
+// then waits for the running scan to finish. The flags dorky was started
+// with are the defaults of every scan.
+func runStdio(in io.Reader, out io.Writer, cfg config) error {
+	s := &stdioServer{cfg: cfg, scan: searchPlatforms, out: json.NewEncoder(out)}
+	return s.serve(in)
+}
+
+func (s *stdioServer) serve(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxRPCLine)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			s.handle([]byte(line))
+		}
+	}
+	s.wg.Wait()
+	return scanner.Err()
+}
+
+func (s *stdioServer) handle(line []byte) {
+	var req rpcRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		s.respondError(json.RawMessage("null"), rpcParseError, err.Error())
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		s.respondError(req.ID, rpcInvalidRequest, `requests need "jsonrpc": "2.0" and a method`)
+		return
+	}
+
+	switch req.Method {
+	case "scan":
+		s.startScan(req)
+	case "cancel":
+		var params struct {
+			RunID string `json:"run_id"`
+		}
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &params); err != nil {
+				s.respondError(req.ID, rpcInvalidParams, err.Error())
+				return
+			}
+		}
+		s.respond(req.ID, map[string]bool{"cancelled": s.cancelScan(params.RunID)})
+	case "version":
+		s.respond(req.ID, map[string]string{"version": version})
+	default:
+		s.respondError(req.ID, rpcMethodNotFound, fmt.Sprintf("unknown method %q", req.Method))
+	}
+}
+
+// startScan validates a scan request and runs the scan in the background,
+// answering the request once it is over.
+func (s *stdioServer) startScan(req rpcRequest) {
+	var params scanParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			s.respondError(req.ID, rpcInvalidParams, err.Error())
+			return
+		}
+	}
+
+	cfg, err := params.config(s.cfg)
+	if err != nil {
+		s.respondError(req.ID, rpcInvalidParams, err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		s.respondError(req.ID, rpcServerError, "a scan is already running")
+		return
+	}
+	s.running, s.runID, s.cancel = true, "", make(chan struct{})
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	// The keywords' tags and seeds are read by the searches of a running
+	// scan, so they're only built over once the scan is claimed.
+	resetKeywordTags()
+	resetKeywordSources()
+	words := make(map[string]struct{})
+	for _, keyword := range params.Keywords {
+		processWord(keyword, words, cfg)
+	}
+	if len(words) == 0 {
+		s.endScan()
+		s.respondError(req.ID, rpcInvalidParams, "no keyword left to search")
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		summary, err := s.runScan(words, cfg, cancel)
+		s.endScan()
+
+		if err != nil {
+			s.respondError(req.ID, rpcServerError, err.Error())
+			return
+		}
+		s.respond(req.ID, summary)
+	}()
+}
+
+// endScan lets the next scan start.
+func (s *stdioServer) endScan() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.running, s.runID, s.cancel = false, "", nil
+}
+
+// runScan runs a scan, streaming its events, and returns its summary.
+func (s *stdioServer) runScan(words map[string]struct{}, cfg config, cancel chan struct{}) (scanSummary, error) {
+	var id string
+	previous := hooks
+	hooks = runHooks{
+		OnResult: func(r result) {
+			s.notify("result", map[string]interface{}{"run_id": id, "result": r})
+		},
+		OnError: func(e searchError) {
+			s.notify("error", map[string]interface{}{"run_id": id, "error": e})
+		},
+		OnRateLimit: func(e rateLimitEvent) {
+			s.notify("rate_limit", map[string]interface{}{"run_id": id, "platform": e.Platform, "waited_ms": e.Waited.Milliseconds(), "throttled": e.Throttled})
+		},
+		OnProgress: func(p scanProgress) {
+			s.notify("progress", map[string]interface{}{"run_id": id, "keyword": p.Keyword, "done": p.Done, "total": p.Total})
+		},
+	}
+	cancelSearch = cancel
+	defer func() { hooks, cancelSearch = previous, nil }()
+	startDeadline(cfg)
+
+	// A cancelled scan starts no new searches, but those under way carry
+	// on in the background until they return: the next scan waits for them.
+	searchDone := make(chan struct{})
+	err := runAndExport(cfg, func() {
+		defer close(searchDone)
+		id = runID
+		s.mu.Lock()
+		s.runID = id
+		s.mu.Unlock()
+		s.notify("started", map[string]string{"run_id": id})
+		s.scan(words, cfg)
+	})
+	<-searchDone
+
+	stateMu.Lock()
+	summary := scanSummary{
+		RunID:       runID,
+		StartedAt:   runStarted,
+		FinishedAt:  time.Now().UTC(),
+		ResultCount: len(collectedResults),
+		ErrorCount:  failedOperations(),
+		Partial:     partialReason,
+	}
+	stateMu.Unlock()
+	summary.Unsearched = remainingKeywords()
+
+	var interrupted *runInterruptedError
+	var exceeded *runtimeExceededError
+	var aborted *errorBudgetError
+	var partial *partialFailureError
+	switch {
+	case err == nil, errors.As(err, &exceeded), errors.As(err, &aborted), errors.As(err, &partial):
+		return summary, nil
+	case errors.As(err, &interrupted):
+		summary.Cancelled = interrupted.reason == cancelledReason
+		return summary, nil
+	}
+	return summary, err
+}
+
+// cancelScan cancels the running scan, if it has the given run ID or none
+// is given.
+func (s *stdioServer) cancelScan(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel == nil || (id != "" && id != s.runID) {
+		return false
+	}
+	close(s.cancel)
+	s.cancel = nil
+	return true
+}
+
+// config applies the parameters of a scan to the flags dorky was started
+// with, checking the result as the flags of a run are.
+func (p scanParams) config(base config) (config, error) {
+	cfg := base
+	if p.Organizations || p.Repositories || p.Users {
+		cfg.orgFlag, cfg.repoFlag, cfg.userFlag = p.Organizations, p.Repositories, p.Users
+	}
+	if p.MaxResults != 0 {
+		cfg.maxFlag = p.MaxResults
+	}
+	cfg.cleanFlag = cfg.cleanFlag || p.Clean
+
+	if len(p.Platforms) > 0 {
+		known := scanPlatforms(base)
+		for _, platform := range p.Platforms {
+			if !containsString(known, platform) {
+				return cfg, fmt.Errorf("unknown platform %q: must be one of %s", platform, strings.Join(known, ", "))
+			}
+		}
+		cfg.platforms = strings.Join(p.Platforms, ",")
+
+		// Pastes and Stack Exchange are searches of their own, which
+		// naming them asks for.
+		cfg.pastesFlag = platformSelected(cfg, "pastes")
+		cfg.stackFlag = platformSelected(cfg, "stackexchange")
+		if !platformSelected(cfg, "bitbucket") {
+			cfg.bbURLFlag = ""
+		} else if cfg.bbURLFlag == "" {
+			return cfg, errors.New("searching bitbucket needs dorky started with -bb-url")
+		}
+		if len(p.Platforms) == 1 {
+			cfg.ghOnlyFlag, cfg.glOnlyFlag = p.Platforms[0] == "github", p.Platforms[0] == "gitlab"
+		}
+	}
+
+	problems := append(searchFlagProblems(cfg), outputFlagProblems(cfg)...)
+	if len(problems) > 0 {
+		return cfg, errors.New(strings.Join(problems, "; "))
+	}
+	return cfg, nil
+}
+
+// scanPlatforms returns the platforms a scan may name: the built-in ones and
+// the plugins dorky would run.
+func scanPlatforms(cfg config) []string {
+	names := append([]string(nil), platformNames...)
+	if dir, err := pluginDir(); err == nil {
+		plugins, _ := discoverPlugins(dir, splitList(cfg.pluginsFlag))
+		for _, p := range plugins {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+func (s *stdioServer) send(message interface{}) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+
+	if err := s.out.Encode(message); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing JSON-RPC message: %s\n", err)
+	}
+}
+
+func (s *stdioServer) respond(id json.RawMessage, result interface{}) {
+	if id == nil {
+		// Notifications get no response.
+		return
+	}
+	s.send(rpcResponse{JSONRPC: "2.0", ID: id, Result: result})
+}
+
+func (s *stdioServer) respondError(id json.RawMessage, code int, message string) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	s.send(rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}})
+}
+
+// notify sends a scan event, such as "result", as a notification.
+func (s *stdioServer) notify(event string, params interface{}) {
+	s.send(rpcNotification{JSONRPC: "2.0", Method: "scan." + event, Params: params})
+}
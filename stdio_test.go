@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stdioMessage is any message written by the -stdio server.
+type stdioMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func readStdioMessages(t *testing.T, out *bytes.Buffer) []stdioMessage {
+	t.Helper()
+
+	var messages []stdioMessage
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var m stdioMessage
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Fatalf("output line %q is not JSON: %s", line, err)
+		}
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+func findResponse(t *testing.T, messages []stdioMessage, id string) stdioMessage {
+	t.Helper()
+
+	for _, m := range messages {
+		if string(m.ID) == id {
+			return m
+		}
+	}
+	t.Fatalf("no response to request %s", id)
+	return stdioMessage{}
+}
+
+func TestStdioScan(t *testing.T) {
+	setupRun(t, config{})
+
+	var scanned config
+	var out bytes.Buffer
+	s := &stdioServer{cfg: config{ghAPIFlag: "rest", maxFlag: 10, concurrencyFlag: 1, orBatchFlag: 1, recurseMinReposFlag: 2, minWordLengthFlag: 2}, out: json.NewEncoder(&out), scan: func(words map[string]struct{}, cfg config) {
+		scanned = cfg
+		emitResults("github", "organization", "acme", "GitHub organizations matching 'acme'", "github_organizations.txt", []string{"acme"})
+		recordSearchError("github", "user search", "acme", errors.New("boom"))
+	}}
+
+	in := strings.Join([]string{
+		`{"jsonrpc": "2.0", "id": 1, "method": "scan", "params": {"keywords": ["https://www.acme.com"], "organizations": true, "clean": true, "platforms": ["github"]}}`,
+		`{"jsonrpc": "2.0", "id": 2, "method": "scan", "params": {"keywords": ["acme"]}}`,
+		`{"jsonrpc": "2.0", "id": 3, "method": "scan", "params": {"keywords": ["acme"], "users": true, "platforms": ["sourceforge"]}}`,
+		`{"jsonrpc": "2.0", "id": 5, "method": "scan", "params": {"keywords": ["acme"], "organizations": true, "max_results": -1}}`,
+		`{"jsonrpc": "2.0", "id": "v", "method": "version"}`,
+		`{"jsonrpc": "2.0", "id": 4, "method": "explode"}`,
+		`{"jsonrpc": "2.0", "method": "version"}`,
+		`not json`,
+	}, "\n")
+	if err := s.serve(strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	messages := readStdioMessages(t, &out)
+
+	var summary scanSummary
+	if err := json.Unmarshal(findResponse(t, messages, "1").Result, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.RunID == "" || summary.ResultCount != 1 || summary.ErrorCount != 1 || summary.Cancelled {
+		t.Errorf("summary = %+v, want one result and one error", summary)
+	}
+	if !scanned.orgFlag || scanned.repoFlag || !scanned.ghOnlyFlag || scanned.maxFlag != 10 {
+		t.Errorf("scan config = %+v, want organizations on GitHub with the default -max", scanned)
+	}
+
+	var events []string
+	for _, m := range messages {
+		if m.Method != "" {
+			events = append(events, m.Method)
+		}
+	}
+	if want := []string{"scan.started", "scan.result", "scan.error"}; !equalStrings(events, want) {
+		t.Errorf("notifications = %v, want %v", events, want)
+	}
+	var result struct {
+		RunID  string `json:"run_id"`
+		Result result `json:"result"`
+	}
+	for _, m := range messages {
+		if m.Method == "scan.result" {
+			json.Unmarshal(m.Params, &result)
+		}
+	}
+	if result.RunID != summary.RunID || result.Result.Name != "acme" || result.Result.Category != "organization" {
+		t.Errorf("result notification = %+v", result)
+	}
+
+	for id, code := range map[string]int{"2": rpcInvalidParams, "3": rpcInvalidParams, "5": rpcInvalidParams, "4": rpcMethodNotFound, "null": rpcParseError} {
+		if m := findResponse(t, messages, id); m.Error == nil || m.Error.Code != code {
+			t.Errorf("response to %s = %+v, want error %d", id, m, code)
+		}
+	}
+	if m := findResponse(t, messages, `"v"`); !strings.Contains(string(m.Result), version) {
+		t.Errorf("version = %s", m.Result)
+	}
+	if len(messages) != len(events)+7 {
+		t.Errorf("got %d messages, want no response to the notification", len(messages))
+	}
+}
+
+func TestStdioCancel(t *testing.T) {
+	setupRun(t, config{})
+
+	started := make(chan struct{})
+	var out bytes.Buffer
+	s := &stdioServer{cfg: config{orgFlag: true, ghAPIFlag: "rest", maxFlag: 10, concurrencyFlag: 1, orBatchFlag: 1, recurseMinReposFlag: 2, minWordLengthFlag: 2}, out: json.NewEncoder(&out), scan: func(words map[string]struct{}, cfg config) {
+		emitResults("github", "organization", "acme", "GitHub organizations matching 'acme'", "github_organizations.txt", []string{"acme"})
+		close(started)
+		// Like searchPlatforms, stop once the run is cancelled.
+		for deadline := time.Now().Add(5 * time.Second); runPartial() == "" && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+		emitResults("github", "organization", "acme", "GitHub organizations matching 'acme'", "github_organizations.txt", []string{"acme-late"})
+	}}
+
+	r, w := io.Pipe()
+	done := make(chan error)
+	go func() { done <- s.serve(r) }()
+
+	io.WriteString(w, `{"jsonrpc": "2.0", "id": 1, "method": "scan", "params": {"keywords": ["acme"]}}`+"\n")
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scan didn't start")
+	}
+	io.WriteString(w, `{"jsonrpc": "2.0", "id": 2, "method": "scan", "params": {"keywords": ["acme"]}}`+"\n")
+	io.WriteString(w, `{"jsonrpc": "2.0", "id": 3, "method": "cancel", "params": {"run_id": "someone-else"}}`+"\n")
+	io.WriteString(w, `{"jsonrpc": "2.0", "id": 4, "method": "cancel"}`+"\n")
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	messages := readStdioMessages(t, &out)
+
+	var summary scanSummary
+	if err := json.Unmarshal(findResponse(t, messages, "1").Result, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if !summary.Cancelled || summary.ResultCount != 1 || summary.Partial != cancelledReason {
+		t.Errorf("summary = %+v, want a cancelled scan with the result found before", summary)
+	}
+	if m := findResponse(t, messages, "2"); m.Error == nil || m.Error.Code != rpcServerError {
+		t.Errorf("second scan = %+v, want refused while the first runs", m)
+	}
+	if got := string(findResponse(t, messages, "3").Result); got != `{"cancelled":false}` {
+		t.Errorf("cancel of another run = %s", got)
+	}
+	if got := string(findResponse(t, messages, "4").Result); got != `{"cancelled":true}` {
+		t.Errorf("cancel = %s", got)
+	}
+}
+
+func TestScanParamsPlatforms(t *testing.T) {
+	dir := t.TempDir()
+	writePlugin(t, dir, "gitea", "", 0755)
+	setenv(t, "DORKY_PLUGINS", dir)
+
+	base := config{orgFlag: true, ghAPIFlag: "rest", maxFlag: 10, concurrencyFlag: 1, orBatchFlag: 1, recurseMinReposFlag: 2, minWordLengthFlag: 2, bbURLFlag: "https://bitbucket.acme.com", stackFlag: true}
+
+	cfg, err := scanParams{Platforms: []string{"github", "pastes", "gitea"}}.config(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ghOnlyFlag || !cfg.pastesFlag || cfg.stackFlag || cfg.bbURLFlag != "" {
+		t.Errorf("config = %+v, want GitHub, pastes and the plugin alone", cfg)
+	}
+	for platform, want := range map[string]bool{"github": true, "gitlab": false, "gitea": true, "gogs": false} {
+		if got := platformSelected(cfg, platform); got != want {
+			t.Errorf("platformSelected(%s) = %t, want %t", platform, got, want)
+		}
+	}
+
+	if cfg, err := (scanParams{Platforms: []string{"gitlab"}}).config(base); err != nil || !cfg.glOnlyFlag {
+		t.Errorf("config for gitlab = %+v, %v, want -gl", cfg, err)
+	}
+	if cfg, err := (scanParams{}).config(base); err != nil || cfg.platforms != "" || !cfg.stackFlag {
+		t.Errorf("config without platforms = %+v, %v, want the flags dorky was started with", cfg, err)
+	}
+
+	base.bbURLFlag = ""
+	for _, platforms := range [][]string{{"bitbucket"}, {"sourceforge"}, {"github", "all"}} {
+		if _, err := (scanParams{Platforms: platforms}).config(base); err == nil {
+			t.Errorf("config accepted platforms %v", platforms)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+var (
+	// stateMu guards the per-run state that keywords searched in parallel
+	// share: results, output files, errors and lookup caches.
+	stateMu sync.Mutex
+
+	// activeStreams buffers console output while keywords are searched,
+	// guarded by stateMu.
+	activeStreams *keywordStreams
+)
+
+// keywordStreams holds the console output of each keyword until every
+// keyword before it has been searched, so output is grouped and ordered by
+// keyword however many keywords are searched at once. Exports that stream,
+// like -ndjson, are written immediately instead.
+type keywordStreams struct {
+	order   []string
+	buffers map[string]*bytes.Buffer
+	done    map[string]bool
+	next    int
+	out     io.Writer
+}
+
+// startKeywordStreams starts buffering the console output of words, which
+// is printed to out in the order given.
+func startKeywordStreams(words []string, out io.Writer) *keywordStreams {
+	s := &keywordStreams{
+		order:   words,
+		buffers: make(map[string]*bytes.Buffer),
+		done:    make(map[string]bool),
+		out:     out,
+	}
+	for _, word := range words {
+		s.buffers[word] = &bytes.Buffer{}
+	}
+
+	stateMu.Lock()
+	activeStreams = s
+	stateMu.Unlock()
+
+	return s
+}
+
+// finish marks word as searched and prints the output of every keyword
+// that is no longer waiting for an earlier one.
+func (s *keywordStreams) finish(word string) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	s.done[word] = true
+	hooks.progress(scanProgress{Keyword: word, Done: len(s.done), Total: len(s.order)})
+	for s.next < len(s.order) && s.done[s.order[s.next]] {
+		word := s.order[s.next]
+		s.out.Write(s.buffers[word].Bytes())
+		delete(s.buffers, word)
+		s.next++
+	}
+}
+
+// stop prints whatever output is left and stops buffering.
+func (s *keywordStreams) stop() {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	for ; s.next < len(s.order); s.next++ {
+		s.out.Write(s.buffers[s.order[s.next]].Bytes())
+	}
+	s.buffers = nil
+	activeStreams = nil
+}
+
+// consoleFor returns where to print results found for query: its keyword's
+// buffer while keywords are searched, stdout otherwise. stateMu must be
+// held.
+func consoleFor(query string) io.Writer {
+	if activeStreams != nil {
+		if buf, ok := activeStreams.buffers[query]; ok {
+			return buf
+		}
+	}
+	return os.Stdout
+}
+
+func sortedWords(words map[string]struct{}) []string {
+	sorted := make([]string, 0, len(words))
+	for word := range words {
+		sorted = append(sorted, word)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// searchKeywords calls search for every word, up to workers at a time,
+// finishing each word's stream once it has been searched.
+func searchKeywords(words []string, workers int, streams *keywordStreams, search func(word string)) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for word := range jobs {
+				search(word)
+				streams.finish(word)
+			}
+		}()
+	}
+
+	for _, word := range words {
+		jobs <- word
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestKeywordStreamsFlushInOrder(t *testing.T) {
+	setupRun(t, config{simpleFlag: true})
+
+	var out bytes.Buffer
+	streams := startKeywordStreams([]string{"acme", "globex", "initech"}, &out)
+
+	emitResults("github", "user", "initech", "header", "github_users.txt", []string{"initech-bot"})
+	streams.finish("initech")
+	emitResults("github", "user", "globex", "header", "github_users.txt", []string{"globex-ops"})
+	streams.finish("globex")
+	if out.Len() != 0 {
+		t.Fatalf("printed %q before the first keyword finished", out.String())
+	}
+
+	emitResults("github", "user", "acme", "header", "github_users.txt", []string{"acme-dev"})
+	streams.finish("acme")
+	streams.stop()
+
+	if got, want := out.String(), "acme-dev\nglobex-ops\ninitech-bot\n"; got != want {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+	if activeStreams != nil {
+		t.Error("stop left the streams active")
+	}
+}
+
+func TestSearchKeywordsParallel(t *testing.T) {
+	setupRun(t, config{simpleFlag: true})
+
+	words := []string{"a", "b", "c", "d", "e", "f"}
+	var out bytes.Buffer
+	streams := startKeywordStreams(words, &out)
+
+	var mu sync.Mutex
+	searched := make(map[string]int)
+	searchKeywords(words, 3, streams, func(word string) {
+		mu.Lock()
+		searched[word]++
+		mu.Unlock()
+		emitResults("github", "organization", word, "header", "github_organizations.txt", []string{word + "-org"})
+	})
+	streams.stop()
+
+	for _, word := range words {
+		if searched[word] != 1 {
+			t.Errorf("searched %q %d times, want 1", word, searched[word])
+		}
+	}
+	if got, want := out.String(), "a-org\nb-org\nc-org\nd-org\ne-org\nf-org\n"; got != want {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+	if got := resultNames("github", "organization"); len(got) != len(words) {
+		t.Errorf("recorded %v, want %d results", got, len(words))
+	}
+}
+
+func TestNDJSONStreamsResults(t *testing.T) {
+	setupRun(t, config{simpleFlag: true})
+
+	filename := filepath.Join(outputDir, "results.ndjson")
+	if err := openNDJSON(filename); err != nil {
+		t.Fatal(err)
+	}
+	emitResults("github", "organization", "acme", "header", "github_organizations.txt", []string{"acme-corp", "acme-labs"})
+
+	// Results are written as they are recorded, before the stream closes.
+	data, err := ioutil.ReadFile(filename)
+	closeNDJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("streamed %d lines, want 2: %q", len(lines), data)
+	}
+	for i, want := range []string{"acme-corp", "acme-labs"} {
+		var r result
+		if err := json.Unmarshal([]byte(lines[i]), &r); err != nil {
+			t.Fatalf("line %d: %s", i, err)
+		}
+		if r.Name != want || r.Query != "acme" || r.RunID != runID {
+			t.Errorf("line %d = %+v, want %s found by acme", i, r, want)
+		}
+	}
+}
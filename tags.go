@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// keywordFile is the format accepted by -keywords: per-tag behavior, and
+// optionally the keywords themselves, e.g.
+//
+//	tags:
+//	  brand: {exact: true, search: [org, user]}
+//	  product: {search: [repo, wiki], max: 50}
+//	keywords:
+//	  - acme#brand
+//	  - payments#product
+type keywordFile struct {
+	Tags     map[string]tagBehavior `yaml:"tags"`
+	Keywords []string               `yaml:"keywords"`
+}
+
+// tagBehavior adjusts how the keywords carrying a tag are searched.
+type tagBehavior struct {
+	// Exact only reports names equal to the keyword, ignoring the fuzzy
+	// matches the platforms' search returns.
+	Exact bool `yaml:"exact"`
+
+	// Search replaces -o, -r, -u, -d and -w for the tagged keywords.
+	Search []string `yaml:"search"`
+
+	// Max replaces -max for the tagged keywords.
+	Max int `yaml:"max"`
+}
+
+// tagSearches maps the names usable in a tag's search list to the flag they
+// stand for.
+var tagSearches = map[string]func(*config){
+	"org":         func(cfg *config) { cfg.orgFlag = true },
+	"repo":        func(cfg *config) { cfg.repoFlag = true },
+	"user":        func(cfg *config) { cfg.userFlag = true },
+	"discussions": func(cfg *config) { cfg.discussionsFlag = true },
+	"wiki":        func(cfg *config) { cfg.wikiFlag = true },
+}
+
+var (
+	tagRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+	// tagBehaviors holds the behaviors loaded from -keywords.
+	tagBehaviors = make(map[string]tagBehavior)
+
+	// keywordTags maps every search word to the tags of the keyword it
+	// was derived from.
+	keywordTags = make(map[string][]string)
+)
+
+// resetKeywordTags forgets the tags of the words built so far, before
+// building the keywords of another scan.
+func resetKeywordTags() {
+	keywordTags = make(map[string][]string)
+}
+
+func loadKeywordFile(filename string) (*keywordFile, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var kf keywordFile
+	if err := yaml.Unmarshal(data, &kf); err != nil {
+		return nil, err
+	}
+
+	for tag, behavior := range kf.Tags {
+		if !tagRegexp.MatchString(tag) {
+			return nil, fmt.Errorf("invalid tag name %q", tag)
+		}
+		for _, search := range behavior.Search {
+			if _, ok := tagSearches[search]; !ok {
+				return nil, fmt.Errorf("tag '%s': unknown search %q (supported: org, repo, user, discussions, wiki)", tag, search)
+			}
+		}
+		if behavior.Max < 0 || behavior.Max > githubSearchLimit {
+			return nil, fmt.Errorf("tag '%s': max must be between 0 and %d", tag, githubSearchLimit)
+		}
+	}
+
+	return &kf, nil
+}
+
+// applyKeywordFile makes the tag behaviors of kf effective.
+func applyKeywordFile(kf *keywordFile) {
+	tagBehaviors = make(map[string]tagBehavior)
+	for tag, behavior := range kf.Tags {
+		tagBehaviors[strings.ToLower(tag)] = behavior
+	}
+}
+
+// tagsDefineSearches reports whether any tag selects searches of its own,
+// which makes -o, -r and -u optional.
+func tagsDefineSearches() bool {
+	for _, behavior := range tagBehaviors {
+		if len(behavior.Search) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTags separates trailing #tag suffixes from a keyword, so
+// "acme#brand" is the keyword acme tagged brand. Only suffixes that are
+// valid tag names count, leaving keywords like "c#" alone.
+func splitTags(keyword string) (string, []string) {
+	parts := strings.Split(keyword, "#")
+
+	var tags []string
+	for len(parts) > 1 && tagRegexp.MatchString(parts[len(parts)-1]) {
+		tags = append([]string{strings.ToLower(parts[len(parts)-1])}, tags...)
+		parts = parts[:len(parts)-1]
+	}
+
+	return strings.Join(parts, "#"), tags
+}
+
+func tagWord(word string, tags []string) {
+	for _, tag := range tags {
+		if !containsString(keywordTags[word], tag) {
+			keywordTags[word] = append(keywordTags[word], tag)
+		}
+	}
+	sort.Strings(keywordTags[word])
+}
+
+// wordBehavior merges the behaviors of a word's tags: exact if any tag is,
+// the union of their searches, and the largest max.
+func wordBehavior(word string) (tagBehavior, bool) {
+	var merged tagBehavior
+	found := false
+
+	for _, tag := range keywordTags[word] {
+		behavior, ok := tagBehaviors[tag]
+		if !ok {
+			continue
+		}
+		found = true
+
+		merged.Exact = merged.Exact || behavior.Exact
+		for _, search := range behavior.Search {
+			if !containsString(merged.Search, search) {
+				merged.Search = append(merged.Search, search)
+			}
+		}
+		if behavior.Max > merged.Max {
+			merged.Max = behavior.Max
+		}
+	}
+
+	return merged, found
+}
+
+// configForWord returns cfg adjusted by the behaviors of word's tags.
+func configForWord(cfg config, word string) config {
+	behavior, ok := wordBehavior(word)
+	if !ok {
+		return cfg
+	}
+
+	if len(behavior.Search) > 0 {
+		cfg.orgFlag, cfg.repoFlag, cfg.userFlag, cfg.discussionsFlag, cfg.wikiFlag = false, false, false, false, false
+		for _, search := range behavior.Search {
+			tagSearches[search](&cfg)
+		}
+	}
+	if behavior.Max > 0 {
+		cfg.maxFlag = behavior.Max
+	}
+
+	return cfg
+}
+
+// exactNameCategories are the categories whose results are account or
+// repository names that an exact tag can compare with the keyword.
+var exactNameCategories = map[string]bool{
+	"organization": true, "user": true, "group": true,
+	"repository": true, "project": true, "projects": true, "discussion": true,
+}
+
+// applyExactMatch drops names that don't equal query, whatever their case
+// and diacritics, when query carries an exact tag. Repositories and nested
+// groups match by their full path or their last path segment, so both
+// acme/acme and, for the keyword acme/platform, the group acme/platform
+// match.
+func applyExactMatch(category, query string, names []string) []string {
+	behavior, ok := wordBehavior(query)
+	if !ok || !behavior.Exact || !exactNameCategories[category] {
+		return names
+	}
+
+	var kept []string
+	for _, name := range names {
+		last := name[strings.LastIndex(name, "/")+1:]
+		if foldName(name) == foldName(query) || foldName(last) == foldName(query) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
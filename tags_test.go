@@ -0,0 +1,141 @@
+package main
+
+import "testing"
+
+func TestSplitTags(t *testing.T) {
+	tests := []struct {
+		in       string
+		word     string
+		wantTags []string
+	}{
+		{"acme", "acme", nil},
+		{"acme#brand", "acme", []string{"brand"}},
+		{"acme corp#Brand#core", "acme corp", []string{"brand", "core"}},
+		{"c#", "c#", nil},
+		{"acme.com#brand", "acme.com", []string{"brand"}},
+	}
+
+	for _, tt := range tests {
+		word, tags := splitTags(tt.in)
+		if word != tt.word || !equalStrings(tags, tt.wantTags) {
+			t.Errorf("splitTags(%q) = %q, %v, want %q, %v", tt.in, word, tags, tt.word, tt.wantTags)
+		}
+	}
+}
+
+func TestProcessWordTagsVariants(t *testing.T) {
+	setupRun(t, config{})
+
+	words := make(map[string]struct{})
+	processWord("acme corp#brand", words, flags)
+
+	for _, word := range []string{"acme corp", "acmecorp", "acme-corp"} {
+		if got := keywordTags[word]; !equalStrings(got, []string{"brand"}) {
+			t.Errorf("tags of %q = %v, want [brand]", word, got)
+		}
+	}
+	if _, ok := words["acme corp#brand"]; ok {
+		t.Error("the tag suffix was searched as part of the keyword")
+	}
+}
+
+func TestConfigForWord(t *testing.T) {
+	setupRun(t, config{})
+	tagBehaviors = map[string]tagBehavior{
+		"brand":   {Exact: true, Search: []string{"org", "user"}},
+		"product": {Search: []string{"repo", "wiki"}, Max: 50},
+	}
+	tagWord("acme", []string{"brand"})
+	tagWord("payments", []string{"product"})
+	tagWord("both", []string{"brand", "product"})
+
+	base := config{orgFlag: true, repoFlag: true, maxFlag: 10}
+
+	if cfg := configForWord(base, "acme"); !cfg.orgFlag || cfg.repoFlag || !cfg.userFlag || cfg.maxFlag != 10 {
+		t.Errorf("brand config = %+v", cfg)
+	}
+	if cfg := configForWord(base, "payments"); cfg.orgFlag || !cfg.repoFlag || !cfg.wikiFlag || cfg.maxFlag != 50 {
+		t.Errorf("product config = %+v", cfg)
+	}
+	if cfg := configForWord(base, "both"); !cfg.orgFlag || !cfg.repoFlag || !cfg.userFlag || !cfg.wikiFlag || cfg.maxFlag != 50 {
+		t.Errorf("merged config = %+v", cfg)
+	}
+	if cfg := configForWord(base, "untagged"); cfg != base {
+		t.Errorf("untagged config = %+v, want %+v", cfg, base)
+	}
+}
+
+func TestExactTagFiltersResults(t *testing.T) {
+	setupRun(t, config{orgFlag: true, repoFlag: true})
+	tagBehaviors = map[string]tagBehavior{"brand": {Exact: true}}
+	tagWord("acme", []string{"brand"})
+
+	searchGitHubOrganizations(&fakeGitHubSearch{users: map[string][]string{
+		"type:org acme": {"acme-fans", "ACME", "acmecorp"},
+	}}, "acme", 10)
+	searchGitHubRepositories(&fakeGitHubSearch{repos: map[string][]string{
+		"acme": {"acme/acme", "acme/api", "someone/Acme"},
+	}}, "acme", 10)
+
+	if got, want := resultNames("github", "organization"), []string{"ACME"}; !equalStrings(got, want) {
+		t.Errorf("organizations = %v, want %v", got, want)
+	}
+	if got, want := resultNames("github", "repository"), []string{"acme/acme", "someone/Acme"}; !equalStrings(got, want) {
+		t.Errorf("repositories = %v, want %v", got, want)
+	}
+	for _, r := range collectedResults {
+		if !equalStrings(r.Tags, []string{"brand"}) {
+			t.Errorf("result %s has tags %v, want [brand]", r.Name, r.Tags)
+		}
+	}
+}
+
+func TestLoadKeywordFile(t *testing.T) {
+	filename := writeTempFile(t, `
+tags:
+  brand: {exact: true, search: [org, user]}
+  product:
+    search: [repo]
+    max: 50
+keywords:
+  - acme#brand
+  - payments#product
+`)
+
+	kf, err := loadKeywordFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !kf.Tags["brand"].Exact || kf.Tags["product"].Max != 50 {
+		t.Errorf("tags = %+v", kf.Tags)
+	}
+	if !equalStrings(kf.Keywords, []string{"acme#brand", "payments#product"}) {
+		t.Errorf("keywords = %v", kf.Keywords)
+	}
+
+	for _, content := range []string{
+		"tags: {brand: {search: [code]}}",
+		"tags: {brand: {max: -1}}",
+		"tags: {'bad tag': {exact: true}}",
+		"tags: [",
+	} {
+		if _, err := loadKeywordFile(writeTempFile(t, content)); err == nil {
+			t.Errorf("loadKeywordFile(%q) succeeded, want error", content)
+		}
+	}
+}
+
+func TestExactTagMatchesNestedGroupPaths(t *testing.T) {
+	setupRun(t, config{})
+	tagBehaviors = map[string]tagBehavior{"brand": {Exact: true}}
+	tagWord("platform", []string{"brand"})
+	tagWord("acme/platform", []string{"brand"})
+
+	groups := []string{"acme", "acme/platform", "acme/platform/team", "globex/Platform", "platform"}
+	if got, want := applyExactMatch("group", "platform", groups), []string{"acme/platform", "globex/Platform", "platform"}; !equalStrings(got, want) {
+		t.Errorf("platform matched %v, want %v", got, want)
+	}
+	if got, want := applyExactMatch("group", "acme/platform", groups), []string{"acme/platform"}; !equalStrings(got, want) {
+		t.Errorf("acme/platform matched %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations maps the letters that don't decompose into an ASCII
+// letter and diacritics: Latin ligatures and special letters, Cyrillic
+// (Russian and Ukrainian, after BGN/PCGN) and Greek.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th", 'ı': "i",
+
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ж': "zh", 'з': "z",
+	'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p",
+	'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch",
+	'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// germanUmlauts are spelled out as e.g. "ue" in German names, next to the
+// plain "u" the diacritic stripping gives.
+var germanUmlauts = strings.NewReplacer("ä", "ae", "ö", "oe", "ü", "ue")
+
+// asciiCandidates returns the ASCII spellings of a non-ASCII keyword:
+// Müller gives muller and mueller, Яндекс gives yandeks. Scripts without a
+// letter-by-letter transliteration, such as CJK, give none.
+func asciiCandidates(word string) []string {
+	if isASCII(word) {
+		return nil
+	}
+
+	lower := strings.ToLower(word)
+	var candidates []string
+	for _, w := range []string{lower, germanUmlauts.Replace(lower)} {
+		if c, ok := transliterate(w); ok && c != "" && !containsString(candidates, c) {
+			candidates = append(candidates, c)
+		}
+	}
+	return candidates
+}
+
+// transliterate strips diacritics from word and maps the letters of
+// transliterations, reporting whether the result is all ASCII.
+func transliterate(word string) (string, bool) {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(word) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if t, ok := transliterations[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+		if r > unicode.MaxASCII {
+			return "", false
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), true
+}
+
+// foldName folds a name for matching keywords against names and
+// descriptions written in other spellings: normalizeName's case folding,
+// then diacritic stripping and the Latin letters that don't decompose, so
+// Müller, MÜLLER and muller, or Ørsted and orsted, compare equal. Other
+// scripts are kept as they are.
+func foldName(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(normalizeName(name)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if t, ok := transliterations[r]; ok && unicode.Is(unicode.Latin, r) {
+			b.WriteString(t)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+func isASCII(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
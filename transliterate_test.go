@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestASCIICandidates(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"acme", nil},
+		{"Müller", []string{"muller", "mueller"}},
+		{"Škoda Auto", []string{"skoda auto"}},
+		{"Ørsted", []string{"orsted"}},
+		{"Яндекс", []string{"yandeks"}},
+		{"Щука", []string{"shchuka"}},
+		{"Αθηνά", []string{"athina"}},
+		{"東芝", nil},
+	}
+
+	for _, tt := range tests {
+		if got := asciiCandidates(tt.in); !equalStrings(got, tt.want) {
+			t.Errorf("asciiCandidates(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFoldName(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Müller", "muller", true},
+		{"MÜLLER", "müller", true},
+		{"Müller", "Müller", true},
+		{"Straße", "STRASSE", true},
+		{"Ørsted", "orsted", true},
+		{"Škoda-Auto", "skoda-auto", true},
+		{"Яндекс", "ЯНДЕКС", true},
+		{"Яндекс", "yandeks", false},
+		{"muller", "mueller", false},
+	}
+
+	for _, tt := range tests {
+		if got := foldName(tt.a) == foldName(tt.b); got != tt.want {
+			t.Errorf("foldName(%q) == foldName(%q) is %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	if normalizeName("Straße") != normalizeName("STRASSE") || normalizeName("Müller") == normalizeName("muller") {
+		t.Errorf("normalizeName must fold case but keep diacritics")
+	}
+}
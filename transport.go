@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// middleware wraps a transport in one of the protections every provider's
+// HTTP client gets.
+type middleware func(next http.RoundTripper) http.RoundTripper
+
+// newProviderTransport returns the transport of a provider's HTTP client:
+// base (http.DefaultTransport if nil) wrapped in the shared middleware
+// stack, from the outside in:
+//
+//	cache       answers a GET request the run already made
+//	identify    sets the User-Agent and the -request-tag header
+//	retry       sends a request the API throttled once more, after the pause
+//	rate limit  paces requests through limiter and adapts it to the responses
+//	debug       dumps failed exchanges with -debug-http
+//	metrics     records the quota reported by each response
+//
+// Without a limiter, requests are neither paced nor retried.
+func newProviderTransport(platform string, base http.RoundTripper, limiter *adaptiveLimiter) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	stack := []middleware{
+		func(next http.RoundTripper) http.RoundTripper { return &cacheTransport{platform: platform, next: next} },
+		func(next http.RoundTripper) http.RoundTripper {
+			return &identifyTransport{platform: platform, next: next}
+		},
+	}
+	if limiter != nil {
+		stack = append(stack,
+			func(next http.RoundTripper) http.RoundTripper { return &retryTransport{platform: platform, next: next} },
+			func(next http.RoundTripper) http.RoundTripper {
+				return &rateLimitTransport{platform: platform, limiter: limiter, next: next}
+			},
+		)
+	}
+	stack = append(stack,
+		func(next http.RoundTripper) http.RoundTripper { return &debugTransport{platform: platform, next: next} },
+		func(next http.RoundTripper) http.RoundTripper { return &metricsTransport{platform: platform, next: next} },
+	)
+
+	transport := base
+	for i := len(stack) - 1; i >= 0; i-- {
+		transport = stack[i](transport)
+	}
+	return transport
+}
+
+// defaultTagHeader carries a -request-tag given without a header name.
+const defaultTagHeader = "X-Request-Tag"
+
+// headerNameRegexp matches the header names -request-tag accepts.
+var headerNameRegexp = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// parseRequestTag splits a -request-tag into the header it is sent in and
+// its value: "X-Bug-Bounty: alice" names the header, a plain "alice" goes
+// in X-Request-Tag.
+func parseRequestTag(tag string) (name, value string, err error) {
+	name, value = defaultTagHeader, strings.TrimSpace(tag)
+	if i := strings.Index(tag, ":"); i > 0 && headerNameRegexp.MatchString(strings.TrimSpace(tag[:i])) {
+		name, value = strings.TrimSpace(tag[:i]), strings.TrimSpace(tag[i+1:])
+	}
+	if tag != "" && value == "" {
+		return "", "", fmt.Errorf("-request-tag %q has no value", tag)
+	}
+	if strings.IndexFunc(value, func(r rune) bool { return r < ' ' || r == 0x7f }) >= 0 {
+		return "", "", errors.New("-request-tag must not contain control characters")
+	}
+	return name, value, nil
+}
+
+// userAgent identifies dorky's requests to platform, so abuse teams and
+// proxies can attribute the traffic. A plain -request-tag is appended, for
+// programs that look for it in the User-Agent.
+func userAgent(platform string) string {
+	ua := fmt.Sprintf("dorky/%s (%s; +https://github.com/codingo/dorky)", version, platform)
+	if name, value, err := parseRequestTag(flags.requestTagFlag); err == nil && value != "" && name == defaultTagHeader {
+		ua += " " + value
+	}
+	return ua
+}
+
+// identifyTransport sets the User-Agent of every request, replacing the
+// SDKs' own, and the -request-tag header.
+type identifyTransport struct {
+	platform string
+	next     http.RoundTripper
+}
+
+func (t *identifyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", userAgent(t.platform))
+	if name, value, err := parseRequestTag(flags.requestTagFlag); err == nil && value != "" {
+		req.Header.Set(name, value)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// retryTransport sends a request the API throttled once more, provided its
+// body can be replayed. The rate limit layer below holds the retry back
+// until the API's pause is over.
+type retryTransport struct {
+	platform string
+	next     http.RoundTripper
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || !isThrottled(resp) {
+		return resp, err
+	}
+
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	resp.Body.Close()
+	hooks.rateLimit(rateLimitEvent{Platform: t.platform, Throttled: true})
+
+	return t.next.RoundTrip(retry)
+}
+
+// rateLimitTransport paces requests through limiter, holding one of its
+// slots while the request is sent and recording the time spent waiting,
+// and adapts the limiter to every response.
+type rateLimitTransport struct {
+	platform string
+	limiter  *adaptiveLimiter
+	next     http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	if err := t.limiter.Wait(req); err != nil {
+		return nil, err
+	}
+	defer t.limiter.Done(req)
+	recordLimiterWait(t.platform, time.Since(start))
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	t.limiter.observe(req, resp)
+	return resp, nil
+}
+
+// debugTransport dumps failed requests along with their responses to the
+// -debug-http directory.
+type debugTransport struct {
+	platform string
+	next     http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if flags.debugHTTPFlag != "" && failedExchange(resp, err) {
+		dumpHTTPExchange(flags.debugHTTPFlag, t.platform, req, resp, err)
+	}
+	return resp, err
+}
+
+// metricsTransport records the quota reported by each response for the
+// rate limit report.
+type metricsTransport struct {
+	platform string
+	next     http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	recordRateLimitHeaders(t.platform, resp.Header)
+	return resp, nil
+}
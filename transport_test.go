@@ -0,0 +1,130 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProviderTransportWithoutLimiter(t *testing.T) {
+	setupRun(t, config{})
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("RateLimit-Limit", "60")
+		w.Header().Set("RateLimit-Remaining", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newProviderTransport("ct", nil, nil)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// Unpaced requests aren't retried, but still report their quota.
+	if requests != 1 {
+		t.Errorf("sent %d requests, want 1", requests)
+	}
+	summary := rateLimitSummary()
+	if len(summary) != 1 || summary[0].Platform != "ct" || summary[0].Limit != 60 || *summary[0].Remaining != 0 {
+		t.Errorf("rate limits = %+v, want the ct quota", summary)
+	}
+}
+
+func TestIsThrottled(t *testing.T) {
+	tests := []struct {
+		status  int
+		headers map[string]string
+		want    bool
+	}{
+		{http.StatusTooManyRequests, nil, true},
+		{http.StatusForbidden, map[string]string{"Retry-After": "30"}, true},
+		{http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "0"}, true},
+		{http.StatusForbidden, map[string]string{"RateLimit-Remaining": "0"}, true},
+		{http.StatusForbidden, map[string]string{"X-RateLimit-Remaining": "12"}, false},
+		{http.StatusOK, map[string]string{"X-RateLimit-Remaining": "0"}, false},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+		for k, v := range tt.headers {
+			resp.Header.Set(k, v)
+		}
+		if got := isThrottled(resp); got != tt.want {
+			t.Errorf("isThrottled(%d, %v) = %v, want %v", tt.status, tt.headers, got, tt.want)
+		}
+	}
+}
+
+func TestParseRequestTag(t *testing.T) {
+	tests := []struct {
+		tag, name, value string
+		wantErr          bool
+	}{
+		{"", "X-Request-Tag", "", false},
+		{"alice", "X-Request-Tag", "alice", false},
+		{"X-Bug-Bounty: alice", "X-Bug-Bounty", "alice", false},
+		{"hackerone alice: recon", "X-Request-Tag", "hackerone alice: recon", false},
+		{"X-Bug-Bounty:", "", "", true},
+		{"alice\r\nX-Evil: 1", "", "", true},
+	}
+
+	for _, tt := range tests {
+		name, value, err := parseRequestTag(tt.tag)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseRequestTag(%q) error = %v, want error %v", tt.tag, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (name != tt.name || value != tt.value) {
+			t.Errorf("parseRequestTag(%q) = %q, %q, want %q, %q", tt.tag, name, value, tt.name, tt.value)
+		}
+	}
+}
+
+func TestProviderTransportIdentifiesRequests(t *testing.T) {
+	tests := []struct {
+		tag                 string
+		userAgent, tagged   string
+		header, headerValue string
+	}{
+		{"", "dorky/dev (gitlab; +https://github.com/codingo/dorky)", "", "", ""},
+		{"alice", "dorky/dev (gitlab; +https://github.com/codingo/dorky) alice", "alice", "", ""},
+		{"X-Bug-Bounty: alice", "dorky/dev (gitlab; +https://github.com/codingo/dorky)", "", "X-Bug-Bounty", "alice"},
+	}
+
+	for _, tt := range tests {
+		setupRun(t, config{requestTagFlag: tt.tag})
+
+		var got http.Header
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header
+		}))
+
+		client := &http.Client{Transport: newProviderTransport("gitlab", nil, nil)}
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		req.Header.Set("User-Agent", "go-gitlab")
+		resp, err := client.Do(req)
+		srv.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if ua := got.Get("User-Agent"); ua != tt.userAgent {
+			t.Errorf("tag %q: User-Agent = %q, want %q", tt.tag, ua, tt.userAgent)
+		}
+		if tagged := got.Get("X-Request-Tag"); tagged != tt.tagged {
+			t.Errorf("tag %q: X-Request-Tag = %q, want %q", tt.tag, tagged, tt.tagged)
+		}
+		if tt.header != "" && got.Get(tt.header) != tt.headerValue {
+			t.Errorf("tag %q: %s = %q, want %q", tt.tag, tt.header, got.Get(tt.header), tt.headerValue)
+		}
+		if req.Header.Get("User-Agent") != "go-gitlab" {
+			t.Errorf("tag %q: the caller's request was modified", tt.tag)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Triage states analysts mark results with in the -state file.
+const (
+	triageInteresting   = "interesting"
+	triageFalsePositive = "false-positive"
+	triageDone          = "done"
+)
+
+var triageStates = []string{triageInteresting, triageFalsePositive, triageDone}
+
+var (
+	// triageMarks holds the triage state of the results in the -state
+	// file, keyed by platform, category and normalized name. Guarded by
+	// stateMu.
+	triageMarks = make(map[string]string)
+
+	// hiddenResults holds the results of the run hidden as known false
+	// positives, which the -state file still records as seen. Guarded by
+	// stateMu.
+	hiddenResults []result
+)
+
+func triageKey(platform, category, name string) string {
+	return platform + "\x00" + category + "\x00" + normalizeName(name)
+}
+
+// loadTriageMarks reads the triage state of the results in the -state
+// file, for the run to hide known false positives and label the rest.
+func loadTriageMarks(cfg config) error {
+	if cfg.stateFlag == "" {
+		return nil
+	}
+
+	state, err := readStateFile(outputPath(cfg.stateFlag))
+	if err != nil {
+		return err
+	}
+
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	for _, s := range state.Results {
+		if s.Triage != "" {
+			triageMarks[triageKey(s.Platform, s.Category, s.Name)] = s.Triage
+		}
+	}
+	return nil
+}
+
+// hideFalsePositives drops the names marked as false positives, unless
+// -show-false-positives is set, and keeps them for the -state file.
+// stateMu must be held.
+func hideFalsePositives(platform, category, query string, names []string) []string {
+	if flags.showFalsePositivesFlag || len(triageMarks) == 0 {
+		return names
+	}
+
+	var shown []string
+	for _, name := range names {
+		if triageMarks[triageKey(platform, category, name)] == triageFalsePositive {
+			verbosePrint("Hiding %s %s %s, marked as a false positive\n", platform, category, name)
+			hiddenResults = append(hiddenResults, result{Platform: platform, Category: category, Query: query, Name: name, ID: canonicalID(platform, category, query, name)})
+			continue
+		}
+		shown = append(shown, name)
+	}
+	return shown
+}
+
+// triageMark returns the triage state of a result, if it has one.
+func triageMark(platform, category, name string) string {
+	return triageMarks[triageKey(platform, category, name)]
+}
+
+// runTriageCommand implements `dorky triage`: listing the results of a
+// -state file with their triage state, or marking those about the given
+// accounts and repositories.
+func runTriageCommand(args []string) {
+	fs := flag.NewFlagSet("triage", flag.ExitOnError)
+	stateFlag := fs.String("state", "", "state file holding the results to triage")
+	workspace := fs.String("workspace", "", "triage the results of this workspace's state file")
+	mark := fs.String("mark", "", "mark the given results as "+strings.Join(triageStates, ", ")+", or none to clear their mark")
+	note := fs.String("note", "", "note recorded with -mark")
+	category := fs.String("category", "", "only mark results of this category")
+	untriaged := fs.Bool("untriaged", false, "only list results without a mark")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dorky triage -state results.json [-untriaged]")
+		fmt.Fprintln(fs.Output(), "       dorky triage -state results.json -mark false-positive [-note text] [-category repository] github:acme/fork ...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	filename := *stateFlag
+	if filename == "" && *workspace != "" {
+		dir, err := workspacePath(*workspace)
+		if err != nil {
+			fmt.Printf("Error %s\n", err)
+			os.Exit(1)
+		}
+		filename = filepath.Join(dir, "state", "results.json")
+	}
+	if filename == "" || (*mark == "") != (fs.NArg() == 0) {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if *mark == "" {
+		state, err := readStateFile(filename)
+		if err != nil {
+			fmt.Printf("Error reading state file: %s\n", err)
+			os.Exit(1)
+		}
+		printTriage(os.Stdout, state.Results, *untriaged)
+		return
+	}
+
+	marked, err := markResults(filename, fs.Args(), *category, *mark, *note, time.Now().UTC())
+	if err != nil {
+		fmt.Printf("Error %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Marked %d results as %s\n", marked, *mark)
+}
+
+// markResults sets the triage state of the results of the state file about
+// the given accounts and repositories, matched by ID (github:acme/api) or
+// by platform and name, optionally of a single category. "none" clears
+// the mark.
+func markResults(filename string, targets []string, category, mark, note string, now time.Time) (int, error) {
+	if mark == "none" {
+		mark = ""
+	} else if !containsString(triageStates, mark) {
+		return 0, fmt.Errorf("-mark must be one of %s or none", strings.Join(triageStates, ", "))
+	}
+
+	state, err := readStateFile(filename)
+	if err != nil {
+		return 0, err
+	}
+
+	marked := 0
+	for _, target := range targets {
+		found := false
+		for i := range state.Results {
+			s := &state.Results[i]
+			if category != "" && s.Category != category {
+				continue
+			}
+			if !strings.EqualFold(target, s.ID) && normalizeName(target) != normalizeName(s.Platform+":"+s.Name) {
+				continue
+			}
+			found = true
+			marked++
+			s.Triage, s.Note, s.TriagedAt = mark, note, &now
+			if mark == "" {
+				s.Note, s.TriagedAt = "", nil
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("no result in %s matches %q", filename, target)
+		}
+	}
+
+	if err := writeStateFile(filename, state); err != nil {
+		return 0, err
+	}
+	return marked, nil
+}
+
+// printTriage lists results with their triage state, or only the results
+// without one.
+func printTriage(w io.Writer, results []resultState, untriaged bool) {
+	for _, s := range results {
+		if untriaged && s.Triage != "" {
+			continue
+		}
+		line := fmt.Sprintf("%s %s %s", s.Platform, s.Category, s.Name)
+		if s.Triage != "" {
+			line += " [" + s.Triage + "]"
+		}
+		if s.Note != "" {
+			line += " " + s.Note
+		}
+		fmt.Fprintln(w, line)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func writeTriageState(t *testing.T, filename string) {
+	t.Helper()
+
+	seen := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := &stateFile{Results: []resultState{
+		{Platform: "github", Category: "repository", Name: "acme/fork", ID: "github:acme/fork", FirstSeen: seen, LastSeen: seen},
+		{Platform: "github", Category: "wiki", Name: "acme/fork:Home", ID: "github:acme/fork", FirstSeen: seen, LastSeen: seen},
+		{Platform: "github", Category: "repository", Name: "acme/api", ID: "github:acme/api", FirstSeen: seen, LastSeen: seen},
+		{Platform: "gitlab", Category: "project", Name: "acme/db-dump", ID: "gitlab:acme/db-dump", FirstSeen: seen, LastSeen: seen},
+	}}
+	if err := writeStateFile(filename, state); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMarkResults(t *testing.T) {
+	setupRun(t, config{})
+	filename := outputPath("results.json")
+	writeTriageState(t, filename)
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	if marked, err := markResults(filename, []string{"GitHub:acme/fork"}, "", triageFalsePositive, "vendored copy", now); err != nil || marked != 2 {
+		t.Fatalf("markResults = %d, %v, want 2 results marked", marked, err)
+	}
+	if marked, err := markResults(filename, []string{"gitlab:acme/db-dump"}, "project", triageInteresting, "", now); err != nil || marked != 1 {
+		t.Fatalf("markResults = %d, %v, want 1 result marked", marked, err)
+	}
+	if _, err := markResults(filename, []string{"github:acme/unknown"}, "", triageDone, "", now); err == nil {
+		t.Error("marking an unknown result succeeded")
+	}
+	if _, err := markResults(filename, []string{"github:acme/api"}, "", "meh", "", now); err == nil {
+		t.Error("an unknown mark was accepted")
+	}
+	if marked, err := markResults(filename, []string{"github:acme/fork"}, "wiki", "none", "", now); err != nil || marked != 1 {
+		t.Fatalf("clearing = %d, %v, want 1 result cleared", marked, err)
+	}
+
+	state, err := readStateFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	printTriage(&out, state.Results, false)
+	want := "github repository acme/api\n" +
+		"github repository acme/fork [false-positive] vendored copy\n" +
+		"github wiki acme/fork:Home\n" +
+		"gitlab project acme/db-dump [interesting]\n"
+	if out.String() != want {
+		t.Errorf("triage list = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	printTriage(&out, state.Results, true)
+	if want := "github repository acme/api\ngithub wiki acme/fork:Home\n"; out.String() != want {
+		t.Errorf("untriaged list = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRunsRespectTriageMarks(t *testing.T) {
+	for _, show := range []bool{false, true} {
+		setupRun(t, config{stateFlag: "results.json", pruneAfterFlag: "30d", showFalsePositivesFlag: show})
+		writeTriageState(t, outputPath("results.json"))
+		now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+		if _, err := markResults(outputPath("results.json"), []string{"github:acme/fork"}, "repository", triageFalsePositive, "", now); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := markResults(outputPath("results.json"), []string{"github:acme/api"}, "", triageInteresting, "", now); err != nil {
+			t.Fatal(err)
+		}
+		if err := loadTriageMarks(flags); err != nil {
+			t.Fatal(err)
+		}
+
+		emitResults("github", "repository", "acme", "Repositories", "github_repositories.txt", []string{"acme/fork", "acme/api"})
+
+		want := []string{"acme/api"}
+		if show {
+			want = []string{"acme/fork", "acme/api"}
+		}
+		if got := resultNames("github", "repository"); !equalStrings(got, want) {
+			t.Errorf("show=%v: results = %v, want %v", show, got, want)
+		}
+		for _, r := range collectedResults {
+			if r.Name == "acme/api" && r.Triage != triageInteresting {
+				t.Errorf("show=%v: acme/api triage = %q, want interesting", show, r.Triage)
+			}
+		}
+
+		// The hidden false positive is still seen, and triaged results
+		// aren't pruned: only the unmarked, unseen results are.
+		if err := recordState(flags, now); err != nil {
+			t.Fatal(err)
+		}
+		state, err := readStateFile(outputPath("results.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var kept []string
+		for _, s := range state.Results {
+			kept = append(kept, s.Category+" "+s.Name)
+			if s.Name == "acme/fork" && !s.LastSeen.Equal(now) {
+				t.Errorf("show=%v: acme/fork last seen %s, want %s", show, s.LastSeen, now)
+			}
+		}
+		if want := []string{"repository acme/api", "repository acme/fork"}; !equalStrings(kept, want) {
+			t.Errorf("show=%v: state = %v, want %v", show, kept, want)
+		}
+	}
+}
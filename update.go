@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+	"golang.org/x/crypto/blake2b"
+)
+
+// updatePublicKey is the minisign public key release binaries are signed
+// with, set at build time with -ldflags "-X main.updatePublicKey=RW...".
+// Builds without one can't verify, and so refuse, updates.
+var updatePublicKey = ""
+
+// updateReleaseURL is the GitHub API endpoint of the latest dorky release.
+var updateReleaseURL = "https://api.github.com/repos/codingo/dorky/releases/latest"
+
+// maxUpdateSize caps how much of a release asset is downloaded.
+const maxUpdateSize = 200 << 20
+
+// runUpdateCommand implements `dorky update`, replacing the running binary
+// with the latest release once its signature checks out.
+func runUpdateCommand(args []string) {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	check := fs.Bool("check", false, "only report whether a newer release is available")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: dorky update [-check]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	release, err := latestRelease(http.DefaultClient)
+	if err != nil {
+		fmt.Printf("Error checking for updates: %s\n", err)
+		os.Exit(1)
+	}
+	// Development builds have no version to compare, and update to any
+	// release.
+	if cmp, ok := compareVersions(version, release.GetTagName()); ok && cmp >= 0 {
+		if cmp == 0 {
+			fmt.Printf("dorky %s is the latest release\n", version)
+		} else {
+			fmt.Printf("dorky %s is newer than the latest release, %s, not downgrading\n", version, release.GetTagName())
+		}
+		return
+	}
+	if *check {
+		fmt.Printf("dorky %s is available (running %s)\n", release.GetTagName(), version)
+		return
+	}
+
+	binary, err := downloadVerifiedUpdate(http.DefaultClient, release, updatePublicKey)
+	if err != nil {
+		fmt.Printf("Error updating: %s\n", err)
+		os.Exit(1)
+	}
+
+	executable, err := os.Executable()
+	if err == nil {
+		err = replaceExecutable(executable, binary)
+	}
+	if err != nil {
+		fmt.Printf("Error replacing %s: %s\n", executable, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Updated dorky %s to %s\n", version, release.GetTagName())
+}
+
+func latestRelease(client *http.Client) (*github.RepositoryRelease, error) {
+	data, err := download(client, updateReleaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var release github.RepositoryRelease
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+// compareVersions compares two release versions by semantic versioning,
+// such as v1.4.0 and v1.5.0-rc.1, returning -1, 0 or 1 as a is older than,
+// the same as or newer than b. ok is false when either isn't a version.
+func compareVersions(a, b string) (cmp int, ok bool) {
+	coreA, preA, okA := parseVersion(a)
+	coreB, preB, okB := parseVersion(b)
+	if !okA || !okB {
+		return 0, false
+	}
+
+	for i := range coreA {
+		if coreA[i] != coreB[i] {
+			if coreA[i] < coreB[i] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	// A pre-release comes before its release.
+	switch {
+	case preA == preB:
+		return 0, true
+	case preA == "":
+		return 1, true
+	case preB == "":
+		return -1, true
+	case comparePrerelease(preA, preB) < 0:
+		return -1, true
+	default:
+		return 1, true
+	}
+}
+
+// parseVersion splits a version such as v1.5.0-rc.1+build into its major,
+// minor and patch numbers and its pre-release.
+func parseVersion(v string) ([3]int, string, bool) {
+	var core [3]int
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	var pre string
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return core, "", false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return core, "", false
+		}
+		core[i] = n
+	}
+	return core, pre, true
+}
+
+// comparePrerelease compares pre-releases identifier by identifier, numeric
+// ones by value and before alphanumeric ones.
+func comparePrerelease(a, b string) int {
+	idsA, idsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		if idsA[i] == idsB[i] {
+			continue
+		}
+		nA, errA := strconv.Atoi(idsA[i])
+		nB, errB := strconv.Atoi(idsB[i])
+		switch {
+		case errA == nil && errB == nil && nA < nB, errA == nil && errB != nil:
+			return -1
+		case errA == nil && errB == nil, errA != nil && errB == nil:
+			return 1
+		case idsA[i] < idsB[i]:
+			return -1
+		default:
+			return 1
+		}
+	}
+	return len(idsA) - len(idsB)
+}
+
+// updateAssetName is the release asset holding the binary for this platform.
+func updateAssetName() string {
+	name := fmt.Sprintf("dorky_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// downloadVerifiedUpdate downloads this platform's binary from release and
+// returns it only if its minisign signature, published alongside as
+// <asset>.minisig, verifies against publicKey.
+func downloadVerifiedUpdate(client *http.Client, release *github.RepositoryRelease, publicKey string) ([]byte, error) {
+	if publicKey == "" {
+		return nil, errors.New("this build has no release signing key and can't verify updates; reinstall from a signed release instead")
+	}
+
+	urls := make(map[string]string)
+	for _, asset := range release.Assets {
+		urls[asset.GetName()] = asset.GetBrowserDownloadURL()
+	}
+	name := updateAssetName()
+	if urls[name] == "" {
+		return nil, fmt.Errorf("release %s has no %s asset", release.GetTagName(), name)
+	}
+	if urls[name+".minisig"] == "" {
+		return nil, fmt.Errorf("release %s has no signature for %s", release.GetTagName(), name)
+	}
+
+	binary, err := download(client, urls[name])
+	if err != nil {
+		return nil, err
+	}
+	signature, err := download(client, urls[name+".minisig"])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifyMinisign(publicKey, binary, signature); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return binary, nil
+}
+
+func download(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: unexpected status %s", url, resp.Status)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxUpdateSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxUpdateSize {
+		return nil, fmt.Errorf("%s: larger than %d bytes", url, maxUpdateSize)
+	}
+	return data, nil
+}
+
+// verifyMinisign checks a minisign signature of data, either prehashed, the
+// default of minisign, which signs the BLAKE2b-512 hash of data, or legacy
+// (minisign -S -l), which signs data itself. The trusted comment's global
+// signature is checked as well, so the comment can't be swapped either.
+func verifyMinisign(publicKey string, data, signature []byte) error {
+	key, err := decodeMinisignLine(publicKey)
+	if err != nil || len(key) != 42 || string(key[:2]) != "Ed" {
+		return errors.New("malformed minisign public key")
+	}
+	keyID, pub := key[2:10], ed25519.PublicKey(key[10:])
+
+	lines := strings.Split(strings.TrimSpace(string(signature)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return errors.New("malformed minisign signature")
+	}
+	sig, err := decodeMinisignLine(lines[1])
+	if err != nil || len(sig) != 74 {
+		return errors.New("malformed minisign signature")
+	}
+	globalSig, err := decodeMinisignLine(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return errors.New("malformed minisign signature")
+	}
+
+	message := data
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		hash := blake2b.Sum512(data)
+		message = hash[:]
+	default:
+		return errors.New("unknown minisign signature algorithm")
+	}
+	if !bytes.Equal(sig[2:10], keyID) {
+		return errors.New("signed with a different key")
+	}
+	if !ed25519.Verify(pub, message, sig[10:]) {
+		return errors.New("signature verification failed")
+	}
+
+	trustedComment := strings.TrimPrefix(strings.TrimRight(lines[2], "\r"), "trusted comment: ")
+	if !ed25519.Verify(pub, append(sig[10:], trustedComment...), globalSig) {
+		return errors.New("trusted comment verification failed")
+	}
+	return nil
+}
+
+// decodeMinisignLine decodes a base64 line of a minisign key or signature.
+// A whole key file is accepted too: its key is on the last line.
+func decodeMinisignLine(s string) ([]byte, error) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return base64.StdEncoding.DecodeString(strings.TrimSpace(lines[len(lines)-1]))
+}
+
+// replaceExecutable atomically swaps the file at path for binary, writing it
+// next to path first so the rename stays on one filesystem.
+func replaceExecutable(path string, binary []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".dorky-update-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0755); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
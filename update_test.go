@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v38/github"
+	"golang.org/x/crypto/blake2b"
+)
+
+// minisignFixture returns a minisign public key and a function producing
+// signatures made with it: prehashed for the algorithm "ED", legacy for
+// "Ed".
+func minisignFixture(t *testing.T, algorithm string) (string, func(data []byte, trustedComment string) []byte) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID := []byte("12345678")
+	publicKey := "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), keyID...), pub...))
+
+	sign := func(data []byte, trustedComment string) []byte {
+		message := data
+		if algorithm == "ED" {
+			hash := blake2b.Sum512(data)
+			message = hash[:]
+		}
+		sig := ed25519.Sign(priv, message)
+		global := ed25519.Sign(priv, append(append([]byte(nil), sig...), trustedComment...))
+		return []byte("untrusted comment: signature\n" +
+			base64.StdEncoding.EncodeToString(append(append([]byte(algorithm), keyID...), sig...)) + "\n" +
+			"trusted comment: " + trustedComment + "\n" +
+			base64.StdEncoding.EncodeToString(global) + "\n")
+	}
+	return publicKey, sign
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	for _, algorithm := range []string{"ED", "Ed"} {
+		publicKey, sign := minisignFixture(t, algorithm)
+		otherKey, _ := minisignFixture(t, algorithm)
+		data := []byte("dorky binary")
+		signature := sign(data, "timestamp:1700000000")
+
+		if err := verifyMinisign(publicKey, data, signature); err != nil {
+			t.Errorf("%s: valid signature: %s", algorithm, err)
+		}
+		if err := verifyMinisign(publicKey, []byte("tampered binary"), signature); err == nil {
+			t.Errorf("%s: tampered binary verified", algorithm)
+		}
+		if err := verifyMinisign(otherKey, data, signature); err == nil {
+			t.Errorf("%s: signature verified against another key", algorithm)
+		}
+
+		swapped := strings.Replace(string(signature), "timestamp:1700000000", "timestamp:1800000000", 1)
+		if err := verifyMinisign(publicKey, data, []byte(swapped)); err == nil {
+			t.Errorf("%s: signature with a swapped trusted comment verified", algorithm)
+		}
+		if err := verifyMinisign(publicKey, data, []byte("not a signature")); err == nil {
+			t.Errorf("%s: malformed signature verified", algorithm)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"v1.4.0", "v1.4.0", 0, true},
+		{"v1.4.0", "v1.10.0", -1, true},
+		{"v2.0.0", "v1.9.9", 1, true},
+		{"v1.5.0-rc.1", "v1.5.0", -1, true},
+		{"v1.5.0-rc.2", "v1.5.0-rc.10", -1, true},
+		{"v1.5.0-beta", "v1.5.0-alpha.1", 1, true},
+		{"v1.5.0+build.7", "1.5.0", 0, true},
+		{"dev", "v1.5.0", 0, false},
+		{"v1.5", "v1.5.0", 0, false},
+	}
+	for _, tt := range tests {
+		if got, ok := compareVersions(tt.a, tt.b); got != tt.want || ok != tt.ok {
+			t.Errorf("compareVersions(%s, %s) = %d, %t, want %d, %t", tt.a, tt.b, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDownloadVerifiedUpdate(t *testing.T) {
+	publicKey, sign := minisignFixture(t, "ED")
+	name := updateAssetName()
+	binary := []byte("new dorky")
+
+	assets := map[string][]byte{
+		"/" + name:              binary,
+		"/" + name + ".minisig": sign(binary, "dorky v2.0.0"),
+		"/forged":               []byte("evil dorky"),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := assets[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+
+	release := func(binaryPath string) *github.RepositoryRelease {
+		return &github.RepositoryRelease{
+			TagName: github.String("v2.0.0"),
+			Assets: []*github.ReleaseAsset{
+				{Name: github.String(name), BrowserDownloadURL: github.String(srv.URL + binaryPath)},
+				{Name: github.String(name + ".minisig"), BrowserDownloadURL: github.String(srv.URL + "/" + name + ".minisig")},
+			},
+		}
+	}
+
+	got, err := downloadVerifiedUpdate(srv.Client(), release("/"+name), publicKey)
+	if err != nil || string(got) != string(binary) {
+		t.Errorf("downloadVerifiedUpdate = %q, %v, want %q", got, err, binary)
+	}
+	if _, err := downloadVerifiedUpdate(srv.Client(), release("/forged"), publicKey); err == nil {
+		t.Error("forged binary accepted")
+	}
+	if _, err := downloadVerifiedUpdate(srv.Client(), release("/"+name), ""); err == nil {
+		t.Error("update accepted by a build without a signing key")
+	}
+}
+
+func TestReplaceExecutable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dorky")
+	if err := ioutil.WriteFile(path, []byte("old dorky"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := replaceExecutable(path, []byte("new dorky")); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadFile(path); err != nil || string(data) != "new dorky" {
+		t.Errorf("executable = %q, %v, want the new binary", data, err)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".dorky-update-*")); len(matches) != 0 {
+		t.Errorf("left temporary files behind: %v", matches)
+	}
+}
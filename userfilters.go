@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// githubUserQualifiers returns the search qualifiers narrowing user searches,
+// so the location filter is applied by GitHub itself.
+func githubUserQualifiers() string {
+	if flags.locationFlag == "" {
+		return ""
+	}
+	return fmt.Sprintf(` location:"%s"`, strings.Replace(flags.locationFlag, `"`, "", -1))
+}
+
+func userFiltersActive() bool {
+	return flags.locationFlag != "" || flags.bioContainsFlag != ""
+}
+
+// userMatchesFilters reports whether a profile satisfies -location and
+// -bio-contains, both compared regardless of case and diacritics.
+func userMatchesFilters(location, bio string) bool {
+	if flags.locationFlag != "" && !strings.Contains(foldName(location), foldName(flags.locationFlag)) {
+		return false
+	}
+	if flags.bioContainsFlag != "" && !strings.Contains(foldName(bio), foldName(flags.bioContainsFlag)) {
+		return false
+	}
+	return true
+}
+
+// filterGitHubUsersByBio drops users whose bio doesn't match -bio-contains.
+// Search results carry no bio, so each profile is fetched.
+func filterGitHubUsersByBio(users githubUsersService, logins []string) []string {
+	if flags.bioContainsFlag == "" {
+		return logins
+	}
+
+	var kept []string
+	for _, login := range logins {
+		user, _, err := users.Get(context.Background(), login)
+		if err != nil {
+			recordSearchError("github", "profile fetch", login, err)
+			continue
+		}
+		if userMatchesFilters(user.GetLocation(), user.GetBio()) {
+			kept = append(kept, login)
+		}
+	}
+	return kept
+}
+
+// filterGitLabUsers drops users not matching -location and -bio-contains.
+// GitLab's user search returns neither field, so each profile is fetched.
+func filterGitLabUsers(client *gitlab.Client, users []*gitlab.User) []*gitlab.User {
+	if !userFiltersActive() {
+		return users
+	}
+
+	var kept []*gitlab.User
+	for _, user := range users {
+		req, err := client.NewRequest(http.MethodGet, fmt.Sprintf("users/%d", user.ID), nil, nil)
+		if err != nil {
+			recordSearchError("gitlab", "profile fetch", user.Username, err)
+			continue
+		}
+
+		profile := new(gitlab.User)
+		if _, err := client.Do(req, profile); err != nil {
+			recordSearchError("gitlab", "profile fetch", user.Username, err)
+			continue
+		}
+
+		if userMatchesFilters(profile.Location, profile.Bio) {
+			kept = append(kept, user)
+		}
+	}
+	return kept
+}
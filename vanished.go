@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/go-github/v38/github"
+	"github.com/xanzy/go-gitlab"
+)
+
+// What became of a result the monitor no longer finds.
+const (
+	vanishedDeleted         = "deleted"
+	vanishedDeletedOrHidden = "deleted or made private"
+	vanishedPrivate         = "made private"
+	vanishedRenamed         = "renamed"
+	vanishedTakenDown       = "taken down"
+)
+
+// vanishableCategories are the categories whose disappearance the monitor
+// looks into, by platform.
+var vanishableCategories = map[string][]string{
+	"github": {"organization", "repository"},
+	"gitlab": {"group", "project"},
+}
+
+// vanishedResult is a result of a group's previous scan that its latest
+// scan didn't find, with what became of it.
+type vanishedResult struct {
+	result
+	Outcome   string
+	RenamedTo string
+}
+
+func (v vanishedResult) String() string {
+	outcome := v.Outcome
+	if v.Outcome == vanishedRenamed {
+		outcome += " to " + v.RenamedTo
+	}
+	return fmt.Sprintf("%s %s %s: %s", v.Platform, v.Category, v.Name, outcome)
+}
+
+// vanishChecker looks up results that disappeared. Either client may be
+// nil, leaving that platform's results unchecked.
+type vanishChecker struct {
+	gh *github.Client
+	gl *gitlab.Client
+}
+
+func newVanishChecker() vanishChecker {
+	var checker vanishChecker
+	if httpClient, err := createGitHubHTTPClient(); err == nil {
+		checker.gh = github.NewClient(httpClient)
+	}
+	if client, err := createGitLabClient(); err == nil {
+		checker.gl = client
+	}
+	return checker
+}
+
+// missingResults returns the results of the group's previous scan that
+// results, those of the latest, lack, and remembers the latest. A scan
+// with failed or skipped searches proves nothing about what it didn't
+// find: it is only added to what the group remembers.
+func missingResults(group *targetGroup, results []result, complete bool) []result {
+	present := make(map[string]result)
+	for _, res := range results {
+		if containsString(vanishableCategories[res.Platform], res.Category) {
+			present[res.Platform+"\x00"+res.Category+"\x00"+normalizeName(res.Name)] = res
+		}
+	}
+
+	if !complete {
+		if group.present == nil {
+			group.present = make(map[string]result)
+		}
+		for key, res := range present {
+			group.present[key] = res
+		}
+		return nil
+	}
+
+	var missing []result
+	for key, res := range group.present {
+		if _, ok := present[key]; !ok {
+			missing = append(missing, res)
+		}
+	}
+	group.present = present
+
+	sort.Slice(missing, func(i, j int) bool {
+		if missing[i].Platform != missing[j].Platform {
+			return missing[i].Platform < missing[j].Platform
+		}
+		if missing[i].Category != missing[j].Category {
+			return missing[i].Category < missing[j].Category
+		}
+		return missing[i].Name < missing[j].Name
+	})
+	return missing
+}
+
+// verify looks up what became of a missing result. It returns false for a
+// result that still exists and merely wasn't found by the latest searches,
+// or that couldn't be looked up.
+func (c vanishChecker) verify(res result) (vanishedResult, bool) {
+	v := vanishedResult{result: res}
+	var err error
+	switch {
+	case res.Platform == "github" && res.Category == "repository" && c.gh != nil:
+		err = c.verifyGitHubRepo(&v)
+	case res.Platform == "github" && res.Category == "organization" && c.gh != nil:
+		err = c.verifyGitHubOrg(&v)
+	case res.Platform == "gitlab" && c.gl != nil:
+		err = c.verifyGitLab(&v)
+	default:
+		return v, false
+	}
+	if err != nil {
+		recordSearchError(res.Platform, "disappearance check", res.Name, err)
+		return v, false
+	}
+	return v, v.Outcome != ""
+}
+
+// verifyGitHubRepo relies on the API following a renamed repository's
+// redirect, and telling a DMCA takedown (451) from a missing repository.
+// A repository made private is only seen as such by a token with access.
+func (c vanishChecker) verifyGitHubRepo(v *vanishedResult) error {
+	owner, name, _ := cutPath(v.Name)
+	repo, resp, err := c.gh.Repositories.Get(context.Background(), owner, name)
+	switch {
+	case resp != nil && resp.StatusCode == http.StatusUnavailableForLegalReasons:
+		v.Outcome = vanishedTakenDown
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		v.Outcome = vanishedDeletedOrHidden
+	case err != nil:
+		return err
+	case !strings.EqualFold(repo.GetFullName(), v.Name):
+		v.Outcome, v.RenamedTo = vanishedRenamed, repo.GetFullName()
+	case repo.GetPrivate():
+		v.Outcome = vanishedPrivate
+	}
+	return nil
+}
+
+// verifyGitHubOrg follows the web redirect of a renamed organization, which
+// the API doesn't keep. Organizations can't be private, so one that's
+// neither found nor renamed was deleted.
+func (c vanishChecker) verifyGitHubOrg(v *vanishedResult) error {
+	_, resp, err := c.gh.Users.Get(context.Background(), v.Name)
+	if resp == nil || resp.StatusCode != http.StatusNotFound {
+		return err
+	}
+
+	renamedTo, err := followRename(githubWebURL, v.Name)
+	if err != nil {
+		return err
+	}
+	v.Outcome = vanishedDeleted
+	if renamedTo != "" {
+		v.Outcome, v.RenamedTo = vanishedRenamed, renamedTo
+	}
+	return nil
+}
+
+// verifyGitLab looks a group or project up by its path, which GitLab
+// resolves through the redirect a rename leaves behind.
+func (c vanishChecker) verifyGitLab(v *vanishedResult) error {
+	var path, visibility string
+	var resp *gitlab.Response
+	var err error
+	if v.Category == "project" {
+		var project *gitlab.Project
+		if project, resp, err = c.gl.Projects.GetProject(v.Name, nil); err == nil {
+			path, visibility = project.PathWithNamespace, string(project.Visibility)
+		}
+	} else {
+		var group *gitlab.Group
+		if group, resp, err = c.gl.Groups.GetGroup(v.Name); err == nil {
+			path, visibility = group.FullPath, string(group.Visibility)
+		}
+	}
+
+	switch {
+	case resp != nil && resp.StatusCode == http.StatusNotFound:
+		v.Outcome = vanishedDeletedOrHidden
+	case err != nil:
+		return err
+	case !strings.EqualFold(path, v.Name):
+		v.Outcome, v.RenamedTo = vanishedRenamed, path
+	case visibility != "" && visibility != string(gitlab.PublicVisibility):
+		v.Outcome = vanishedPrivate
+	}
+	return nil
+}
+
+// reportVanished checks what became of the results the group's latest scan
+// no longer found, then prints and saves the outcomes to vanished.txt and
+// sends those matching the notification routes to Slack and the webhook.
+// Each outcome means something else to a defender: a rename may leave the
+// old name free to squat, a repository made private may have been leaking,
+// and a deleted one may still live on in forks.
+func reportVanished(group *targetGroup, checker vanishChecker, notify notifyConfig, complete bool) []vanishedResult {
+	var vanished []vanishedResult
+	// Hidden false positives were still found.
+	found := append(append([]result(nil), collectedResults...), hiddenResults...)
+	for _, res := range missingResults(group, found, complete) {
+		if v, ok := checker.verify(res); ok {
+			vanished = append(vanished, v)
+		} else {
+			verbosePrint("Group '%s': %s %s %s is no longer found but still exists\n", group.Name, res.Platform, res.Category, res.Name)
+		}
+	}
+	if len(vanished) == 0 {
+		return nil
+	}
+
+	lines := make([]string, len(vanished))
+	var routed []string
+	var routedResults []vanishedResult
+	for i, v := range vanished {
+		lines[i] = v.String()
+		if len(routeResults([]result{v.result}, notify.Routes)) > 0 {
+			routed = append(routed, lines[i])
+			routedResults = append(routedResults, v)
+		}
+	}
+	printResults(os.Stdout, resultBatch{Platform: "all", Category: "vanished", Header: fmt.Sprintf("Results of '%s' that disappeared", group.Name), Results: lines})
+	saveResults("vanished.txt", lines)
+
+	if notify.SlackWebhook != "" && len(routed) > 0 {
+		text := fmt.Sprintf("dorky: %d results of '%s' disappeared:\n- %s", len(routed), group.Name, strings.Join(routed, "\n- "))
+		if err := postSlack(notify.SlackWebhook, text); err != nil {
+			fmt.Printf("Error notifying Slack for group '%s': %s\n", group.Name, err)
+		}
+	}
+	if notify.Webhook != "" && len(routedResults) > 0 {
+		results := make([]result, len(routedResults))
+		for i, v := range routedResults {
+			results[i] = v.result
+		}
+		payload := newWebhookPayload(webhookVanishedResults, group.Name, results, stateFirstSeen(flags))
+		for i, v := range routedResults {
+			payload.Results[i].Outcome, payload.Results[i].RenamedTo = v.Outcome, v.RenamedTo
+		}
+		if err := postJSON(notify.Webhook, payload); err != nil {
+			fmt.Printf("Error notifying the webhook for group '%s': %s\n", group.Name, err)
+		}
+	}
+	return vanished
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReportVanished(t *testing.T) {
+	setupRun(t, config{})
+
+	ghSrv, _ := newFakeAPI(t, map[string]interface{}{
+		"/repos/acme/old":    map[string]interface{}{"full_name": "acme/new"},
+		"/repos/acme/secret": map[string]interface{}{"full_name": "acme/secret", "private": true},
+		"/repos/acme/kept":   map[string]interface{}{"full_name": "acme/kept"},
+	})
+	glSrv, _ := newFakeAPI(t, map[string]interface{}{
+		"/api/v4/groups/acme/team": map[string]interface{}{"full_path": "acme/squad", "visibility": "public"},
+	})
+	web := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/acme-legacy" {
+			http.Redirect(w, r, "/acme", http.StatusMovedPermanently)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer web.Close()
+	oldWebURL := githubWebURL
+	githubWebURL = web.URL + "/"
+	t.Cleanup(func() { githubWebURL = oldWebURL })
+
+	checker := vanishChecker{gh: newFakeGitHubClient(t, ghSrv), gl: newFakeGitLabClient(t, glSrv)}
+	group := &targetGroup{Name: "acme"}
+	scan := func(complete bool, results ...result) []vanishedResult {
+		collectedResults = results
+		return reportVanished(group, checker, notifyConfig{}, complete)
+	}
+
+	first := []result{
+		{Platform: "github", Category: "repository", Name: "acme/old"},
+		{Platform: "github", Category: "repository", Name: "acme/gone"},
+		{Platform: "github", Category: "repository", Name: "acme/secret"},
+		{Platform: "github", Category: "repository", Name: "acme/kept"},
+		{Platform: "github", Category: "organization", Name: "acme-legacy"},
+		{Platform: "github", Category: "organization", Name: "acme-defunct"},
+		{Platform: "github", Category: "user", Name: "bob"},
+		{Platform: "gitlab", Category: "group", Name: "acme/team"},
+		{Platform: "gitlab", Category: "project", Name: "acme/app"},
+	}
+	if vanished := scan(true, first...); len(vanished) != 0 {
+		t.Fatalf("first scan reported %v", vanished)
+	}
+
+	// An incomplete scan proves nothing.
+	if vanished := scan(false); len(vanished) != 0 {
+		t.Fatalf("incomplete scan reported %v", vanished)
+	}
+
+	vanished := scan(true)
+	var got []string
+	for _, v := range vanished {
+		got = append(got, v.String())
+	}
+	want := []string{
+		"github organization acme-defunct: deleted",
+		"github organization acme-legacy: renamed to acme",
+		"github repository acme/gone: deleted or made private",
+		"github repository acme/old: renamed to acme/new",
+		"github repository acme/secret: made private",
+		"gitlab group acme/team: renamed to acme/squad",
+		"gitlab project acme/app: deleted or made private",
+	}
+	if !equalStrings(got, want) {
+		t.Errorf("vanished = %q, want %q", got, want)
+	}
+	if lines := readOutputLines(t, "vanished.txt"); !equalStrings(lines, want) {
+		t.Errorf("vanished.txt = %q", lines)
+	}
+}
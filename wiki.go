@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/go-github/v38/github"
+)
+
+// githubWikiQualifier narrows code search to wiki content. GitHub does not
+// index the wiki tab of repositories, so this finds wiki pages kept in the
+// repository tree and in published .wiki mirrors.
+const githubWikiQualifier = " path:wiki"
+
+func searchGitHubWikis(client githubSearchService, query string, maxResults int) {
+	ctx := context.Background()
+
+	opt := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: pageSize(maxResults)}}
+	results, _, err := client.Code(ctx, query+githubWikiQualifier, opt)
+	if err != nil {
+		recordSearchError("github", "wiki search", query, err)
+		return
+	}
+
+	pages := make([]string, len(results.CodeResults))
+	for i, page := range results.CodeResults {
+		pages[i] = page.GetRepository().GetFullName() + ":" + page.GetPath()
+	}
+
+	emitResults("github", "wiki", query, fmt.Sprintf("GitHub wiki pages matching '%s'", query), "github_wikis.txt", pages)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// A workspace keeps everything belonging to one target or client in its own
+// directory, so outputs from different engagements never mix:
+//
+//	config.json   default flag values, e.g. {"o": true, "max": 50}
+//	keywords.txt  keywords used when none are given as arguments
+//	state/        persistent state between runs
+//	results/      output files and reports
+const (
+	workspaceConfigFile   = "config.json"
+	workspaceKeywordsFile = "keywords.txt"
+	workspaceResultsDir   = "results"
+)
+
+var workspaceSubdirs = []string{"state", workspaceResultsDir}
+
+func workspaceRoot() (string, error) {
+	if dir := os.Getenv("DORKY_WORKSPACES"); dir != "" {
+		return dir, nil
+	}
+
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "workspaces"), nil
+}
+
+func workspacePath(name string) (string, error) {
+	if !validDirName(name) {
+		return "", fmt.Errorf("invalid workspace name %q", name)
+	}
+
+	root, err := workspaceRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, name), nil
+}
+
+func runWorkspaceCommand(args []string) {
+	usage := "Usage: dorky workspace <create|list|remove|path> [name]"
+	if len(args) == 0 {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+
+	var err error
+	switch args[0] {
+	case "list":
+		err = listWorkspaces()
+	case "create", "remove", "path":
+		if len(args) != 2 {
+			fmt.Println(usage)
+			os.Exit(1)
+		}
+		switch args[0] {
+		case "create":
+			err = createWorkspace(args[1])
+		case "remove":
+			err = removeWorkspace(args[1])
+		case "path":
+			var dir string
+			if dir, err = workspacePath(args[1]); err == nil {
+				fmt.Println(dir)
+			}
+		}
+	default:
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Printf("Error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func createWorkspace(name string) error {
+	dir, err := workspacePath(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("workspace '%s' already exists", name)
+	}
+
+	for _, sub := range workspaceSubdirs {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return err
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, workspaceConfigFile), []byte("{}\n"), 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, workspaceKeywordsFile), nil, 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created workspace '%s' in %s\n", name, dir)
+	return nil
+}
+
+func listWorkspaces() error {
+	root, err := workspaceRoot()
+	if err != nil {
+		return err
+	}
+
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+func removeWorkspace(name string) error {
+	dir, err := workspacePath(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("workspace '%s' does not exist", name)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed workspace '%s'\n", name)
+	return nil
+}
+
+// applyWorkspace loads the workspace's default flag values for every flag not
+// given explicitly, and points output at the workspace's results directory.
+func applyWorkspace(fs *flag.FlagSet, name string) error {
+	dir, err := workspacePath(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("workspace '%s' does not exist (create it with `dorky workspace create %s`)", name, name)
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, workspaceConfigFile))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if len(data) > 0 {
+		// Numbers are kept as written, so integer flags don't arrive as
+		// floats.
+		var defaults map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&defaults); err != nil {
+			return fmt.Errorf("%s: %w", workspaceConfigFile, err)
+		}
+
+		for key, value := range defaults {
+			if key == "workspace" || explicit[key] {
+				continue
+			}
+			if fs.Lookup(key) == nil {
+				return fmt.Errorf("%s: unknown flag %q", workspaceConfigFile, key)
+			}
+			if err := fs.Set(key, fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("%s: flag %q: %w", workspaceConfigFile, key, err)
+			}
+		}
+	}
+
+	// Workspaces keep result history in their state directory.
+	if f := fs.Lookup("state"); f != nil && f.Value.String() == "" {
+		if err := fs.Set("state", filepath.Join(dir, "state", "results.json")); err != nil {
+			return err
+		}
+	}
+
+	outputDir = filepath.Join(dir, workspaceResultsDir)
+	verbosePrint("Using workspace '%s' in %s\n", name, dir)
+	return nil
+}
+
+// workspaceKeywords returns the workspace's keyword file, or an error if the
+// workspace has no keywords saved.
+func workspaceKeywords(name string) (*os.File, error) {
+	dir, err := workspacePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(filepath.Join(dir, workspaceKeywordsFile))
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := f.Stat(); err != nil || info.Size() == 0 {
+		f.Close()
+		return nil, errors.New("workspace has no keywords")
+	}
+
+	return f, nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestApplyWorkspace(t *testing.T) {
+	setupRun(t, config{})
+	setenv(t, "DORKY_WORKSPACES", t.TempDir())
+
+	if err := createWorkspace("acme"); err != nil {
+		t.Fatal(err)
+	}
+	dir, _ := workspacePath("acme")
+	if _, err := os.Stat(filepath.Join(dir, "cache")); !os.IsNotExist(err) {
+		t.Errorf("workspace has a cache directory: %v", err)
+	}
+
+	config := `{"max": 9007199254740993, "o": true, "name": "acme"}`
+	if err := ioutil.WriteFile(filepath.Join(dir, workspaceConfigFile), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("dorky", flag.ContinueOnError)
+	max := fs.Int64("max", 0, "")
+	o := fs.Bool("o", false, "")
+	name := fs.String("name", "", "")
+	fs.Parse([]string{"-name", "explicit"})
+
+	if err := applyWorkspace(fs, "acme"); err != nil {
+		t.Fatal(err)
+	}
+	if *max != 9007199254740993 {
+		t.Errorf("max = %d, want 9007199254740993", *max)
+	}
+	if !*o {
+		t.Error("o not set from the workspace")
+	}
+	if *name != "explicit" {
+		t.Errorf("name = %q, the explicit flag was overridden", *name)
+	}
+	if want := filepath.Join(dir, workspaceResultsDir); outputDir != want {
+		t.Errorf("outputDir = %s, want %s", outputDir, want)
+	}
+}